@@ -0,0 +1,232 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// maxAliasDepth stops expansion of aliases that refer to other aliases long
+// before anything could loop; real cycles are reported by name.
+const maxAliasDepth = 16
+
+// builtinCommand reports whether name is dispatched by main without
+// consulting aliases.
+func builtinCommand(name string) bool {
+	switch name {
+	case "help", "-h", "--help", "--version", "completion", "alias":
+		return true
+	}
+	return findCommand(name) != nil
+}
+
+func readAliases(value interface{}, into map[string]string, warn func(string)) {
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		warn("aliases should map names to command lines; ignored")
+		return
+	}
+	for name, expansion := range m {
+		s, ok := expansion.(string)
+		switch {
+		case !ok || strings.TrimSpace(s) == "":
+			warn(fmt.Sprintf("alias %q should be a command line; ignored", name))
+		case builtinCommand(name):
+			warn(fmt.Sprintf("alias %q would shadow the built-in command; ignored", name))
+		default:
+			into[name] = s
+		}
+	}
+}
+
+// expandAlias replaces a leading alias in args with its expansion, keeping
+// the remaining arguments after it. Aliases may refer to other aliases;
+// a cycle is an error rather than an endless loop.
+func expandAlias(args []string, aliases map[string]string) ([]string, error) {
+	var chain []string
+	for len(args) > 0 && !builtinCommand(args[0]) {
+		expansion, ok := aliases[args[0]]
+		if !ok {
+			return args, nil
+		}
+		for _, seen := range chain {
+			if seen == args[0] {
+				return nil, fmt.Errorf("alias cycle: %s -> %s", strings.Join(chain, " -> "), args[0])
+			}
+		}
+		chain = append(chain, args[0])
+		if len(chain) > maxAliasDepth {
+			return nil, fmt.Errorf("alias %s expands through more than %d aliases", chain[0], maxAliasDepth)
+		}
+		words, err := splitCommandLine(expansion)
+		if err != nil {
+			return nil, fmt.Errorf("alias %s: %w", args[0], err)
+		}
+		if len(words) == 0 {
+			return nil, fmt.Errorf("alias %s is empty", args[0])
+		}
+		args = append(words, args[1:]...)
+	}
+	return args, nil
+}
+
+// splitCommandLine splits an alias expansion into words the way a shell
+// would for simple quoting: single quotes are literal, double quotes allow
+// backslash escapes, and a backslash outside quotes escapes the next rune.
+func splitCommandLine(s string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+	for _, r := range s {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case quote == '"':
+			switch r {
+			case '"':
+				quote = 0
+			case '\\':
+				escaped = true
+			default:
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == '\\':
+			escaped = true
+			inWord = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if escaped {
+		return nil, errors.New("trailing backslash")
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+func runAlias(args []string) error {
+	fs := newFlagSet("alias", printAliasUsage)
+	if err := parseFlags(fs, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	rest := fs.Args()
+	if len(rest) == 0 {
+		return errors.New("provide an action: set, list or delete")
+	}
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+	switch rest[0] {
+	case "set":
+		if len(rest) != 3 {
+			return errors.New("usage: gh-pr-review alias set <name> '<command line>'")
+		}
+		name, expansion := rest[1], rest[2]
+		if builtinCommand(name) {
+			return fmt.Errorf("%q is a built-in command and can't be an alias", name)
+		}
+		if strings.ContainsAny(name, " \t\n") || strings.HasPrefix(name, "-") {
+			return fmt.Errorf("invalid alias name %q", name)
+		}
+		words, err := splitCommandLine(expansion)
+		if err != nil {
+			return err
+		}
+		if len(words) == 0 {
+			return errors.New("the alias needs a command line")
+		}
+		aliases := map[string]string{name: expansion}
+		for n, e := range userConfig.aliases {
+			if n != name {
+				aliases[n] = e
+			}
+		}
+		if _, err := expandAlias([]string{name}, aliases); err != nil {
+			return err
+		}
+		if err := setConfigValue(path, []string{"aliases"}, name, expansion); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stdout, "added alias %s: %s\n", name, expansion)
+		return nil
+	case "list":
+		if len(rest) != 1 {
+			return errors.New("usage: gh-pr-review alias list")
+		}
+		printAliases(os.Stdout, userConfig.aliases)
+		return nil
+	case "delete":
+		if len(rest) != 2 {
+			return errors.New("usage: gh-pr-review alias delete <name>")
+		}
+		found, err := deleteConfigValue(path, []string{"aliases"}, rest[1])
+		if err != nil {
+			return err
+		}
+		if !found {
+			return fmt.Errorf("no alias named %q", rest[1])
+		}
+		fmt.Fprintf(os.Stdout, "deleted alias %s\n", rest[1])
+		return nil
+	default:
+		return fmt.Errorf("unknown alias action %q (expected set, list or delete)", rest[0])
+	}
+}
+
+func printAliases(w io.Writer, aliases map[string]string) {
+	if len(aliases) == 0 {
+		fmt.Fprintln(w, "no aliases configured")
+		return
+	}
+	names := make([]string, 0, len(aliases))
+	for name := range aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "%s: %s\n", name, aliases[name])
+	}
+}
+
+func printAliasUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage:")
+	fmt.Fprintln(w, "  gh-pr-review alias set <name> '<command line>'")
+	fmt.Fprintln(w, "  gh-pr-review alias list")
+	fmt.Fprintln(w, "  gh-pr-review alias delete <name>")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Aliases are stored under aliases: in the config file. Running the alias runs")
+	fmt.Fprintln(w, "its command line with any further arguments appended. An alias can refer to")
+	fmt.Fprintln(w, "another alias but not to itself, and can't take a built-in command's name.")
+}
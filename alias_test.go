@@ -0,0 +1,90 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExpandAlias(t *testing.T) {
+	aliases := map[string]string{
+		"todo":   "list --status unresolved --format table",
+		"mine":   "todo --repo 'me/my repo'",
+		"list":   "view",
+		"loop-a": "loop-b --json",
+		"loop-b": "loop-a",
+	}
+	cases := []struct {
+		args    []string
+		want    []string
+		wantErr string
+	}{
+		{args: []string{"todo", "--pr", "42"}, want: []string{"list", "--status", "unresolved", "--format", "table", "--pr", "42"}},
+		{args: []string{"mine"}, want: []string{"list", "--status", "unresolved", "--format", "table", "--repo", "me/my repo"}},
+		{args: []string{"list", "--pr", "1"}, want: []string{"list", "--pr", "1"}},
+		{args: []string{"unknown"}, want: []string{"unknown"}},
+		{args: []string{"loop-a"}, wantErr: "alias cycle: loop-a -> loop-b -> loop-a"},
+	}
+	for _, tc := range cases {
+		got, err := expandAlias(tc.args, aliases)
+		if tc.wantErr != "" {
+			if err == nil || err.Error() != tc.wantErr {
+				t.Errorf("expandAlias(%q) error = %v, want %q", tc.args, err, tc.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("expandAlias(%q): %v", tc.args, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("expandAlias(%q) = %q, want %q", tc.args, got, tc.want)
+		}
+	}
+}
+
+func TestSplitCommandLine(t *testing.T) {
+	cases := map[string][]string{
+		`list --status unresolved`:         {"list", "--status", "unresolved"},
+		`  reply  --body "Fixed, thanks!"`: {"reply", "--body", "Fixed, thanks!"},
+		`reply --body 'say "hi"'`:          {"reply", "--body", `say "hi"`},
+		`reply --body "a \"b\""`:           {"reply", "--body", `a "b"`},
+		`list --repo a\ b ''`:              {"list", "--repo", "a b", ""},
+	}
+	for input, want := range cases {
+		got, err := splitCommandLine(input)
+		if err != nil {
+			t.Errorf("splitCommandLine(%q): %v", input, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("splitCommandLine(%q) = %q, want %q", input, got, want)
+		}
+	}
+	for _, input := range []string{`list "open`, `list 'open`, `list \`} {
+		if _, err := splitCommandLine(input); err == nil {
+			t.Errorf("splitCommandLine(%q) succeeded, want an error", input)
+		}
+	}
+}
+
+func TestConfigAliases(t *testing.T) {
+	cfg, warnings := mustParseConfig(t, `
+aliases:
+  todo: list --status unresolved
+  resolve: resolve --yes
+  empty: ""
+`)
+	if got := cfg.aliases["todo"]; got != "list --status unresolved" {
+		t.Errorf("todo = %q", got)
+	}
+	if _, ok := cfg.aliases["resolve"]; ok {
+		t.Error("alias shadowing a built-in command was kept")
+	}
+	joined := strings.Join(warnings, "\n")
+	for _, want := range []string{`alias "resolve" would shadow`, `alias "empty" should be a command line`} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("warnings %q missing %q", joined, want)
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+
+	"gh-pr-review/internal/gh"
+)
+
+// archiveMarkdown renders comment bodies. Raw HTML in a body is left out and
+// dangerous link targets dropped, since the archive is opened in a browser.
+var archiveMarkdown = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+type archivePage struct {
+	Title    string
+	Repo     string
+	PR       int
+	PRURL    string
+	Threads  int
+	Resolved int
+	Files    []archiveFile
+}
+
+type archiveFile struct {
+	Path    string
+	Threads []archiveThread
+}
+
+type archiveThread struct {
+	ID         string
+	Location   string
+	Resolved   bool
+	ResolvedBy string
+	Diff       []archiveDiffLine
+	Comments   []archiveComment
+}
+
+type archiveDiffLine struct {
+	Class string
+	Text  string
+}
+
+type archiveComment struct {
+	Author    string
+	AuthorURL string
+	CreatedAt string
+	URL       string
+	Body      template.HTML
+}
+
+type archiveFlags struct {
+	repo  string
+	pr    int
+	out   string
+	title string
+	host  string
+}
+
+func (o *archiveFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.repo, "repo", "", "owner/name (defaults to gh repo view)")
+	fs.IntVar(&o.pr, "pr", 0, "PR number")
+	fs.StringVar(&o.out, "out", "-", "file to write (- for stdout)")
+	fs.StringVar(&o.title, "title", "", "page title (default: Review of owner/name#N)")
+	fs.StringVar(&o.host, "host", gh.DefaultHost(), "GitHub host")
+}
+
+func runArchive(args []string) error {
+	fs := newFlagSet("archive", printArchiveUsage)
+	var flags archiveFlags
+	flags.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+
+	ctx := context.Background()
+	var err error
+	flags.pr, err = resolvePR(ctx, flags.pr)
+	if err != nil {
+		return err
+	}
+	owner, name, err := resolveRepo(ctx, flags.repo)
+	if err != nil {
+		return err
+	}
+	client, err := newClient(ctx, flags.host)
+	if err != nil {
+		return err
+	}
+	threads, err := fetchAllThreads(ctx, client, owner, name, flags.pr)
+	if err != nil {
+		return err
+	}
+	if err := fetchRemainingComments(ctx, client, threads); err != nil {
+		return err
+	}
+	export := newThreadExport(owner+"/"+name, flags.pr, threads)
+	var buf bytes.Buffer
+	if err := writeArchiveHTML(&buf, export, flags.host, flags.title); err != nil {
+		return err
+	}
+	if flags.out == "-" || flags.out == "" {
+		_, err := os.Stdout.Write(buf.Bytes())
+		return err
+	}
+	if err := os.WriteFile(flags.out, buf.Bytes(), 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "wrote %d threads to %s\n", len(export.Threads), flags.out)
+	return nil
+}
+
+// writeArchiveHTML renders an export as one self-contained HTML page with a
+// section per file. It uses only what the export holds, so the same review
+// always produces the same bytes.
+func writeArchiveHTML(w io.Writer, export threadExport, host, title string) error {
+	if title == "" {
+		title = fmt.Sprintf("Review of %s#%d", export.Repo, export.PR)
+	}
+	web := "https://" + host
+	if host == "" {
+		web = "https://github.com"
+	}
+	page := archivePage{
+		Title:   title,
+		Repo:    export.Repo,
+		PR:      export.PR,
+		PRURL:   fmt.Sprintf("%s/%s/pull/%d", web, export.Repo, export.PR),
+		Threads: len(export.Threads),
+	}
+	for _, t := range export.Threads {
+		if len(page.Files) == 0 || page.Files[len(page.Files)-1].Path != t.Path {
+			page.Files = append(page.Files, archiveFile{Path: t.Path})
+		}
+		at := archiveThread{ID: t.ID, Location: lineLabel(t) + outdatedLabel(t), Resolved: t.IsResolved}
+		if t.IsResolved {
+			page.Resolved++
+			if t.ResolvedBy != nil {
+				at.ResolvedBy = t.ResolvedBy.Login
+			}
+		}
+		if len(t.Comments.Nodes) > 0 && t.Comments.Nodes[0].DiffHunk != "" {
+			for _, line := range strings.Split(strings.TrimRight(t.Comments.Nodes[0].DiffHunk, "\n"), "\n") {
+				at.Diff = append(at.Diff, archiveDiffLine{Class: diffLineClass(line), Text: line})
+			}
+		}
+		for _, c := range t.Comments.Nodes {
+			ac := archiveComment{Author: c.Author.Login, CreatedAt: c.CreatedAt, URL: c.URL}
+			if ac.Author == "" {
+				ac.Author = "unknown"
+			} else {
+				ac.AuthorURL = web + "/" + c.Author.Login
+			}
+			var body bytes.Buffer
+			if err := archiveMarkdown.Convert([]byte(c.Body), &body); err != nil {
+				return fmt.Errorf("rendering a comment on thread %s: %w", t.ID, err)
+			}
+			ac.Body = template.HTML(body.String())
+			at.Comments = append(at.Comments, ac)
+		}
+		file := &page.Files[len(page.Files)-1]
+		file.Threads = append(file.Threads, at)
+	}
+	return archiveTemplate.Execute(w, page)
+}
+
+func diffLineClass(line string) string {
+	switch {
+	case strings.HasPrefix(line, "@@"):
+		return "hunk"
+	case strings.HasPrefix(line, "+"):
+		return "add"
+	case strings.HasPrefix(line, "-"):
+		return "del"
+	}
+	return "ctx"
+}
+
+var archiveTemplate = template.Must(template.New("archive").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font: 14px/1.5 -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; color: #1f2328; max-width: 980px; margin: 2em auto; padding: 0 1em; }
+a { color: #0969da; }
+h2 { font-family: ui-monospace, SFMono-Regular, Menlo, monospace; font-size: 1.1em; border-bottom: 1px solid #d0d7de; padding-bottom: .3em; margin-top: 2em; }
+.thread { border: 1px solid #d0d7de; border-radius: 6px; margin: 1em 0; }
+.thread > header { background: #f6f8fa; border-bottom: 1px solid #d0d7de; padding: .5em 1em; }
+.state { border-radius: 1em; padding: 0 .6em; font-size: .85em; color: #fff; }
+.resolved { background: #8250df; }
+.unresolved { background: #1a7f37; }
+.id { color: #656d76; font-family: ui-monospace, SFMono-Regular, Menlo, monospace; font-size: .85em; }
+pre.diff { margin: 0; padding: .5em 0; overflow-x: auto; border-bottom: 1px solid #d0d7de; font-size: 12px; }
+pre.diff span { display: block; padding: 0 1em; }
+.hunk { background: #ddf4ff; color: #656d76; }
+.add { background: #e6ffec; }
+.del { background: #ffebe9; }
+.comment { padding: .5em 1em; }
+.comment + .comment { border-top: 1px solid #d0d7de; }
+.meta { color: #656d76; font-size: .9em; }
+.body pre { background: #f6f8fa; padding: .5em; overflow-x: auto; }
+.body code { font-family: ui-monospace, SFMono-Regular, Menlo, monospace; }
+.body table { border-collapse: collapse; }
+.body th, .body td { border: 1px solid #d0d7de; padding: .2em .6em; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p><a href="{{.PRURL}}">{{.Repo}}#{{.PR}}</a> · {{.Threads}} threads, {{.Resolved}} resolved</p>
+{{- if not .Files}}
+<p>No review threads.</p>
+{{- end}}
+{{- range .Files}}
+<h2>{{if .Path}}{{.Path}}{{else}}(no file){{end}}</h2>
+{{- range .Threads}}
+<section class="thread" id="{{.ID}}">
+<header>
+<strong>{{.Location}}</strong>
+{{if .Resolved}}<span class="state resolved">resolved{{if .ResolvedBy}} by {{.ResolvedBy}}{{end}}</span>{{else}}<span class="state unresolved">unresolved</span>{{end}}
+<span class="id">{{.ID}}</span>
+</header>
+{{- if .Diff}}
+<pre class="diff">{{range .Diff}}<span class="{{.Class}}">{{.Text}}</span>{{end}}</pre>
+{{- end}}
+{{- range .Comments}}
+<article class="comment">
+<div class="meta">{{if .AuthorURL}}<a href="{{.AuthorURL}}"><strong>{{.Author}}</strong></a>{{else}}<strong>{{.Author}}</strong>{{end}} commented {{if .URL}}<a href="{{.URL}}">{{.CreatedAt}}</a>{{else}}{{.CreatedAt}}{{end}}</div>
+<div class="body">
+{{.Body}}</div>
+</article>
+{{- end}}
+</section>
+{{- end}}
+{{- end}}
+</body>
+</html>
+`))
+
+func printArchiveUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage:")
+	fmt.Fprintln(w, "  gh-pr-review archive [--pr <number>] [--repo owner/name] [--out file] [--title text] [--host host]")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Writes the PR's review threads as a single HTML page for offline reading or record keeping:")
+	fmt.Fprintln(w, "threads grouped by file with their diff hunks, resolution state, timestamps, and comments")
+	fmt.Fprintln(w, "rendered from markdown. The page has no external assets, and the same review always")
+	fmt.Fprintln(w, "produces the same file.")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Flags:")
+	fmt.Fprintln(w, "  --pr <number>   PR number (defaults to current branch PR if available)")
+	fmt.Fprintln(w, "  --repo <owner/name>   Repository (defaults to gh repo view)")
+	fmt.Fprintln(w, "  --out <file>   File to write (default - for stdout)")
+	fmt.Fprintln(w, "  --title <text>   Page title (default: Review of owner/name#N)")
+	fmt.Fprintln(w, "  --host <host>   GitHub host")
+}
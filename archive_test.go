@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite golden files in testdata")
+
+func archiveFixture() threadExport {
+	resolved := exportThread("PRRT_b", "internal/api/handler.go", 42, "2024-03-01T10:00:00Z", "2024-03-01T12:30:00Z")
+	resolved.IsResolved = true
+	resolved.ResolvedBy = &actor{Login: "bob"}
+	resolved.Comments.Nodes[0].Body = "Should this **retry**?\n\n```go\nfor i := 0; i < 3; i++ {}\n```"
+	resolved.Comments.Nodes[0].URL = "https://github.com/owner/repo/pull/42#discussion_r1"
+	resolved.Comments.Nodes[0].DiffHunk = "@@ -40,3 +40,4 @@ func handle()\n \tctx := r.Context()\n-\tdo(ctx)\n+\tif err := do(ctx); err != nil {"
+	resolved.Comments.Nodes[1].Body = "Done in abc1234 <script>alert(1)</script>"
+
+	outdated := exportThread("PRRT_a", "README.md", 3, "2024-03-02T09:00:00Z")
+	outdated.IsOutdated = true
+	outdated.Line = nil
+	outdated.OriginalLine = intPtr(3)
+	outdated.Comments.Nodes[0].Body = "Typo: *recieve*\n\n| a | b |\n|---|---|\n| 1 | 2 |"
+	outdated.Comments.Nodes[0].Author.Login = ""
+
+	return newThreadExport("owner/repo", 42, []reviewThread{resolved, outdated})
+}
+
+func TestArchiveHTMLGolden(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeArchiveHTML(&buf, archiveFixture(), "github.com", ""); err != nil {
+		t.Fatal(err)
+	}
+	golden := filepath.Join("testdata", "archive.golden.html")
+	if *updateGolden {
+		if err := os.WriteFile(golden, buf.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("%v (run go test -run TestArchiveHTMLGolden -update to create it)", err)
+	}
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("archive HTML differs from %s; rerun with -update and review the diff\n%s", golden, buf.String())
+	}
+
+	var again bytes.Buffer
+	if err := writeArchiveHTML(&again, archiveFixture(), "github.com", ""); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), again.Bytes()) {
+		t.Error("archive output is not deterministic")
+	}
+}
+
+func TestArchiveHTMLEscapes(t *testing.T) {
+	export := archiveFixture()
+	var buf bytes.Buffer
+	if err := writeArchiveHTML(&buf, export, "ghe.example.com", "Audit <Q1>"); err != nil {
+		t.Fatal(err)
+	}
+	html := buf.String()
+	for _, bad := range []string{"<script>", "<Q1>", "http://", `src="`} {
+		if strings.Contains(html, bad) {
+			t.Errorf("archive contains %q", bad)
+		}
+	}
+	if !strings.Contains(html, `href="https://ghe.example.com/alice"`) || !strings.Contains(html, "<title>Audit &lt;Q1&gt;</title>") {
+		t.Errorf("unexpected archive:\n%s", html)
+	}
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"gh-pr-review/internal/gh"
+	"gh-pr-review/internal/github"
+)
+
+// batchAction is one entry of a batch plan.
+type batchAction struct {
+	Action   string `json:"action"`
+	ThreadID string `json:"threadId"`
+	Body     string `json:"body,omitempty"`
+}
+
+type batchResult struct {
+	Index      int    `json:"index"`
+	Action     string `json:"action"`
+	ThreadID   string `json:"threadId"`
+	OK         bool   `json:"ok"`
+	CommentURL string `json:"commentUrl,omitempty"`
+	IsResolved *bool  `json:"isResolved,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+type batchFlags struct {
+	file    string
+	dryRun  bool
+	jsonOut bool
+	host    string
+}
+
+func (o *batchFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.file, "file", "", "JSON plan to run (- for stdin)")
+	fs.BoolVar(&o.dryRun, "dry-run", false, "validate the plan without changing anything")
+	fs.BoolVar(&o.jsonOut, "json", false, "output per-action results as JSON")
+	fs.StringVar(&o.host, "host", gh.DefaultHost(), "GitHub host")
+}
+
+func runBatch(args []string) error {
+	fs := newFlagSet("batch", printBatchUsage)
+	var flags batchFlags
+	flags.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	switch {
+	case fs.NArg() == 1 && flags.file == "":
+		flags.file = fs.Arg(0)
+	case fs.NArg() > 0:
+		return fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+	if flags.file == "" {
+		return errors.New("--file is required (use - to read the plan from stdin)")
+	}
+	actions, err := readBatchPlan(flags.file)
+	if err != nil {
+		return err
+	}
+	// A malformed plan is rejected before anything runs, so a typo can't
+	// leave it half applied.
+	if err := validateBatch(actions); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	client, err := newClient(ctx, flags.host)
+	if err != nil {
+		return err
+	}
+	out := os.Stdout
+	if flags.jsonOut {
+		out = os.Stderr
+	}
+	var results []batchResult
+	if flags.dryRun {
+		results = checkBatch(ctx, client, actions)
+	} else {
+		results = runBatchActions(ctx, client, actions, func(r batchResult) { printBatchResult(out, r, false) })
+	}
+	failed := 0
+	for _, r := range results {
+		if !r.OK {
+			failed++
+		}
+		if flags.dryRun {
+			printBatchResult(out, r, true)
+		}
+	}
+	if flags.jsonOut {
+		if err := writeJSON(os.Stdout, results); err != nil {
+			return err
+		}
+	}
+	if flags.dryRun {
+		fmt.Fprintf(out, "%d valid, %d invalid\n", len(results)-failed, failed)
+	} else {
+		fmt.Fprintf(out, "succeeded %d, failed %d\n", len(results)-failed, failed)
+	}
+	if failed > 0 {
+		return &exitError{code: 1, err: fmt.Errorf("%d of %d actions failed", failed, len(results))}
+	}
+	return nil
+}
+
+func readBatchPlan(file string) ([]batchAction, error) {
+	var data []byte
+	var err error
+	if file == "-" {
+		data, err = io.ReadAll(promptReader())
+	} else {
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var actions []batchAction
+	if err := json.Unmarshal(data, &actions); err != nil {
+		return nil, fmt.Errorf("reading batch plan %s: %w (expected a JSON array of {action, threadId, body})", file, err)
+	}
+	return actions, nil
+}
+
+// validateBatch checks each action's shape without contacting GitHub.
+func validateBatch(actions []batchAction) error {
+	if len(actions) == 0 {
+		return errors.New("batch plan has no actions")
+	}
+	var problems []string
+	for i, a := range actions {
+		var problem string
+		switch {
+		case a.Action != "reply" && a.Action != "resolve" && a.Action != "unresolve":
+			problem = fmt.Sprintf("unknown action %q (expected reply, resolve or unresolve)", a.Action)
+		case a.ThreadID == "":
+			problem = "missing threadId"
+		case a.Action == "reply" && strings.TrimSpace(a.Body) == "":
+			problem = "reply needs a body"
+		}
+		if problem != "" {
+			problems = append(problems, fmt.Sprintf("action %d: %s", i+1, problem))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid batch plan:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+// checkBatch looks up every thread in the plan and reports whether each
+// action could be carried out.
+func checkBatch(ctx context.Context, client *github.Client, actions []batchAction) []batchResult {
+	type lookup struct {
+		thread reviewThread
+		err    error
+	}
+	threads := map[string]lookup{}
+	var results []batchResult
+	for i, a := range actions {
+		l, ok := threads[a.ThreadID]
+		if !ok {
+			l.thread, l.err = fetchThread(ctx, client, a.ThreadID)
+			threads[a.ThreadID] = l
+		}
+		var t threadTarget
+		if a.Action == "reply" {
+			t = checkReplyTarget(a.ThreadID, l.thread, l.err)
+		} else {
+			t = checkResolveTarget(a.ThreadID, l.thread, l.err, a.Action == "resolve", false)
+		}
+		r := batchResult{Index: i + 1, Action: a.Action, ThreadID: a.ThreadID, OK: t.err == nil}
+		if t.err != nil {
+			r.Error = t.err.Error()
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+// runBatchActions carries out the actions in order, reporting each result
+// as it completes and carrying on past failures.
+func runBatchActions(ctx context.Context, client *github.Client, actions []batchAction, report func(batchResult)) []batchResult {
+	var results []batchResult
+	for i, a := range actions {
+		r := batchResult{Index: i + 1, Action: a.Action, ThreadID: a.ThreadID}
+		var err error
+		switch a.Action {
+		case "reply":
+			var comment postedComment
+			if comment, err = replyToThread(ctx, client, a.ThreadID, a.Body); err == nil {
+				r.CommentURL = comment.URL
+			}
+		case "resolve", "unresolve":
+			var resolved bool
+			if resolved, err = setThreadResolved(ctx, client, a.ThreadID, a.Action == "resolve"); err == nil {
+				r.IsResolved = &resolved
+			}
+		}
+		r.OK = err == nil
+		if err != nil {
+			r.Error = err.Error()
+		}
+		report(r)
+		results = append(results, r)
+	}
+	return results
+}
+
+func printBatchResult(w io.Writer, r batchResult, dryRun bool) {
+	styler := newStyler(w)
+	outcome := "ok"
+	switch {
+	case !r.OK && dryRun:
+		outcome = "invalid: " + r.Error
+	case !r.OK:
+		outcome = "failed: " + r.Error
+	case dryRun:
+	case r.CommentURL != "":
+		outcome = "replied " + r.CommentURL
+	case r.IsResolved != nil:
+		outcome = resolutionState(*r.IsResolved)
+	}
+	fmt.Fprintf(w, "%d. %s %s: %s\n", r.Index, r.Action, styler.threadID(r.ThreadID), outcome)
+}
+
+func printBatchUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage:")
+	fmt.Fprintln(w, "  gh-pr-review batch --file <plan.json|-> [--dry-run] [--json] [--host host]")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "The plan is a JSON array of actions run in order:")
+	fmt.Fprintln(w, `  [{"action": "reply", "threadId": "PRRT_xxx", "body": "Fixed in abc123"},`)
+	fmt.Fprintln(w, `   {"action": "resolve", "threadId": "PRRT_xxx"}]`)
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Flags:")
+	fmt.Fprintln(w, "  --file <path>   Plan to run; - reads it from stdin")
+	fmt.Fprintln(w, "  --dry-run   Check the plan (actions, bodies, thread IDs and permissions) without changing anything")
+	fmt.Fprintln(w, "  --json   Print per-action results as JSON ({index, action, threadId, ok, commentUrl, isResolved, error})")
+	fmt.Fprintln(w, "  --host <host>   GitHub host")
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gh-pr-review/internal/github"
+)
+
+func TestValidateBatch(t *testing.T) {
+	err := validateBatch([]batchAction{
+		{Action: "reply", ThreadID: "A", Body: "Done"},
+		{Action: "approve", ThreadID: "A"},
+		{Action: "resolve"},
+		{Action: "reply", ThreadID: "B", Body: "  "},
+	})
+	if err == nil {
+		t.Fatal("expected an invalid plan")
+	}
+	for _, want := range []string{`action 2: unknown action "approve"`, "action 3: missing threadId", "action 4: reply needs a body"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+	if strings.Contains(err.Error(), "action 1") {
+		t.Errorf("valid action reported: %v", err)
+	}
+	if err := validateBatch(nil); err == nil {
+		t.Error("expected an empty plan to be rejected")
+	}
+}
+
+// fakeBatchServer knows threads A and B; mutations on B fail.
+func fakeBatchServer(t *testing.T) (*github.Client, *int) {
+	t.Helper()
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	mutations := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req github.GraphQLRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+			return
+		}
+		enc := json.NewEncoder(w)
+		if strings.HasPrefix(req.Query, "mutation") {
+			mutations++
+			if req.Variables["threadId"] == "B" {
+				enc.Encode(map[string]interface{}{"errors": []map[string]string{{"message": "boom"}}})
+				return
+			}
+			enc.Encode(map[string]interface{}{"data": map[string]interface{}{
+				"addPullRequestReviewThreadReply": map[string]interface{}{"comment": map[string]string{"id": "C1", "url": "https://example.com/c1"}},
+				"resolveReviewThread":             map[string]interface{}{"thread": map[string]interface{}{"id": "A", "isResolved": true}},
+			}})
+			return
+		}
+		id := req.Variables["id"].(string)
+		if id != "A" && id != "B" {
+			enc.Encode(map[string]interface{}{"errors": []map[string]string{{"type": "NOT_FOUND", "message": "Could not resolve to a node"}}})
+			return
+		}
+		enc.Encode(map[string]interface{}{"data": map[string]interface{}{"node": map[string]interface{}{
+			"id": id, "viewerCanResolve": true, "viewerCanUnresolve": true, "viewerCanReply": true,
+		}}})
+	}))
+	t.Cleanup(srv.Close)
+	return github.NewClient(srv.URL, "token"), &mutations
+}
+
+func TestRunBatchActionsContinuesPastFailures(t *testing.T) {
+	client, mutations := fakeBatchServer(t)
+	actions := []batchAction{
+		{Action: "reply", ThreadID: "A", Body: "Done"},
+		{Action: "resolve", ThreadID: "B"},
+		{Action: "resolve", ThreadID: "A"},
+	}
+	var reported []int
+	results := runBatchActions(context.Background(), client, actions, func(r batchResult) { reported = append(reported, r.Index) })
+	if *mutations != 3 || len(reported) != 3 {
+		t.Fatalf("expected every action to run, got %d mutations, reported %v", *mutations, reported)
+	}
+	if !results[0].OK || results[0].CommentURL != "https://example.com/c1" {
+		t.Errorf("reply result = %+v", results[0])
+	}
+	if results[1].OK || !strings.Contains(results[1].Error, "boom") {
+		t.Errorf("failing resolve result = %+v", results[1])
+	}
+	if !results[2].OK || results[2].IsResolved == nil || !*results[2].IsResolved {
+		t.Errorf("resolve result = %+v", results[2])
+	}
+}
+
+func TestCheckBatchFlagsUnknownThreads(t *testing.T) {
+	client, mutations := fakeBatchServer(t)
+	results := checkBatch(context.Background(), client, []batchAction{
+		{Action: "reply", ThreadID: "A", Body: "Done"},
+		{Action: "resolve", ThreadID: "MISSING"},
+	})
+	if *mutations != 0 {
+		t.Fatalf("dry run sent %d mutations", *mutations)
+	}
+	if !results[0].OK || results[1].OK || !strings.Contains(results[1].Error, "not found") {
+		t.Fatalf("results = %+v", results)
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+)
+
+// openBrowser opens url with $BROWSER, or the platform's default handler.
+func openBrowser(ctx context.Context, url string) error {
+	var cmd *exec.Cmd
+	switch {
+	case os.Getenv("BROWSER") != "":
+		cmd = exec.CommandContext(ctx, "sh", "-c", os.Getenv("BROWSER")+` "$1"`, "sh", url)
+	case runtime.GOOS == "darwin":
+		cmd = exec.CommandContext(ctx, "open", url)
+	case runtime.GOOS == "windows":
+		cmd = exec.CommandContext(ctx, "rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.CommandContext(ctx, "xdg-open", url)
+	}
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("opening %s in a browser: %w", url, err)
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"gh-pr-review/internal/github"
+)
+
+// optionalFields are selections that older GitHub Enterprise servers may not
+// know about. Each one a server rejects is left out of queries for that host
+// until its version changes, and features relying on it degrade quietly.
+var optionalFields = []string{
+	"fullDatabaseId",
+	"resolvedBy",
+	"viewerCanReply",
+	"lastEditedAt",
+	"includesCreatedEdit",
+	"originalCommit",
+}
+
+type hostCapabilities struct {
+	Version     string   `json:"version,omitempty"`
+	Unsupported []string `json:"unsupported"`
+}
+
+var capabilities struct {
+	sync.Mutex
+	loaded bool
+	hosts  map[string]hostCapabilities
+	// verified holds the hosts whose recorded version was checked against
+	// the server in this run.
+	verified map[string]bool
+}
+
+func cacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gh-pr-review"), nil
+}
+
+func capabilitiesPath() (string, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "capabilities.json"), nil
+}
+
+func loadCapabilities() {
+	if capabilities.loaded {
+		return
+	}
+	capabilities.loaded = true
+	capabilities.hosts = map[string]hostCapabilities{}
+	path, err := capabilitiesPath()
+	if err != nil {
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	_ = json.Unmarshal(data, &capabilities.hosts)
+}
+
+func saveCapabilities() {
+	path, err := capabilitiesPath()
+	if err != nil {
+		return
+	}
+	data, err := json.MarshalIndent(capabilities.hosts, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return
+	}
+	_ = os.WriteFile(path, data, 0o644)
+}
+
+// unsupportedFields returns the optional fields known to be missing on the
+// client's host. The first lookup in a run checks the recorded fields
+// against the server's current version and forgets them after an upgrade.
+func unsupportedFields(ctx context.Context, client *github.Client) map[string]bool {
+	capabilities.Lock()
+	loadCapabilities()
+	host, known := capabilities.hosts[client.Endpoint()]
+	verified := capabilities.verified[client.Endpoint()]
+	capabilities.Unlock()
+	if known && !verified {
+		version, err := client.ServerVersion(ctx)
+		capabilities.Lock()
+		if capabilities.verified == nil {
+			capabilities.verified = map[string]bool{}
+		}
+		if err == nil {
+			capabilities.verified[client.Endpoint()] = true
+			if version != host.Version {
+				delete(capabilities.hosts, client.Endpoint())
+				saveCapabilities()
+				host = hostCapabilities{}
+			}
+		}
+		capabilities.Unlock()
+	}
+	skip := map[string]bool{}
+	for _, f := range host.Unsupported {
+		skip[f] = true
+	}
+	return skip
+}
+
+func markUnsupported(ctx context.Context, client *github.Client, field string) {
+	version, _ := client.ServerVersion(ctx)
+	capabilities.Lock()
+	defer capabilities.Unlock()
+	loadCapabilities()
+	host := capabilities.hosts[client.Endpoint()]
+	if host.Version != version {
+		host = hostCapabilities{Version: version}
+	}
+	if !containsString(host.Unsupported, field) {
+		host.Unsupported = append(host.Unsupported, field)
+		sort.Strings(host.Unsupported)
+	}
+	capabilities.hosts[client.Endpoint()] = host
+	if capabilities.verified == nil {
+		capabilities.verified = map[string]bool{}
+	}
+	capabilities.verified[client.Endpoint()] = true
+	saveCapabilities()
+
+	fmt.Fprintf(os.Stderr, "note: %s does not support %s; continuing without it\n", serverName(version), field)
+}
+
+// requireField fails with a message naming feature when the client's host
+// is known to lack field, for features that can't degrade without giving
+// wrong answers.
+func requireField(ctx context.Context, client *github.Client, field, feature string) error {
+	if !unsupportedFields(ctx, client)[field] {
+		return nil
+	}
+	capabilities.Lock()
+	version := capabilities.hosts[client.Endpoint()].Version
+	capabilities.Unlock()
+	return fmt.Errorf("%s needs a newer server: %s does not support %s", feature, serverName(version), field)
+}
+
+func serverName(version string) string {
+	if version == "" {
+		return "this GitHub server"
+	}
+	return "GitHub Enterprise " + version
+}
+
+// queryWithFallback runs the query built for the host's known capabilities.
+// Each optional field the server rejects is recorded and the query retried
+// without it, so a server missing several fields costs one retry per field.
+func queryWithFallback(ctx context.Context, client *github.Client, build func(skip map[string]bool) string, vars map[string]interface{}, out interface{}) error {
+	skip := unsupportedFields(ctx, client)
+	for {
+		err := client.Do(ctx, build(skip), vars, out)
+		field, ok := github.UndefinedField(err)
+		if !ok || skip[field] || !containsString(optionalFields, field) {
+			return err
+		}
+		markUnsupported(ctx, client, field)
+		skip[field] = true
+	}
+}
+
+// optionalField returns selection unless the host lacks field.
+func optionalField(skip map[string]bool, field, selection string) string {
+	if skip[field] {
+		return ""
+	}
+	return selection
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
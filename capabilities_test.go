@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gh-pr-review/internal/github"
+)
+
+// resetCapabilities starts the test with nothing known about any host.
+func resetCapabilities(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	capabilities.Lock()
+	capabilities.loaded, capabilities.hosts, capabilities.verified = false, nil, nil
+	capabilities.Unlock()
+}
+
+func TestFetchThreadDegradesPerField(t *testing.T) {
+	resetCapabilities(t)
+
+	version := "3.4.0"
+	missing := []string{"resolvedBy", "viewerCanReply"}
+	var queries []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/meta") {
+			fmt.Fprintf(w, `{"installed_version":%q}`, version)
+			return
+		}
+		var req struct {
+			Query string `json:"query"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		queries = append(queries, req.Query)
+		for _, f := range missing {
+			if strings.Contains(req.Query, f) {
+				fmt.Fprintf(w, `{"errors":[{"message":"Field '%s' doesn't exist on type 'PullRequestReviewThread'"}]}`, f)
+				return
+			}
+		}
+		fmt.Fprint(w, `{"data":{"node":{"id":"T1","viewerCanResolve":true,"comments":{"nodes":[]}}}}`)
+	}))
+	defer srv.Close()
+	client := github.NewClient(srv.URL+"/api/graphql", "token")
+	ctx := context.Background()
+
+	thread, err := fetchThread(ctx, client, "T1")
+	if err != nil {
+		t.Fatalf("fetchThread: %v", err)
+	}
+	if len(queries) != 3 {
+		t.Errorf("queries = %d, want one retry per missing field", len(queries))
+	}
+	if !thread.CanReply {
+		t.Error("CanReply = false without viewerCanReply, want replies assumed allowed")
+	}
+	if last := queries[len(queries)-1]; !strings.Contains(last, "lastEditedAt") {
+		t.Error("a supported optional field was dropped")
+	}
+
+	queries = nil
+	if _, err := fetchThread(ctx, client, "T1"); err != nil {
+		t.Fatalf("fetchThread: %v", err)
+	}
+	if len(queries) != 1 {
+		t.Errorf("queries = %d with recorded capabilities, want 1", len(queries))
+	}
+
+	// After an upgrade the recorded fields are tried again.
+	version, missing = "3.9.0", nil
+	capabilities.Lock()
+	capabilities.loaded, capabilities.hosts, capabilities.verified = false, nil, nil
+	capabilities.Unlock()
+	queries = nil
+	if _, err := fetchThread(ctx, client, "T1"); err != nil {
+		t.Fatalf("fetchThread: %v", err)
+	}
+	if len(queries) != 1 || !strings.Contains(queries[0], "resolvedBy") {
+		t.Errorf("queries after upgrade = %q, want resolvedBy selected again", queries)
+	}
+}
+
+func TestResolvedByNeedsField(t *testing.T) {
+	resetCapabilities(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/meta") {
+			fmt.Fprint(w, `{"installed_version":"3.4.0"}`)
+			return
+		}
+		var req struct {
+			Query string `json:"query"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if strings.Contains(req.Query, "resolvedBy") {
+			fmt.Fprint(w, `{"errors":[{"message":"Field 'resolvedBy' doesn't exist on type 'PullRequestReviewThread'"}]}`)
+			return
+		}
+		fmt.Fprint(w, `{"data":{"repository":{"pullRequest":{"reviewThreads":{"pageInfo":{"hasNextPage":false},"nodes":[]}}}}}`)
+	}))
+	defer srv.Close()
+	client := github.NewClient(srv.URL+"/api/graphql", "token")
+
+	_, err := matchingThreads(context.Background(), client, "o/r", 1, threadFilter{status: "all", resolvedBy: "bot"})
+	if err == nil || !strings.Contains(err.Error(), "--resolved-by needs a newer server: GitHub Enterprise 3.4.0") {
+		t.Fatalf("err = %v, want --resolved-by refused", err)
+	}
+	if _, err := matchingThreads(context.Background(), client, "o/r", 1, threadFilter{status: "all"}); err != nil {
+		t.Errorf("without --resolved-by: %v", err)
+	}
+}
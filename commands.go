@@ -0,0 +1,648 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// command describes a subcommand for dispatch, usage and help output.
+type command struct {
+	name     string
+	summary  string
+	usage    func(w io.Writer)
+	examples []string
+	// flags defines the command's flags. run registers the same ones, so
+	// completion and the usage checks see exactly what parsing accepts.
+	flags func(fs *flag.FlagSet)
+	// actionFlags defines the flags of actions that parse their own, such
+	// as suggestions apply.
+	actionFlags map[string]func(fs *flag.FlagSet)
+	run         func(args []string) error
+}
+
+var commands = []command{
+	{
+		name:    "list",
+		summary: "List review threads on a PR",
+		usage:   printListUsage,
+		examples: []string{
+			"# Unresolved threads on the current branch's PR",
+			"gh-pr-review list --status unresolved",
+			"",
+			"# Fail CI while unresolved threads remain",
+			"gh-pr-review list --pr 42 --status unresolved --count --exit-status",
+		},
+		flags: new(listFlags).register,
+		run:   runList,
+	},
+	{
+		name:    "tui",
+		summary: "Browse review threads interactively",
+		usage:   printTUIUsage,
+		examples: []string{
+			"gh-pr-review tui --pr 42 --status unresolved",
+		},
+		flags: new(tuiFlags).register,
+		run:   runTUI,
+	},
+	{
+		name:    "reply",
+		summary: "Reply to a review thread",
+		usage:   printReplyUsage,
+		examples: []string{
+			"# Reply and resolve the thread in one go",
+			"gh-pr-review reply --thread-id PRRT_xxx --body \"Fixed in abc1234\" --resolve",
+			"# Answer every unresolved thread from alice, then resolve them",
+			"gh-pr-review reply --pr 42 --all --author alice --body \"Addressed in 9f3c2ab\" --resolve",
+			"# Reply from the template templates/done.md (\"Done in {{.commit}}\")",
+			"gh-pr-review reply --thread-id PRRT_xxx --template done --var commit=abc1234",
+			"# Post one answer to several threads",
+			"gh-pr-review reply --thread-id PRRT_a --thread-id PRRT_b --body \"Fixed in abc1234\"",
+		},
+		flags: new(replyFlags).register,
+		run:   runReply,
+	},
+	{
+		name:    "resolve",
+		summary: "Resolve a review thread",
+		usage:   func(w io.Writer) { printResolveUsage(w, true) },
+		examples: []string{
+			"gh-pr-review resolve --thread-id PRRT_xxx",
+			"",
+			"# Resolve several threads; the rest still run if one fails",
+			"gh-pr-review resolve PRRT_aaa PRRT_bbb,PRRT_ccc",
+			"",
+			"# Resolve threads 3, 5 and 7 from the last list",
+			"gh-pr-review list --pr 42 && gh-pr-review resolve --pr 42 3 5 7",
+			"",
+			"# Resolve the thread on line 88 without looking up its ID",
+			"gh-pr-review resolve --pr 42 --path internal/github/graphql.go --line 88",
+			"",
+			"# Say why before resolving",
+			"gh-pr-review resolve --thread-id PRRT_xxx --comment \"Fixed in abc1234\"",
+			"",
+			"# Resolve every outdated thread after a fix-up push",
+			"gh-pr-review resolve --pr 42 --all --outdated",
+		},
+		flags: (&resolveFlags{resolve: true}).register,
+		run:   func(args []string) error { return runResolve(args, true) },
+	},
+	{
+		name:    "unresolve",
+		summary: "Reopen a resolved review thread",
+		usage:   func(w io.Writer) { printResolveUsage(w, false) },
+		examples: []string{
+			"gh-pr-review unresolve --thread-id PRRT_xxx",
+			"",
+			"# Reopen what a bot resolved in the last day",
+			"gh-pr-review unresolve --pr 42 --resolved-by some-bot --since 24h",
+		},
+		flags: new(resolveFlags).register,
+		run:   func(args []string) error { return runResolve(args, false) },
+	},
+	{
+		name:    "resolve-stale",
+		summary: "Resolve outdated threads on removed code",
+		usage:   printResolveStaleUsage,
+		examples: []string{
+			"# See which threads would be resolved",
+			"gh-pr-review resolve-stale --pr 42 --dry-run",
+			"",
+			"# Resolve threads untouched for two weeks, saying why",
+			"gh-pr-review resolve-stale --pr 42 --older-than 14d --comment \"Resolving: code was removed/refactored\"",
+		},
+		flags: new(resolveStaleFlags).register,
+		run:   runResolveStale,
+	},
+	{
+		name:    "view",
+		summary: "Show a single review thread",
+		usage:   printViewUsage,
+		examples: []string{
+			"gh-pr-review view --thread-id PRRT_xxx --diff",
+			"",
+			"# Open a thread from a link in the browser",
+			"gh-pr-review view --url https://github.com/owner/repo/pull/42#discussion_r123456789 --web",
+		},
+		flags: new(viewFlags).register,
+		run:   runView,
+	},
+	{
+		name:    "first-comment",
+		summary: "Print a thread's opening comment in one request",
+		usage:   printFirstCommentUsage,
+		examples: []string{
+			"gh-pr-review first-comment --thread-id PRRT_xxx",
+			"gh-pr-review first-comment PRRT_xxx --format json | jq -r .body",
+		},
+		flags: new(firstCommentFlags).register,
+		run:   runFirstComment,
+	},
+	{
+		name:    "diff",
+		summary: "Show a thread's diff hunk next to the same lines in your checkout",
+		usage:   printDiffUsage,
+		examples: []string{
+			"gh-pr-review diff --thread-id PRRT_xxx --context 10",
+		},
+		flags: new(diffFlags).register,
+		run:   runDiff,
+	},
+	{
+		name:    "edit",
+		summary: "Edit one of your review comments",
+		usage:   printEditUsage,
+		examples: []string{
+			"# Fix a typo in the editor",
+			"gh-pr-review edit --url https://github.com/owner/repo/pull/42#discussion_r123456789",
+			"",
+			"gh-pr-review edit --comment-id PRRC_xxx --body \"Use a buffered channel here.\"",
+		},
+		flags: new(editFlags).register,
+		run:   runEdit,
+	},
+	{
+		name:    "delete",
+		summary: "Delete a review comment",
+		usage:   printDeleteUsage,
+		examples: []string{
+			"gh-pr-review delete --url https://github.com/owner/repo/pull/42#discussion_r123456789",
+			"",
+			"# In a script",
+			"gh-pr-review delete --comment-id PRRC_xxx --yes --json",
+		},
+		flags: new(deleteFlags).register,
+		run:   runDelete,
+	},
+	{
+		name:    "react",
+		summary: "Add or remove a reaction on a review comment",
+		usage:   printReactUsage,
+		examples: []string{
+			"gh-pr-review react --url https://github.com/owner/repo/pull/42#discussion_r123456789 --emoji +1",
+			"gh-pr-review react --comment-id PRRC_xxx --emoji tada --remove",
+		},
+		flags: new(reactFlags).register,
+		run:   runReact,
+	},
+	{
+		name:    "minimize",
+		summary: "Hide a noisy comment as off-topic, outdated, resolved, ...",
+		usage:   printMinimizeUsage,
+		examples: []string{
+			"gh-pr-review minimize --url https://github.com/owner/repo/pull/42#discussion_r123456789 --reason outdated",
+			"gh-pr-review minimize --url https://github.com/owner/repo/pull/42#issuecomment-987654321 --reason off_topic",
+		},
+		flags: (&minimizeFlags{minimize: true}).register,
+		run:   func(args []string) error { return runMinimize(args, true) },
+	},
+	{
+		name:    "unminimize",
+		summary: "Show a minimized comment again",
+		usage:   printUnminimizeUsage,
+		examples: []string{
+			"gh-pr-review unminimize --comment-id PRRC_xxx",
+		},
+		flags: new(minimizeFlags).register,
+		run:   func(args []string) error { return runMinimize(args, false) },
+	},
+	{
+		name:    "goto",
+		summary: "Open the file a thread is on in your editor, at its line",
+		usage:   printGotoUsage,
+		examples: []string{
+			"gh-pr-review goto --thread-id PRRT_xxx",
+			"gh-pr-review goto --pr 42 --index 3",
+		},
+		flags: new(gotoFlags).register,
+		run:   runGoto,
+	},
+	{
+		name:    "lines",
+		summary: "Print a file's threads as path:line: entries for your editor",
+		usage:   printLinesUsage,
+		examples: []string{
+			"gh-pr-review lines --pr 42 --path internal/github/graphql.go",
+			"",
+			"# Step through every unresolved thread in vim's quickfix list",
+			"vim -q <(gh-pr-review lines --pr 42 --all-files --status unresolved)",
+		},
+		flags: new(linesFlags).register,
+		run:   runLines,
+	},
+	{
+		name:    "open",
+		summary: "Open a thread or the PR's changed files in the browser",
+		usage:   printOpenUsage,
+		examples: []string{
+			"gh-pr-review open --pr 42 --index 3",
+			"gh-pr-review open --pr 42",
+			"gh-pr-review open --thread-id PRRT_xxx --print",
+		},
+		flags: new(openFlags).register,
+		run:   runOpen,
+	},
+	{
+		name:    "copy",
+		summary: "Copy a thread's ID, URL or latest comment to the clipboard",
+		usage:   printCopyUsage,
+		examples: []string{
+			"gh-pr-review copy --thread-id PRRT_xxx --what url",
+			"",
+			"# Quote the latest reply somewhere else",
+			"gh-pr-review copy PRRT_xxx --what body",
+		},
+		flags: new(copyFlags).register,
+		run:   runCopy,
+	},
+	{
+		name:    "note",
+		summary: "Keep private notes on threads",
+		usage:   printNoteUsage,
+		examples: []string{
+			"gh-pr-review note set --thread-id PRRT_xxx --text \"waiting on perf numbers\"",
+			"gh-pr-review note show",
+		},
+		flags: new(noteFlags).register,
+		run:   runNote,
+	},
+	{
+		name:    "subscribe",
+		summary: "Turn on notifications for a PR",
+		usage:   func(w io.Writer) { printSubscribeUsage(w, true) },
+		examples: []string{
+			"gh-pr-review subscribe --pr 42",
+		},
+		flags: new(subscribeFlags).register,
+		run:   func(args []string) error { return runSubscribe(args, true) },
+	},
+	{
+		name:    "unsubscribe",
+		summary: "Turn off notifications for a PR",
+		usage:   func(w io.Writer) { printSubscribeUsage(w, false) },
+		examples: []string{
+			"gh-pr-review unsubscribe --pr 42",
+			"",
+			"# The thread's PR, since GitHub has no per-thread subscriptions",
+			"gh-pr-review unsubscribe --thread-id PRRT_xxx",
+		},
+		flags: new(subscribeFlags).register,
+		run:   func(args []string) error { return runSubscribe(args, false) },
+	},
+	{
+		name:    "batch",
+		summary: "Run a list of reply/resolve/unresolve actions from a file",
+		usage:   printBatchUsage,
+		examples: []string{
+			"gh-pr-review batch --file actions.json --dry-run",
+			"generate-plan | gh-pr-review batch --file - --json",
+		},
+		flags: new(batchFlags).register,
+		run:   runBatch,
+	},
+	{
+		name:    "inbox",
+		summary: "Unresolved threads across your open PRs",
+		usage:   printInboxUsage,
+		examples: []string{
+			"gh-pr-review inbox",
+			"gh-pr-review inbox --org my-org --unanswered",
+		},
+		flags: new(inboxFlags).register,
+		run:   runInbox,
+	},
+	{
+		name:    "watch",
+		summary: "Print new comments and resolution changes on a PR as they happen",
+		usage:   printWatchUsage,
+		examples: []string{
+			"gh-pr-review watch --pr 42",
+			"gh-pr-review watch --pr 42 --interval 2m --notify",
+		},
+		flags: new(watchFlags).register,
+		run:   runWatch,
+	},
+	{
+		name:    "pending",
+		summary: "Show, submit or discard your pending review",
+		usage:   printPendingUsage,
+		examples: []string{
+			"gh-pr-review pending --pr 42",
+			"gh-pr-review pending submit --pr 42 --event REQUEST_CHANGES --body \"See inline comments.\"",
+			"gh-pr-review pending discard --pr 42",
+		},
+		flags: new(pendingFlags).register,
+		run:   runPending,
+	},
+	{
+		name:    "review",
+		summary: "Approve, request changes or comment on a PR",
+		usage:   printReviewUsage,
+		examples: []string{
+			"gh-pr-review review --pr 42 --approve",
+			"gh-pr-review review --pr 42 --request-changes --body-file notes.md",
+		},
+		flags: new(reviewFlags).register,
+		run:   runReview,
+	},
+	{
+		name:    "comment",
+		summary: "Post a file of line comments as one review",
+		usage:   printCommentUsage,
+		examples: []string{
+			"gh-pr-review comment --pr 42 --from-file findings.json --dry-run",
+			"lint --json | to-findings | gh-pr-review comment --pr 42 --from-file - --pending",
+		},
+		flags: new(commentFlags).register,
+		run:   runComment,
+	},
+	{
+		name:    "rerequest",
+		summary: "Re-request review from previous reviewers",
+		usage:   printRerequestUsage,
+		examples: []string{
+			"# Everyone who has reviewed the PR",
+			"gh-pr-review rerequest --pr 42",
+			"",
+			"gh-pr-review rerequest --pr 42 --reviewer alice --reviewer bob",
+		},
+		flags: new(rerequestFlags).register,
+		run:   runRerequest,
+	},
+	{
+		name:    "stats",
+		summary: "Per-person review metrics for a PR or a repository",
+		usage:   printStatsUsage,
+		examples: []string{
+			"gh-pr-review stats --pr 42",
+			"gh-pr-review stats --all-prs --since 30d --json",
+		},
+		flags: new(statsFlags).register,
+		run:   runStats,
+	},
+	{
+		name:    "status",
+		summary: "Summarize a PR's review threads and review decision",
+		usage:   printStatusUsage,
+		examples: []string{
+			"gh-pr-review status --pr 42",
+			"gh-pr-review status --json | jq .unresolved",
+		},
+		flags: new(statusFlags).register,
+		run:   runStatus,
+	},
+	{
+		name:    "export",
+		summary: "Write a PR's review threads to a JSON or Markdown file",
+		usage:   printExportUsage,
+		examples: []string{
+			"gh-pr-review export --pr 42 --out review-42.json",
+			"gh-pr-review export --pr 42 --format markdown --out review-42.md",
+		},
+		flags: new(exportFlags).register,
+		run:   runExport,
+	},
+	{
+		name:    "archive",
+		summary: "Write a PR's review threads as a self-contained HTML page",
+		usage:   printArchiveUsage,
+		examples: []string{
+			"gh-pr-review archive --pr 42 --out review-42.html",
+			"gh-pr-review archive --pr 42 --title \"Q3 audit: payments API\" --out review-42.html",
+		},
+		flags: new(archiveFlags).register,
+		run:   runArchive,
+	},
+	{
+		name:    "suggestions",
+		summary: "List suggestion blocks and check them against the local checkout",
+		usage:   printSuggestionsUsage,
+		examples: []string{
+			"# Review pending suggestions, then apply them with git",
+			"gh-pr-review suggestions --pr 42 --unresolved-only",
+			"gh-pr-review suggestions --pr 42 --unresolved-only --format patch | git apply",
+		},
+		flags:       new(suggestionsFlags).register,
+		actionFlags: map[string]func(fs *flag.FlagSet){"apply": new(applyFlags).register},
+		run:         runSuggestions,
+	},
+	{
+		name:    "apply",
+		summary: "Apply suggestion blocks to the local working tree",
+		usage:   printApplyUsage,
+		examples: []string{
+			"# Apply one suggestion in place and tell the reviewer",
+			"gh-pr-review apply --thread-id PRRT_xxx --ack",
+			"",
+			"# Preview every pending suggestion, then apply and resolve them",
+			"gh-pr-review apply --all --pr 42 --dry-run",
+			"gh-pr-review apply --all --pr 42 --resolve",
+		},
+		flags: new(applyFlags).register,
+		run:   runApply,
+	},
+	{
+		name:    "config",
+		summary: "Read and change settings in the config file",
+		usage:   printConfigUsage,
+		examples: []string{
+			"gh-pr-review config set default_status unresolved",
+			"gh-pr-review config set default_host github.example.com --repo corp/api",
+			"gh-pr-review config list",
+		},
+		flags: new(configFlags).register,
+		run:   runConfig,
+	},
+	{
+		name:    "doctor",
+		summary: "Check that gh, the token and the API are set up",
+		usage:   printDoctorUsage,
+		examples: []string{
+			"gh-pr-review doctor",
+			"gh-pr-review doctor --host github.example.com",
+		},
+		flags: new(doctorFlags).register,
+		run:   runDoctor,
+	},
+	{
+		name:    "version",
+		summary: "Print version information",
+		usage:   printVersionUsage,
+		run: func(args []string) error {
+			fs := newFlagSet("version", printVersionUsage)
+			if err := parseFlags(fs, args); err != nil {
+				if errors.Is(err, flag.ErrHelp) {
+					return nil
+				}
+				return err
+			}
+			printVersion(os.Stdout)
+			return nil
+		},
+	},
+}
+
+// newFlagSet returns a FlagSet for a command that reports errors on stderr
+// and prints usage for -h.
+func newFlagSet(name string, usage func(io.Writer)) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	fs.Usage = func() { usage(fs.Output()) }
+	return fs
+}
+
+// commandFlags returns the flags cmd and its actions define, sorted by
+// name, or nil for a command without flags.
+func commandFlags(cmd command) []*flag.Flag {
+	var flags []*flag.Flag
+	seen := map[string]bool{}
+	add := func(register func(fs *flag.FlagSet)) {
+		if register == nil {
+			return
+		}
+		fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+		register(fs)
+		fs.VisitAll(func(f *flag.Flag) {
+			if !seen[f.Name] {
+				seen[f.Name] = true
+				flags = append(flags, f)
+			}
+		})
+	}
+	add(cmd.flags)
+	actions := make([]string, 0, len(cmd.actionFlags))
+	for action := range cmd.actionFlags {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+	for _, action := range actions {
+		add(cmd.actionFlags[action])
+	}
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Name < flags[j].Name })
+	return flags
+}
+
+// synopsis returns the command lines from the Usage section of the
+// command's usage text, so the overview always matches help <command>.
+func (c command) synopsis() []string {
+	var b strings.Builder
+	c.usage(&b)
+	lines := strings.Split(b.String(), "\n")
+	if lines[0] != "Usage:" {
+		return nil
+	}
+	var out []string
+	for _, line := range lines[1:] {
+		if strings.TrimSpace(line) == "" {
+			break
+		}
+		out = append(out, strings.TrimSpace(line))
+	}
+	return out
+}
+
+func findCommand(name string) *command {
+	for i := range commands {
+		if commands[i].name == name {
+			return &commands[i]
+		}
+	}
+	return nil
+}
+
+func runHelp(args []string) error {
+	if len(args) == 0 {
+		printUsage()
+		fmt.Fprintln(os.Stdout, "")
+		fmt.Fprintln(os.Stdout, "Commands:")
+		for _, cmd := range commands {
+			fmt.Fprintf(os.Stdout, "  %-10s %s\n", cmd.name, cmd.summary)
+		}
+		fmt.Fprintln(os.Stdout, "")
+		fmt.Fprintln(os.Stdout, "Run 'gh-pr-review help <command>' for flags and examples.")
+		return nil
+	}
+	switch args[0] {
+	case "completion":
+		printCompletionUsage(os.Stdout)
+		return nil
+	case "alias":
+		printAliasUsage(os.Stdout)
+		return nil
+	}
+	cmd := findCommand(args[0])
+	if cmd == nil {
+		msg := fmt.Sprintf("unknown command %q", args[0])
+		if suggestion := suggestCommand(args[0]); suggestion != "" {
+			msg += fmt.Sprintf(" (did you mean %q?)", suggestion)
+		}
+		return &exitError{code: 2, err: fmt.Errorf("%s", msg)}
+	}
+	printCommandHelp(os.Stdout, cmd)
+	return nil
+}
+
+func printCommandHelp(w io.Writer, cmd *command) {
+	fmt.Fprintf(w, "%s\n\n", cmd.summary)
+	cmd.usage(w)
+	if len(cmd.examples) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Examples:")
+	for _, line := range cmd.examples {
+		if line == "" {
+			fmt.Fprintln(w, "")
+			continue
+		}
+		fmt.Fprintf(w, "  %s\n", line)
+	}
+}
+
+// suggestCommand returns the closest command name to a mistyped one, or ""
+// when nothing is within two edits (the same cut-off gh uses).
+func suggestCommand(name string) string {
+	best := ""
+	bestDist := 0
+	for _, cmd := range commands {
+		d := editDistance(name, cmd.name)
+		if best == "" || d < bestDist {
+			best, bestDist = cmd.name, d
+		}
+	}
+	if best == "" || bestDist > 2 || bestDist >= len([]rune(name)) {
+		return ""
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+func printVersionUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage:\n  gh-pr-review version")
+}
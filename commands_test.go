@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestEditDistance(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"list", "list", 0},
+		{"", "reply", 5},
+		{"resolv", "resolve", 1},
+		{"rsolve", "resolve", 1},
+		{"replly", "reply", 1},
+		{"lsit", "list", 2},
+		{"kitten", "sitting", 3},
+	}
+	for _, tc := range cases {
+		if got := editDistance(tc.a, tc.b); got != tc.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestSuggestCommand(t *testing.T) {
+	cases := map[string]string{
+		"resolv":     "resolve",
+		"unreslve":   "unresolve",
+		"lst":        "list",
+		"repyl":      "reply",
+		"frobnicate": "",
+		"x":          "",
+	}
+	for input, want := range cases {
+		if got := suggestCommand(input); got != want {
+			t.Errorf("suggestCommand(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+// TestDocumentedCommandsDispatch checks that every command the README shows
+// reaches a handler: a registered command, one of the commands main handles
+// itself, or an alias the README defines first.
+func TestDocumentedCommandsDispatch(t *testing.T) {
+	data, err := os.ReadFile("README.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	builtin := map[string]bool{"help": true, "completion": true, "alias": true}
+	aliases := map[string]bool{}
+	inBlock := false
+	seen := 0
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(line, "```") {
+			inBlock = line == "```bash"
+			continue
+		}
+		fields := strings.Fields(line)
+		if !inBlock || len(fields) < 2 || fields[0] != "gh-pr-review" {
+			continue
+		}
+		name := fields[1]
+		if name == "alias" && len(fields) > 3 && fields[2] == "set" {
+			aliases[fields[3]] = true
+		}
+		seen++
+		if findCommand(name) == nil && !builtin[name] && !aliases[name] {
+			t.Errorf("README shows %q, which main doesn't dispatch", line)
+		}
+	}
+	if seen == 0 {
+		t.Fatal("found no commands in the README")
+	}
+	for _, cmd := range commands {
+		if cmd.run == nil || cmd.usage == nil {
+			t.Errorf("command %s has no run or usage function", cmd.name)
+		}
+		if c := findCommand(cmd.name); c == nil || c.name != cmd.name {
+			t.Errorf("findCommand(%q) doesn't find it", cmd.name)
+		}
+		for _, s := range cmd.synopsis() {
+			if !strings.HasPrefix(s, "gh-pr-review "+cmd.name) {
+				t.Errorf("synopsis %q doesn't start with the command name %s", s, cmd.name)
+			}
+		}
+		if !strings.Contains(string(data), "gh-pr-review "+cmd.name) {
+			t.Errorf("command %s isn't documented in the README", cmd.name)
+		}
+	}
+}
+
+func TestDefaultArgs(t *testing.T) {
+	origIn, origOut, origNoInput := stdinIsTTY, stdoutIsTTY, noInput
+	t.Cleanup(func() { stdinIsTTY, stdoutIsTTY, noInput = origIn, origOut, origNoInput })
+	cases := []struct {
+		stdin, stdout, noInput bool
+		want                   []string
+	}{
+		{true, true, false, []string{"tui"}},
+		{true, false, false, nil},
+		{false, true, false, nil},
+		{true, true, true, nil},
+	}
+	for _, c := range cases {
+		stdinIsTTY = func() bool { return c.stdin }
+		stdoutIsTTY = func() bool { return c.stdout }
+		noInput = c.noInput
+		if got := defaultArgs(); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("stdin %v, stdout %v, no-input %v: got %v, want %v", c.stdin, c.stdout, c.noInput, got, c.want)
+		}
+	}
+}
+
+// TestUsageMatchesFlags keeps each command's usage text, which help and the
+// overview print, in step with the flags the command actually parses.
+func TestUsageMatchesFlags(t *testing.T) {
+	option := regexp.MustCompile(`--[a-z][a-z0-9-]*`)
+	for _, cmd := range commands {
+		var b bytes.Buffer
+		cmd.usage(&b)
+		usage := b.String()
+		// Flags of actions with their own usage, like suggestions apply,
+		// are checked against that command.
+		fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+		if cmd.flags != nil {
+			cmd.flags(fs)
+		}
+		defined := map[string]bool{}
+		fs.VisitAll(func(f *flag.Flag) {
+			defined["--"+f.Name] = true
+			if len(f.Name) > 1 && !strings.Contains(usage, "--"+f.Name) {
+				t.Errorf("%s: --%s isn't in its usage", cmd.name, f.Name)
+			}
+		})
+		for _, line := range cmd.synopsis() {
+			for _, name := range option.FindAllString(line, -1) {
+				if !defined[name] {
+					t.Errorf("%s: synopsis shows %s, which it doesn't define", cmd.name, name)
+				}
+			}
+		}
+	}
+}
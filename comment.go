@@ -0,0 +1,418 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"gh-pr-review/internal/gh"
+	"gh-pr-review/internal/git"
+	"gh-pr-review/internal/github"
+)
+
+// commentEntry is one finding of a --from-file import: a comment to leave on
+// a line, or a range of lines, of the PR's diff.
+type commentEntry struct {
+	Path      string `json:"path"`
+	Line      int    `json:"line"`
+	StartLine int    `json:"startLine,omitempty"`
+	Side      string `json:"side,omitempty"`
+	Body      string `json:"body"`
+}
+
+type commentResult struct {
+	Index    int    `json:"index"`
+	Path     string `json:"path"`
+	Line     int    `json:"line"`
+	OK       bool   `json:"ok"`
+	ThreadID string `json:"threadId,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+type commentReport struct {
+	Results         []commentResult  `json:"results"`
+	PendingReviewID string           `json:"pendingReviewId,omitempty"`
+	Review          *submittedReview `json:"review,omitempty"`
+}
+
+type commentFlags struct {
+	repo     string
+	pr       int
+	file     string
+	event    string
+	body     string
+	bodyFile string
+	pending  bool
+	dryRun   bool
+	jsonOut  bool
+	host     string
+}
+
+func (o *commentFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.repo, "repo", "", "owner/name (defaults to gh repo view)")
+	fs.IntVar(&o.pr, "pr", 0, "PR number (defaults to current branch PR if available)")
+	fs.StringVar(&o.file, "from-file", "", "JSON array of comments to post (- for stdin)")
+	fs.StringVar(&o.event, "event", "", "submit the review as APPROVE|REQUEST_CHANGES|COMMENT (default COMMENT)")
+	fs.StringVar(&o.body, "body", "", "Review body")
+	fs.StringVar(&o.bodyFile, "body-file", "", "Read the review body from file (- for stdin)")
+	fs.BoolVar(&o.pending, "pending", false, "leave the review pending instead of submitting it")
+	fs.BoolVar(&o.dryRun, "dry-run", false, "check the comments against the diff without posting")
+	fs.BoolVar(&o.jsonOut, "json", false, "output per-comment results as JSON")
+	fs.StringVar(&o.host, "host", gh.DefaultHost(), "GitHub host")
+}
+
+func runComment(args []string) error {
+	fs := newFlagSet("comment", printCommentUsage)
+	var flags commentFlags
+	flags.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+	if flags.file == "" {
+		return errors.New("--from-file is required (use - to read the comments from stdin)")
+	}
+	if flags.file == "-" && flags.bodyFile == "-" {
+		return errors.New("only one of --from-file and --body-file can read stdin")
+	}
+	if flags.pending && (flags.event != "" || flags.body != "" || flags.bodyFile != "") {
+		return errors.New("--event, --body and --body-file don't apply with --pending")
+	}
+	flags.event = strings.ToUpper(strings.TrimSpace(flags.event))
+	if flags.event == "" {
+		flags.event = reviewEventComment
+	}
+	if flags.event != reviewEventApprove && flags.event != reviewEventRequestChanges && flags.event != reviewEventComment {
+		return fmt.Errorf("invalid --event %q (expected APPROVE|REQUEST_CHANGES|COMMENT)", flags.event)
+	}
+	entries, err := readCommentEntries(flags.file)
+	if err != nil {
+		return err
+	}
+	flags.body, err = resolveBody(flags.body, flags.bodyFile)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	flags.pr, err = resolvePR(ctx, flags.pr)
+	if err != nil {
+		return err
+	}
+	owner, name, err := resolveRepo(ctx, flags.repo)
+	if err != nil {
+		return err
+	}
+	patch, err := gh.PRDiff(ctx, flags.host+"/"+owner+"/"+name, flags.pr)
+	if err != nil {
+		return fmt.Errorf("fetching the diff of %s/%s#%d: %w", owner, name, flags.pr, err)
+	}
+	results := checkCommentEntries(entries, git.ParseDiffLines(patch))
+	valid := 0
+	for _, r := range results {
+		if r.OK {
+			valid++
+		}
+	}
+	out := os.Stdout
+	if flags.jsonOut {
+		out = os.Stderr
+	}
+	if flags.dryRun {
+		for _, r := range results {
+			printCommentResult(out, r, false)
+		}
+		if flags.jsonOut {
+			if err := writeJSON(os.Stdout, commentReport{Results: results}); err != nil {
+				return err
+			}
+		}
+		fmt.Fprintf(out, "%d valid, %d invalid\n", valid, len(results)-valid)
+		if valid < len(results) {
+			return &exitError{code: 1, err: fmt.Errorf("%d of %d comments are not on the diff", len(results)-valid, len(results))}
+		}
+		return nil
+	}
+	for _, r := range results {
+		if !r.OK {
+			printCommentResult(out, r, false)
+		}
+	}
+	if valid == 0 {
+		return errors.New("none of the comments are on the diff; nothing was posted")
+	}
+
+	client, err := newClient(ctx, flags.host)
+	if err != nil {
+		return err
+	}
+	prID, reviewID, err := fetchReviewTarget(ctx, client, owner, name, flags.pr)
+	if err != nil {
+		return err
+	}
+	existing := reviewID != ""
+	if !existing {
+		if reviewID, err = startPendingReview(ctx, client, prID); err != nil {
+			return err
+		}
+	}
+	results = postCommentEntries(ctx, client, reviewID, entries, results, func(r commentResult) { printCommentResult(out, r, true) })
+	posted, failed := 0, 0
+	for _, r := range results {
+		if r.ThreadID != "" {
+			posted++
+		} else {
+			failed++
+		}
+	}
+	report := commentReport{Results: results}
+	switch {
+	case posted == 0:
+		// Nothing to submit; don't leave behind an empty review this run started.
+		if !existing {
+			if err := deletePendingReview(ctx, client, reviewID); err != nil {
+				fmt.Fprintf(os.Stderr, "warning: couldn't delete the empty pending review: %v\n", err)
+			}
+		}
+	case flags.pending:
+		report.PendingReviewID = reviewID
+	default:
+		review, err := submitReview(ctx, client, prID, reviewID, flags.event, strings.TrimSpace(flags.body))
+		if err != nil {
+			return fmt.Errorf("posted %d comments but couldn't submit the review (it is still pending): %w", posted, err)
+		}
+		report.Review = &review
+	}
+	if flags.jsonOut {
+		if err := writeJSON(os.Stdout, report); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(out, "posted %d, failed %d\n", posted, failed)
+	switch {
+	case report.Review != nil:
+		if existing {
+			fmt.Fprintln(os.Stderr, "Submitted your pending review along with its earlier comments.")
+		}
+		fmt.Fprintf(out, "%s %s\n", reviewStateText(report.Review.State), report.Review.URL)
+	case report.PendingReviewID != "":
+		fmt.Fprintf(out, "left the review pending; submit it with `gh-pr-review pending submit --pr %d --event COMMENT`\n", flags.pr)
+	}
+	if failed > 0 {
+		return &exitError{code: 1, err: fmt.Errorf("%d of %d comments failed", failed, len(results))}
+	}
+	return nil
+}
+
+func readCommentEntries(file string) ([]commentEntry, error) {
+	var data []byte
+	var err error
+	if file == "-" {
+		data, err = io.ReadAll(promptReader())
+	} else {
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []commentEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("reading comments from %s: %w (expected a JSON array of {path, line, startLine, side, body})", file, err)
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("%s has no comments", file)
+	}
+	for i := range entries {
+		entries[i].Side = strings.ToUpper(strings.TrimSpace(entries[i].Side))
+		if entries[i].Side == "" {
+			entries[i].Side = "RIGHT"
+		}
+	}
+	return entries, nil
+}
+
+// checkCommentEntries validates every entry against the PR's diff, so
+// problems are reported before anything is posted.
+func checkCommentEntries(entries []commentEntry, diff map[string]git.DiffLines) []commentResult {
+	var results []commentResult
+	for i, e := range entries {
+		r := commentResult{Index: i + 1, Path: e.Path, Line: e.Line}
+		if err := checkCommentEntry(e, diff); err != nil {
+			r.Error = err.Error()
+		} else {
+			r.OK = true
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+// checkCommentEntry reports why GitHub would refuse an entry. A comment can
+// go on any line a hunk shows on the chosen side, context included, and a
+// multi-line comment must stay within one hunk.
+func checkCommentEntry(e commentEntry, diff map[string]git.DiffLines) error {
+	switch {
+	case e.Path == "":
+		return errors.New("missing path")
+	case e.Line <= 0:
+		return errors.New("missing line")
+	case e.StartLine < 0 || e.StartLine > e.Line:
+		return fmt.Errorf("startLine %d is after line %d", e.StartLine, e.Line)
+	case e.Side != "LEFT" && e.Side != "RIGHT":
+		return fmt.Errorf("invalid side %q (expected LEFT or RIGHT)", e.Side)
+	case strings.TrimSpace(e.Body) == "":
+		return errors.New("missing body")
+	}
+	file, ok := diff[e.Path]
+	if !ok {
+		return fmt.Errorf("%s is not in the PR's diff", e.Path)
+	}
+	ranges := file.Right
+	if e.Side == "LEFT" {
+		ranges = file.Left
+	}
+	start := e.StartLine
+	if start == 0 {
+		start = e.Line
+	}
+	for _, r := range ranges {
+		if start >= r.Start && e.Line <= r.End {
+			return nil
+		}
+	}
+	if start == e.Line {
+		return fmt.Errorf("line %d is not in the diff (%s side)", e.Line, strings.ToLower(e.Side))
+	}
+	return fmt.Errorf("lines %d-%d are not within one hunk of the diff (%s side)", start, e.Line, strings.ToLower(e.Side))
+}
+
+// startPendingReview creates an empty pending review; leaving out the event
+// keeps it unsubmitted.
+func startPendingReview(ctx context.Context, client *github.Client, prID string) (string, error) {
+	mutation := `mutation($id:ID!) {
+  addPullRequestReview(input:{pullRequestId:$id}) { pullRequestReview { id } }
+}`
+	var resp struct {
+		AddPullRequestReview struct {
+			PullRequestReview struct {
+				ID string `json:"id"`
+			} `json:"pullRequestReview"`
+		} `json:"addPullRequestReview"`
+	}
+	if err := client.Do(ctx, mutation, map[string]interface{}{"id": prID}, &resp); err != nil {
+		return "", err
+	}
+	if resp.AddPullRequestReview.PullRequestReview.ID == "" {
+		return "", errors.New("missing mutation response")
+	}
+	return resp.AddPullRequestReview.PullRequestReview.ID, nil
+}
+
+// postCommentEntries adds a thread to the pending review for each entry that
+// passed checkCommentEntries, reporting each result as it completes and
+// carrying on past failures.
+func postCommentEntries(ctx context.Context, client *github.Client, reviewID string, entries []commentEntry, checked []commentResult, report func(commentResult)) []commentResult {
+	results := make([]commentResult, len(checked))
+	copy(results, checked)
+	for i, e := range entries {
+		if !results[i].OK {
+			continue
+		}
+		threadID, err := addReviewThread(ctx, client, reviewID, e)
+		if err != nil {
+			results[i].OK = false
+			results[i].Error = err.Error()
+		} else {
+			results[i].ThreadID = threadID
+		}
+		report(results[i])
+	}
+	return results
+}
+
+func addReviewThread(ctx context.Context, client *github.Client, reviewID string, e commentEntry) (string, error) {
+	mutation := `mutation($review:ID!, $path:String!, $line:Int!, $side:DiffSide, $startLine:Int, $startSide:DiffSide, $body:String!) {
+  addPullRequestReviewThread(input:{pullRequestReviewId:$review, path:$path, line:$line, side:$side, startLine:$startLine, startSide:$startSide, body:$body}) {
+    thread { id }
+  }
+}`
+	vars := map[string]interface{}{
+		"review": reviewID,
+		"path":   e.Path,
+		"line":   e.Line,
+		"side":   e.Side,
+		"body":   e.Body,
+	}
+	if e.StartLine > 0 && e.StartLine < e.Line {
+		vars["startLine"] = e.StartLine
+		vars["startSide"] = e.Side
+	}
+	var resp struct {
+		AddPullRequestReviewThread struct {
+			Thread *struct {
+				ID string `json:"id"`
+			} `json:"thread"`
+		} `json:"addPullRequestReviewThread"`
+	}
+	if err := client.Do(ctx, mutation, vars, &resp); err != nil {
+		return "", err
+	}
+	if resp.AddPullRequestReviewThread.Thread == nil || resp.AddPullRequestReviewThread.Thread.ID == "" {
+		return "", errors.New("GitHub didn't create the thread")
+	}
+	return resp.AddPullRequestReviewThread.Thread.ID, nil
+}
+
+// printCommentResult prints an entry's outcome. Entries that fail the diff
+// check are invalid; ones GitHub refuses while posting have failed.
+func printCommentResult(w io.Writer, r commentResult, posting bool) {
+	styler := newStyler(w)
+	outcome := "ok"
+	switch {
+	case !r.OK && !posting:
+		outcome = "invalid: " + r.Error
+	case !r.OK:
+		outcome = "failed: " + r.Error
+	case r.ThreadID != "":
+		outcome = "posted " + styler.threadID(r.ThreadID)
+	}
+	fmt.Fprintf(w, "%d. %s:%d: %s\n", r.Index, r.Path, r.Line, outcome)
+}
+
+func printCommentUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage:")
+	fmt.Fprintln(w, "  gh-pr-review comment --from-file <findings.json|-> [--pr <number>] [--repo owner/name] [--event APPROVE|REQUEST_CHANGES|COMMENT] [--body <text>|--body-file <path>] [--pending] [--dry-run] [--json] [--host host]")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Posts a file of findings as one review with a thread per entry. The file is a JSON array:")
+	fmt.Fprintln(w, `  [{"path": "main.go", "line": 42, "body": "Check the error here."},`)
+	fmt.Fprintln(w, `   {"path": "util.go", "startLine": 10, "line": 14, "side": "RIGHT", "body": "This loop can go."}]`)
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "side is RIGHT (the new code, default) or LEFT (the old code); startLine makes a multi-line")
+	fmt.Fprintln(w, "comment. Every entry is checked against the PR's diff first: entries on lines the diff")
+	fmt.Fprintln(w, "doesn't show are reported and skipped, the rest are posted, and the exit status is 1 if any")
+	fmt.Fprintln(w, "were skipped or failed. The comments go into your pending review, which is then submitted")
+	fmt.Fprintln(w, "with --event unless --pending is given. An existing pending review is added to, and its earlier")
+	fmt.Fprintln(w, "comments are submitted with it.")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Flags:")
+	fmt.Fprintln(w, "  --from-file <path>   JSON array of comments to post (- for stdin)")
+	fmt.Fprintln(w, "  --pr <number>   PR number (defaults to current branch PR if available)")
+	fmt.Fprintln(w, "  --repo <owner/name>   Repository (defaults to gh repo view)")
+	fmt.Fprintln(w, "  --event <value>   Submit the review as APPROVE, REQUEST_CHANGES or COMMENT (default)")
+	fmt.Fprintln(w, "  --body <text>   Review body")
+	fmt.Fprintln(w, "  --body-file <path>   Read the review body from file (- for stdin)")
+	fmt.Fprintln(w, "  --pending   Leave the review pending instead of submitting it")
+	fmt.Fprintln(w, "  --dry-run   Check the comments against the diff without posting anything")
+	fmt.Fprintln(w, "  --json   Output per-comment results, and the review, as JSON")
+	fmt.Fprintln(w, "  --host <host>   GitHub host")
+}
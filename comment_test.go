@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gh-pr-review/internal/git"
+	"gh-pr-review/internal/github"
+)
+
+func TestCheckCommentEntry(t *testing.T) {
+	diff := map[string]git.DiffLines{
+		"main.go": {
+			Left:  []git.LineRange{{Start: 8, End: 13}},
+			Right: []git.LineRange{{Start: 8, End: 16}, {Start: 40, End: 45}},
+		},
+	}
+	cases := []struct {
+		name  string
+		entry commentEntry
+		want  string
+	}{
+		{"added line", commentEntry{Path: "main.go", Line: 12, Side: "RIGHT", Body: "x"}, ""},
+		{"context line", commentEntry{Path: "main.go", Line: 8, Side: "RIGHT", Body: "x"}, ""},
+		{"old side", commentEntry{Path: "main.go", Line: 13, Side: "LEFT", Body: "x"}, ""},
+		{"range in hunk", commentEntry{Path: "main.go", StartLine: 40, Line: 45, Side: "RIGHT", Body: "x"}, ""},
+		{"outside hunks", commentEntry{Path: "main.go", Line: 20, Side: "RIGHT", Body: "x"}, "line 20 is not in the diff"},
+		{"old side outside", commentEntry{Path: "main.go", Line: 16, Side: "LEFT", Body: "x"}, "line 16 is not in the diff (left side)"},
+		{"range across hunks", commentEntry{Path: "main.go", StartLine: 14, Line: 41, Side: "RIGHT", Body: "x"}, "lines 14-41 are not within one hunk"},
+		{"file not in diff", commentEntry{Path: "other.go", Line: 1, Side: "RIGHT", Body: "x"}, "other.go is not in the PR's diff"},
+		{"no body", commentEntry{Path: "main.go", Line: 12, Side: "RIGHT", Body: " "}, "missing body"},
+		{"bad side", commentEntry{Path: "main.go", Line: 12, Side: "UP", Body: "x"}, "invalid side"},
+		{"start after line", commentEntry{Path: "main.go", StartLine: 13, Line: 12, Side: "RIGHT", Body: "x"}, "startLine 13 is after line 12"},
+	}
+	for _, tc := range cases {
+		err := checkCommentEntry(tc.entry, diff)
+		switch {
+		case tc.want == "" && err != nil:
+			t.Errorf("%s: unexpected error %v", tc.name, err)
+		case tc.want != "" && (err == nil || !strings.Contains(err.Error(), tc.want)):
+			t.Errorf("%s: error = %v, want %q", tc.name, err, tc.want)
+		}
+	}
+}
+
+func TestPostCommentEntriesCarriesOnPastFailures(t *testing.T) {
+	var posted []map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req github.GraphQLRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+			return
+		}
+		posted = append(posted, req.Variables)
+		if req.Variables["path"] == "gone.go" {
+			json.NewEncoder(w).Encode(map[string]interface{}{"errors": []map[string]string{{"message": "Path could not be resolved"}}})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{
+			"addPullRequestReviewThread": map[string]interface{}{"thread": map[string]string{"id": "PRRT_" + req.Variables["path"].(string)}},
+		}})
+	}))
+	defer srv.Close()
+	client := github.NewClient(srv.URL, "token")
+
+	entries := []commentEntry{
+		{Path: "a.go", StartLine: 3, Line: 5, Side: "RIGHT", Body: "one"},
+		{Path: "skipped.go", Line: 1, Side: "RIGHT", Body: "two"},
+		{Path: "gone.go", Line: 1, Side: "RIGHT", Body: "three"},
+		{Path: "b.go", Line: 7, Side: "LEFT", Body: "four"},
+	}
+	checked := []commentResult{
+		{Index: 1, Path: "a.go", Line: 5, OK: true},
+		{Index: 2, Path: "skipped.go", Line: 1, Error: "skipped.go is not in the PR's diff"},
+		{Index: 3, Path: "gone.go", Line: 1, OK: true},
+		{Index: 4, Path: "b.go", Line: 7, OK: true},
+	}
+	var reported []int
+	results := postCommentEntries(context.Background(), client, "PRR_1", entries, checked, func(r commentResult) { reported = append(reported, r.Index) })
+
+	if len(posted) != 3 {
+		t.Fatalf("posted %d threads, want 3 (the invalid entry is skipped)", len(posted))
+	}
+	if posted[0]["review"] != "PRR_1" || posted[0]["startLine"] != float64(3) || posted[0]["startSide"] != "RIGHT" {
+		t.Errorf("multi-line variables = %v", posted[0])
+	}
+	if _, ok := posted[2]["startLine"]; ok || posted[2]["side"] != "LEFT" {
+		t.Errorf("single-line variables = %v", posted[2])
+	}
+	if len(reported) != 3 || reported[0] != 1 || reported[2] != 4 {
+		t.Errorf("reported = %v", reported)
+	}
+	if results[0].ThreadID != "PRRT_a.go" || results[3].ThreadID != "PRRT_b.go" {
+		t.Errorf("results = %+v", results)
+	}
+	if results[1].OK || results[1].ThreadID != "" || !strings.Contains(results[1].Error, "not in the PR's diff") {
+		t.Errorf("skipped result = %+v", results[1])
+	}
+	if results[2].OK || !strings.Contains(results[2].Error, "Path could not be resolved") {
+		t.Errorf("failed result = %+v", results[2])
+	}
+	if !checked[2].OK {
+		t.Error("postCommentEntries modified its input")
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gh-pr-review/internal/github"
+)
+
+// commentRef identifies a PR comment by its REST (database) ID, as found in
+// comment URLs.
+type commentRef struct {
+	host  string
+	owner string
+	name  string
+	pr    int
+	id    string
+	// issueComment is set for #issuecomment-<id> URLs, which point at PR
+	// conversation comments rather than review comments.
+	issueComment bool
+}
+
+var (
+	pullPathPattern        = regexp.MustCompile(`^/([^/]+)/([^/]+)/pull/(\d+)(?:/(?:files|changes|commits)(?:/[0-9a-f]*)?)?/?$`)
+	reviewFragmentPattern  = regexp.MustCompile(`^(?:discussion_)?r(\d+)$`)
+	issueFragmentPattern   = regexp.MustCompile(`^issuecomment-(\d+)$`)
+	errCommentURLNoComment = errors.New("the URL doesn't point at a comment (expected a #discussion_r<id> fragment)")
+)
+
+// parseCommentRef accepts a comment URL, or a bare numeric comment ID that
+// is looked up on the PR given by --pr/--repo.
+func parseCommentRef(value string) (commentRef, error) {
+	value = strings.TrimSpace(value)
+	if isDigits(value) {
+		return commentRef{id: value}, nil
+	}
+	return parseCommentURL(value)
+}
+
+// parseCommentURL parses URLs like
+// https://github.com/owner/repo/pull/42#discussion_r123456789.
+func parseCommentURL(raw string) (commentRef, error) {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return commentRef{}, fmt.Errorf("invalid comment URL %q: expected https://HOST/OWNER/REPO/pull/N#discussion_r<id> or a numeric comment ID", raw)
+	}
+	m := pullPathPattern.FindStringSubmatch(u.Path)
+	if m == nil {
+		return commentRef{}, fmt.Errorf("invalid comment URL %q: not a pull request URL", raw)
+	}
+	pr, _ := strconv.Atoi(m[3])
+	ref := commentRef{host: strings.TrimPrefix(u.Host, "www."), owner: m[1], name: m[2], pr: pr}
+	if f := reviewFragmentPattern.FindStringSubmatch(u.Fragment); f != nil {
+		ref.id = f[1]
+		return ref, nil
+	}
+	if f := issueFragmentPattern.FindStringSubmatch(u.Fragment); f != nil {
+		ref.id = f[1]
+		ref.issueComment = true
+		return ref, nil
+	}
+	return commentRef{}, fmt.Errorf("invalid comment URL %q: %w", raw, errCommentURLNoComment)
+}
+
+// threadForComment finds the thread holding the comment with the given
+// database ID. Both the 32-bit databaseId and fullDatabaseId are compared, as
+// newer IDs only fit the latter.
+func threadForComment(threads []reviewThread, id string) (reviewThread, bool) {
+	for _, t := range threads {
+		for _, c := range t.Comments.Nodes {
+			if commentHasID(c, id) {
+				return t, true
+			}
+		}
+	}
+	return reviewThread{}, false
+}
+
+// commentHasID reports whether id is the comment's numeric database ID.
+func commentHasID(c reviewComment, id string) bool {
+	return c.FullDatabaseID == id || (c.DatabaseID != 0 && strconv.FormatInt(c.DatabaseID, 10) == id)
+}
+
+// threadIDForComment returns the node ID of the review thread holding the
+// referenced comment. repo and pr fill in what a bare numeric ID lacks.
+func threadIDForComment(ctx context.Context, client *github.Client, ref commentRef, repo string, pr int) (string, error) {
+	t, _, err := commentForRef(ctx, client, ref, repo, pr)
+	return t.ID, err
+}
+
+// commentForRef finds the referenced review comment and the thread holding
+// it.
+func commentForRef(ctx context.Context, client *github.Client, ref commentRef, repo string, pr int) (reviewThread, reviewComment, error) {
+	if ref.issueComment {
+		return reviewThread{}, reviewComment{}, fmt.Errorf("comment %s is a PR conversation comment, not part of a review thread", ref.id)
+	}
+	owner, name := ref.owner, ref.name
+	if owner == "" {
+		var err error
+		if pr, err = resolvePR(ctx, pr); err != nil {
+			return reviewThread{}, reviewComment{}, err
+		}
+		if owner, name, err = resolveRepo(ctx, repo); err != nil {
+			return reviewThread{}, reviewComment{}, err
+		}
+	} else {
+		pr = ref.pr
+	}
+	threads, err := fetchAllThreads(ctx, client, owner, name, pr)
+	if err != nil {
+		return reviewThread{}, reviewComment{}, err
+	}
+	if t, ok := threadForComment(threads, ref.id); ok {
+		for _, c := range t.Comments.Nodes {
+			if commentHasID(c, ref.id) {
+				return t, c, nil
+			}
+		}
+	}
+	comments, err := fetchPRComments(ctx, client, owner, name, pr)
+	if err != nil {
+		return reviewThread{}, reviewComment{}, err
+	}
+	for _, c := range comments {
+		if strconv.FormatInt(c.DatabaseID, 10) == ref.id {
+			return reviewThread{}, reviewComment{}, fmt.Errorf("comment %s is a PR conversation comment, not part of a review thread", ref.id)
+		}
+	}
+	return reviewThread{}, reviewComment{}, fmt.Errorf("comment %s not found on %s/%s#%d", ref.id, owner, name, pr)
+}
+
+// commentRefHost returns the host of the first comment URL, or fallback
+// when none carries one.
+func commentRefHost(refs []commentRef, fallback string) string {
+	for _, r := range refs {
+		if r.host != "" {
+			return r.host
+		}
+	}
+	return fallback
+}
+
+func parseCommentRefs(values []string) ([]commentRef, error) {
+	refs := make([]commentRef, 0, len(values))
+	for _, v := range values {
+		ref, err := parseCommentRef(v)
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, ref)
+	}
+	return refs, nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseCommentURL(t *testing.T) {
+	cases := []struct {
+		input string
+		want  commentRef
+		err   string
+	}{
+		{input: "https://github.com/owner/repo/pull/42#discussion_r123456789", want: commentRef{host: "github.com", owner: "owner", name: "repo", pr: 42, id: "123456789"}},
+		{input: "https://github.com/owner/repo/pull/42/files#r987", want: commentRef{host: "github.com", owner: "owner", name: "repo", pr: 42, id: "987"}},
+		{input: "https://ghe.example.com/org/app/pull/7/changes/abc123#r5", want: commentRef{host: "ghe.example.com", owner: "org", name: "app", pr: 7, id: "5"}},
+		{input: "https://github.com/owner/repo/pull/42#issuecomment-55", want: commentRef{host: "github.com", owner: "owner", name: "repo", pr: 42, id: "55", issueComment: true}},
+		{input: "123456789", want: commentRef{id: "123456789"}},
+		{input: "https://github.com/owner/repo/pull/42", err: "doesn't point at a comment"},
+		{input: "https://github.com/owner/repo/issues/42#issuecomment-55", err: "not a pull request URL"},
+		{input: "discussion_r123", err: "invalid comment URL"},
+	}
+	for _, tc := range cases {
+		got, err := parseCommentRef(tc.input)
+		if tc.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("%s: expected error containing %q, got %v", tc.input, tc.err, err)
+			}
+			continue
+		}
+		if err != nil || got != tc.want {
+			t.Errorf("%s: got %+v (%v), want %+v", tc.input, got, err, tc.want)
+		}
+	}
+	if _, err := parseCommentURL("https://github.com/o/r/pull/1"); !errors.Is(err, errCommentURLNoComment) {
+		t.Errorf("expected errCommentURLNoComment, got %v", err)
+	}
+}
+
+// TestCommentReferencesRoundTrip checks that a comment URL, its numeric
+// database ID and the comment's node lead to the same thread.
+func TestCommentReferencesRoundTrip(t *testing.T) {
+	threads := []reviewThread{{ID: "PRRT_a"}, {ID: "PRRT_b"}, {ID: "PRRT_c"}}
+	threads[0].Comments.Nodes = []reviewComment{{ID: "PRRC_1", DatabaseID: 1001, FullDatabaseID: "1001"}}
+	threads[1].Comments.Nodes = []reviewComment{
+		{ID: "PRRC_2", DatabaseID: 1002, FullDatabaseID: "1002"},
+		{ID: "PRRC_3", DatabaseID: 1003, FullDatabaseID: "1003", URL: "https://github.com/o/r/pull/9#discussion_r1003"},
+	}
+	// IDs beyond 32 bits only come back as fullDatabaseId.
+	threads[2].Comments.Nodes = []reviewComment{{ID: "PRRC_4", FullDatabaseID: "3000000000", URL: "https://github.com/o/r/pull/9#discussion_r3000000000"}}
+
+	for _, want := range []struct{ url, node, thread string }{
+		{"https://github.com/o/r/pull/9#discussion_r1003", "PRRC_3", "PRRT_b"},
+		{"https://github.com/o/r/pull/9#discussion_r3000000000", "PRRC_4", "PRRT_c"},
+	} {
+		fromURL, err := parseCommentRef(want.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fromID, err := parseCommentRef(fromURL.id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		byURL, ok1 := threadForComment(threads, fromURL.id)
+		byID, ok2 := threadForComment(threads, fromID.id)
+		if !ok1 || !ok2 || byURL.ID != want.thread || byID.ID != want.thread {
+			t.Fatalf("%s: resolved to %q/%q, want %s", want.url, byURL.ID, byID.ID, want.thread)
+		}
+		var node reviewComment
+		for _, c := range byURL.Comments.Nodes {
+			if c.FullDatabaseID == fromURL.id {
+				node = c
+			}
+		}
+		if node.ID != want.node || node.URL != want.url {
+			t.Fatalf("%s: node %q has URL %q", want.url, node.ID, node.URL)
+		}
+	}
+	if _, ok := threadForComment(threads, "42"); ok {
+		t.Fatal("expected unknown comment ID not to match")
+	}
+}
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+var completionShells = []string{"bash", "zsh", "fish"}
+
+var threadStatuses = []string{"all", "resolved", "unresolved", "resolved-no-reply"}
+
+// flagValueHints lists the values offered when completing a flag's
+// argument, per command.
+var flagValueHints = map[string]map[string][]string{
+	"list": {
+		"status": threadStatuses,
+		"format": {formatText, formatTable},
+		"sort":   {sortAPI, sortDiff, sortPath, sortNewest, sortOldestUnresolved},
+		"round":  {"latest", "all"},
+	},
+	"tui":           {"status": threadStatuses},
+	"reply":         {"status": threadStatuses},
+	"resolve":       {"status": threadStatuses},
+	"unresolve":     {"status": threadStatuses},
+	"export":        {"status": threadStatuses, "format": {"json", "markdown"}},
+	"suggestions":   {"format": {"text", "patch"}},
+	"pending":       {"event": {reviewEventApprove, reviewEventRequestChanges, reviewEventComment}},
+	"comment":       {"event": {reviewEventApprove, reviewEventRequestChanges, reviewEventComment}},
+	"react":         {"emoji": reactionNames()},
+	"minimize":      {"reason": minimizeReasonNames()},
+	"copy":          {"what": copyTargets},
+	"first-comment": {"format": {formatText, "json"}},
+}
+
+// commandArgs lists the positional words a command accepts.
+var commandArgs = map[string][]string{
+	"suggestions": {"apply"},
+	"pending":     {"show", "submit", "discard"},
+	"config":      {"get", "set", "list"},
+	"note":        {"set", "show", "clear"},
+}
+
+type completionFlag struct {
+	name       string
+	usage      string
+	takesValue bool
+	repeatable bool
+	values     []string
+}
+
+// option is the flag as typed: -y for one-letter names, --name otherwise.
+func (f completionFlag) option() string {
+	if len(f.name) == 1 {
+		return "-" + f.name
+	}
+	return "--" + f.name
+}
+
+type completionCommand struct {
+	name    string
+	summary string
+	args    []string
+	flags   []completionFlag
+}
+
+var globalCompletionFlags = []completionFlag{
+	{name: "timeout", usage: "Limit for each gh call and API request", takesValue: true},
+	{name: "yes", usage: "Answer yes to every confirmation prompt"},
+	{name: "no-input", usage: "Never prompt"},
+}
+
+func runCompletion(args []string) error {
+	fs := newFlagSet("completion", printCompletionUsage)
+	if err := parseFlags(fs, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("provide a shell: bash, zsh or fish")
+	}
+	cmds := completionCommands()
+	switch fs.Arg(0) {
+	case "bash":
+		writeBashCompletion(os.Stdout, cmds)
+	case "zsh":
+		writeZshCompletion(os.Stdout, cmds)
+	case "fish":
+		writeFishCompletion(os.Stdout, cmds)
+	default:
+		return fmt.Errorf("unsupported shell %q (expected bash, zsh or fish)", fs.Arg(0))
+	}
+	return nil
+}
+
+// completionCommands describes every command with the flags its FlagSet
+// actually defines, so the scripts can't drift from the real flags.
+func completionCommands() []completionCommand {
+	var cmds []completionCommand
+	for _, cmd := range commands {
+		c := completionCommand{name: cmd.name, summary: cmd.summary, args: commandArgs[cmd.name]}
+		for _, f := range commandFlags(cmd) {
+			cf := completionFlag{name: f.Name, usage: f.Usage, takesValue: true, values: flagValueHints[cmd.name][f.Name]}
+			if b, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && b.IsBoolFlag() {
+				cf.takesValue = false
+			}
+			if _, ok := f.Value.(*stringList); ok {
+				cf.repeatable = true
+			}
+			c.flags = append(c.flags, cf)
+		}
+		cmds = append(cmds, c)
+	}
+	var names []string
+	for _, c := range cmds {
+		names = append(names, c.name)
+	}
+	cmds = append(cmds,
+		completionCommand{name: "help", summary: "Show help for a command", args: names},
+		completionCommand{name: "completion", summary: "Print a shell completion script", args: completionShells},
+		completionCommand{name: "alias", summary: "Define shortcuts for command lines", args: []string{"set", "list", "delete"}},
+	)
+	return cmds
+}
+
+func writeBashCompletion(w io.Writer, cmds []completionCommand) {
+	var names []string
+	for _, c := range cmds {
+		names = append(names, c.name)
+	}
+	var globals []string
+	for _, f := range globalCompletionFlags {
+		globals = append(globals, f.option())
+	}
+	fmt.Fprintln(w, "# bash completion for gh-pr-review")
+	fmt.Fprintln(w, "_gh_pr_review() {")
+	fmt.Fprintln(w, "    local cur prev cmd i words")
+	fmt.Fprintln(w, `    cur="${COMP_WORDS[COMP_CWORD]}"`)
+	fmt.Fprintln(w, `    prev="${COMP_WORDS[COMP_CWORD-1]}"`)
+	fmt.Fprintln(w, `    cmd=""`)
+	fmt.Fprintln(w, "    for ((i = 1; i < COMP_CWORD; i++)); do")
+	fmt.Fprintln(w, `        case "${COMP_WORDS[i]}" in`)
+	fmt.Fprintln(w, "            --timeout) ((i++)) ;;")
+	fmt.Fprintln(w, "            -*) ;;")
+	fmt.Fprintln(w, `            *) cmd="${COMP_WORDS[i]}"; break ;;`)
+	fmt.Fprintln(w, "        esac")
+	fmt.Fprintln(w, "    done")
+	fmt.Fprintln(w, `    if [[ -z "$cmd" ]]; then`)
+	fmt.Fprintf(w, "        [[ \"$prev\" == --timeout ]] && return\n")
+	fmt.Fprintf(w, "        COMPREPLY=($(compgen -W \"%s %s\" -- \"$cur\"))\n", strings.Join(names, " "), strings.Join(globals, " "))
+	fmt.Fprintln(w, "        return")
+	fmt.Fprintln(w, "    fi")
+	fmt.Fprintln(w, `    case "$cmd $prev" in`)
+	for _, c := range cmds {
+		var free []string
+		for _, f := range c.flags {
+			switch {
+			case len(f.values) > 0:
+				fmt.Fprintf(w, "        \"%s %s\") COMPREPLY=($(compgen -W \"%s\" -- \"$cur\")); return ;;\n", c.name, f.option(), strings.Join(f.values, " "))
+			case f.takesValue:
+				free = append(free, fmt.Sprintf("\"%s %s\"", c.name, f.option()))
+			}
+		}
+		// Flags with free-form values fall back to file names.
+		if len(free) > 0 {
+			fmt.Fprintf(w, "        %s) COMPREPLY=(); return ;;\n", strings.Join(free, "|"))
+		}
+	}
+	fmt.Fprintln(w, "    esac")
+	fmt.Fprintln(w, `    case "$cmd" in`)
+	for _, c := range cmds {
+		words := append([]string{}, c.args...)
+		for _, f := range c.flags {
+			words = append(words, f.option())
+		}
+		fmt.Fprintf(w, "        %s) words=\"%s\" ;;\n", c.name, strings.Join(words, " "))
+	}
+	fmt.Fprintln(w, `        *) words="" ;;`)
+	fmt.Fprintln(w, "    esac")
+	fmt.Fprintln(w, `    COMPREPLY=($(compgen -W "$words" -- "$cur"))`)
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w, "complete -o default -F _gh_pr_review gh-pr-review")
+}
+
+func writeZshCompletion(w io.Writer, cmds []completionCommand) {
+	fmt.Fprintln(w, "#compdef gh-pr-review")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "_gh_pr_review() {")
+	fmt.Fprintln(w, "    local curcontext=\"$curcontext\" state line")
+	fmt.Fprintln(w, "    local -a commands")
+	fmt.Fprintln(w, "    commands=(")
+	for _, c := range cmds {
+		fmt.Fprintf(w, "        %s\n", zshQuote(c.name+":"+c.summary))
+	}
+	fmt.Fprintln(w, "    )")
+	fmt.Fprintln(w, "    _arguments -C \\")
+	for _, f := range globalCompletionFlags {
+		fmt.Fprintf(w, "        %s \\\n", zshQuote(zshFlagSpec(f)))
+	}
+	fmt.Fprintln(w, "        '1:command:->command' \\")
+	fmt.Fprintln(w, "        '*::arg:->args'")
+	fmt.Fprintln(w, "    case $state in")
+	fmt.Fprintln(w, "    command)")
+	fmt.Fprintln(w, "        _describe -t commands 'gh-pr-review command' commands")
+	fmt.Fprintln(w, "        ;;")
+	fmt.Fprintln(w, "    args)")
+	fmt.Fprintln(w, "        case $line[1] in")
+	for _, c := range cmds {
+		fmt.Fprintf(w, "        %s)\n", c.name)
+		fmt.Fprint(w, "            _arguments")
+		if len(c.args) > 0 {
+			fmt.Fprintf(w, " \\\n                %s", zshQuote("1:argument:("+strings.Join(c.args, " ")+")"))
+		}
+		for _, f := range c.flags {
+			fmt.Fprintf(w, " \\\n                %s", zshQuote(zshFlagSpec(f)))
+		}
+		fmt.Fprintln(w, "")
+		fmt.Fprintln(w, "            ;;")
+	}
+	fmt.Fprintln(w, "        esac")
+	fmt.Fprintln(w, "        ;;")
+	fmt.Fprintln(w, "    esac")
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, `_gh_pr_review "$@"`)
+}
+
+func zshFlagSpec(f completionFlag) string {
+	desc := strings.NewReplacer("[", `\[`, "]", `\]`).Replace(f.usage)
+	spec := f.option()
+	if f.repeatable {
+		spec = "*" + spec
+	}
+	if !f.takesValue {
+		return spec + "[" + desc + "]"
+	}
+	spec += "=[" + desc + "]:" + f.name + ":"
+	if len(f.values) > 0 {
+		spec += "(" + strings.Join(f.values, " ") + ")"
+	}
+	return spec
+}
+
+func zshQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func writeFishCompletion(w io.Writer, cmds []completionCommand) {
+	fmt.Fprintln(w, "# fish completion for gh-pr-review")
+	fmt.Fprintln(w, "complete -c gh-pr-review -f")
+	for _, f := range globalCompletionFlags {
+		fmt.Fprintln(w, "complete -c gh-pr-review -n __fish_use_subcommand "+fishFlagSpec(f))
+	}
+	for _, c := range cmds {
+		fmt.Fprintf(w, "complete -c gh-pr-review -n __fish_use_subcommand -a %s -d %s\n", c.name, fishQuote(c.summary))
+	}
+	for _, c := range cmds {
+		cond := fishQuote("__fish_seen_subcommand_from " + c.name)
+		if len(c.args) > 0 {
+			fmt.Fprintf(w, "complete -c gh-pr-review -n %s -a %s\n", cond, fishQuote(strings.Join(c.args, " ")))
+		}
+		for _, f := range c.flags {
+			fmt.Fprintf(w, "complete -c gh-pr-review -n %s %s\n", cond, fishFlagSpec(f))
+		}
+	}
+}
+
+func fishFlagSpec(f completionFlag) string {
+	spec := "-l " + f.name
+	if len(f.name) == 1 {
+		spec = "-s " + f.name
+	}
+	switch {
+	case len(f.values) > 0:
+		spec += " -x -a " + fishQuote(strings.Join(f.values, " "))
+	case f.takesValue && (strings.HasSuffix(f.name, "file") || f.name == "out"):
+		spec += " -r -F"
+	case f.takesValue:
+		spec += " -x"
+	}
+	return spec + " -d " + fishQuote(f.usage)
+}
+
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+}
+
+func printCompletionUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage:")
+	fmt.Fprintln(w, "  gh-pr-review completion bash|zsh|fish")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Print a completion script covering every command and its flags.")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Examples:")
+	fmt.Fprintln(w, "  source <(gh-pr-review completion bash)")
+	fmt.Fprintln(w, "  gh-pr-review completion zsh > \"${fpath[1]}/_gh-pr-review\"")
+	fmt.Fprintln(w, "  gh-pr-review completion fish > ~/.config/fish/completions/gh-pr-review.fish")
+}
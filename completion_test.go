@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCommandFlagsFromRegistry(t *testing.T) {
+	for _, cmd := range commands {
+		flags := commandFlags(cmd)
+		if cmd.name == "version" {
+			if len(flags) != 0 {
+				t.Errorf("version: got %d flags, want none", len(flags))
+			}
+			continue
+		}
+		if len(flags) == 0 {
+			t.Errorf("%s: no flags found; does its entry set flags?", cmd.name)
+		}
+	}
+	// suggestions apply parses its own flags; completion offers them too.
+	var names []string
+	for _, f := range commandFlags(*findCommand("suggestions")) {
+		names = append(names, f.Name)
+	}
+	for _, want := range []string{"unresolved-only", "all", "resume", "dry-run"} {
+		if !containsString(names, want) {
+			t.Errorf("suggestions flags %v lack --%s", names, want)
+		}
+	}
+}
+
+func TestCompletionHintsMatchFlags(t *testing.T) {
+	cmds := map[string]completionCommand{}
+	for _, c := range completionCommands() {
+		cmds[c.name] = c
+	}
+	for name, hints := range flagValueHints {
+		c, ok := cmds[name]
+		if !ok {
+			t.Errorf("hints for unknown command %q", name)
+			continue
+		}
+		for flagName := range hints {
+			found := false
+			for _, f := range c.flags {
+				if f.name == flagName {
+					found = true
+					if !f.takesValue {
+						t.Errorf("%s --%s has value hints but is a boolean flag", name, flagName)
+					}
+				}
+			}
+			if !found {
+				t.Errorf("%s: hints for --%s, which the command doesn't define", name, flagName)
+			}
+		}
+	}
+	for name := range commandArgs {
+		if _, ok := cmds[name]; !ok {
+			t.Errorf("arguments for unknown command %q", name)
+		}
+	}
+}
+
+func TestCompletionScriptsCoverFlags(t *testing.T) {
+	cmds := completionCommands()
+	writers := map[string]func(*bytes.Buffer){
+		"bash": func(b *bytes.Buffer) { writeBashCompletion(b, cmds) },
+		"zsh":  func(b *bytes.Buffer) { writeZshCompletion(b, cmds) },
+		"fish": func(b *bytes.Buffer) { writeFishCompletion(b, cmds) },
+	}
+	for shell, write := range writers {
+		var buf bytes.Buffer
+		write(&buf)
+		script := buf.String()
+		for _, c := range cmds {
+			if !strings.Contains(script, c.name) {
+				t.Errorf("%s: command %s missing", shell, c.name)
+			}
+			for _, f := range c.flags {
+				want := f.option()
+				if shell == "fish" {
+					want = fishFlagSpec(f)
+				}
+				if !strings.Contains(script, want) {
+					t.Errorf("%s: %s %s missing", shell, c.name, f.option())
+				}
+			}
+		}
+	}
+}
+
+func TestBashCompletionWordsMatchFlagSets(t *testing.T) {
+	var buf bytes.Buffer
+	writeBashCompletion(&buf, completionCommands())
+	lines := map[string]string{}
+	for _, line := range strings.Split(buf.String(), "\n") {
+		name, words, ok := strings.Cut(strings.TrimSpace(line), `) words="`)
+		if ok {
+			lines[name] = strings.TrimSuffix(words, `" ;;`)
+		}
+	}
+	for _, cmd := range commands {
+		got, ok := lines[cmd.name]
+		if !ok {
+			t.Errorf("no completion words for %s", cmd.name)
+			continue
+		}
+		want := append([]string{}, commandArgs[cmd.name]...)
+		for _, f := range commandFlags(cmd) {
+			name := "--" + f.Name
+			if len(f.Name) == 1 {
+				name = "-" + f.Name
+			}
+			want = append(want, name)
+		}
+		if got != strings.Join(want, " ") {
+			t.Errorf("%s words = %q, want %q", cmd.name, got, strings.Join(want, " "))
+		}
+	}
+}
@@ -0,0 +1,530 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gh-pr-review/internal/gh"
+
+	"gopkg.in/yaml.v3"
+)
+
+// configKey is a setting the config file understands. Settings that back a
+// flag fill it in when the flag isn't passed; an environment variable listed
+// in env takes precedence over the file.
+type configKey struct {
+	name     string
+	desc     string
+	flag     string
+	commands []string // commands whose flag it fills; nil means all
+	env      []string
+	check    func(string) error
+}
+
+var configKeys = []configKey{
+	{name: "default_status", desc: "Thread status shown by list, tui and export", flag: "status", commands: []string{"list", "tui", "export"}, check: oneOf(threadStatuses...)},
+	{name: "default_host", desc: "GitHub host when --host isn't given", flag: "host", env: []string{"GH_HOST"}},
+	{name: "theme", desc: "Markdown style: auto, dark, light, notty, ...", flag: "theme"},
+	{name: "max_lines", desc: "Truncate comment bodies to N lines (0 = no limit)", flag: "max-lines", check: nonNegativeInt},
+	{name: "timeout", desc: "Limit for each gh call and API request", env: []string{"GH_PR_REVIEW_TIMEOUT"}, check: validDuration},
+	{name: "color", desc: "auto, always or never", env: []string{"NO_COLOR"}, check: oneOf("auto", "always", "never")},
+	{name: "pager", desc: "Command that pages list and view output on a terminal", env: []string{"GH_PR_REVIEW_PAGER"}},
+	{name: "editor", desc: "Editor for composing replies and goto", env: []string{"GIT_EDITOR", "VISUAL", "EDITOR"}},
+	{name: "notes_file", desc: "File for thread notes (default: notes.json in the user cache directory)"},
+}
+
+func findConfigKey(name string) *configKey {
+	for i := range configKeys {
+		if configKeys[i].name == name {
+			return &configKeys[i]
+		}
+	}
+	return nil
+}
+
+func (k configKey) validate(value string) error {
+	if k.check == nil {
+		return nil
+	}
+	if err := k.check(value); err != nil {
+		return fmt.Errorf("invalid %s %q: %w", k.name, value, err)
+	}
+	return nil
+}
+
+func (k configKey) appliesTo(command string) bool {
+	if k.commands == nil {
+		return true
+	}
+	for _, c := range k.commands {
+		if c == command {
+			return true
+		}
+	}
+	return false
+}
+
+func oneOf(values ...string) func(string) error {
+	return func(v string) error {
+		for _, allowed := range values {
+			if v == allowed {
+				return nil
+			}
+		}
+		return fmt.Errorf("expected %s", strings.Join(values, "|"))
+	}
+}
+
+func nonNegativeInt(v string) error {
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return errors.New("expected a number of lines")
+	}
+	return nil
+}
+
+func validDuration(v string) error {
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		return errors.New("expected a duration such as 30s")
+	}
+	return nil
+}
+
+// config holds the settings read from the config file: top-level values,
+// per-repository overrides keyed by lower-cased owner/name, and command
+// aliases.
+type config struct {
+	values  map[string]string
+	repos   map[string]map[string]string
+	aliases map[string]string
+}
+
+// userConfig is loaded by main before dispatch; it stays empty in tests
+// unless a test sets it.
+var userConfig = &config{}
+
+func configPath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config.yml"), nil
+}
+
+// loadConfig reads the config file, if there is one. Problems are reported
+// on w as warnings and the offending entries skipped, so a file written for
+// a newer version, or with a typo, never stops a command from running.
+func loadConfig(w io.Writer) *config {
+	path, err := configPath()
+	if err != nil {
+		return &config{}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			fmt.Fprintf(w, "warning: ignoring %s: %v\n", path, err)
+		}
+		return &config{}
+	}
+	cfg, err := parseConfig(data, func(msg string) {
+		fmt.Fprintf(w, "warning: %s: %s\n", path, msg)
+	})
+	if err != nil {
+		fmt.Fprintf(w, "warning: ignoring %s: %v\n", path, err)
+		return &config{}
+	}
+	return cfg
+}
+
+func parseConfig(data []byte, warn func(string)) (*config, error) {
+	cfg := &config{values: map[string]string{}, repos: map[string]map[string]string{}, aliases: map[string]string{}}
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	for key, value := range raw {
+		if key == "repos" {
+			repos, ok := value.(map[string]interface{})
+			if !ok {
+				warn("repos should map owner/name to settings; ignored")
+				continue
+			}
+			for repo, settings := range repos {
+				m, ok := settings.(map[string]interface{})
+				if !ok {
+					warn(fmt.Sprintf("repos.%s should be a map of settings; ignored", repo))
+					continue
+				}
+				values := map[string]string{}
+				readConfigValues(m, values, "repos."+repo+".", warn)
+				cfg.repos[strings.ToLower(repo)] = values
+			}
+			continue
+		}
+		if key == "aliases" {
+			readAliases(value, cfg.aliases, warn)
+			continue
+		}
+		readConfigValues(map[string]interface{}{key: value}, cfg.values, "", warn)
+	}
+	return cfg, nil
+}
+
+func readConfigValues(m map[string]interface{}, into map[string]string, prefix string, warn func(string)) {
+	for name, value := range m {
+		key := findConfigKey(name)
+		if key == nil {
+			warn(fmt.Sprintf("unknown key %q ignored", prefix+name))
+			continue
+		}
+		switch value.(type) {
+		case map[string]interface{}, []interface{}:
+			warn(fmt.Sprintf("%s should be a single value; ignored", prefix+name))
+			continue
+		}
+		s := ""
+		if value != nil {
+			s = fmt.Sprint(value)
+		}
+		if err := key.validate(s); err != nil {
+			warn(err.Error() + "; ignored")
+			continue
+		}
+		into[name] = s
+	}
+}
+
+// get returns a setting, preferring the override for repo when there is one.
+func (c *config) get(key, repo string) (string, bool) {
+	if repo != "" {
+		if v, ok := c.repos[strings.ToLower(repo)][key]; ok {
+			return v, true
+		}
+	}
+	v, ok := c.values[key]
+	return v, ok
+}
+
+// lookupEnv reports whether any of the variables that take precedence over
+// a setting are set.
+func lookupEnv(names []string) bool {
+	for _, name := range names {
+		if strings.TrimSpace(os.Getenv(name)) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// configValue returns a setting that isn't tied to a flag, unless the
+// environment overrides it.
+func configValue(key string) string {
+	k := findConfigKey(key)
+	if k == nil || lookupEnv(k.env) {
+		return ""
+	}
+	v, _ := userConfig.get(key, "")
+	return v
+}
+
+// parseFlags parses a command's flags and fills the ones that weren't
+// passed from the config file, so the order is flag, environment, config,
+// built-in default.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	return applyConfig(fs, userConfig)
+}
+
+func applyConfig(fs *flag.FlagSet, cfg *config) error {
+	passed := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { passed[f.Name] = true })
+	repo, repoKnown := "", false
+	for _, key := range configKeys {
+		if key.flag == "" || passed[key.flag] || !key.appliesTo(fs.Name()) || lookupEnv(key.env) {
+			continue
+		}
+		f := fs.Lookup(key.flag)
+		if f == nil {
+			continue
+		}
+		if !repoKnown {
+			repo, repoKnown = configRepo(fs, cfg), true
+		}
+		value, ok := cfg.get(key.name, repo)
+		if !ok {
+			continue
+		}
+		if err := f.Value.Set(value); err != nil {
+			return fmt.Errorf("config %s: %w", key.name, err)
+		}
+	}
+	return nil
+}
+
+// configRepo picks the repository whose overrides apply: --repo when given,
+// otherwise the current one. The lookup is skipped when the file has no
+// per-repo settings.
+func configRepo(fs *flag.FlagSet, cfg *config) string {
+	if len(cfg.repos) == 0 {
+		return ""
+	}
+	if f := fs.Lookup("repo"); f != nil && strings.TrimSpace(f.Value.String()) != "" {
+		return strings.TrimSpace(f.Value.String())
+	}
+	view, err := gh.RepoViewCurrent(context.Background())
+	if err != nil {
+		return ""
+	}
+	return view.NameWithOwner
+}
+
+type configFlags struct {
+	repo string
+}
+
+func (o *configFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.repo, "repo", "", "owner/name whose overrides to read or write")
+}
+
+func runConfig(args []string) error {
+	fs := newFlagSet("config", printConfigUsage)
+	var flags configFlags
+	flags.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	// Allow --repo among the arguments too: config set key value --repo x.
+	var rest []string
+	for remaining := fs.Args(); len(remaining) > 0; remaining = fs.Args() {
+		rest = append(rest, remaining[0])
+		if err := parseFlags(fs, remaining[1:]); err != nil {
+			return err
+		}
+	}
+	if len(rest) == 0 {
+		return errors.New("provide an action: get, set or list")
+	}
+	if flags.repo != "" {
+		owner, name, err := parseRepo(flags.repo)
+		if err != nil {
+			return err
+		}
+		flags.repo = owner + "/" + name
+	}
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+	switch rest[0] {
+	case "get":
+		if len(rest) != 2 {
+			return errors.New("usage: gh-pr-review config get <key> [--repo owner/name]")
+		}
+		if findConfigKey(rest[1]) == nil {
+			return fmt.Errorf("unknown key %q (see gh-pr-review help config)", rest[1])
+		}
+		if v, ok := userConfig.get(rest[1], flags.repo); ok {
+			fmt.Fprintln(os.Stdout, v)
+		}
+		return nil
+	case "set":
+		if len(rest) != 3 {
+			return errors.New("usage: gh-pr-review config set <key> <value> [--repo owner/name]")
+		}
+		key := findConfigKey(rest[1])
+		if key == nil {
+			return fmt.Errorf("unknown key %q (see gh-pr-review help config)", rest[1])
+		}
+		if err := key.validate(rest[2]); err != nil {
+			return err
+		}
+		var section []string
+		if flags.repo != "" {
+			section = []string{"repos", flags.repo}
+		}
+		return setConfigValue(path, section, key.name, rest[2])
+	case "list":
+		if len(rest) != 1 {
+			return errors.New("usage: gh-pr-review config list [--repo owner/name]")
+		}
+		printConfig(os.Stdout, userConfig, flags.repo)
+		return nil
+	default:
+		return fmt.Errorf("unknown config action %q (expected get, set or list)", rest[0])
+	}
+}
+
+// printConfig prints the settings in effect as key=value lines. Without a
+// repo, each repository's overrides follow under its own heading.
+func printConfig(w io.Writer, cfg *config, repo string) {
+	for _, key := range configKeys {
+		if v, ok := cfg.get(key.name, repo); ok {
+			fmt.Fprintf(w, "%s=%s\n", key.name, v)
+		}
+	}
+	if repo != "" {
+		return
+	}
+	var repos []string
+	for r := range cfg.repos {
+		repos = append(repos, r)
+	}
+	sort.Strings(repos)
+	for _, r := range repos {
+		fmt.Fprintf(w, "\n[%s]\n", r)
+		for _, key := range configKeys {
+			if v, ok := cfg.repos[r][key.name]; ok {
+				fmt.Fprintf(w, "%s=%s\n", key.name, v)
+			}
+		}
+	}
+}
+
+// setConfigValue writes one setting, in the mapping reached by following
+// section from the top level (e.g. repos, owner/name).
+func setConfigValue(path string, section []string, key, value string) error {
+	return editConfigFile(path, func(root *yaml.Node) error {
+		m, err := sectionMapping(root, section)
+		if err != nil {
+			return err
+		}
+		setMappingValue(m, key, value)
+		return nil
+	})
+}
+
+// deleteConfigValue removes a setting, reporting whether it was there.
+func deleteConfigValue(path string, section []string, key string) (bool, error) {
+	found := false
+	err := editConfigFile(path, func(root *yaml.Node) error {
+		m, err := sectionMapping(root, section)
+		if err != nil {
+			return err
+		}
+		for i := 0; i+1 < len(m.Content); i += 2 {
+			if m.Content[i].Value == key {
+				m.Content = append(m.Content[:i], m.Content[i+2:]...)
+				found = true
+				break
+			}
+		}
+		return nil
+	})
+	return found, err
+}
+
+// editConfigFile applies edit to the config file's top-level mapping and
+// writes the result, editing the YAML document in place so comments and
+// other entries survive.
+func editConfigFile(path string, edit func(root *yaml.Node) error) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	var doc yaml.Node
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	if len(doc.Content) == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("%s: expected settings at the top level", path)
+	}
+	if err := edit(root); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+func sectionMapping(m *yaml.Node, section []string) (*yaml.Node, error) {
+	for _, key := range section {
+		var err error
+		if m, err = childMapping(m, key); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// childMapping returns the mapping stored under key in m, adding an empty
+// one if key is missing.
+func childMapping(m *yaml.Node, key string) (*yaml.Node, error) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if strings.EqualFold(m.Content[i].Value, key) {
+			child := m.Content[i+1]
+			if child.Kind != yaml.MappingNode {
+				return nil, fmt.Errorf("%s is not a map", key)
+			}
+			return child, nil
+		}
+	}
+	child := &yaml.Node{Kind: yaml.MappingNode}
+	m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, child)
+	return child, nil
+}
+
+func setMappingValue(m *yaml.Node, key, value string) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			m.Content[i+1] = &yaml.Node{Kind: yaml.ScalarNode, Value: value, LineComment: m.Content[i+1].LineComment}
+			return
+		}
+	}
+	m.Content = append(m.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Value: key},
+		&yaml.Node{Kind: yaml.ScalarNode, Value: value},
+	)
+}
+
+func printConfigUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage:")
+	fmt.Fprintln(w, "  gh-pr-review config get <key> [--repo owner/name]")
+	fmt.Fprintln(w, "  gh-pr-review config set <key> <value> [--repo owner/name]")
+	fmt.Fprintln(w, "  gh-pr-review config list [--repo owner/name]")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Settings live in <config dir>/gh-pr-review/config.yml. A flag always wins,")
+	fmt.Fprintln(w, "then the environment, then the config file (a repo's overrides before the")
+	fmt.Fprintln(w, "top-level values), then the built-in default.")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Keys:")
+	for _, key := range configKeys {
+		fmt.Fprintf(w, "  %s   %s\n", key.name, key.desc)
+	}
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Flags:")
+	fmt.Fprintln(w, "  --repo <owner/name>   Read or write that repository's overrides")
+}
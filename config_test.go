@@ -0,0 +1,230 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gh-pr-review/internal/gh"
+)
+
+func mustParseConfig(t *testing.T, data string) (*config, []string) {
+	t.Helper()
+	var warnings []string
+	cfg, err := parseConfig([]byte(data), func(msg string) { warnings = append(warnings, msg) })
+	if err != nil {
+		t.Fatalf("parseConfig: %v", err)
+	}
+	return cfg, warnings
+}
+
+// listFlagSet mirrors the flags list defines that config keys can fill.
+func listFlagSet(name string) (*flag.FlagSet, *string, *string, *int) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	status := fs.String("status", "all", "")
+	host := fs.String("host", gh.DefaultHost(), "")
+	maxLines := fs.Int("max-lines", 0, "")
+	fs.String("repo", "", "")
+	return fs, status, host, maxLines
+}
+
+func TestConfigPrecedence(t *testing.T) {
+	cfg, warnings := mustParseConfig(t, `
+default_status: unresolved
+default_host: config.example.com
+max_lines: 5
+`)
+	if len(warnings) != 0 {
+		t.Fatalf("unexpected warnings: %v", warnings)
+	}
+
+	t.Run("built-in default", func(t *testing.T) {
+		t.Setenv("GH_HOST", "")
+		fs, status, host, maxLines := listFlagSet("list")
+		if err := fs.Parse(nil); err != nil {
+			t.Fatal(err)
+		}
+		if err := applyConfig(fs, &config{}); err != nil {
+			t.Fatal(err)
+		}
+		if *status != "all" || *host != "github.com" || *maxLines != 0 {
+			t.Errorf("got status=%q host=%q max-lines=%d", *status, *host, *maxLines)
+		}
+	})
+	t.Run("config over default", func(t *testing.T) {
+		t.Setenv("GH_HOST", "")
+		fs, status, host, maxLines := listFlagSet("list")
+		if err := fs.Parse(nil); err != nil {
+			t.Fatal(err)
+		}
+		if err := applyConfig(fs, cfg); err != nil {
+			t.Fatal(err)
+		}
+		if *status != "unresolved" || *host != "config.example.com" || *maxLines != 5 {
+			t.Errorf("got status=%q host=%q max-lines=%d", *status, *host, *maxLines)
+		}
+	})
+	t.Run("env over config", func(t *testing.T) {
+		t.Setenv("GH_HOST", "env.example.com")
+		fs, _, host, _ := listFlagSet("list")
+		if err := fs.Parse(nil); err != nil {
+			t.Fatal(err)
+		}
+		if err := applyConfig(fs, cfg); err != nil {
+			t.Fatal(err)
+		}
+		if *host != "env.example.com" {
+			t.Errorf("host = %q, want env.example.com", *host)
+		}
+	})
+	t.Run("flag over env and config", func(t *testing.T) {
+		t.Setenv("GH_HOST", "env.example.com")
+		fs, status, host, maxLines := listFlagSet("list")
+		if err := fs.Parse([]string{"--host", "flag.example.com", "--status", "resolved", "--max-lines", "0"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := applyConfig(fs, cfg); err != nil {
+			t.Fatal(err)
+		}
+		if *status != "resolved" || *host != "flag.example.com" || *maxLines != 0 {
+			t.Errorf("got status=%q host=%q max-lines=%d", *status, *host, *maxLines)
+		}
+	})
+	t.Run("status only for listing commands", func(t *testing.T) {
+		fs, status, _, _ := listFlagSet("reply")
+		if err := fs.Parse(nil); err != nil {
+			t.Fatal(err)
+		}
+		if err := applyConfig(fs, cfg); err != nil {
+			t.Fatal(err)
+		}
+		if *status != "all" {
+			t.Errorf("reply status = %q, want the flag default", *status)
+		}
+	})
+}
+
+func TestConfigRepoOverrides(t *testing.T) {
+	t.Setenv("GH_HOST", "")
+	cfg, _ := mustParseConfig(t, `
+default_status: unresolved
+repos:
+  Corp/API:
+    default_status: resolved-no-reply
+    default_host: ghe.corp.example
+`)
+	fs, status, host, _ := listFlagSet("list")
+	if err := fs.Parse([]string{"--repo", "corp/api"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := applyConfig(fs, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if *status != "resolved-no-reply" || *host != "ghe.corp.example" {
+		t.Errorf("got status=%q host=%q, want the corp/api overrides", *status, *host)
+	}
+
+	fs, status, host, _ = listFlagSet("list")
+	if err := fs.Parse([]string{"--repo", "other/repo"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := applyConfig(fs, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if *status != "unresolved" || *host != "github.com" {
+		t.Errorf("got status=%q host=%q, want the top-level values", *status, *host)
+	}
+}
+
+func TestConfigWarnsInsteadOfFailing(t *testing.T) {
+	cfg, warnings := mustParseConfig(t, `
+default_status: open
+colour: always
+color: never
+future_setting: {nested: true}
+repos:
+  corp/api:
+    pagerr: less
+    pager: less -S
+`)
+	joined := strings.Join(warnings, "\n")
+	for _, want := range []string{`invalid default_status "open"`, `unknown key "colour"`, `unknown key "future_setting"`, `unknown key "repos.corp/api.pagerr"`} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("warnings %q missing %q", joined, want)
+		}
+	}
+	if _, ok := cfg.get("default_status", ""); ok {
+		t.Error("invalid default_status was kept")
+	}
+	if v, _ := cfg.get("color", ""); v != "never" {
+		t.Errorf("color = %q, want never", v)
+	}
+	if v, _ := cfg.get("pager", "corp/api"); v != "less -S" {
+		t.Errorf("corp/api pager = %q, want less -S", v)
+	}
+}
+
+func TestConfigValueEnvPrecedence(t *testing.T) {
+	saved := userConfig
+	defer func() { userConfig = saved }()
+	userConfig, _ = mustParseConfig(t, "editor: nano\n")
+
+	for _, name := range []string{"GIT_EDITOR", "VISUAL", "EDITOR"} {
+		t.Setenv(name, "")
+	}
+	if got := editorCommand(); got != "nano" {
+		t.Errorf("editorCommand() = %q, want the config editor", got)
+	}
+	t.Setenv("EDITOR", "vim")
+	if got := editorCommand(); got != "vim" {
+		t.Errorf("editorCommand() = %q, want $EDITOR", got)
+	}
+	userConfig = &config{}
+	t.Setenv("EDITOR", "")
+	if got := editorCommand(); got != "vi" {
+		t.Errorf("editorCommand() = %q, want vi", got)
+	}
+}
+
+func TestSetConfigValueKeepsComments(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gh-pr-review", "config.yml")
+	if err := setConfigValue(path, nil, "pager", "less"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "pager: less\n" {
+		t.Errorf("new file = %q", data)
+	}
+
+	if err := os.WriteFile(path, []byte("# my settings\ndefault_status: all # for now\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := setConfigValue(path, nil, "default_status", "unresolved"); err != nil {
+		t.Fatal(err)
+	}
+	if err := setConfigValue(path, []string{"repos", "corp/api"}, "theme", "light"); err != nil {
+		t.Fatal(err)
+	}
+	data, err = os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# my settings\ndefault_status: unresolved # for now\nrepos:\n  corp/api:\n    theme: light\n"
+	if string(data) != want {
+		t.Errorf("file = %q, want %q", data, want)
+	}
+	cfg, warnings := mustParseConfig(t, string(data))
+	if len(warnings) != 0 {
+		t.Errorf("unexpected warnings: %v", warnings)
+	}
+	if v, _ := cfg.get("theme", "Corp/API"); v != "light" {
+		t.Errorf("theme for corp/api = %q", v)
+	}
+}
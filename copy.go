@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"golang.org/x/term"
+
+	"gh-pr-review/internal/gh"
+)
+
+var copyTargets = []string{"id", "url", "body"}
+
+type copyFlags struct {
+	threadID string
+	what     string
+	host     string
+}
+
+func (o *copyFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.threadID, "thread-id", "", "Review thread ID")
+	fs.StringVar(&o.what, "what", "id", strings.Join(copyTargets, "|"))
+	fs.StringVar(&o.host, "host", gh.DefaultHost(), "GitHub host")
+}
+
+func runCopy(args []string) error {
+	fs := newFlagSet("copy", printCopyUsage)
+	var flags copyFlags
+	flags.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	switch fs.NArg() {
+	case 0:
+	case 1:
+		if flags.threadID != "" {
+			return errors.New("provide the thread as an argument or with --thread-id, not both")
+		}
+		flags.threadID = fs.Arg(0)
+	default:
+		return errors.New("copy takes one thread at a time")
+	}
+	if flags.threadID == "" {
+		return errors.New("--thread-id is required")
+	}
+
+	var text, label string
+	switch flags.what {
+	case "id":
+		// The ID is already known; copying it needs no request.
+		text, label = flags.threadID, "thread ID "+flags.threadID
+	case "url", "body":
+		ctx := context.Background()
+		client, err := newClient(ctx, flags.host)
+		if err != nil {
+			return err
+		}
+		thread, err := fetchThread(ctx, client, flags.threadID)
+		if err != nil {
+			return err
+		}
+		if text, label, err = copyValue(thread, flags.what); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("invalid --what %q (expected %s)", flags.what, strings.Join(copyTargets, "|"))
+	}
+	how, err := copyToClipboard(text)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "copied %s to the clipboard (%s)\n", label, how)
+	return nil
+}
+
+// copyValue picks what --what names from a thread, with a description of it
+// for the confirmation message.
+func copyValue(thread reviewThread, what string) (text, label string, err error) {
+	nodes := thread.Comments.Nodes
+	if len(nodes) == 0 {
+		return "", "", fmt.Errorf("thread %s has no comments", thread.ID)
+	}
+	switch what {
+	case "url":
+		if nodes[0].URL == "" {
+			return "", "", fmt.Errorf("thread %s has no comment URL", thread.ID)
+		}
+		return nodes[0].URL, nodes[0].URL, nil
+	case "body":
+		latest := nodes[len(nodes)-1]
+		return latest.Body, fmt.Sprintf("%s's comment (%d characters)", latest.Author.Login, len([]rune(latest.Body))), nil
+	default:
+		return thread.ID, "thread ID " + thread.ID, nil
+	}
+}
+
+// copyToClipboard puts text on the clipboard and says how. On a terminal it
+// uses an OSC 52 escape sequence, which the terminal handles even when the
+// command runs over SSH; otherwise it falls back to a local clipboard tool.
+func copyToClipboard(text string) (string, error) {
+	if term.IsTerminal(int(os.Stderr.Fd())) {
+		if _, err := io.WriteString(os.Stderr, osc52Sequence(text, os.Getenv("TMUX") != "")); err != nil {
+			return "", err
+		}
+		return "OSC 52", nil
+	}
+	name, args := clipboardCommand(runtime.GOOS, os.Getenv("WAYLAND_DISPLAY") != "", func(name string) bool {
+		_, err := exec.LookPath(name)
+		return err == nil
+	})
+	if name == "" {
+		return "", errors.New("no clipboard available: not on a terminal, and none of pbcopy, wl-copy, xclip or clip.exe was found")
+	}
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = strings.NewReader(text)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if len(out) > 0 {
+			return "", fmt.Errorf("%s: %w: %s", name, err, strings.TrimSpace(string(out)))
+		}
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	return name, nil
+}
+
+// osc52Sequence asks the terminal to set the clipboard to text. Inside tmux
+// the sequence is wrapped so tmux passes it on to the outer terminal.
+func osc52Sequence(text string, tmux bool) string {
+	seq := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"
+	if tmux {
+		return "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+	}
+	return seq
+}
+
+// clipboardCommand picks the first clipboard tool available on goos.
+// wl-copy is only tried under Wayland, where xclip may reach the wrong
+// clipboard; clip.exe also covers WSL.
+func clipboardCommand(goos string, wayland bool, available func(string) bool) (string, []string) {
+	candidates := []struct {
+		name string
+		args []string
+	}{
+		{"pbcopy", nil},
+		{"wl-copy", nil},
+		{"xclip", []string{"-selection", "clipboard"}},
+		{"clip.exe", nil},
+	}
+	for _, c := range candidates {
+		switch {
+		case c.name == "pbcopy" && goos != "darwin":
+			continue
+		case c.name == "wl-copy" && !wayland:
+			continue
+		case !available(c.name):
+			continue
+		}
+		return c.name, c.args
+	}
+	return "", nil
+}
+
+func printCopyUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage:")
+	fmt.Fprintln(w, "  gh-pr-review copy --thread-id <id> [--what id|url|body] [--host host]")
+	fmt.Fprintln(w, "  gh-pr-review copy <id> [--what id|url|body]")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Puts a thread's ID, link or latest comment on the clipboard and says what was copied on")
+	fmt.Fprintln(w, "stderr. On a terminal the copy is done with an OSC 52 escape sequence, which also works over")
+	fmt.Fprintln(w, "SSH if the terminal supports it; otherwise pbcopy, wl-copy, xclip or clip.exe is used.")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Flags:")
+	fmt.Fprintln(w, "  --thread-id <id>   Review thread ID (may also be given as an argument)")
+	fmt.Fprintln(w, "  --what <value>   id (default), url (the thread's first comment), or body (the latest comment's markdown)")
+	fmt.Fprintln(w, "  --host <host>   GitHub host")
+}
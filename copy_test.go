@@ -0,0 +1,65 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOSC52Sequence(t *testing.T) {
+	if got := osc52Sequence("hi", false); got != "\x1b]52;c;aGk=\a" {
+		t.Errorf("osc52Sequence = %q", got)
+	}
+	got := osc52Sequence("hi", true)
+	if !strings.HasPrefix(got, "\x1bPtmux;\x1b\x1b]52;c;aGk=") || !strings.HasSuffix(got, "\x1b\\") {
+		t.Errorf("tmux osc52Sequence = %q", got)
+	}
+}
+
+func TestClipboardCommand(t *testing.T) {
+	only := func(names ...string) func(string) bool {
+		return func(name string) bool {
+			for _, n := range names {
+				if n == name {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	tests := []struct {
+		goos      string
+		wayland   bool
+		available func(string) bool
+		want      string
+	}{
+		{"darwin", false, only("pbcopy", "xclip"), "pbcopy"},
+		{"linux", false, only("pbcopy", "wl-copy", "xclip"), "xclip"},
+		{"linux", true, only("wl-copy", "xclip"), "wl-copy"},
+		{"linux", false, only("clip.exe"), "clip.exe"},
+		{"linux", false, only(), ""},
+	}
+	for _, tt := range tests {
+		if got, _ := clipboardCommand(tt.goos, tt.wayland, tt.available); got != tt.want {
+			t.Errorf("clipboardCommand(%s, wayland=%v) = %q, want %q", tt.goos, tt.wayland, got, tt.want)
+		}
+	}
+}
+
+func TestCopyValue(t *testing.T) {
+	var thread reviewThread
+	thread.ID = "PRRT_1"
+	thread.Comments.Nodes = []reviewComment{
+		{Body: "first", URL: "https://github.com/o/r/pull/1#discussion_r1", Author: actor{Login: "alice"}},
+		{Body: "**latest** reply", Author: actor{Login: "bob"}},
+	}
+	if text, _, _ := copyValue(thread, "url"); text != "https://github.com/o/r/pull/1#discussion_r1" {
+		t.Errorf("url = %q", text)
+	}
+	text, label, _ := copyValue(thread, "body")
+	if text != "**latest** reply" || !strings.Contains(label, "bob") {
+		t.Errorf("body = %q (%s)", text, label)
+	}
+	if _, _, err := copyValue(reviewThread{ID: "PRRT_2"}, "body"); err == nil {
+		t.Error("expected an error for a thread without comments")
+	}
+}
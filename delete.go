@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"gh-pr-review/internal/gh"
+	"gh-pr-review/internal/github"
+)
+
+// errDeleteForbidden replaces GitHub's message when a delete is refused.
+var errDeleteForbidden = errors.New("you can only delete your own comments (or need admin rights)")
+
+type deleteFlags struct {
+	commentID  string
+	commentURL string
+	repo       string
+	pr         int
+	jsonOut    bool
+	host       string
+}
+
+func (o *deleteFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.commentID, "comment-id", "", "review comment node ID (or numeric ID)")
+	fs.StringVar(&o.commentURL, "url", "", "review comment URL or numeric ID")
+	fs.StringVar(&o.repo, "repo", "", "owner/name for a numeric ID (defaults to gh repo view)")
+	fs.IntVar(&o.pr, "pr", 0, "PR number for a numeric ID")
+	fs.BoolVar(&o.jsonOut, "json", false, "output JSON")
+	addPromptFlags(fs)
+	fs.StringVar(&o.host, "host", gh.DefaultHost(), "GitHub host")
+}
+
+func runDelete(args []string) error {
+	fs := newFlagSet("delete", printDeleteUsage)
+	var flags deleteFlags
+	flags.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if flags.commentID != "" && flags.commentURL != "" {
+		return errors.New("provide only one of --comment-id or --url")
+	}
+	if flags.commentID == "" && flags.commentURL == "" {
+		return errors.New("--comment-id or --url is required")
+	}
+	var refs []commentRef
+	if flags.commentURL != "" {
+		ref, err := parseCommentRef(flags.commentURL)
+		if err != nil {
+			return err
+		}
+		refs = append(refs, ref)
+	}
+
+	ctx := context.Background()
+	client, err := newClient(ctx, commentRefHost(refs, flags.host))
+	if err != nil {
+		return err
+	}
+	var ref *commentRef
+	if len(refs) > 0 {
+		ref = &refs[0]
+	}
+	id, err := reviewCommentID(ctx, client, flags.commentID, ref, flags.repo, flags.pr)
+	if err != nil {
+		return err
+	}
+	comment, err := fetchOwnedComment(ctx, client, id)
+	if err != nil {
+		return err
+	}
+	if !comment.ViewerCanDelete {
+		return errDeleteForbidden
+	}
+	repoInfo := comment.PullRequest.Repository
+	threads, err := fetchAllThreads(ctx, client, repoInfo.Owner.Login, repoInfo.Name, comment.PullRequest.Number)
+	if err != nil {
+		return err
+	}
+	thread, _ := threadWithComment(threads, comment.ID)
+	lastComment := len(thread.Comments.Nodes) == 1
+
+	if !assumeYes {
+		styler := newStyler(promptOut)
+		fmt.Fprintf(promptOut, "  %s\n", styler.dim(truncateRunes(firstLine(comment.Body), 100)))
+		if lastComment {
+			fmt.Fprintln(promptOut, "  This is the only comment in its thread, so the whole thread will disappear.")
+		}
+	}
+	question := "Delete comment by " + comment.Author.Login
+	if location := strings.TrimSpace(formatLineInfo(thread)); location != "" {
+		question += " on " + strings.Trim(location, "[]")
+	}
+	ok, err := confirm(question + "?")
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("aborted; the comment was not deleted")
+	}
+
+	if err := deleteReviewComment(ctx, client, comment.ID); err != nil {
+		return err
+	}
+	if flags.jsonOut {
+		return writeJSON(os.Stdout, deleteResult{
+			CommentID:     comment.ID,
+			URL:           comment.URL,
+			ThreadID:      thread.ID,
+			ThreadDeleted: lastComment,
+		})
+	}
+	fmt.Fprintf(os.Stdout, "deleted comment %s\n", comment.URL)
+	if lastComment {
+		fmt.Fprintf(os.Stdout, "thread %s had no other comments and is gone\n", thread.ID)
+	}
+	return nil
+}
+
+type deleteResult struct {
+	CommentID     string `json:"commentId"`
+	URL           string `json:"url"`
+	ThreadID      string `json:"threadId,omitempty"`
+	ThreadDeleted bool   `json:"threadDeleted"`
+}
+
+// threadWithComment finds the thread holding the comment with this node ID.
+func threadWithComment(threads []reviewThread, commentID string) (reviewThread, bool) {
+	for _, t := range threads {
+		for _, c := range t.Comments.Nodes {
+			if c.ID == commentID {
+				return t, true
+			}
+		}
+	}
+	return reviewThread{}, false
+}
+
+func deleteReviewComment(ctx context.Context, client *github.Client, commentID string) error {
+	mutation := `mutation($id:ID!) {
+  deletePullRequestReviewComment(input:{id:$id}) { clientMutationId }
+}`
+	err := client.Do(ctx, mutation, map[string]interface{}{"id": commentID}, nil)
+	if github.Forbidden(err) {
+		return errDeleteForbidden
+	}
+	return err
+}
+
+func printDeleteUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage:")
+	fmt.Fprintln(w, "  gh-pr-review delete --comment-id <id> [--yes] [--json] [--host host]")
+	fmt.Fprintln(w, "  gh-pr-review delete --url <comment-url|comment-id> [--pr <number>] [--repo owner/name] [--yes] [--json] [--host host]")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Shows the comment and asks before deleting it. Deleting the only comment in a thread removes the thread.")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Flags:")
+	fmt.Fprintln(w, "  --comment-id <id>   Review comment node ID (PRRC_...), or a numeric comment ID with --pr")
+	fmt.Fprintln(w, "  --url <url>   Review comment URL (.../pull/42#discussion_r123), or a numeric comment ID with --pr")
+	fmt.Fprintln(w, "  --pr <number>   PR for a numeric ID (defaults to current branch PR if available)")
+	fmt.Fprintln(w, "  --repo <owner/name>   Repository for a numeric ID (defaults to gh repo view)")
+	fmt.Fprintln(w, "  --json   Print {commentId, url, threadId, threadDeleted}")
+	fmt.Fprintln(w, "  --yes, -y   Delete without asking")
+	fmt.Fprintln(w, "  --no-input   Fail instead of prompting")
+	fmt.Fprintln(w, "  --host <host>   GitHub host")
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gh-pr-review/internal/gh"
+	"gh-pr-review/internal/git"
+	"gh-pr-review/internal/suggest"
+)
+
+type diffFlags struct {
+	threadID     string
+	commentURL   string
+	repo         string
+	pr           int
+	contextLines int
+	host         string
+}
+
+func (o *diffFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.threadID, "thread-id", "", "Review thread ID")
+	fs.StringVar(&o.commentURL, "url", "", "review comment URL or numeric ID")
+	fs.StringVar(&o.repo, "repo", "", "owner/name for a numeric --url ID (defaults to gh repo view)")
+	fs.IntVar(&o.pr, "pr", 0, "PR number for a numeric --url ID")
+	fs.IntVar(&o.contextLines, "context", 3, "lines of local context around the commented lines")
+	fs.StringVar(&o.host, "host", gh.DefaultHost(), "GitHub host")
+}
+
+func runDiff(args []string) error {
+	fs := newFlagSet("diff", printDiffUsage)
+	var flags diffFlags
+	flags.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	switch {
+	case fs.NArg() > 0:
+		return fmt.Errorf("unexpected arguments: %v", fs.Args())
+	case flags.threadID != "" && flags.commentURL != "":
+		return errors.New("provide only one of --thread-id or --url")
+	case flags.threadID == "" && flags.commentURL == "":
+		return errors.New("--thread-id or --url is required")
+	case flags.contextLines < 0:
+		return fmt.Errorf("invalid --context %d", flags.contextLines)
+	}
+	var refs []commentRef
+	if flags.commentURL != "" {
+		ref, err := parseCommentRef(flags.commentURL)
+		if err != nil {
+			return err
+		}
+		refs = append(refs, ref)
+	}
+
+	ctx := context.Background()
+	client, err := newClient(ctx, commentRefHost(refs, flags.host))
+	if err != nil {
+		return err
+	}
+	for _, ref := range refs {
+		if flags.threadID, err = threadIDForComment(ctx, client, ref, flags.repo, flags.pr); err != nil {
+			return err
+		}
+	}
+	thread, err := fetchThread(ctx, client, flags.threadID)
+	if err != nil {
+		return err
+	}
+	root, err := git.TopLevel(ctx)
+	writeThreadDiff(os.Stdout, thread, root, err, flags.contextLines)
+	return nil
+}
+
+// writeThreadDiff prints the hunk the thread was left on, then the same
+// lines as they are in the local checkout under root, with context.
+func writeThreadDiff(w io.Writer, t reviewThread, root string, rootErr error, contextLines int) {
+	styler := newStyler(w)
+	fmt.Fprintf(w, "%s %s%s%s\n\n", styler.label("Thread"), styler.threadID(t.ID), threadBadges(t, styler), formatLineInfo(t))
+	hunk := ""
+	if len(t.Comments.Nodes) > 0 {
+		hunk = strings.TrimRight(t.Comments.Nodes[0].DiffHunk, "\n")
+	}
+	if hunk == "" {
+		fmt.Fprintf(w, "  %s\n", styler.dim("(no diff hunk)"))
+	} else {
+		for _, line := range strings.Split(hunk, "\n") {
+			fmt.Fprintf(w, "    %s\n", styler.diffLine(line))
+		}
+	}
+	fmt.Fprintln(w, "")
+
+	start, end := threadRange(t)
+	switch {
+	case rootErr != nil:
+		fmt.Fprintf(w, "%s\n", styler.dim("not in a git checkout; showing only the diff hunk from GitHub"))
+		return
+	case t.Path == "" || end == 0:
+		fmt.Fprintf(w, "%s\n", styler.dim("thread has no line range to show locally"))
+		return
+	}
+	file, err := readFileLines(filepath.Join(root, t.Path))
+	if err != nil {
+		fmt.Fprintf(w, "%s\n", styler.dim(fmt.Sprintf("%s is not in the local checkout", t.Path)))
+		return
+	}
+
+	count := end - start + 1
+	note := ""
+	if original, ok := suggest.OriginalLines(hunk, count); ok {
+		if at, found := suggest.Locate(file, original, start); !found {
+			note = "local file has diverged from the hunk (thread likely outdated)"
+		} else if at != start {
+			note = fmt.Sprintf("commented lines have moved to %s locally", lineSpan(at, at+count-1))
+			start, end = at, at+count-1
+		}
+	}
+	if start > len(file) {
+		fmt.Fprintf(w, "%s\n", styler.dim(fmt.Sprintf("local %s has only %d lines; the commented lines are gone", t.Path, len(file))))
+		return
+	}
+	from := max(1, start-contextLines)
+	to := min(len(file), end+contextLines)
+	fmt.Fprintf(w, "%s %s:%s\n", styler.label("Local"), t.Path, lineSpan(from, to))
+	if note != "" {
+		fmt.Fprintf(w, "%s\n", styler.wrap("33", note))
+	}
+	width := len(fmt.Sprintf("%d", to))
+	for n := from; n <= to; n++ {
+		marker := " "
+		if n >= start && n <= end {
+			marker = ">"
+		}
+		fmt.Fprintf(w, "  %s %s  %s\n", marker, styler.dim(fmt.Sprintf("%*d", width, n)), file[n-1])
+	}
+}
+
+func printDiffUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage:")
+	fmt.Fprintln(w, "  gh-pr-review diff --thread-id <id> [--context n] [--host host]")
+	fmt.Fprintln(w, "  gh-pr-review diff --url <comment-url|comment-id> [--pr <number>] [--repo owner/name] [--context n] [--host host]")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Flags:")
+	fmt.Fprintln(w, "  --thread-id <id>   Review thread ID")
+	fmt.Fprintln(w, "  --url <url>   Review comment URL (.../pull/42#discussion_r123), or a numeric comment ID with --pr")
+	fmt.Fprintln(w, "  --pr <number>   PR for a numeric --url ID (defaults to current branch PR if available)")
+	fmt.Fprintln(w, "  --repo <owner/name>   Repository for a numeric --url ID (defaults to gh repo view)")
+	fmt.Fprintln(w, "  --context <n>   Lines of local context around the commented lines (default 3)")
+	fmt.Fprintln(w, "  --host <host>   GitHub host")
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func diffThread(hunk string, line int) reviewThread {
+	return reviewThread{
+		ID:       "T1",
+		Path:     "a.go",
+		Line:     intPtr(line),
+		Comments: reviewThreadComment{Nodes: []reviewComment{{DiffHunk: hunk}}},
+	}
+}
+
+func TestWriteThreadDiff(t *testing.T) {
+	root := t.TempDir()
+	write := func(content string) {
+		if err := os.WriteFile(filepath.Join(root, "a.go"), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	hunk := "@@ -1,2 +1,3 @@\n one\n+two\n three"
+
+	write("one\ntwo\nthree\nfour\n")
+	var buf bytes.Buffer
+	writeThreadDiff(&buf, diffThread(hunk, 3), root, nil, 1)
+	out := buf.String()
+	if !strings.Contains(out, "+two") || !strings.Contains(out, "Local a.go:2-4") || !strings.Contains(out, "> 3  three") {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+	if strings.Contains(out, "diverged") || strings.Contains(out, "moved") {
+		t.Fatalf("matching file reported as changed:\n%s", out)
+	}
+
+	write("zero\none\ntwo\nthree\n")
+	buf.Reset()
+	writeThreadDiff(&buf, diffThread(hunk, 3), root, nil, 0)
+	if out := buf.String(); !strings.Contains(out, "moved to 4 locally") || !strings.Contains(out, "> 4  three") {
+		t.Fatalf("moved lines not followed:\n%s", out)
+	}
+
+	write("one\ntwo\nTHREE\n")
+	buf.Reset()
+	writeThreadDiff(&buf, diffThread(hunk, 3), root, nil, 0)
+	if out := buf.String(); !strings.Contains(out, "diverged") {
+		t.Fatalf("divergence not flagged:\n%s", out)
+	}
+
+	buf.Reset()
+	writeThreadDiff(&buf, diffThread(hunk, 3), "", errors.New("not a git repository"), 3)
+	if out := buf.String(); !strings.Contains(out, "+two") || !strings.Contains(out, "not in a git checkout") || strings.Contains(out, "Local") {
+		t.Fatalf("outside a checkout:\n%s", out)
+	}
+}
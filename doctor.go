@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"gh-pr-review/internal/gh"
+	"gh-pr-review/internal/github"
+)
+
+// doctorReport prints a checklist line per check and remembers whether any
+// failed.
+type doctorReport struct {
+	w      io.Writer
+	styler styler
+	failed bool
+}
+
+func (r *doctorReport) pass(detail string) {
+	fmt.Fprintf(r.w, "%s %s\n", r.styler.wrap("32", "✓"), detail)
+}
+
+// note is a passing check with something worth knowing.
+func (r *doctorReport) note(detail, hint string) {
+	r.pass(detail)
+	fmt.Fprintf(r.w, "    %s\n", r.styler.dim(hint))
+}
+
+func (r *doctorReport) fail(detail, hint string) {
+	r.failed = true
+	fmt.Fprintf(r.w, "%s %s\n", r.styler.wrap("31", "✗"), detail)
+	if hint != "" {
+		fmt.Fprintf(r.w, "    %s\n", hint)
+	}
+}
+
+func (r *doctorReport) skip(detail string) {
+	fmt.Fprintf(r.w, "%s %s\n", r.styler.dim("-"), r.styler.dim(detail+" (skipped)"))
+}
+
+type doctorFlags struct {
+	host string
+}
+
+func (o *doctorFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.host, "host", gh.DefaultHost(), "GitHub host")
+}
+
+func runDoctor(args []string) error {
+	fs := newFlagSet("doctor", printDoctorUsage)
+	var flags doctorFlags
+	flags.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+
+	ctx := context.Background()
+	r := &doctorReport{w: os.Stdout, styler: newStyler(os.Stdout)}
+	endpoint := github.GraphQLEndpoint(flags.host)
+	fmt.Fprintf(os.Stdout, "Checking %s (%s)\n", flags.host, endpoint)
+
+	path, err := exec.LookPath("gh")
+	if err != nil {
+		r.fail("gh is not installed or not on PATH", "fix: install the GitHub CLI from https://cli.github.com")
+		r.skip("gh auth token")
+		r.skip("GraphQL endpoint")
+		r.skip("viewer query")
+		r.skip("token scopes")
+		return &exitError{code: 1}
+	}
+	if version, err := gh.Version(ctx); err != nil {
+		r.fail(fmt.Sprintf("gh at %s doesn't run: %v", path, err), "fix: reinstall the GitHub CLI")
+	} else {
+		r.pass(fmt.Sprintf("%s (%s)", version, path))
+	}
+
+	token, err := gh.AuthToken(ctx, flags.host)
+	if err != nil {
+		r.fail(fmt.Sprintf("gh auth token for %s failed: %v", flags.host, err), fmt.Sprintf("fix: run `gh auth login --hostname %s`", flags.host))
+	} else {
+		r.pass("gh has a token for " + flags.host)
+	}
+
+	client := github.NewClient(endpoint, token)
+	if err := client.Reachable(ctx); err != nil {
+		hint := "fix: check your network connection and proxy settings"
+		if flags.host != "github.com" {
+			hint = "fix: check the host name (--host, GH_HOST or default_host) and that you can reach it"
+		}
+		r.fail(fmt.Sprintf("can't reach %s: %v", endpoint, err), hint)
+		r.skip("viewer query")
+		r.skip("token scopes")
+		return &exitError{code: 1}
+	}
+	r.pass(endpoint + " is reachable")
+
+	if token == "" {
+		r.skip("viewer query")
+		r.skip("token scopes")
+		return &exitError{code: 1}
+	}
+	viewer, err := client.Viewer(ctx)
+	if err != nil {
+		var statusErr *github.StatusError
+		hint := ""
+		if errors.As(err, &statusErr) && statusErr.StatusCode == 401 {
+			hint = fmt.Sprintf("fix: the token was rejected; run `gh auth refresh --hostname %s` or log in again", flags.host)
+		}
+		r.fail(fmt.Sprintf("viewer query failed: %v", err), hint)
+		r.skip("token scopes")
+		return &exitError{code: 1}
+	}
+	r.pass("logged in as " + viewer.Login)
+
+	ok, detail, hint := tokenScopeCheck(viewer, flags.host)
+	switch {
+	case !ok:
+		r.fail(detail, hint)
+	case hint != "":
+		r.note(detail, hint)
+	default:
+		r.pass(detail)
+	}
+	if r.failed {
+		return &exitError{code: 1}
+	}
+	return nil
+}
+
+// tokenScopeCheck says whether the token can resolve threads. That needs
+// the repo scope, or public_repo for public repositories. Tokens that don't
+// report scopes are granted access per repository, which can't be checked
+// here.
+func tokenScopeCheck(v github.Viewer, host string) (ok bool, detail, hint string) {
+	if !v.ScopesReported {
+		return true, "token scopes not reported (fine-grained or app token)", "resolving threads needs read and write access to pull requests on the repository"
+	}
+	scopes := "none"
+	if len(v.Scopes) > 0 {
+		scopes = strings.Join(v.Scopes, ", ")
+	}
+	has := map[string]bool{}
+	for _, s := range v.Scopes {
+		has[s] = true
+	}
+	switch {
+	case has["repo"]:
+		return true, "token scopes: " + scopes, ""
+	case has["public_repo"]:
+		return true, "token scopes: " + scopes, fmt.Sprintf("public repositories only; for private ones run `gh auth refresh --hostname %s --scopes repo`", host)
+	default:
+		return false, "token scopes: " + scopes + " (resolving threads needs repo or public_repo)", fmt.Sprintf("fix: run `gh auth refresh --hostname %s --scopes repo`", host)
+	}
+}
+
+func printDoctorUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage:")
+	fmt.Fprintln(w, "  gh-pr-review doctor [--host host]")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Checks the setup in order: gh is installed, gh has a token for the host, the GraphQL")
+	fmt.Fprintln(w, "endpoint is reachable, a viewer query works (printing the account in use), and the token")
+	fmt.Fprintln(w, "has the scopes needed to resolve threads. Failed checks come with a suggested fix, and")
+	fmt.Fprintln(w, "the exit status is 1 if any failed.")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Flags:")
+	fmt.Fprintln(w, "  --host <host>   GitHub host")
+}
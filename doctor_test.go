@@ -0,0 +1,34 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"gh-pr-review/internal/github"
+)
+
+func TestTokenScopeCheck(t *testing.T) {
+	tests := []struct {
+		name     string
+		viewer   github.Viewer
+		ok       bool
+		wantHint string
+	}{
+		{name: "repo", viewer: github.Viewer{Scopes: []string{"gist", "read:org", "repo"}, ScopesReported: true}, ok: true},
+		{name: "public only", viewer: github.Viewer{Scopes: []string{"public_repo"}, ScopesReported: true}, ok: true, wantHint: "public repositories only"},
+		{name: "missing", viewer: github.Viewer{Scopes: []string{"read:org"}, ScopesReported: true}, wantHint: "--scopes repo"},
+		{name: "no scopes", viewer: github.Viewer{ScopesReported: true}, wantHint: "--scopes repo"},
+		{name: "fine-grained", viewer: github.Viewer{}, ok: true, wantHint: "access to pull requests"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, detail, hint := tokenScopeCheck(tt.viewer, "github.com")
+			if ok != tt.ok {
+				t.Errorf("ok = %v (%s)", ok, detail)
+			}
+			if tt.wantHint == "" && hint != "" || !strings.Contains(hint, tt.wantHint) {
+				t.Errorf("hint = %q, want it to mention %q", hint, tt.wantHint)
+			}
+		})
+	}
+}
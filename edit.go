@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"gh-pr-review/internal/gh"
+	"gh-pr-review/internal/github"
+)
+
+type editFlags struct {
+	commentID  string
+	commentURL string
+	repo       string
+	pr         int
+	body       string
+	bodyFile   string
+	host       string
+}
+
+func (o *editFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.commentID, "comment-id", "", "review comment node ID (or numeric ID)")
+	fs.StringVar(&o.commentURL, "url", "", "review comment URL or numeric ID")
+	fs.StringVar(&o.repo, "repo", "", "owner/name for a numeric ID (defaults to gh repo view)")
+	fs.IntVar(&o.pr, "pr", 0, "PR number for a numeric ID")
+	fs.StringVar(&o.body, "body", "", "New comment body")
+	fs.StringVar(&o.bodyFile, "body-file", "", "Read the new body from file (- for stdin)")
+	fs.StringVar(&o.host, "host", gh.DefaultHost(), "GitHub host")
+}
+
+func runEdit(args []string) error {
+	fs := newFlagSet("edit", printEditUsage)
+	var flags editFlags
+	flags.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if flags.commentID != "" && flags.commentURL != "" {
+		return errors.New("provide only one of --comment-id or --url")
+	}
+	if flags.commentID == "" && flags.commentURL == "" {
+		return errors.New("--comment-id or --url is required")
+	}
+	var ref *commentRef
+	if flags.commentURL != "" {
+		r, err := parseCommentRef(flags.commentURL)
+		if err != nil {
+			return err
+		}
+		ref = &r
+	}
+	var err error
+	flags.body, err = resolveBody(flags.body, flags.bodyFile)
+	if err != nil {
+		return err
+	}
+	if flags.bodyFile != "" && strings.TrimSpace(flags.body) == "" {
+		return errors.New("--body-file is empty")
+	}
+
+	ctx := context.Background()
+	var refs []commentRef
+	if ref != nil {
+		refs = append(refs, *ref)
+	}
+	client, err := newClient(ctx, commentRefHost(refs, flags.host))
+	if err != nil {
+		return err
+	}
+	id, err := reviewCommentID(ctx, client, flags.commentID, ref, flags.repo, flags.pr)
+	if err != nil {
+		return err
+	}
+	comment, err := fetchOwnedComment(ctx, client, id)
+	if err != nil {
+		return err
+	}
+	if !comment.ViewerDidAuthor {
+		return fmt.Errorf("you can only edit your own comments; %s is by %s", comment.URL, comment.Author.Login)
+	}
+	if !comment.ViewerCanUpdate {
+		return fmt.Errorf("comment %s can't be edited (the conversation may be locked)", comment.URL)
+	}
+
+	if flags.body == "" {
+		// The editor starts from the current body, verbatim: '#' lines are
+		// Markdown headings here, not comments.
+		edited, err := editRaw(comment.Body)
+		if err != nil {
+			return err
+		}
+		flags.body = strings.TrimSpace(edited)
+		if flags.body == "" {
+			return errors.New("empty body; the comment was not changed")
+		}
+	}
+	if strings.TrimSpace(flags.body) == strings.TrimSpace(comment.Body) {
+		fmt.Fprintf(os.Stderr, "no changes; %s was left as it was\n", comment.URL)
+		return nil
+	}
+	url, err := updateReviewComment(ctx, client, comment.ID, flags.body)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stdout, "updated comment %s\n", url)
+	return nil
+}
+
+func updateReviewComment(ctx context.Context, client *github.Client, commentID, body string) (string, error) {
+	mutation := `mutation($id:ID!, $body:String!) {
+  updatePullRequestReviewComment(input:{pullRequestReviewCommentId:$id, body:$body}) {
+    pullRequestReviewComment { id url }
+  }
+}`
+	var resp struct {
+		Update struct {
+			Comment struct {
+				ID  string `json:"id"`
+				URL string `json:"url"`
+			} `json:"pullRequestReviewComment"`
+		} `json:"updatePullRequestReviewComment"`
+	}
+	err := client.Do(ctx, mutation, map[string]interface{}{"id": commentID, "body": body}, &resp)
+	if github.Forbidden(err) {
+		return "", errors.New("you can only edit your own comments")
+	}
+	if err != nil {
+		return "", err
+	}
+	return resp.Update.Comment.URL, nil
+}
+
+func printEditUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage:")
+	fmt.Fprintln(w, "  gh-pr-review edit --comment-id <id> [--body <text>|--body-file <path>] [--host host]")
+	fmt.Fprintln(w, "  gh-pr-review edit --url <comment-url|comment-id> [--pr <number>] [--repo owner/name] [--body <text>|--body-file <path>] [--host host]")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Without --body or --body-file the current body opens in $GIT_EDITOR, $VISUAL or $EDITOR.")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Flags:")
+	fmt.Fprintln(w, "  --comment-id <id>   Review comment node ID (PRRC_...), or a numeric comment ID with --pr")
+	fmt.Fprintln(w, "  --url <url>   Review comment URL (.../pull/42#discussion_r123), or a numeric comment ID with --pr")
+	fmt.Fprintln(w, "  --pr <number>   PR for a numeric ID (defaults to current branch PR if available)")
+	fmt.Fprintln(w, "  --repo <owner/name>   Repository for a numeric ID (defaults to gh repo view)")
+	fmt.Fprintln(w, "  --body <text>   New comment body")
+	fmt.Fprintln(w, "  --body-file <path>   Read the new body from a file (use - for stdin)")
+	fmt.Fprintln(w, "  --host <host>   GitHub host")
+}
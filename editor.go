@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// editorCommand returns the user's editor the way git picks it, with the
+// config file's editor ahead of the vi fallback.
+func editorCommand() string {
+	for _, name := range []string{"GIT_EDITOR", "VISUAL", "EDITOR"} {
+		if v := strings.TrimSpace(os.Getenv(name)); v != "" {
+			return v
+		}
+	}
+	if v := configValue("editor"); v != "" {
+		return v
+	}
+	if runtime.GOOS == "windows" {
+		return "notepad"
+	}
+	return "vi"
+}
+
+// editorCmd runs editor, which may carry its own arguments ("code
+// --wait"), with args after them. The shell splits the editor the way git
+// does; Windows has no sh, so there it is split into words here and run
+// directly.
+func editorCmd(ctx context.Context, editor string, args ...string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		words := editorWords(editor)
+		if len(words) == 0 {
+			words = []string{editor}
+		}
+		return exec.CommandContext(ctx, words[0], append(words[1:], args...)...)
+	}
+	return exec.CommandContext(ctx, "sh", append([]string{"-c", editor + ` "$@"`, "sh"}, args...)...)
+}
+
+// editorWords splits an editor command into words at spaces outside
+// quotes. Quotes group words, such as a program under "C:\Program Files",
+// and are removed. Unlike splitCommandLine, backslashes are kept as they
+// are, being path separators on Windows.
+func editorWords(s string) []string {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	for _, r := range s {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			word.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words
+}
+
+// editText opens the user's editor on a temp file holding initial and
+// returns what was saved with '#' comment lines removed, like git commit.
+func editText(initial string) (string, error) {
+	text, err := editRaw(initial)
+	if err != nil {
+		return "", err
+	}
+	return stripCommentLines(text), nil
+}
+
+// editRaw opens the user's editor on a temp file holding initial and
+// returns exactly what was saved.
+func editRaw(initial string) (string, error) {
+	if !canPrompt() {
+		reason := "stdin is not a terminal"
+		if noInput {
+			reason = "--no-input is set"
+		}
+		return "", &exitError{code: exitCodeNoInput, err: fmt.Errorf("can't open an editor: %s", reason)}
+	}
+	f, err := os.CreateTemp("", "gh-pr-review-*.md")
+	if err != nil {
+		return "", err
+	}
+	path := f.Name()
+	defer os.Remove(path)
+	if _, err := f.WriteString(initial); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+
+	editor := editorCommand()
+	cmd := editorCmd(context.Background(), editor, path)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("editor %q failed: %w", editor, err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// stripCommentLines drops lines starting with '#' and trims surrounding
+// blank lines.
+func stripCommentLines(text string) string {
+	var kept []string
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return strings.Trim(strings.Join(kept, "\n"), "\n \t")
+}
+
+// commentedQuote renders a comment as '#'-prefixed lines for an editor
+// template.
+func commentedQuote(c reviewComment) string {
+	var b strings.Builder
+	author := c.Author.Login
+	if author == "" {
+		author = "unknown"
+	}
+	fmt.Fprintf(&b, "# %s wrote:\n#\n", author)
+	for _, line := range strings.Split(strings.TrimRight(c.Body, "\n"), "\n") {
+		b.WriteString(strings.TrimRight("#   "+line, " ") + "\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,333 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"gh-pr-review/internal/gh"
+	"gh-pr-review/internal/github"
+)
+
+// exportSchemaVersion is bumped whenever the export's shape changes in a way
+// readers need to know about.
+const exportSchemaVersion = 1
+
+// threadExport is the document `export` writes. It carries no timestamps of
+// its own so exports of an unchanged PR are byte-for-byte identical.
+type threadExport struct {
+	SchemaVersion int            `json:"schemaVersion"`
+	Repo          string         `json:"repo"`
+	PR            int            `json:"pr"`
+	Threads       []reviewThread `json:"threads"`
+}
+
+type exportFlags struct {
+	repo            string
+	pr              int
+	out             string
+	format          string
+	status          string
+	review          string
+	includeOutdated bool
+	excludeBots     bool
+	onlyBots        bool
+	noIgnore        bool
+	host            string
+}
+
+func (o *exportFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.repo, "repo", "", "owner/name (defaults to gh repo view)")
+	fs.IntVar(&o.pr, "pr", 0, "PR number")
+	fs.StringVar(&o.out, "out", "-", "file to write (- for stdout)")
+	fs.StringVar(&o.format, "format", "json", "json|markdown")
+	fs.StringVar(&o.status, "status", "all", "all|resolved|unresolved|resolved-no-reply")
+	fs.StringVar(&o.review, "review", "", "review id|index|none")
+	fs.BoolVar(&o.includeOutdated, "include-outdated", true, "include outdated threads")
+	fs.BoolVar(&o.excludeBots, "exclude-bots", false, "drop threads where every comment is from a bot")
+	fs.BoolVar(&o.onlyBots, "only-bots", false, "only threads where every comment is from a bot")
+	fs.BoolVar(&o.noIgnore, "no-ignore", false, "include threads on paths in "+ignoreFileName)
+	fs.StringVar(&o.host, "host", gh.DefaultHost(), "GitHub host")
+}
+
+func runExport(args []string) error {
+	fs := newFlagSet("export", printExportUsage)
+	var flags exportFlags
+	flags.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+	flags.format = strings.ToLower(strings.TrimSpace(flags.format))
+	if flags.format != "json" && flags.format != "markdown" {
+		return fmt.Errorf("invalid --format %q (expected json|markdown)", flags.format)
+	}
+	flags.status = strings.ToLower(strings.TrimSpace(flags.status))
+	if flags.status != "all" && flags.status != "resolved" && flags.status != "unresolved" && flags.status != "resolved-no-reply" {
+		return fmt.Errorf("invalid --status %q", flags.status)
+	}
+	if flags.excludeBots && flags.onlyBots {
+		return errors.New("provide only one of --exclude-bots or --only-bots")
+	}
+	flags.review = strings.TrimSpace(flags.review)
+	if flags.review == "list" {
+		return errors.New("--review list is only supported by `list`")
+	}
+
+	ctx := context.Background()
+	var err error
+	flags.pr, err = resolvePR(ctx, flags.pr)
+	if err != nil {
+		return err
+	}
+	owner, name, err := resolveRepo(ctx, flags.repo)
+	if err != nil {
+		return err
+	}
+	client, err := newClient(ctx, flags.host)
+	if err != nil {
+		return err
+	}
+	threads, err := fetchAllThreads(ctx, client, owner, name, flags.pr)
+	if err != nil {
+		return err
+	}
+	if err := fetchRemainingComments(ctx, client, threads); err != nil {
+		return err
+	}
+	filtered, err := applyIgnoreFile(ctx, filterThreads(threads, flags.status), flags.noIgnore)
+	if err != nil {
+		return err
+	}
+	if flags.review != "" {
+		var reviews []pullRequestReview
+		if flags.review != "none" {
+			if reviews, err = fetchReviews(ctx, client, owner, name, flags.pr); err != nil {
+				return err
+			}
+		}
+		if filtered, err = filterByReview(filtered, reviews, flags.review); err != nil {
+			return err
+		}
+	}
+	if flags.excludeBots || flags.onlyBots {
+		filtered = filterBotThreads(filtered, flags.onlyBots)
+	}
+	if !flags.includeOutdated {
+		current := filtered[:0]
+		for _, t := range filtered {
+			if !t.IsOutdated {
+				current = append(current, t)
+			}
+		}
+		filtered = current
+	}
+	export := newThreadExport(owner+"/"+name, flags.pr, filtered)
+
+	var buf bytes.Buffer
+	if flags.format == "markdown" {
+		writeExportMarkdown(&buf, export)
+	} else if err := writeJSON(&buf, export); err != nil {
+		return err
+	}
+	if flags.out == "-" || flags.out == "" {
+		_, err := os.Stdout.Write(buf.Bytes())
+		return err
+	}
+	if err := os.WriteFile(flags.out, buf.Bytes(), 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "wrote %d threads to %s\n", len(export.Threads), flags.out)
+	return nil
+}
+
+// fetchRemainingComments pages through the comments of threads whose first
+// page came back full, replacing their comments with the complete list.
+func fetchRemainingComments(ctx context.Context, client *github.Client, threads []reviewThread) error {
+	query := func(skip map[string]bool) string {
+		return `query($id:ID!, $after:String) {
+  node(id:$id) {
+    ... on PullRequestReviewThread {
+      comments(first:100, after:$after) {
+        pageInfo { hasNextPage endCursor }
+        nodes {` + commentFields(skip) + `}
+      }
+    }
+  }
+}`
+	}
+	for i := range threads {
+		t := &threads[i]
+		if len(t.Comments.Nodes) < 100 {
+			continue
+		}
+		var comments []reviewComment
+		var after *string
+		for {
+			var resp struct {
+				Node struct {
+					Comments struct {
+						PageInfo struct {
+							HasNextPage bool    `json:"hasNextPage"`
+							EndCursor   *string `json:"endCursor"`
+						} `json:"pageInfo"`
+						Nodes []reviewComment `json:"nodes"`
+					} `json:"comments"`
+				} `json:"node"`
+			}
+			vars := map[string]interface{}{"id": t.ID, "after": after}
+			if err := queryWithFallback(ctx, client, query, vars, &resp); err != nil {
+				return fmt.Errorf("fetching comments of thread %s: %w", t.ID, err)
+			}
+			comments = append(comments, resp.Node.Comments.Nodes...)
+			info := resp.Node.Comments.PageInfo
+			if !info.HasNextPage || info.EndCursor == nil || *info.EndCursor == "" {
+				break
+			}
+			after = info.EndCursor
+		}
+		t.Comments.Nodes = comments
+		annotateThread(t)
+	}
+	return nil
+}
+
+// newThreadExport orders threads by file, then line, then when they were
+// opened, and each thread's comments by creation time, so the same review
+// always exports the same way.
+func newThreadExport(repo string, pr int, threads []reviewThread) threadExport {
+	sorted := make([]reviewThread, len(threads))
+	copy(sorted, threads)
+	for i := range sorted {
+		comments := append([]reviewComment(nil), sorted[i].Comments.Nodes...)
+		sort.SliceStable(comments, func(a, b int) bool {
+			if comments[a].CreatedAt != comments[b].CreatedAt {
+				return comments[a].CreatedAt < comments[b].CreatedAt
+			}
+			return comments[a].ID < comments[b].ID
+		})
+		sorted[i].Comments.Nodes = comments
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		aStart, aEnd, _ := threadLines(a)
+		bStart, bEnd, _ := threadLines(b)
+		if aStart != bStart {
+			return aStart < bStart
+		}
+		if aEnd != bEnd {
+			return aEnd < bEnd
+		}
+		if ac, bc := firstCommentTime(a), firstCommentTime(b); ac != bc {
+			return ac < bc
+		}
+		return a.ID < b.ID
+	})
+	return threadExport{SchemaVersion: exportSchemaVersion, Repo: repo, PR: pr, Threads: sorted}
+}
+
+func firstCommentTime(t reviewThread) string {
+	if len(t.Comments.Nodes) == 0 {
+		return ""
+	}
+	return t.Comments.Nodes[0].CreatedAt
+}
+
+// writeExportMarkdown renders an export as a document with one section per
+// file.
+func writeExportMarkdown(w io.Writer, export threadExport) {
+	fmt.Fprintf(w, "<!-- gh-pr-review export, schema version %d -->\n", export.SchemaVersion)
+	fmt.Fprintf(w, "# Review threads for %s#%d\n", export.Repo, export.PR)
+	if len(export.Threads) == 0 {
+		fmt.Fprintln(w, "\nNo review threads.")
+		return
+	}
+	path := ""
+	for i, t := range export.Threads {
+		if i == 0 || t.Path != path {
+			path = t.Path
+			heading := "(no file)"
+			if path != "" {
+				heading = "`" + path + "`"
+			}
+			fmt.Fprintf(w, "\n## %s\n", heading)
+		}
+		state := "unresolved"
+		if t.IsResolved {
+			state = "resolved"
+			if t.ResolvedBy != nil && t.ResolvedBy.Login != "" {
+				state += " by " + t.ResolvedBy.Login
+			}
+		}
+		fmt.Fprintf(w, "\n### %s%s (%s)\n", lineLabel(t), outdatedLabel(t), state)
+		fmt.Fprintf(w, "\nThread `%s`\n", t.ID)
+		if len(t.Comments.Nodes) > 0 && t.Comments.Nodes[0].DiffHunk != "" {
+			fmt.Fprintf(w, "\n```diff\n%s\n```\n", strings.TrimRight(t.Comments.Nodes[0].DiffHunk, "\n"))
+		}
+		for _, c := range t.Comments.Nodes {
+			author := c.Author.Login
+			if author == "" {
+				author = "unknown"
+			}
+			fmt.Fprintf(w, "\n**%s** commented at %s", author, c.CreatedAt)
+			if c.URL != "" {
+				fmt.Fprintf(w, " ([link](%s))", c.URL)
+			}
+			fmt.Fprintf(w, ":\n\n%s\n", strings.TrimRight(c.Body, "\n"))
+		}
+	}
+}
+
+func lineLabel(t reviewThread) string {
+	start, end, ok := threadLines(t)
+	switch {
+	case !ok:
+		return "File"
+	case start == end:
+		return fmt.Sprintf("Line %d", end)
+	default:
+		return fmt.Sprintf("Lines %d-%d", start, end)
+	}
+}
+
+func outdatedLabel(t reviewThread) string {
+	if !t.IsOutdated {
+		return ""
+	}
+	if t.OriginalCommit != nil && t.OriginalCommit.AbbreviatedOID != "" {
+		return " [outdated @ " + t.OriginalCommit.AbbreviatedOID + "]"
+	}
+	return " [outdated]"
+}
+
+func printExportUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage:")
+	fmt.Fprintln(w, "  gh-pr-review export [--pr <number>] [--repo owner/name] [--out file] [--format json|markdown] [--status value] [--review id|index|none] [--include-outdated=false] [--exclude-bots|--only-bots] [--no-ignore] [--host host]")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Flags:")
+	fmt.Fprintln(w, "  --pr <number>   PR number (defaults to current branch PR if available)")
+	fmt.Fprintln(w, "  --repo <owner/name>   Repository (defaults to gh repo view)")
+	fmt.Fprintln(w, "  --out <file>   File to write (default - for stdout)")
+	fmt.Fprintln(w, "  --format <value>   json (default, with a schemaVersion field) or markdown grouped by file")
+	fmt.Fprintln(w, "  --status <value>   all|resolved|unresolved|resolved-no-reply (default: all)")
+	fmt.Fprintln(w, "  --review <value>   Only threads from one review (id, index from `list --review list`, or none)")
+	fmt.Fprintln(w, "  --include-outdated   Include outdated threads (default true; pass --include-outdated=false to drop them)")
+	fmt.Fprintln(w, "  --exclude-bots   Drop threads where every comment is from a bot")
+	fmt.Fprintln(w, "  --only-bots   Only threads where every comment is from a bot")
+	fmt.Fprintln(w, "  --no-ignore   Include threads on paths matched by "+ignoreFileName)
+	fmt.Fprintln(w, "  --host <host>   GitHub host")
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func exportThread(id, path string, line int, created ...string) reviewThread {
+	t := reviewThread{ID: id, Path: path, Line: intPtr(line)}
+	for i, at := range created {
+		c := reviewComment{ID: id + "-c" + string(rune('0'+i)), CreatedAt: at, Body: "body " + at, DiffHunk: "@@ -1 +1 @@\n+x"}
+		c.Author.Login = "alice"
+		t.Comments.Nodes = append(t.Comments.Nodes, c)
+	}
+	return t
+}
+
+func TestNewThreadExportOrdering(t *testing.T) {
+	threads := []reviewThread{
+		exportThread("T3", "b.go", 5, "2024-01-03T00:00:00Z"),
+		exportThread("T2", "a.go", 20, "2024-01-02T00:00:00Z"),
+		exportThread("T1", "a.go", 3, "2024-01-05T00:00:00Z", "2024-01-04T00:00:00Z"),
+	}
+	export := newThreadExport("owner/repo", 42, threads)
+	if export.SchemaVersion != exportSchemaVersion {
+		t.Fatalf("schema version = %d", export.SchemaVersion)
+	}
+	got := threadIDs(export.Threads)
+	if strings.Join(got, ",") != "T1,T2,T3" {
+		t.Fatalf("order = %v", got)
+	}
+	if c := export.Threads[0].Comments.Nodes; c[0].CreatedAt != "2024-01-04T00:00:00Z" {
+		t.Fatalf("comments not sorted by creation time: %+v", c)
+	}
+	if threads[0].ID != "T3" || threads[2].Comments.Nodes[0].CreatedAt != "2024-01-05T00:00:00Z" {
+		t.Fatal("newThreadExport modified its input")
+	}
+
+	var first, second bytes.Buffer
+	writeExportMarkdown(&first, export)
+	writeExportMarkdown(&second, newThreadExport("owner/repo", 42, []reviewThread{threads[2], threads[0], threads[1]}))
+	if first.String() != second.String() {
+		t.Fatal("markdown export depends on input order")
+	}
+	md := first.String()
+	if strings.Count(md, "## `a.go`") != 1 || strings.Count(md, "## `b.go`") != 1 {
+		t.Fatalf("expected one section per file:\n%s", md)
+	}
+	if !strings.Contains(md, "### Line 3 (unresolved)") || !strings.Contains(md, "```diff\n@@ -1 +1 @@\n+x\n```") {
+		t.Fatalf("unexpected markdown:\n%s", md)
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"gh-pr-review/internal/gh"
+	"gh-pr-review/internal/github"
+)
+
+// firstComment is a thread's opening comment with just enough context to
+// act on it.
+type firstComment struct {
+	ThreadID   string `json:"threadId"`
+	Author     string `json:"author"`
+	Body       string `json:"body"`
+	Path       string `json:"path"`
+	Line       *int   `json:"line"`
+	StartLine  *int   `json:"startLine,omitempty"`
+	URL        string `json:"url"`
+	CreatedAt  string `json:"createdAt"`
+	IsResolved bool   `json:"isResolved"`
+	IsOutdated bool   `json:"isOutdated"`
+}
+
+type firstCommentFlags struct {
+	threadID string
+	format   string
+	host     string
+}
+
+func (o *firstCommentFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.threadID, "thread-id", "", "Review thread ID")
+	fs.StringVar(&o.format, "format", formatText, "text|json")
+	fs.StringVar(&o.host, "host", gh.DefaultHost(), "GitHub host")
+}
+
+func runFirstComment(args []string) error {
+	fs := newFlagSet("first-comment", printFirstCommentUsage)
+	var flags firstCommentFlags
+	flags.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	switch fs.NArg() {
+	case 0:
+	case 1:
+		if flags.threadID != "" {
+			return errors.New("provide the thread as an argument or with --thread-id, not both")
+		}
+		flags.threadID = fs.Arg(0)
+	default:
+		return errors.New("first-comment takes one thread at a time")
+	}
+	if flags.threadID == "" {
+		return errors.New("--thread-id is required")
+	}
+	if flags.format != formatText && flags.format != "json" {
+		return fmt.Errorf("invalid --format %q (expected text|json)", flags.format)
+	}
+
+	ctx := context.Background()
+	client, err := newClient(ctx, flags.host)
+	if err != nil {
+		return err
+	}
+	first, err := fetchFirstComment(ctx, client, flags.threadID)
+	if err != nil {
+		return err
+	}
+	if flags.format == "json" {
+		return writeJSON(os.Stdout, first)
+	}
+	printFirstComment(os.Stdout, first)
+	return nil
+}
+
+// fetchFirstComment loads only a thread's location and opening comment, in
+// one request with nothing to page through, so scripts can call it often.
+func fetchFirstComment(ctx context.Context, client *github.Client, threadID string) (firstComment, error) {
+	query := `query($id:ID!) {
+  node(id:$id) {
+    ... on PullRequestReviewThread {
+      id
+      isResolved
+      isOutdated
+      path
+      line
+      originalLine
+      startLine
+      originalStartLine
+      comments(first:1) {
+        nodes { body createdAt url author { login } }
+      }
+    }
+  }
+}`
+	var resp struct {
+		Node *reviewThread `json:"node"`
+	}
+	err := client.Do(ctx, query, map[string]interface{}{"id": threadID}, &resp)
+	if err := threadLookupError(threadID, resp.Node, err); err != nil {
+		return firstComment{}, err
+	}
+	t := *resp.Node
+	if len(t.Comments.Nodes) == 0 {
+		return firstComment{}, fmt.Errorf("thread %s has no comments", threadID)
+	}
+	c := t.Comments.Nodes[0]
+	first := firstComment{
+		ThreadID:   t.ID,
+		Author:     c.Author.Login,
+		Body:       c.Body,
+		Path:       t.Path,
+		URL:        c.URL,
+		CreatedAt:  c.CreatedAt,
+		IsResolved: t.IsResolved,
+		IsOutdated: t.IsOutdated,
+	}
+	if start, end, ok := threadLines(t); ok {
+		first.Line = &end
+		if start != end {
+			first.StartLine = &start
+		}
+	}
+	return first, nil
+}
+
+// printFirstComment prints the comment as a compact block: who and where,
+// the body, then the link.
+func printFirstComment(w io.Writer, c firstComment) {
+	styler := newStyler(w)
+	location := c.Path
+	switch {
+	case c.Line != nil && c.StartLine != nil:
+		location += fmt.Sprintf(":%d-%d", *c.StartLine, *c.Line)
+	case c.Line != nil:
+		location += fmt.Sprintf(":%d", *c.Line)
+	}
+	if c.IsOutdated {
+		location += " (outdated)"
+	}
+	author := c.Author
+	if author == "" {
+		author = "unknown"
+	}
+	fmt.Fprintf(w, "%s %s\n", styler.author(author), location)
+	fmt.Fprintln(w, strings.TrimRight(c.Body, "\n"))
+	fmt.Fprintln(w, styler.dim(c.URL))
+}
+
+func printFirstCommentUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage:")
+	fmt.Fprintln(w, "  gh-pr-review first-comment --thread-id <id> [--format text|json] [--host host]")
+	fmt.Fprintln(w, "  gh-pr-review first-comment <id> [--format text|json]")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Prints a thread's opening comment: author, path:line, body and URL. It makes a single small")
+	fmt.Fprintln(w, "request, so it suits scripts and CI jobs that only have a thread ID; use view for the whole")
+	fmt.Fprintln(w, "thread.")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Flags:")
+	fmt.Fprintln(w, "  --thread-id <id>   Review thread ID (may also be given as an argument)")
+	fmt.Fprintln(w, "  --format <value>   text (default) or json")
+	fmt.Fprintln(w, "  --host <host>   GitHub host")
+}
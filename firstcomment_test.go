@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gh-pr-review/internal/github"
+)
+
+func TestFetchFirstComment(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		var req github.GraphQLRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+			return
+		}
+		if !strings.Contains(req.Query, "comments(first:1)") || strings.Contains(req.Query, "pageInfo") {
+			t.Errorf("expected a single-comment query without pagination, got %q", req.Query)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{
+			"node": map[string]interface{}{
+				"id":           "PRRT_1",
+				"isOutdated":   true,
+				"path":         "internal/github/graphql.go",
+				"line":         nil,
+				"originalLine": 88,
+				"comments": map[string]interface{}{"nodes": []map[string]interface{}{{
+					"body":      "Handle the error.\n",
+					"createdAt": "2024-05-01T10:00:00Z",
+					"url":       "https://github.com/o/r/pull/1#discussion_r1",
+					"author":    map[string]string{"login": "alice"},
+				}}},
+			},
+		}})
+	}))
+	defer srv.Close()
+	client := github.NewClient(srv.URL, "token")
+
+	first, err := fetchFirstComment(context.Background(), client, "PRRT_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requests != 1 {
+		t.Errorf("made %d requests, want 1", requests)
+	}
+	if first.Author != "alice" || first.Line == nil || *first.Line != 88 || first.StartLine != nil || !first.IsOutdated {
+		t.Fatalf("first = %+v", first)
+	}
+	var buf bytes.Buffer
+	printFirstComment(&buf, first)
+	want := "alice internal/github/graphql.go:88 (outdated)\nHandle the error.\nhttps://github.com/o/r/pull/1#discussion_r1\n"
+	if buf.String() != want {
+		t.Errorf("printed %q, want %q", buf.String(), want)
+	}
+}
+
+func TestFetchFirstCommentNotFound(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"node": nil}})
+	}))
+	defer srv.Close()
+	client := github.NewClient(srv.URL, "token")
+
+	_, err := fetchFirstComment(context.Background(), client, "PRRT_missing")
+	if err == nil || !strings.Contains(err.Error(), "thread PRRT_missing not found") {
+		t.Fatalf("err = %v", err)
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gh-pr-review/internal/gh"
+	"gh-pr-review/internal/git"
+)
+
+type gotoFlags struct {
+	threadID  string
+	repo      string
+	pr        int
+	index     int
+	printOnly bool
+	host      string
+}
+
+func (o *gotoFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.threadID, "thread-id", "", "Review thread ID")
+	fs.StringVar(&o.repo, "repo", "", "owner/name for --index (defaults to gh repo view)")
+	fs.IntVar(&o.pr, "pr", 0, "PR number for --index")
+	fs.IntVar(&o.index, "index", 0, "thread index from the last list of the PR")
+	fs.BoolVar(&o.printOnly, "print", false, "print path:line instead of opening the editor")
+	fs.StringVar(&o.host, "host", gh.DefaultHost(), "GitHub host")
+}
+
+func runGoto(args []string) error {
+	fs := newFlagSet("goto", printGotoUsage)
+	var flags gotoFlags
+	flags.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+	switch {
+	case flags.threadID != "" && flags.index != 0:
+		return errors.New("provide only one of --thread-id or --index")
+	case flags.threadID == "" && flags.index == 0:
+		return errors.New("--thread-id or --index is required")
+	case flags.index < 0:
+		return fmt.Errorf("invalid --index %d", flags.index)
+	}
+
+	ctx := context.Background()
+	if flags.index != 0 {
+		ids, err := threadIDsForIndexes(ctx, flags.host, flags.repo, flags.pr, []int{flags.index})
+		if err != nil {
+			return err
+		}
+		flags.threadID = ids[0]
+	}
+	client, err := newClient(ctx, flags.host)
+	if err != nil {
+		return err
+	}
+	thread, err := fetchThread(ctx, client, flags.threadID)
+	if err != nil {
+		return err
+	}
+	if thread.Path == "" {
+		return fmt.Errorf("thread %s is not attached to a file", flags.threadID)
+	}
+	line := gotoLine(thread)
+	if thread.IsOutdated {
+		at := ""
+		if thread.OriginalCommit != nil && thread.OriginalCommit.AbbreviatedOID != "" {
+			at = " at " + thread.OriginalCommit.AbbreviatedOID
+		}
+		fmt.Fprintf(os.Stderr, "warning: thread %s is outdated; line %d is where it was left%s and may have moved\n", flags.threadID, line, at)
+	}
+
+	root, err := git.TopLevel(ctx)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(root, thread.Path)
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("%s is not in the working tree (is the PR branch checked out?)", thread.Path)
+	}
+	location := path
+	if line > 0 {
+		location = fmt.Sprintf("%s:%d", path, line)
+	}
+	if flags.printOnly {
+		fmt.Fprintln(os.Stdout, location)
+		return nil
+	}
+	editor := editorCommand()
+	jump, ok := editorJumpArgs(editor, path, line)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "don't know how to open %q at a line; open this yourself:\n", editor)
+		fmt.Fprintln(os.Stdout, location)
+		return nil
+	}
+	cmd := editorCmd(ctx, editor, jump...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("editor %q failed: %w", editor, err)
+	}
+	return nil
+}
+
+// gotoLine is the line to open a thread's file at: where an outdated
+// thread was left, otherwise where it is now, or 0 for the whole file.
+func gotoLine(t reviewThread) int {
+	switch {
+	case t.IsOutdated && t.OriginalLine != nil:
+		return *t.OriginalLine
+	case t.Line != nil:
+		return *t.Line
+	case t.OriginalLine != nil:
+		return *t.OriginalLine
+	}
+	return 0
+}
+
+// editorJumpArgs returns the arguments that make editor open path at line,
+// for the editors whose syntax is known.
+func editorJumpArgs(editor, path string, line int) ([]string, bool) {
+	fields := editorWords(editor)
+	if len(fields) == 0 {
+		return nil, false
+	}
+	if line <= 0 {
+		return []string{path}, true
+	}
+	// Windows editors are named with or without their extension.
+	name := filepath.Base(strings.ReplaceAll(fields[0], `\`, "/"))
+	for _, ext := range []string{".exe", ".cmd", ".bat"} {
+		if strings.HasSuffix(strings.ToLower(name), ext) {
+			name = name[:len(name)-len(ext)]
+		}
+	}
+	switch strings.ToLower(name) {
+	case "vi", "vim", "nvim", "gvim", "mvim", "nano", "emacs", "emacsclient", "micro", "kak", "joe", "mg":
+		return []string{fmt.Sprintf("+%d", line), path}, true
+	case "code", "code-insiders", "codium", "cursor", "windsurf":
+		return []string{"-g", fmt.Sprintf("%s:%d", path, line)}, true
+	case "subl", "hx", "helix", "zed":
+		return []string{fmt.Sprintf("%s:%d", path, line)}, true
+	case "mate":
+		return []string{"-l", fmt.Sprintf("%d", line), path}, true
+	case "idea", "goland", "pycharm", "webstorm":
+		return []string{"--line", fmt.Sprintf("%d", line), path}, true
+	}
+	return nil, false
+}
+
+func printGotoUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage:")
+	fmt.Fprintln(w, "  gh-pr-review goto --thread-id <id> [--print] [--host host]")
+	fmt.Fprintln(w, "  gh-pr-review goto --index n [--pr <number>] [--repo owner/name] [--print] [--host host]")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Flags:")
+	fmt.Fprintln(w, "  --thread-id <id>   Review thread ID")
+	fmt.Fprintln(w, "  --index <n>   Thread n from the last `list` of the PR")
+	fmt.Fprintln(w, "  --pr <number>   PR for --index (defaults to current branch PR if available)")
+	fmt.Fprintln(w, "  --repo <owner/name>   Repository for --index (defaults to gh repo view)")
+	fmt.Fprintln(w, "  --print   Print path:line instead of opening $GIT_EDITOR/$VISUAL/$EDITOR")
+	fmt.Fprintln(w, "  --host <host>   GitHub host")
+}
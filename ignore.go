@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"gh-pr-review/internal/git"
+	gitignore "github.com/sabhiram/go-gitignore"
+)
+
+// ignoreFileName lists gitignore-style patterns, at the repository root, for
+// paths whose threads are hidden by default (snapshots, lockfiles, vendored
+// code).
+const ignoreFileName = ".gh-pr-review-ignore"
+
+// loadIgnoreFile compiles the ignore file of the current checkout. It returns
+// nil when there is no checkout or no ignore file.
+func loadIgnoreFile(ctx context.Context) (*gitignore.GitIgnore, error) {
+	root, err := git.TopLevel(ctx)
+	if err != nil {
+		return nil, nil
+	}
+	matcher, err := gitignore.CompileIgnoreFile(filepath.Join(root, ignoreFileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", ignoreFileName, err)
+	}
+	return matcher, nil
+}
+
+// splitIgnored drops threads on paths matched by the ignore patterns and
+// reports how many were dropped. PR-level threads without a path are kept.
+func splitIgnored(threads []reviewThread, matcher *gitignore.GitIgnore) ([]reviewThread, int) {
+	if matcher == nil {
+		return threads, 0
+	}
+	kept := make([]reviewThread, 0, len(threads))
+	for _, t := range threads {
+		if t.Path != "" && matcher.MatchesPath(t.Path) {
+			continue
+		}
+		kept = append(kept, t)
+	}
+	return kept, len(threads) - len(kept)
+}
+
+// applyIgnoreFile hides threads matched by the ignore file unless noIgnore is
+// set, and says on stderr how many were hidden so they aren't silently lost.
+func applyIgnoreFile(ctx context.Context, threads []reviewThread, noIgnore bool) ([]reviewThread, error) {
+	if noIgnore {
+		return threads, nil
+	}
+	matcher, err := loadIgnoreFile(ctx)
+	if err != nil {
+		return nil, err
+	}
+	kept, ignored := splitIgnored(threads, matcher)
+	switch {
+	case ignored == 1:
+		fmt.Fprintf(os.Stderr, "1 thread ignored by %s (use --no-ignore to show)\n", ignoreFileName)
+	case ignored > 1:
+		fmt.Fprintf(os.Stderr, "%d threads ignored by %s (use --no-ignore to show)\n", ignored, ignoreFileName)
+	}
+	return kept, nil
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"gh-pr-review/internal/gh"
+	"gh-pr-review/internal/github"
+)
+
+// inboxConcurrency caps how many PRs' threads are fetched at once.
+const inboxConcurrency = 4
+
+// forEachConcurrently calls fn for 0..n-1 with at most limit calls running
+// at a time, and waits for them all.
+func forEachConcurrently(n, limit int, fn func(i int)) {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, limit)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// inboxPR summarizes the unresolved threads on one of the viewer's PRs.
+type inboxPR struct {
+	Repo             string       `json:"repo"`
+	Number           int          `json:"number"`
+	Title            string       `json:"title"`
+	URL              string       `json:"url"`
+	Unresolved       int          `json:"unresolved"`
+	Unanswered       int          `json:"unanswered"`
+	OldestUnanswered *inboxThread `json:"oldestUnanswered,omitempty"`
+	Error            string       `json:"error,omitempty"`
+}
+
+// inboxThread is an unresolved thread whose latest comment is someone
+// else's.
+type inboxThread struct {
+	ThreadID string `json:"threadId"`
+	Location string `json:"location,omitempty"`
+	Author   string `json:"author"`
+	Since    string `json:"since"`
+	URL      string `json:"url"`
+}
+
+type inboxFlags struct {
+	org        string
+	repo       string
+	unanswered bool
+	limit      int
+	jsonOut    bool
+	host       string
+}
+
+func (o *inboxFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.org, "org", "", "only PRs in this organization")
+	fs.StringVar(&o.repo, "repo", "", "only PRs in this repository (owner/name)")
+	fs.BoolVar(&o.unanswered, "unanswered", false, "only count threads whose last comment isn't yours")
+	fs.IntVar(&o.limit, "limit", 20, "scan at most N open PRs (1-100)")
+	fs.BoolVar(&o.jsonOut, "json", false, "output JSON")
+	fs.StringVar(&o.host, "host", gh.DefaultHost(), "GitHub host")
+}
+
+func runInbox(args []string) error {
+	fs := newFlagSet("inbox", printInboxUsage)
+	var flags inboxFlags
+	flags.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+	if flags.limit < 1 || flags.limit > 100 {
+		return fmt.Errorf("invalid --limit %d (expected 1-100)", flags.limit)
+	}
+	if flags.org != "" && flags.repo != "" {
+		return errors.New("provide only one of --org or --repo")
+	}
+	if flags.repo != "" {
+		if _, _, err := parseRepo(flags.repo); err != nil {
+			return err
+		}
+	}
+
+	ctx := context.Background()
+	client, err := newClient(ctx, flags.host)
+	if err != nil {
+		return err
+	}
+	viewer, err := fetchViewerLogin(ctx, client)
+	if err != nil {
+		return err
+	}
+	prs, err := searchOpenPRs(ctx, client, inboxSearchQuery(flags.org, flags.repo), flags.limit)
+	if err != nil {
+		return err
+	}
+
+	forEachConcurrently(len(prs), inboxConcurrency, func(i int) {
+		pr := &prs[i]
+		owner, name, _ := parseRepo(pr.Repo)
+		threads, err := fetchAllThreads(ctx, client, owner, name, pr.Number)
+		if err != nil {
+			pr.Error = err.Error()
+			return
+		}
+		summarizeInbox(pr, threads, viewer)
+	})
+
+	var shown []inboxPR
+	for _, pr := range prs {
+		count := pr.Unresolved
+		if flags.unanswered {
+			count = pr.Unanswered
+		}
+		if count > 0 || pr.Error != "" {
+			shown = append(shown, pr)
+		}
+	}
+	if flags.jsonOut {
+		if shown == nil {
+			shown = []inboxPR{}
+		}
+		return writeJSON(os.Stdout, shown)
+	}
+	printInbox(os.Stdout, shown, len(prs), time.Now())
+	return nil
+}
+
+func inboxSearchQuery(org, repo string) string {
+	q := "is:pr is:open archived:false author:@me"
+	switch {
+	case org != "":
+		q += " org:" + org
+	case repo != "":
+		q += " repo:" + repo
+	}
+	return q
+}
+
+// searchOpenPRs returns up to limit PRs matching the search, most recently
+// updated first.
+func searchOpenPRs(ctx context.Context, client *github.Client, search string, limit int) ([]inboxPR, error) {
+	query := `query($q:String!, $limit:Int!) {
+  search(query:$q, type:ISSUE, first:$limit) {
+    nodes {
+      ... on PullRequest {
+        number
+        title
+        url
+        repository { nameWithOwner }
+      }
+    }
+  }
+}`
+	var resp struct {
+		Search struct {
+			Nodes []struct {
+				Number     int    `json:"number"`
+				Title      string `json:"title"`
+				URL        string `json:"url"`
+				Repository struct {
+					NameWithOwner string `json:"nameWithOwner"`
+				} `json:"repository"`
+			} `json:"nodes"`
+		} `json:"search"`
+	}
+	if err := client.Do(ctx, query, map[string]interface{}{"q": search + " sort:updated-desc", "limit": limit}, &resp); err != nil {
+		return nil, err
+	}
+	var prs []inboxPR
+	for _, n := range resp.Search.Nodes {
+		if n.Number == 0 {
+			continue
+		}
+		prs = append(prs, inboxPR{Repo: n.Repository.NameWithOwner, Number: n.Number, Title: n.Title, URL: n.URL})
+	}
+	return prs, nil
+}
+
+// summarizeInbox counts the unresolved threads and those waiting on the
+// viewer, and picks the one that has waited longest.
+func summarizeInbox(pr *inboxPR, threads []reviewThread, viewer string) {
+	for _, t := range threads {
+		if t.IsResolved || len(t.Comments.Nodes) == 0 {
+			continue
+		}
+		pr.Unresolved++
+		last := t.Comments.Nodes[len(t.Comments.Nodes)-1]
+		if strings.EqualFold(last.Author.Login, viewer) {
+			continue
+		}
+		pr.Unanswered++
+		if pr.OldestUnanswered != nil && pr.OldestUnanswered.Since <= last.CreatedAt {
+			continue
+		}
+		pr.OldestUnanswered = &inboxThread{
+			ThreadID: t.ID,
+			Location: strings.Trim(strings.TrimSpace(formatLineInfo(t)), "[]"),
+			Author:   last.Author.Login,
+			Since:    last.CreatedAt,
+			URL:      last.URL,
+		}
+	}
+}
+
+func printInbox(w io.Writer, prs []inboxPR, scanned int, now time.Time) {
+	styler := newStyler(w)
+	if len(prs) == 0 {
+		fmt.Fprintf(w, "no unresolved threads on your %d open PR(s)\n", scanned)
+		return
+	}
+	for _, pr := range prs {
+		fmt.Fprintf(w, "%s %s\n", styler.label(fmt.Sprintf("%s#%d", pr.Repo, pr.Number)), pr.Title)
+		if pr.Error != "" {
+			fmt.Fprintf(w, "  %s\n", styler.dim("error: "+pr.Error))
+			continue
+		}
+		fmt.Fprintf(w, "  %d unresolved, %d awaiting your reply\n", pr.Unresolved, pr.Unanswered)
+		if o := pr.OldestUnanswered; o != nil {
+			location := ""
+			if o.Location != "" {
+				location = " " + styler.dim("["+o.Location+"]")
+			}
+			fmt.Fprintf(w, "  oldest: %s from %s %s%s\n", relativeTime(o.Since, now), styler.author(o.Author), styler.dim(o.URL), location)
+		}
+	}
+	fmt.Fprintln(w, styler.dim(fmt.Sprintf("%d of %d open PR(s) shown", len(prs), scanned)))
+}
+
+func printInboxUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage:")
+	fmt.Fprintln(w, "  gh-pr-review inbox [--org <org>|--repo owner/name] [--unanswered] [--limit n] [--json] [--host host]")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Flags:")
+	fmt.Fprintln(w, "  --org <org>   Only your PRs in this organization")
+	fmt.Fprintln(w, "  --repo <owner/name>   Only your PRs in this repository")
+	fmt.Fprintln(w, "  --unanswered   Only list PRs with threads whose last comment isn't yours")
+	fmt.Fprintln(w, "  --limit <n>   Scan at most n open PRs, most recently updated first (default 20, max 100)")
+	fmt.Fprintln(w, "  --json   Output JSON ({repo, number, title, url, unresolved, unanswered, oldestUnanswered, error})")
+	fmt.Fprintln(w, "  --host <host>   GitHub host")
+}
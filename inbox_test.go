@@ -0,0 +1,32 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSummarizeInbox(t *testing.T) {
+	threads := []reviewThread{
+		statusThread("answered", false, false, "2024-05-01T00:00:00Z", "alice", "me"),
+		statusThread("newer", false, false, "2024-05-03T00:00:00Z", "alice"),
+		statusThread("older", false, false, "2024-05-02T00:00:00Z", "me", "Bob"),
+		statusThread("resolved", true, false, "2024-04-01T00:00:00Z", "alice"),
+	}
+	var pr inboxPR
+	summarizeInbox(&pr, threads, "ME")
+	if pr.Unresolved != 3 || pr.Unanswered != 2 {
+		t.Fatalf("counts = %d unresolved, %d unanswered", pr.Unresolved, pr.Unanswered)
+	}
+	if o := pr.OldestUnanswered; o == nil || o.ThreadID != "older" || o.Author != "Bob" {
+		t.Fatalf("oldest unanswered = %+v", o)
+	}
+}
+
+func TestInboxSearchQuery(t *testing.T) {
+	if got := inboxSearchQuery("acme", ""); got != "is:pr is:open archived:false author:@me org:acme" {
+		t.Fatalf("org query = %q", got)
+	}
+	if got := inboxSearchQuery("", "acme/tool"); !strings.HasSuffix(got, " repo:acme/tool") {
+		t.Fatalf("repo query = %q", got)
+	}
+}
@@ -4,11 +4,18 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
 	"os/exec"
+	"strconv"
 	"strings"
+	"time"
 )
 
+// Timeout bounds every gh invocation so a hung credential helper or network
+// call can't freeze the tool. Zero disables the limit.
+var Timeout = 30 * time.Second
+
 type RepoView struct {
 	NameWithOwner string `json:"nameWithOwner"`
 }
@@ -29,8 +36,7 @@ func AuthToken(ctx context.Context, host string) (string, error) {
 	if host != "" {
 		args = append(args, "--hostname", host)
 	}
-	cmd := exec.CommandContext(ctx, "gh", args...)
-	out, err := cmd.Output()
+	out, err := run(ctx, args...)
 	if err != nil {
 		return "", err
 	}
@@ -42,8 +48,7 @@ func AuthToken(ctx context.Context, host string) (string, error) {
 }
 
 func RepoViewCurrent(ctx context.Context) (RepoView, error) {
-	cmd := exec.CommandContext(ctx, "gh", "repo", "view", "--json", "nameWithOwner")
-	out, err := cmd.Output()
+	out, err := run(ctx, "repo", "view", "--json", "nameWithOwner")
 	if err != nil {
 		return RepoView{}, err
 	}
@@ -58,8 +63,7 @@ func RepoViewCurrent(ctx context.Context) (RepoView, error) {
 }
 
 func CurrentPrNumber(ctx context.Context) (int, error) {
-	cmd := exec.CommandContext(ctx, "gh", "pr", "view", "--json", "number")
-	out, err := cmd.Output()
+	out, err := run(ctx, "pr", "view", "--json", "number")
 	if err != nil {
 		return 0, err
 	}
@@ -72,3 +76,38 @@ func CurrentPrNumber(ctx context.Context) (int, error) {
 	}
 	return view.Number, nil
 }
+
+// PRDiff returns the unified diff of a pull request. repo is
+// [HOST/]OWNER/REPO, as gh's --repo flag takes it.
+func PRDiff(ctx context.Context, repo string, number int) ([]byte, error) {
+	return run(ctx, "pr", "diff", strconv.Itoa(number), "--repo", repo, "--color", "never")
+}
+
+// Version returns the first line of gh --version, e.g.
+// "gh version 2.40.1 (2023-12-13)".
+func Version(ctx context.Context) (string, error) {
+	out, err := run(ctx, "--version")
+	if err != nil {
+		return "", err
+	}
+	line, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+	return line, nil
+}
+
+// run executes gh with the package timeout applied, killing the process when
+// it expires.
+func run(ctx context.Context, args ...string) ([]byte, error) {
+	if Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, Timeout)
+		defer cancel()
+	}
+	cmd := exec.CommandContext(ctx, "gh", args...)
+	// Don't wait on grandchildren holding stdout open after gh is killed.
+	cmd.WaitDelay = time.Second
+	out, err := cmd.Output()
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("gh %s did not finish within %s (is it waiting for a prompt or the network?)", strings.Join(args, " "), Timeout)
+	}
+	return out, err
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestCurrentPrNumber(t *testing.T) {
@@ -50,6 +52,49 @@ echo 'not-json'
 	})
 }
 
+func TestVersion(t *testing.T) {
+	setupFakeGh(t, `#!/bin/sh
+echo 'gh version 2.40.1 (2023-12-13)'
+echo 'https://github.com/cli/cli/releases/tag/v2.40.1'
+`)
+	version, err := Version(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != "gh version 2.40.1 (2023-12-13)" {
+		t.Errorf("Version = %q", version)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	setupFakeGh(t, `#!/bin/sh
+sleep 10
+`)
+	orig := Timeout
+	Timeout = 200 * time.Millisecond
+	t.Cleanup(func() { Timeout = orig })
+
+	start := time.Now()
+	_, err := CurrentPrNumber(context.Background())
+	if err == nil {
+		t.Fatal("expected timeout error")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("expected gh to be killed promptly, took %s", elapsed)
+	}
+	if !strings.Contains(err.Error(), "gh pr view --json number") {
+		t.Fatalf("expected error to name the command, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "200ms") {
+		t.Fatalf("expected error to mention the timeout, got %v", err)
+	}
+
+	_, err = AuthToken(context.Background(), "example.com")
+	if err == nil || !strings.Contains(err.Error(), "gh auth token --hostname example.com") {
+		t.Fatalf("expected auth token timeout naming the command, got %v", err)
+	}
+}
+
 func setupFakeGh(t *testing.T, script string) string {
 	t.Helper()
 
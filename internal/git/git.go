@@ -0,0 +1,174 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// LineRange is an inclusive range of line numbers on the new side of a diff.
+type LineRange struct {
+	Start int
+	End   int
+}
+
+// Overlaps reports whether the range shares at least one line with start..end.
+func (r LineRange) Overlaps(start, end int) bool {
+	return start <= r.End && end >= r.Start
+}
+
+// ChangedLines runs git diff between two revisions and returns the changed
+// line ranges per file, keyed by the new path.
+func ChangedLines(ctx context.Context, from, to string) (map[string][]LineRange, error) {
+	args := []string{from}
+	if to != "" {
+		args = append(args, to)
+	}
+	return diffRanges(ctx, args...)
+}
+
+// UncommittedChangedLines returns the line ranges of path that differ from
+// HEAD in the working tree, whether staged or not. path is relative to the
+// root of the working tree, wherever git is run from.
+func UncommittedChangedLines(ctx context.Context, path string) ([]LineRange, error) {
+	changed, err := diffRanges(ctx, "HEAD", "--", ":(top)"+path)
+	if err != nil {
+		return nil, err
+	}
+	var ranges []LineRange
+	for _, r := range changed {
+		ranges = append(ranges, r...)
+	}
+	return ranges, nil
+}
+
+func diffRanges(ctx context.Context, extra ...string) (map[string][]LineRange, error) {
+	args := append([]string{"diff", "--unified=0", "--no-color", "--no-ext-diff", "--src-prefix=a/", "--dst-prefix=b/", "--no-relative"}, extra...)
+	cmd := exec.CommandContext(ctx, "git", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git %s: %s", strings.Join(args, " "), msg)
+		}
+		return nil, fmt.Errorf("git %s: %w", strings.Join(args, " "), err)
+	}
+	return ParseChangedLines(out), nil
+}
+
+// ParseChangedLines extracts the new-side line ranges of every hunk in a
+// unified diff. Hunks that only delete lines are skipped.
+func ParseChangedLines(patch []byte) map[string][]LineRange {
+	changed := map[string][]LineRange{}
+	path := ""
+	scanner := bufio.NewScanner(bytes.NewReader(patch))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "+++ "):
+			path = strings.TrimPrefix(line, "+++ ")
+			if path == "/dev/null" {
+				path = ""
+			}
+			path = strings.TrimPrefix(path, "b/")
+		case strings.HasPrefix(line, "@@ ") && path != "":
+			if r, ok := parseHunkHeader(line); ok {
+				changed[path] = append(changed[path], r)
+			}
+		}
+	}
+	return changed
+}
+
+// DiffLines holds the line ranges of a file's hunks on each side of a diff.
+type DiffLines struct {
+	Left  []LineRange
+	Right []LineRange
+}
+
+// ParseDiffLines returns the line ranges each file's hunks cover, context
+// included, on the old (Left) and new (Right) side of a unified diff. These
+// are the lines a review comment can be left on. Files are keyed by the new
+// path, or the old one for deleted files.
+func ParseDiffLines(patch []byte) map[string]DiffLines {
+	files := map[string]DiffLines{}
+	oldPath, path := "", ""
+	scanner := bufio.NewScanner(bytes.NewReader(patch))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "diff "):
+			oldPath, path = "", ""
+		case strings.HasPrefix(line, "--- ") && path == "":
+			oldPath = strings.TrimPrefix(strings.TrimPrefix(line, "--- "), "a/")
+		case strings.HasPrefix(line, "+++ ") && path == "":
+			path = strings.TrimPrefix(line, "+++ ")
+			if path == "/dev/null" {
+				path = oldPath
+			}
+			path = strings.TrimPrefix(path, "b/")
+		case strings.HasPrefix(line, "@@ ") && path != "":
+			fields := strings.Fields(line)
+			if len(fields) < 3 {
+				continue
+			}
+			d := files[path]
+			if r, ok := parseHunkRange(fields[1], "-"); ok {
+				d.Left = append(d.Left, r)
+			}
+			if r, ok := parseHunkRange(fields[2], "+"); ok {
+				d.Right = append(d.Right, r)
+			}
+			files[path] = d
+		}
+	}
+	return files
+}
+
+// parseHunkHeader parses "@@ -a,b +c,d @@" into the new-side range c..c+d-1.
+func parseHunkHeader(line string) (LineRange, bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 3 {
+		return LineRange{}, false
+	}
+	return parseHunkRange(fields[2], "+")
+}
+
+// parseHunkRange parses one side of a hunk header, such as "+c,d", into the
+// range c..c+d-1. Empty ranges are skipped.
+func parseHunkRange(field, prefix string) (LineRange, bool) {
+	if !strings.HasPrefix(field, prefix) {
+		return LineRange{}, false
+	}
+	spec := strings.TrimPrefix(field, prefix)
+	start, count := spec, "1"
+	if i := strings.IndexByte(spec, ','); i >= 0 {
+		start, count = spec[:i], spec[i+1:]
+	}
+	s, err := strconv.Atoi(start)
+	if err != nil {
+		return LineRange{}, false
+	}
+	n, err := strconv.Atoi(count)
+	if err != nil || n == 0 {
+		return LineRange{}, false
+	}
+	return LineRange{Start: s, End: s + n - 1}, true
+}
+
+// TopLevel returns the root of the working tree containing the current
+// directory.
+func TopLevel(ctx context.Context) (string, error) {
+	out, err := exec.CommandContext(ctx, "git", "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return "", fmt.Errorf("not inside a git checkout: %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,201 @@
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseChangedLines(t *testing.T) {
+	patch := `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -10,0 +11,3 @@ func main() {
++	a
++	b
++	c
+@@ -40 +43 @@ func other() {
+-	old
++	new
+@@ -50,2 +52,0 @@ func gone() {
+-	x
+-	y
+diff --git a/removed.go b/removed.go
+deleted file mode 100644
+--- a/removed.go
++++ /dev/null
+@@ -1,2 +0,0 @@
+-package x
+-
+`
+	got := ParseChangedLines([]byte(patch))
+	want := map[string][]LineRange{
+		"main.go": {{Start: 11, End: 13}, {Start: 43, End: 43}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected ranges: %#v", got)
+	}
+}
+
+func TestLineRangeOverlaps(t *testing.T) {
+	r := LineRange{Start: 10, End: 12}
+	cases := []struct {
+		start, end int
+		want       bool
+	}{
+		{9, 9, false},
+		{9, 10, true},
+		{11, 11, true},
+		{12, 20, true},
+		{13, 13, false},
+	}
+	for _, tc := range cases {
+		if got := r.Overlaps(tc.start, tc.end); got != tc.want {
+			t.Errorf("Overlaps(%d, %d) = %v, want %v", tc.start, tc.end, got, tc.want)
+		}
+	}
+}
+
+func TestParseDiffLines(t *testing.T) {
+	patch := `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -8,6 +8,9 @@ func main() {
+ 	x
+ 	y
+ 	z
++	a
++	b
++	c
+ 	u
+ 	v
+ 	w
+@@ -40,3 +43,2 @@ func other() {
+ 	keep
+--- removed line that looks like a header
+ 	keep
+diff --git a/old.go b/new.go
+similarity index 90%
+rename from old.go
+rename to new.go
+--- a/old.go
++++ b/new.go
+@@ -1 +1 @@
+-package old
++package new
+diff --git a/removed.go b/removed.go
+deleted file mode 100644
+--- a/removed.go
++++ /dev/null
+@@ -1,2 +0,0 @@
+-package x
+-
+`
+	got := ParseDiffLines([]byte(patch))
+	want := map[string]DiffLines{
+		"main.go": {
+			Left:  []LineRange{{Start: 8, End: 13}, {Start: 40, End: 42}},
+			Right: []LineRange{{Start: 8, End: 16}, {Start: 43, End: 44}},
+		},
+		"new.go": {
+			Left:  []LineRange{{Start: 1, End: 1}},
+			Right: []LineRange{{Start: 1, End: 1}},
+		},
+		"removed.go": {
+			Left: []LineRange{{Start: 1, End: 2}},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected ranges: %#v", got)
+	}
+}
+
+// gitRepo makes a repository with one commit of files and changes into
+// dir inside it for the rest of the test.
+func gitRepo(t *testing.T, files map[string]string, dir string) string {
+	t.Helper()
+	root := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = root
+		cmd.Env = append(os.Environ(), "GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	run("init", "-q")
+	run("add", "-A")
+	run("-c", "user.name=t", "-c", "user.email=t@example.com", "commit", "-q", "-m", "init")
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(filepath.Join(root, dir)); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	return root
+}
+
+func TestUncommittedChangedLinesFromSubdirectory(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	root := gitRepo(t, map[string]string{
+		"pkg/a.go":  "one\ntwo\nthree\n",
+		"sub/b.txt": "x\n",
+	}, "sub")
+	if err := os.WriteFile(filepath.Join(root, "pkg/a.go"), []byte("one\nTWO\nthree\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := UncommittedChangedLines(context.Background(), "pkg/a.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []LineRange{{Start: 2, End: 2}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestChangedLinesIgnoresDiffConfig(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	root := gitRepo(t, map[string]string{
+		"b/a.go":    "one\ntwo\n",
+		"sub/c.txt": "x\n",
+	}, "sub")
+	for _, kv := range [][2]string{{"diff.mnemonicPrefix", "true"}, {"diff.noprefix", "true"}, {"diff.relative", "true"}} {
+		cmd := exec.Command("git", "config", kv[0], kv[1])
+		cmd.Dir = root
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git config: %v\n%s", err, out)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(root, "b/a.go"), []byte("one\nTWO\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ChangedLines(context.Background(), "HEAD", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string][]LineRange{"b/a.go": {{Start: 2, End: 2}}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -7,7 +7,10 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -24,7 +27,53 @@ type GraphQLRequest struct {
 }
 
 type graphQLError struct {
-	Message string `json:"message"`
+	Message string        `json:"message"`
+	Type    string        `json:"type"`
+	Path    []interface{} `json:"path"`
+}
+
+// Error is a GraphQL-level failure reported in the response's errors list.
+type Error struct {
+	Messages []string
+	Types    []string
+	// RetryAfter is how long the rate limit asks callers to wait when the
+	// errors include RATE_LIMITED.
+	RetryAfter time.Duration
+	errors     []graphQLError
+}
+
+func (e *Error) Error() string {
+	return "graphql error: " + strings.Join(e.Messages, "; ")
+}
+
+// For returns the errors reported against the top-level response field
+// (or alias) named field, or nil if there are none. It lets a request that
+// batches several aliased operations tell which of them failed.
+func (e *Error) For(field string) *Error {
+	if e == nil {
+		return nil
+	}
+	var sub *Error
+	for _, ge := range e.errors {
+		if len(ge.Path) == 0 || ge.Path[0] != field {
+			continue
+		}
+		if sub == nil {
+			sub = &Error{}
+		}
+		sub.add(ge)
+	}
+	return sub
+}
+
+func (e *Error) add(ge graphQLError) {
+	if ge.Message != "" {
+		e.Messages = append(e.Messages, ge.Message)
+	}
+	if ge.Type != "" {
+		e.Types = append(e.Types, ge.Type)
+	}
+	e.errors = append(e.errors, ge)
 }
 
 type graphQLResponse struct {
@@ -32,27 +81,40 @@ type graphQLResponse struct {
 	Errors []graphQLError  `json:"errors"`
 }
 
+// Timeout bounds each API request made by clients created afterwards.
+var Timeout = 20 * time.Second
+
 func NewClient(endpoint, token string) *Client {
 	return &Client{
 		endpoint: endpoint,
 		token:    token,
 		httpClient: &http.Client{
-			Timeout: 20 * time.Second,
+			Timeout: Timeout,
 		},
 	}
 }
 
+// Do runs a GraphQL query or mutation and decodes its data into out. When
+// the response reports errors, Do returns them as an *Error; any data that
+// came back alongside them is still decoded into out, so callers batching
+// aliased operations can use the parts that succeeded.
 func (c *Client) Do(ctx context.Context, query string, variables map[string]interface{}, out interface{}) error {
+	_, err := c.do(ctx, query, variables, out)
+	return err
+}
+
+// do is Do, also returning the response headers when a response arrived.
+func (c *Client) do(ctx context.Context, query string, variables map[string]interface{}, out interface{}) (http.Header, error) {
 	if c == nil {
-		return errors.New("nil github client")
+		return nil, errors.New("nil github client")
 	}
 	payload, err := json.Marshal(GraphQLRequest{Query: query, Variables: variables})
 	if err != nil {
-		return err
+		return nil, err
 	}
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(payload))
 	if err != nil {
-		return err
+		return nil, err
 	}
 	req.Header.Set("Authorization", "bearer "+c.token)
 	req.Header.Set("Content-Type", "application/json")
@@ -60,38 +122,260 @@ func (c *Client) Do(ctx context.Context, query string, variables map[string]inte
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return err
+		return resp.Header, err
 	}
 	if resp.StatusCode < 200 || resp.StatusCode > 299 {
-		return fmt.Errorf("github api error: status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+		statusErr := &StatusError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(body))}
+		if resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests {
+			statusErr.RetryAfter, statusErr.rateLimited = rateLimitWait(resp.Header, time.Now())
+		}
+		return resp.Header, statusErr
 	}
 
 	var gr graphQLResponse
 	if err := json.Unmarshal(body, &gr); err != nil {
-		return err
+		return resp.Header, err
 	}
 	if len(gr.Errors) > 0 {
-		msgs := make([]string, 0, len(gr.Errors))
+		gqlErr := &Error{}
 		for _, ge := range gr.Errors {
-			if ge.Message != "" {
-				msgs = append(msgs, ge.Message)
+			gqlErr.add(ge)
+		}
+		for _, t := range gqlErr.Types {
+			if t == "RATE_LIMITED" {
+				gqlErr.RetryAfter, _ = rateLimitWait(resp.Header, time.Now())
 			}
 		}
-		return fmt.Errorf("graphql error: %s", strings.Join(msgs, "; "))
+		if out != nil && len(gr.Data) > 0 && string(gr.Data) != "null" {
+			_ = json.Unmarshal(gr.Data, out)
+		}
+		return resp.Header, gqlErr
 	}
 	if out == nil {
-		return nil
+		return resp.Header, nil
 	}
 	if len(gr.Data) == 0 {
-		return errors.New("graphql response missing data")
+		return resp.Header, errors.New("graphql response missing data")
+	}
+	return resp.Header, json.Unmarshal(gr.Data, out)
+}
+
+var undefinedFieldPattern = regexp.MustCompile(`Field '([A-Za-z0-9_]+)' doesn't exist on type`)
+
+// UndefinedField reports the field named by a GraphQL "field doesn't exist"
+// validation error, which older GitHub Enterprise servers return for
+// selections newer than their schema.
+func UndefinedField(err error) (string, bool) {
+	if err == nil {
+		return "", false
+	}
+	m := undefinedFieldPattern.FindStringSubmatch(err.Error())
+	if m == nil {
+		return "", false
+	}
+	return m[1], true
+}
+
+// StatusError is a non-2xx HTTP response from the API.
+type StatusError struct {
+	StatusCode int
+	Body       string
+	// RetryAfter is how long to wait before retrying a rate-limited request.
+	RetryAfter  time.Duration
+	rateLimited bool
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("github api error: status %d: %s", e.StatusCode, e.Body)
+}
+
+// Transient reports whether a request failed in a way that may succeed when
+// retried: a timeout or a 5xx response. The request may still have taken
+// effect on the server.
+func Transient(err error) bool {
+	if err == nil {
+		return false
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= 500
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// rateLimitWait reads how long the API wants callers to back off from a
+// response's Retry-After header (secondary limits) or, once the primary
+// limit is spent, the time until X-RateLimit-Reset.
+func rateLimitWait(h http.Header, now time.Time) (time.Duration, bool) {
+	if v := h.Get("Retry-After"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+			return time.Duration(secs) * time.Second, true
+		}
+	}
+	if h.Get("X-RateLimit-Remaining") != "0" {
+		return 0, false
+	}
+	reset, err := strconv.ParseInt(h.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return 0, true
+	}
+	wait := time.Unix(reset, 0).Sub(now)
+	if wait < 0 {
+		wait = 0
+	}
+	return wait, true
+}
+
+// RateLimited reports whether a request was refused by the rate limit and,
+// if so, how long the API asked to wait (zero when it didn't say).
+func RateLimited(err error) (time.Duration, bool) {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.RetryAfter, statusErr.rateLimited
+	}
+	var gqlErr *Error
+	if !errors.As(err, &gqlErr) {
+		return 0, false
+	}
+	for _, t := range gqlErr.Types {
+		if t == "RATE_LIMITED" {
+			return gqlErr.RetryAfter, true
+		}
+	}
+	return 0, false
+}
+
+// NotFound reports whether a GraphQL request failed because an ID didn't
+// resolve to an object, as happens for mistyped node IDs.
+func NotFound(err error) bool {
+	var gqlErr *Error
+	if !errors.As(err, &gqlErr) {
+		return false
+	}
+	for _, t := range gqlErr.Types {
+		if t == "NOT_FOUND" {
+			return true
+		}
+	}
+	for _, m := range gqlErr.Messages {
+		if strings.Contains(m, "Could not resolve to a node") {
+			return true
+		}
+	}
+	return false
+}
+
+// Forbidden reports whether a request was refused for lack of access, either
+// as a GraphQL FORBIDDEN error or an HTTP 403.
+func Forbidden(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == 403
+	}
+	var gqlErr *Error
+	if !errors.As(err, &gqlErr) {
+		return false
+	}
+	for _, t := range gqlErr.Types {
+		if t == "FORBIDDEN" {
+			return true
+		}
+	}
+	return false
+}
+
+// Endpoint returns the GraphQL endpoint the client talks to.
+func (c *Client) Endpoint() string {
+	return c.endpoint
+}
+
+// ServerVersion returns the GitHub Enterprise version reported by the meta
+// endpoint, or "" for github.com.
+func (c *Client) ServerVersion(ctx context.Context) (string, error) {
+	if !strings.HasSuffix(c.endpoint, "/api/graphql") {
+		return "", nil
+	}
+	metaURL := strings.TrimSuffix(c.endpoint, "/graphql") + "/v3/meta"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, metaURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "bearer "+c.token)
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", fmt.Errorf("github api error: status %d", resp.StatusCode)
+	}
+	var meta struct {
+		InstalledVersion string `json:"installed_version"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&meta); err != nil {
+		return "", err
+	}
+	return meta.InstalledVersion, nil
+}
+
+// Viewer is the account a token belongs to.
+type Viewer struct {
+	Login string
+	// Scopes are the token's OAuth scopes. ScopesReported is false for
+	// fine-grained and GitHub App tokens, which have per-repository
+	// permissions instead.
+	Scopes         []string
+	ScopesReported bool
+}
+
+// Viewer looks up the token's account and the scopes GitHub reports for it
+// in the X-OAuth-Scopes header.
+func (c *Client) Viewer(ctx context.Context) (Viewer, error) {
+	var resp struct {
+		Viewer struct {
+			Login string `json:"login"`
+		} `json:"viewer"`
+	}
+	header, err := c.do(ctx, `query { viewer { login } }`, nil, &resp)
+	if err != nil {
+		return Viewer{}, err
+	}
+	v := Viewer{Login: resp.Viewer.Login}
+	if values, ok := header["X-Oauth-Scopes"]; ok {
+		v.ScopesReported = true
+		for _, s := range strings.Split(strings.Join(values, ","), ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				v.Scopes = append(v.Scopes, s)
+			}
+		}
+	}
+	return v, nil
+}
+
+// Reachable reports whether the endpoint answers HTTP at all; any response,
+// even 401 to the unauthenticated request, counts.
+func (c *Client) Reachable(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.endpoint, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
 	}
-	return json.Unmarshal(gr.Data, out)
+	resp.Body.Close()
+	return nil
 }
 
 func GraphQLEndpoint(host string) string {
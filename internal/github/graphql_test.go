@@ -0,0 +1,220 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestUndefinedField(t *testing.T) {
+	err := errors.New("graphql error: Field 'fullDatabaseId' doesn't exist on type 'PullRequestReviewComment'")
+	field, ok := UndefinedField(err)
+	if !ok || field != "fullDatabaseId" {
+		t.Fatalf("expected fullDatabaseId, got %q (ok=%v)", field, ok)
+	}
+	if _, ok := UndefinedField(errors.New("graphql error: Could not resolve to a node")); ok {
+		t.Fatal("expected no match for unrelated error")
+	}
+	if _, ok := UndefinedField(nil); ok {
+		t.Fatal("expected no match for nil error")
+	}
+}
+
+func TestServerVersion(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v3/meta" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"installed_version":"3.8.2"}`))
+	}))
+	defer srv.Close()
+
+	client := NewClient(srv.URL+"/api/graphql", "token")
+	version, err := client.ServerVersion(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if version != "3.8.2" {
+		t.Fatalf("expected 3.8.2, got %q", version)
+	}
+
+	dotcom := NewClient(GraphQLEndpoint("github.com"), "token")
+	version, err = dotcom.ServerVersion(context.Background())
+	if err != nil || version != "" {
+		t.Fatalf("expected empty version for github.com, got %q (%v)", version, err)
+	}
+}
+
+func TestNotFound(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"node":null},"errors":[{"type":"NOT_FOUND","message":"Could not resolve to a node with the global id of 'PRRT_typo'"}]}`))
+	}))
+	defer srv.Close()
+
+	err := NewClient(srv.URL, "token").Do(context.Background(), "query { node(id:\"PRRT_typo\") { id } }", nil, nil)
+	if !NotFound(err) {
+		t.Fatalf("expected a not-found error, got %v", err)
+	}
+	if err.Error() != "graphql error: Could not resolve to a node with the global id of 'PRRT_typo'" {
+		t.Fatalf("unexpected message %q", err.Error())
+	}
+	if NotFound(errors.New("github api error: status 502")) {
+		t.Fatal("expected transport errors not to count as not found")
+	}
+}
+
+func TestTransient(t *testing.T) {
+	status := http.StatusBadGateway
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte("oops"))
+	}))
+	defer srv.Close()
+	client := NewClient(srv.URL, "token")
+
+	err := client.Do(context.Background(), "query { viewer { login } }", nil, nil)
+	if !Transient(err) || err.Error() != "github api error: status 502: oops" {
+		t.Fatalf("expected transient 502, got %v", err)
+	}
+	status = http.StatusUnauthorized
+	if err := client.Do(context.Background(), "query { viewer { login } }", nil, nil); Transient(err) {
+		t.Fatalf("expected 401 not to be transient, got %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	if err := client.Do(ctx, "query { viewer { login } }", nil, nil); !Transient(err) {
+		t.Fatalf("expected deadline to be transient, got %v", err)
+	}
+	if Transient(errors.New("graphql error: something")) {
+		t.Fatal("expected GraphQL errors not to be transient")
+	}
+}
+
+func TestErrorForAlias(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"r0":{"id":"a"},"r1":null},"errors":[{"type":"NOT_FOUND","path":["r1"],"message":"Could not resolve to a node with the global id of 'b'"}]}`))
+	}))
+	defer srv.Close()
+
+	var out map[string]*struct {
+		ID string `json:"id"`
+	}
+	err := NewClient(srv.URL, "token").Do(context.Background(), "mutation { r0: a r1: b }", nil, &out)
+	var gqlErr *Error
+	if !errors.As(err, &gqlErr) {
+		t.Fatalf("expected a GraphQL error, got %v", err)
+	}
+	if out["r0"] == nil || out["r0"].ID != "a" {
+		t.Fatalf("expected partial data to be decoded, got %+v", out)
+	}
+	if gqlErr.For("r0") != nil {
+		t.Fatal("expected no error for r0")
+	}
+	if r1 := gqlErr.For("r1"); r1 == nil || !NotFound(r1) {
+		t.Fatalf("expected a not-found error for r1, got %v", r1)
+	}
+	if (*Error)(nil).For("r0") != nil {
+		t.Fatal("expected For on a nil error to return nil")
+	}
+}
+
+func TestForbidden(t *testing.T) {
+	if !Forbidden(&Error{Types: []string{"FORBIDDEN"}}) {
+		t.Fatal("expected FORBIDDEN to count as forbidden")
+	}
+	if !Forbidden(&StatusError{StatusCode: 403}) {
+		t.Fatal("expected HTTP 403 to count as forbidden")
+	}
+	if Forbidden(&Error{Types: []string{"NOT_FOUND"}}) || Forbidden(&StatusError{StatusCode: 500}) {
+		t.Fatal("expected other errors not to count as forbidden")
+	}
+}
+
+func TestRateLimited(t *testing.T) {
+	var header http.Header
+	status := http.StatusForbidden
+	body := "rate limited"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for k, v := range header {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+	client := NewClient(srv.URL, "token")
+	query := "query { viewer { login } }"
+
+	header = http.Header{"Retry-After": {"42"}}
+	wait, ok := RateLimited(client.Do(context.Background(), query, nil, nil))
+	if !ok || wait != 42*time.Second {
+		t.Fatalf("Retry-After: got %v (ok=%v), want 42s", wait, ok)
+	}
+
+	reset := time.Now().Add(90 * time.Second).Unix()
+	header = http.Header{"X-Ratelimit-Remaining": {"0"}, "X-Ratelimit-Reset": {strconv.FormatInt(reset, 10)}}
+	wait, ok = RateLimited(client.Do(context.Background(), query, nil, nil))
+	if !ok || wait < 80*time.Second || wait > 90*time.Second {
+		t.Fatalf("X-RateLimit-Reset: got %v (ok=%v), want about 90s", wait, ok)
+	}
+
+	header = nil
+	if _, ok := RateLimited(client.Do(context.Background(), query, nil, nil)); ok {
+		t.Fatal("expected a plain 403 not to count as rate limited")
+	}
+
+	status = http.StatusOK
+	body = `{"data":null,"errors":[{"type":"RATE_LIMITED","message":"API rate limit exceeded"}]}`
+	header = http.Header{"X-Ratelimit-Remaining": {"0"}, "X-Ratelimit-Reset": {strconv.FormatInt(reset, 10)}}
+	wait, ok = RateLimited(client.Do(context.Background(), query, nil, nil))
+	if !ok || wait < 80*time.Second {
+		t.Fatalf("RATE_LIMITED: got %v (ok=%v), want about 90s", wait, ok)
+	}
+}
+
+func TestViewer(t *testing.T) {
+	scopes := "repo, read:org"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if scopes != "-" {
+			w.Header().Set("X-OAuth-Scopes", scopes)
+		}
+		w.Write([]byte(`{"data":{"viewer":{"login":"octocat"}}}`))
+	}))
+	defer srv.Close()
+	client := NewClient(srv.URL, "token")
+
+	v, err := client.Viewer(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Login != "octocat" || !v.ScopesReported || len(v.Scopes) != 2 || v.Scopes[0] != "repo" || v.Scopes[1] != "read:org" {
+		t.Errorf("Viewer = %+v", v)
+	}
+
+	scopes = "-"
+	if v, err = client.Viewer(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if v.ScopesReported || v.Scopes != nil {
+		t.Errorf("without the header, Viewer = %+v", v)
+	}
+}
+
+func TestReachable(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	client := NewClient(srv.URL, "")
+	if err := client.Reachable(context.Background()); err != nil {
+		t.Errorf("a 401 should count as reachable: %v", err)
+	}
+	srv.Close()
+	if err := client.Reachable(context.Background()); err == nil {
+		t.Error("expected an error once the server is gone")
+	}
+}
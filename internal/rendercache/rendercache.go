@@ -0,0 +1,130 @@
+// Package rendercache stores rendered markdown on disk so repeated runs over
+// the same comments skip the renderer.
+package rendercache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Cache is a content-addressable directory of rendered output. Entries are
+// evicted least-recently-used first when the directory exceeds maxBytes.
+type Cache struct {
+	dir      string
+	maxBytes int64
+}
+
+// Open prepares the cache directory and trims it to maxBytes, so the cap is
+// enforced once per run rather than on every write.
+func Open(dir string, maxBytes int64) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	c := &Cache{dir: dir, maxBytes: maxBytes}
+	if err := c.Prune(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Key derives the entry name from everything that affects rendered output.
+func Key(body string, width int, theme, version string) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%d\x00%s\x00", version, width, theme)
+	h.Write([]byte(body))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, key[:2], key)
+}
+
+// Get returns the cached value and marks the entry as recently used.
+func (c *Cache) Get(key string) (string, bool) {
+	if c == nil || len(key) < 2 {
+		return "", false
+	}
+	path := c.path(key)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+	return string(data), true
+}
+
+// Put stores a value. Writes go through a temp file so concurrent runs never
+// observe partial entries.
+func (c *Cache) Put(key, value string) error {
+	if c == nil || len(key) < 2 {
+		return nil
+	}
+	path := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.WriteString(value); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Prune removes the least recently used entries until the cache fits in
+// maxBytes. A non-positive cap disables eviction.
+func (c *Cache) Prune() error {
+	if c == nil || c.maxBytes <= 0 {
+		return nil
+	}
+	type entry struct {
+		path string
+		size int64
+		used time.Time
+	}
+	var entries []entry
+	var total int64
+	err := filepath.WalkDir(c.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		entries = append(entries, entry{path: path, size: info.Size(), used: info.ModTime()})
+		total += info.Size()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if total <= c.maxBytes {
+		return nil
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].used.Before(entries[j].used) })
+	for _, e := range entries {
+		if total <= c.maxBytes {
+			break
+		}
+		if err := os.Remove(e.path); err == nil {
+			total -= e.size
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package rendercache
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetPut(t *testing.T) {
+	c, err := Open(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	key := Key("**hello**", 80, "dark", "v1")
+	if _, ok := c.Get(key); ok {
+		t.Fatal("expected miss on empty cache")
+	}
+	if err := c.Put(key, "rendered"); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	got, ok := c.Get(key)
+	if !ok || got != "rendered" {
+		t.Fatalf("expected hit, got %q (ok=%v)", got, ok)
+	}
+}
+
+func TestKeyVariesWithInputs(t *testing.T) {
+	base := Key("body", 80, "dark", "v1")
+	for _, other := range []string{
+		Key("body!", 80, "dark", "v1"),
+		Key("body", 81, "dark", "v1"),
+		Key("body", 80, "light", "v1"),
+		Key("body", 80, "dark", "v2"),
+	} {
+		if other == base {
+			t.Fatal("expected distinct keys for distinct inputs")
+		}
+	}
+}
+
+func TestPruneEvictsLeastRecentlyUsed(t *testing.T) {
+	dir := t.TempDir()
+	c, err := Open(dir, 0)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	value := strings.Repeat("x", 100)
+	keys := []string{Key("a", 1, "", ""), Key("b", 1, "", ""), Key("c", 1, "", "")}
+	old := time.Now().Add(-time.Hour)
+	for i, k := range keys {
+		if err := c.Put(k, value); err != nil {
+			t.Fatalf("put: %v", err)
+		}
+		stamp := old.Add(time.Duration(i) * time.Minute)
+		if err := os.Chtimes(c.path(k), stamp, stamp); err != nil {
+			t.Fatalf("chtimes: %v", err)
+		}
+	}
+	// Touch the oldest entry so the middle one becomes least recently used.
+	if _, ok := c.Get(keys[0]); !ok {
+		t.Fatal("expected hit")
+	}
+
+	c, err = Open(dir, 200)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	if _, ok := c.Get(keys[1]); ok {
+		t.Fatal("expected least recently used entry to be evicted")
+	}
+	for _, k := range []string{keys[0], keys[2]} {
+		if _, ok := c.Get(k); !ok {
+			t.Fatalf("expected %s to survive pruning", k)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+// Package suggest extracts GitHub suggestion blocks from review comments and
+// maps them onto local files.
+package suggest
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Parse returns the contents of every ```suggestion fence in a comment body,
+// in order. An empty suggestion (deleting the lines) is returned as "".
+func Parse(body string) []string {
+	var out []string
+	lines := strings.Split(strings.ReplaceAll(body, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		fence, ok := openingFence(lines[i])
+		if !ok {
+			continue
+		}
+		var content []string
+		closed := false
+		for i++; i < len(lines); i++ {
+			if isClosingFence(lines[i], fence) {
+				closed = true
+				break
+			}
+			content = append(content, lines[i])
+		}
+		if closed {
+			out = append(out, strings.Join(content, "\n"))
+		}
+	}
+	return out
+}
+
+// openingFence reports whether line opens a suggestion block, returning the
+// fence marker (``` or longer) that closes it.
+func openingFence(line string) (string, bool) {
+	trimmed := strings.TrimSpace(line)
+	n := 0
+	for n < len(trimmed) && trimmed[n] == '`' {
+		n++
+	}
+	if n < 3 {
+		return "", false
+	}
+	info := strings.TrimSpace(trimmed[n:])
+	if info != "suggestion" && !strings.HasPrefix(info, "suggestion ") {
+		return "", false
+	}
+	return trimmed[:n], true
+}
+
+func isClosingFence(line, fence string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, "`") == ""
+}
+
+// Lines splits suggestion text into lines; an empty suggestion has none.
+func Lines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+// OriginalLines returns the last count lines on the new side of a diff hunk,
+// which are the lines a review comment (and its suggestion) targets.
+func OriginalLines(diffHunk string, count int) ([]string, bool) {
+	if count <= 0 {
+		return nil, false
+	}
+	var newSide []string
+	for _, line := range strings.Split(strings.TrimRight(diffHunk, "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "@@"):
+			newSide = newSide[:0]
+		case strings.HasPrefix(line, "+"), strings.HasPrefix(line, " "):
+			newSide = append(newSide, line[1:])
+		case line == "":
+			newSide = append(newSide, "")
+		}
+	}
+	if len(newSide) < count {
+		return nil, false
+	}
+	return append([]string{}, newSide[len(newSide)-count:]...), true
+}
+
+// Locate finds where want appears in file, returning the 1-based start line.
+// The hinted line is preferred; otherwise a single unambiguous match anywhere
+// in the file is accepted (the code moved), and multiple matches are not.
+func Locate(file, want []string, hint int) (int, bool) {
+	if len(want) == 0 {
+		return 0, false
+	}
+	if hint >= 1 && matchesAt(file, want, hint-1) {
+		return hint, true
+	}
+	found := 0
+	for i := 0; i+len(want) <= len(file); i++ {
+		if matchesAt(file, want, i) {
+			if found != 0 {
+				return 0, false
+			}
+			found = i + 1
+		}
+	}
+	return found, found != 0
+}
+
+func matchesAt(file, want []string, idx int) bool {
+	if idx < 0 || idx+len(want) > len(file) {
+		return false
+	}
+	for i, line := range want {
+		if file[idx+i] != line {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply replaces count lines starting at the 1-based line start.
+func Apply(file []string, start, count int, replacement []string) []string {
+	out := make([]string, 0, len(file)-count+len(replacement))
+	out = append(out, file[:start-1]...)
+	out = append(out, replacement...)
+	out = append(out, file[start-1+count:]...)
+	return out
+}
+
+// UnifiedDiff renders replacing count lines at start with replacement as a
+// git-style unified diff with three lines of context.
+func UnifiedDiff(path string, file []string, start, count int, replacement []string) string {
+	const context = 3
+	from := start - 1 - context
+	if from < 0 {
+		from = 0
+	}
+	to := start - 1 + count + context
+	if to > len(file) {
+		to = len(file)
+	}
+	before := file[from : start-1]
+	after := file[start-1+count : to]
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- a/%s\n+++ b/%s\n", path, path)
+	oldLen := len(before) + count + len(after)
+	newLen := len(before) + len(replacement) + len(after)
+	fmt.Fprintf(&b, "@@ -%s +%s @@\n", hunkRange(from+1, oldLen), hunkRange(from+1, newLen))
+	for _, line := range before {
+		b.WriteString(" " + line + "\n")
+	}
+	for _, line := range file[start-1 : start-1+count] {
+		b.WriteString("-" + line + "\n")
+	}
+	for _, line := range replacement {
+		b.WriteString("+" + line + "\n")
+	}
+	for _, line := range after {
+		b.WriteString(" " + line + "\n")
+	}
+	return b.String()
+}
+
+func hunkRange(start, length int) string {
+	if length == 0 {
+		return fmt.Sprintf("%d,0", start-1)
+	}
+	if length == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, length)
+}
@@ -0,0 +1,68 @@
+package suggest
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	body := "Consider this:\n\n```suggestion\nreturn nil\n```\n\nand also\n\n````suggestion\nfoo()\n```go\nnested\n```\n````\n\n```go\nnot a suggestion\n```\n\n```suggestion\n```\n\n```suggestion\nunterminated"
+	got := Parse(body)
+	want := []string{"return nil", "foo()\n```go\nnested\n```", ""}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestOriginalLines(t *testing.T) {
+	hunk := "@@ -10,5 +10,6 @@ func main() {\n \ta := 1\n-\tb := 2\n+\tb := 3\n+\tc := 4\n \treturn a"
+	got, ok := OriginalLines(hunk, 2)
+	if !ok || !reflect.DeepEqual(got, []string{"\tc := 4", "\treturn a"}) {
+		t.Fatalf("unexpected lines %q (ok=%v)", got, ok)
+	}
+	if _, ok := OriginalLines(hunk, 10); ok {
+		t.Fatal("expected failure when the hunk is shorter than the range")
+	}
+}
+
+func TestLocate(t *testing.T) {
+	file := []string{"a", "b", "c", "b", "c", "d"}
+	if start, ok := Locate(file, []string{"b", "c"}, 4); !ok || start != 4 {
+		t.Fatalf("expected hinted match at 4, got %d (ok=%v)", start, ok)
+	}
+	if _, ok := Locate(file, []string{"b", "c"}, 1); ok {
+		t.Fatal("expected ambiguous match to fail")
+	}
+	if start, ok := Locate(file, []string{"c", "d"}, 1); !ok || start != 5 {
+		t.Fatalf("expected moved match at 5, got %d (ok=%v)", start, ok)
+	}
+	if _, ok := Locate(file, []string{"x"}, 1); ok {
+		t.Fatal("expected missing lines to fail")
+	}
+}
+
+func TestApplyAndUnifiedDiff(t *testing.T) {
+	file := []string{"one", "two", "three", "four", "five", "six"}
+	got := Apply(file, 3, 2, []string{"THREE"})
+	if !reflect.DeepEqual(got, []string{"one", "two", "THREE", "five", "six"}) {
+		t.Fatalf("unexpected apply result %q", got)
+	}
+	diff := UnifiedDiff("x.txt", file, 3, 2, []string{"THREE"})
+	want := strings.Join([]string{
+		"--- a/x.txt",
+		"+++ b/x.txt",
+		"@@ -1,6 +1,5 @@",
+		" one",
+		" two",
+		"-three",
+		"-four",
+		"+THREE",
+		" five",
+		" six",
+		"",
+	}, "\n")
+	if diff != want {
+		t.Fatalf("got diff:\n%s\nwant:\n%s", diff, want)
+	}
+}
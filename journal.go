@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Journal entry results.
+const (
+	journalDone    = "done"
+	journalFailed  = "failed"
+	journalSkipped = "skipped"
+)
+
+// journal records the progress of a bulk command item by item, so a run
+// that dies halfway can be audited and resumed without repeating what
+// already went through. It is rewritten after every entry. A nil journal
+// records nothing, which is what dry runs and single threads use.
+type journal struct {
+	path string
+	keep bool
+	// saveErr is the first failure to write the journal; later entries
+	// aren't saved either, and finish says so.
+	saveErr error
+
+	Command string         `json:"command"`
+	Started time.Time      `json:"started"`
+	Entries []journalEntry `json:"entries"`
+}
+
+// journalEntry is the outcome of one action on one thread.
+type journalEntry struct {
+	ThreadID string    `json:"threadId"`
+	Action   string    `json:"action"`
+	Result   string    `json:"result"`
+	Error    string    `json:"error,omitempty"`
+	At       time.Time `json:"at"`
+}
+
+// openJournal starts a journal for command under the cache directory, or
+// with resume set carries on with that one.
+func openJournal(command, resume string, keep bool) (*journal, error) {
+	if resume != "" {
+		data, err := os.ReadFile(resume)
+		if err != nil {
+			return nil, fmt.Errorf("reading journal: %w", err)
+		}
+		j := &journal{path: resume, keep: keep}
+		if err := json.Unmarshal(data, j); err != nil {
+			return nil, fmt.Errorf("reading journal %s: %w", resume, err)
+		}
+		if j.Command != command {
+			return nil, fmt.Errorf("journal %s is from `%s`, not `%s`", resume, j.Command, command)
+		}
+		return j, nil
+	}
+	dir, err := cacheDir()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	path := filepath.Join(dir, "journals", fmt.Sprintf("%s-%s.json", command, now.Format("20060102-150405.000")))
+	return &journal{path: path, keep: keep, Command: command, Started: now}, nil
+}
+
+// done reports whether action already went through on the thread, in this
+// run or the one being resumed.
+func (j *journal) done(threadID, action string) bool {
+	if j == nil {
+		return false
+	}
+	for i := len(j.Entries) - 1; i >= 0; i-- {
+		if e := j.Entries[i]; e.ThreadID == threadID && e.Action == action {
+			return e.Result == journalDone
+		}
+	}
+	return false
+}
+
+// record adds an entry: done when err is nil, otherwise result with the
+// error.
+func (j *journal) record(threadID, action, result string, err error) {
+	if j == nil {
+		return
+	}
+	entry := journalEntry{ThreadID: threadID, Action: action, Result: journalDone, At: time.Now().UTC()}
+	if err != nil {
+		entry.Result, entry.Error = result, err.Error()
+	}
+	j.Entries = append(j.Entries, entry)
+	if j.saveErr == nil {
+		j.saveErr = j.save()
+	}
+}
+
+func (j *journal) save() error {
+	data, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(j.path), 0o755); err != nil {
+		return err
+	}
+	tmp := j.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, j.path)
+}
+
+// finish removes the journal after a clean run unless it is to be kept,
+// and otherwise says where it is and how to carry on.
+func (j *journal) finish(clean bool) {
+	if j == nil || len(j.Entries) == 0 {
+		return
+	}
+	if j.saveErr != nil {
+		fmt.Fprintf(os.Stderr, "warning: progress journal not saved: %v\n", j.saveErr)
+		return
+	}
+	switch {
+	case clean && !j.keep:
+		if err := os.Remove(j.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		}
+	case clean:
+		fmt.Fprintf(os.Stderr, "journal: %s\n", j.path)
+	default:
+		fmt.Fprintf(os.Stderr, "journal: %s (rerun with --resume %s to skip what went through)\n", j.path, j.path)
+	}
+}
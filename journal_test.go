@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestJournal(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	j, err := openJournal("resolve", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	j.record("A", "resolve", "", nil)
+	j.record("B", "comment", journalFailed, errors.New("rate limited"))
+	j.record("C", "resolve", journalFailed, errors.New("timeout"))
+	j.record("C", "resolve", "", nil)
+	for _, tc := range []struct {
+		id, action string
+		want       bool
+	}{
+		{"A", "resolve", true},
+		{"A", "comment", false},
+		{"B", "comment", false},
+		{"C", "resolve", true},
+	} {
+		if got := j.done(tc.id, tc.action); got != tc.want {
+			t.Errorf("done(%s, %s) = %v, want %v", tc.id, tc.action, got, tc.want)
+		}
+	}
+
+	// A failed run leaves the journal to resume from.
+	j.finish(false)
+	resumed, err := openJournal("resolve", j.path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resumed.Entries) != 4 || resumed.Entries[1].Error != "rate limited" || !resumed.done("A", "resolve") {
+		t.Errorf("resumed entries: %+v", resumed.Entries)
+	}
+	if _, err := openJournal("apply", j.path, false); err == nil || !strings.Contains(err.Error(), "is from `resolve`") {
+		t.Errorf("resuming another command's journal: %v", err)
+	}
+
+	// A clean run removes it, unless it is to be kept.
+	resumed.record("B", "comment", "", nil)
+	resumed.keep = true
+	resumed.finish(true)
+	if _, err := os.Stat(j.path); err != nil {
+		t.Errorf("kept journal: %v", err)
+	}
+	resumed.keep = false
+	resumed.finish(true)
+	if _, err := os.Stat(j.path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("journal left after a clean run: %v", err)
+	}
+
+	var none *journal
+	none.record("A", "resolve", "", nil)
+	if none.done("A", "resolve") {
+		t.Error("a nil journal recorded something")
+	}
+	none.finish(true)
+}
+
+func TestResolveTargetsResume(t *testing.T) {
+	ctx := context.Background()
+	client, mutations := fakeThreadServer(t, map[string]bool{})
+	j, err := openJournal("resolve", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	j.record("A", "resolve", "", nil)
+	j.record("B", "resolve", journalFailed, errors.New("timeout"))
+
+	var targets []threadTarget
+	for _, id := range []string{"A", "B", "C"} {
+		targets = append(targets, loadResolveTarget(ctx, client, id, true, false))
+	}
+	if err := resolveTargets(ctx, client, targets, resolveOptions{resolve: true, all: true, batch: true, journal: j}); err != nil {
+		t.Fatal(err)
+	}
+	if len(*mutations) != 1 || strings.Contains((*mutations)[0], "r2:") || !strings.Contains((*mutations)[0], "r1:") {
+		t.Fatalf("expected one batched mutation for B and C only, got %q", *mutations)
+	}
+	if !j.done("B", "resolve") || !j.done("C", "resolve") {
+		t.Errorf("entries: %+v", j.Entries)
+	}
+	if _, err := os.Stat(j.path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("journal left after a clean run: %v", err)
+	}
+}
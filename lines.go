@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gh-pr-review/internal/gh"
+	"gh-pr-review/internal/git"
+)
+
+type linesFlags struct {
+	repo     string
+	pr       int
+	filePath string
+	allFiles bool
+	status   string
+	host     string
+}
+
+func (o *linesFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.repo, "repo", "", "owner/name (defaults to gh repo view)")
+	fs.IntVar(&o.pr, "pr", 0, "PR number (defaults to current branch PR if available)")
+	fs.StringVar(&o.filePath, "path", "", "file to list threads for (a glob, or a directory for everything below it)")
+	fs.BoolVar(&o.allFiles, "all-files", false, "list threads on every file")
+	fs.StringVar(&o.status, "status", "all", strings.Join(threadStatuses, "|"))
+	fs.StringVar(&o.host, "host", gh.DefaultHost(), "GitHub host")
+}
+
+func runLines(args []string) error {
+	fs := newFlagSet("lines", printLinesUsage)
+	var flags linesFlags
+	flags.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+	if (flags.filePath == "") == !flags.allFiles {
+		return errors.New("provide exactly one of --path or --all-files")
+	}
+	flags.status = strings.ToLower(strings.TrimSpace(flags.status))
+	if flags.status != "all" && flags.status != "resolved" && flags.status != "unresolved" && flags.status != "resolved-no-reply" {
+		return fmt.Errorf("invalid --status %q", flags.status)
+	}
+
+	ctx := context.Background()
+	var err error
+	flags.pr, err = resolvePR(ctx, flags.pr)
+	if err != nil {
+		return err
+	}
+	owner, name, err := resolveRepo(ctx, flags.repo)
+	if err != nil {
+		return err
+	}
+	client, err := newClient(ctx, flags.host)
+	if err != nil {
+		return err
+	}
+	threads, err := fetchAllThreads(ctx, client, owner, name, flags.pr)
+	if err != nil {
+		return err
+	}
+	threads = filterThreads(threads, flags.status)
+	if flags.filePath != "" {
+		threads = filterByPath(threads, filepath.ToSlash(flags.filePath))
+	}
+	writeThreadLines(os.Stdout, threads, localPathFunc(ctx))
+	return nil
+}
+
+// localPathFunc maps a repository path to one relative to the working
+// directory, so editors started here can open it. Outside a checkout paths
+// are left as they are.
+func localPathFunc(ctx context.Context) func(string) string {
+	root, err := git.TopLevel(ctx)
+	if err != nil {
+		return func(p string) string { return p }
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return func(p string) string { return p }
+	}
+	return func(p string) string {
+		rel, err := filepath.Rel(cwd, filepath.Join(root, filepath.FromSlash(p)))
+		if err != nil {
+			return p
+		}
+		return rel
+	}
+}
+
+// writeThreadLines prints one "path:line:col: message" line per thread,
+// ordered by file and line, in the format compilers use so editors can step
+// through the threads as a list of errors. Outdated threads point at their
+// original line.
+func writeThreadLines(w io.Writer, threads []reviewThread, localPath func(string) string) {
+	type entry struct {
+		path string
+		line int
+		text string
+	}
+	var entries []entry
+	for _, t := range threads {
+		line := 1
+		switch {
+		case t.IsOutdated && t.OriginalLine != nil:
+			line = *t.OriginalLine
+		case t.Line != nil:
+			line = *t.Line
+		case t.OriginalLine != nil:
+			line = *t.OriginalLine
+		}
+		author, snippet := "unknown", ""
+		if len(t.Comments.Nodes) > 0 {
+			first := t.Comments.Nodes[0]
+			if first.Author.Login != "" {
+				author = first.Author.Login
+			}
+			snippet = firstLine(first.Body)
+		}
+		text := fmt.Sprintf("[%s] %s: %s", resolutionState(t.IsResolved), author, snippet)
+		if t.IsOutdated {
+			text += " (outdated)"
+		}
+		entries = append(entries, entry{path: localPath(t.Path), line: line, text: text})
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].path != entries[j].path {
+			return entries[i].path < entries[j].path
+		}
+		return entries[i].line < entries[j].line
+	})
+	for _, e := range entries {
+		fmt.Fprintf(w, "%s:%d:1: %s\n", e.path, e.line, e.text)
+	}
+}
+
+func printLinesUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage:")
+	fmt.Fprintln(w, "  gh-pr-review lines --path <file> [--pr <number>] [--repo owner/name] [--status value] [--host host]")
+	fmt.Fprintln(w, "  gh-pr-review lines --all-files [--pr <number>] [--repo owner/name] [--status value] [--host host]")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Prints the review threads on a file as compiler-style lines, for vim's quickfix list, VS Code")
+	fmt.Fprintln(w, "problem matchers and the like:")
+	fmt.Fprintln(w, "  internal/github/graphql.go:88:1: [unresolved] alice: first line of comment")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Paths are relative to the current directory when run inside the checkout. Outdated threads")
+	fmt.Fprintln(w, "point at their original line and end in (outdated); file-level threads point at line 1.")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Flags:")
+	fmt.Fprintln(w, "  --path <file>   Repository path of the file (a glob, a bare file name, or a directory for everything below it)")
+	fmt.Fprintln(w, "  --all-files   Every thread on the PR")
+	fmt.Fprintln(w, "  --pr <number>   PR number (defaults to current branch PR if available)")
+	fmt.Fprintln(w, "  --repo <owner/name>   Repository (defaults to gh repo view)")
+	fmt.Fprintln(w, "  --status <value>   all|resolved|unresolved|resolved-no-reply (default: all)")
+	fmt.Fprintln(w, "  --host <host>   GitHub host")
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteThreadLines(t *testing.T) {
+	comment := func(login, body string) reviewThreadComment {
+		return reviewThreadComment{Nodes: []reviewComment{{Author: actor{Login: login}, Body: body}}}
+	}
+	threads := []reviewThread{
+		{Path: "b.go", Line: intPtr(5), Comments: comment("carol", "Later file")},
+		{Path: "a.go", Line: intPtr(88), IsResolved: true, Comments: comment("bob", "\nSecond thread\nmore detail")},
+		{Path: "a.go", IsOutdated: true, OriginalLine: intPtr(12), Comments: comment("alice", "Moved code")},
+		{Path: "a.go", Comments: comment("", "Whole file")},
+	}
+	var buf bytes.Buffer
+	writeThreadLines(&buf, threads, func(p string) string { return "sub/" + p })
+	want := `sub/a.go:1:1: [unresolved] unknown: Whole file
+sub/a.go:12:1: [unresolved] alice: Moved code (outdated)
+sub/a.go:88:1: [resolved] bob: Second thread
+sub/b.go:5:1: [unresolved] carol: Later file
+`
+	if buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
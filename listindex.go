@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// listIndex remembers the threads the last text or table list printed, in
+// order, so later commands can refer to them by their 1-based index.
+type listIndex struct {
+	Host    string           `json:"host"`
+	Repo    string           `json:"repo"`
+	PR      int              `json:"pr"`
+	Threads []listIndexEntry `json:"threads"`
+}
+
+type listIndexEntry struct {
+	ID       string `json:"id"`
+	Location string `json:"location,omitempty"`
+}
+
+func listIndexPath() (string, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "last-list.json"), nil
+}
+
+// saveListIndex records the threads list just printed. Failing to write it
+// only costs the index shorthand, so errors are ignored.
+func saveListIndex(host, owner, name string, pr int, threads []reviewThread) {
+	idx := listIndex{Host: host, Repo: owner + "/" + name, PR: pr, Threads: []listIndexEntry{}}
+	for _, t := range threads {
+		idx.Threads = append(idx.Threads, listIndexEntry{ID: t.ID, Location: strings.Trim(strings.TrimSpace(formatLineInfo(t)), "[]")})
+	}
+	path, err := listIndexPath()
+	if err != nil {
+		return
+	}
+	data, err := json.MarshalIndent(idx, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return
+	}
+	_ = os.WriteFile(path, data, 0o644)
+}
+
+func loadListIndex() (listIndex, error) {
+	var idx listIndex
+	path, err := listIndexPath()
+	if err != nil {
+		return idx, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return idx, err
+	}
+	return idx, json.Unmarshal(data, &idx)
+}
+
+// splitIndexArgs separates bare numbers, which refer to the last list's
+// indexes, from thread IDs.
+func splitIndexArgs(args []string) (ids []string, indexes []int) {
+	for _, arg := range args {
+		if n, err := strconv.Atoi(arg); err == nil {
+			indexes = append(indexes, n)
+			continue
+		}
+		ids = append(ids, arg)
+	}
+	return ids, indexes
+}
+
+// threadIDsForIndexes maps indexes from the last list of this PR to thread
+// IDs, printing each one's location on stderr so a stale list is easy to
+// spot.
+func threadIDsForIndexes(ctx context.Context, host, repo string, pr int, indexes []int) ([]string, error) {
+	pr, err := resolvePR(ctx, pr)
+	if err != nil {
+		return nil, err
+	}
+	owner, name, err := resolveRepo(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+	rerun := fmt.Sprintf("run `gh-pr-review list --pr %d --repo %s/%s` again", pr, owner, name)
+	idx, err := loadListIndex()
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("no saved list to take thread indexes from; %s", rerun)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading the saved list: %w; %s", err, rerun)
+	}
+	return idx.lookup(host, owner+"/"+name, pr, indexes, rerun)
+}
+
+func (idx listIndex) lookup(host, repo string, pr int, indexes []int, rerun string) ([]string, error) {
+	if !strings.EqualFold(idx.Repo, repo) || idx.PR != pr || !strings.EqualFold(idx.Host, host) {
+		return nil, fmt.Errorf("the last list was for %s#%d, not %s#%d; %s", idx.Repo, idx.PR, repo, pr, rerun)
+	}
+	styler := newStyler(os.Stderr)
+	var ids []string
+	for _, n := range indexes {
+		if n < 1 || n > len(idx.Threads) {
+			return nil, fmt.Errorf("index %d is out of range; the last list showed %d threads (%s)", n, len(idx.Threads), rerun)
+		}
+		entry := idx.Threads[n-1]
+		fmt.Fprintf(os.Stderr, "%d: %s %s\n", n, styler.threadID(entry.ID), entry.Location)
+		ids = append(ids, entry.ID)
+	}
+	return ids, nil
+}
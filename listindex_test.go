@@ -0,0 +1,39 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestListIndexRoundTrip(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	line := 10
+	saveListIndex("github.com", "owner", "repo", 42, []reviewThread{
+		{ID: "A", Path: "main.go", Line: &line},
+		{ID: "B"},
+	})
+	idx, err := loadListIndex()
+	if err != nil {
+		t.Fatalf("loadListIndex: %v", err)
+	}
+	ids, err := idx.lookup("github.com", "owner/repo", 42, []int{2, 1}, "rerun")
+	if err != nil || strings.Join(ids, ",") != "B,A" {
+		t.Fatalf("lookup = %v, %v; want B,A", ids, err)
+	}
+	if idx.Threads[0].Location != "main.go:10" {
+		t.Fatalf("location = %q", idx.Threads[0].Location)
+	}
+	if _, err := idx.lookup("github.com", "owner/repo", 41, []int{1}, "rerun"); err == nil || !strings.Contains(err.Error(), "owner/repo#42, not owner/repo#41") {
+		t.Fatalf("lookup for another PR: got %v", err)
+	}
+	if _, err := idx.lookup("github.com", "owner/repo", 42, []int{3}, "rerun"); err == nil || !strings.Contains(err.Error(), "out of range") {
+		t.Fatalf("lookup out of range: got %v", err)
+	}
+}
+
+func TestSplitIndexArgs(t *testing.T) {
+	ids, indexes := splitIndexArgs([]string{"3", "PRRT_a", "7"})
+	if strings.Join(ids, ",") != "PRRT_a" || len(indexes) != 2 || indexes[0] != 3 || indexes[1] != 7 {
+		t.Fatalf("splitIndexArgs = %v, %v", ids, indexes)
+	}
+}
@@ -8,25 +8,44 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path"
 	"runtime/debug"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"gh-pr-review/internal/gh"
+	"gh-pr-review/internal/git"
 	"gh-pr-review/internal/github"
 	"github.com/charmbracelet/glamour"
 	"golang.org/x/term"
 )
 
 type reviewThread struct {
-	ID            string              `json:"id"`
-	IsResolved    bool                `json:"isResolved"`
-	IsOutdated    bool                `json:"isOutdated"`
-	Path          string              `json:"path"`
-	Line          *int                `json:"line"`
-	OriginalLine  *int                `json:"originalLine"`
-	StartLine     *int                `json:"startLine"`
-	OriginalStart *int                `json:"originalStartLine"`
-	Comments      reviewThreadComment `json:"comments"`
+	ID            string `json:"id"`
+	IsResolved    bool   `json:"isResolved"`
+	IsOutdated    bool   `json:"isOutdated"`
+	Path          string `json:"path"`
+	Line          *int   `json:"line"`
+	OriginalLine  *int   `json:"originalLine"`
+	StartLine     *int   `json:"startLine"`
+	OriginalStart *int   `json:"originalStartLine"`
+	IsPending     bool   `json:"isPending"`
+	// OriginalCommit is the commit the thread was first anchored to, taken
+	// from its first comment.
+	OriginalCommit *commitRef `json:"originalCommit,omitempty"`
+	// Round is the review round the thread was opened in; only set with
+	// --round.
+	Round        int                 `json:"round,omitempty"`
+	ResolvedBy   *actor              `json:"resolvedBy,omitempty"`
+	CanResolve   bool                `json:"viewerCanResolve"`
+	CanUnresolve bool                `json:"viewerCanUnresolve"`
+	CanReply     bool                `json:"viewerCanReply"`
+	Comments     reviewThreadComment `json:"comments"`
+	// DiffHunk is the hunk the thread is on, fetched once rather than with
+	// every comment and copied onto them by annotateThread.
+	DiffHunk string `json:"-"`
 }
 
 type reviewThreadComment struct {
@@ -34,13 +53,42 @@ type reviewThreadComment struct {
 }
 
 type reviewComment struct {
-	ID        string `json:"id"`
-	Body      string `json:"body"`
-	CreatedAt string `json:"createdAt"`
-	URL       string `json:"url"`
-	Author    struct {
-		Login string `json:"login"`
-	} `json:"author"`
+	ID                string             `json:"id"`
+	DatabaseID        int64              `json:"databaseId"`
+	FullDatabaseID    string             `json:"fullDatabaseId"`
+	Body              string             `json:"body"`
+	CreatedAt         string             `json:"createdAt"`
+	LastEditedAt      string             `json:"lastEditedAt"`
+	IncludesEdit      bool               `json:"includesCreatedEdit"`
+	DiffHunk          string             `json:"diffHunk"`
+	URL               string             `json:"url"`
+	State             string             `json:"state"`
+	Author            actor              `json:"author"`
+	PullRequestReview *pullRequestReview `json:"pullRequestReview"`
+	OriginalCommit    *commitRef         `json:"originalCommit"`
+}
+
+type commitRef struct {
+	OID            string `json:"oid"`
+	AbbreviatedOID string `json:"abbreviatedOid"`
+}
+
+type pullRequestReview struct {
+	ID          string `json:"id"`
+	State       string `json:"state"`
+	Author      actor  `json:"author"`
+	SubmittedAt string `json:"submittedAt"`
+}
+
+// actor is a comment or review author. Type is the GraphQL typename (User,
+// Bot, ...).
+type actor struct {
+	Login string `json:"login"`
+	Type  string `json:"type,omitempty"`
+}
+
+func (a actor) isBot() bool {
+	return a.Type == "Bot" || strings.HasSuffix(a.Login, "[bot]")
 }
 
 type listResponse struct {
@@ -58,183 +106,403 @@ type listResponse struct {
 }
 
 func main() {
-	if len(os.Args) < 2 {
-		printUsage()
+	userConfig = loadConfig(os.Stderr)
+	args, err := parseGlobalFlags(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(2)
 	}
+	if len(args) < 1 {
+		if args = defaultArgs(); args == nil {
+			printUsage()
+			os.Exit(2)
+		}
+	}
 
-	sub := os.Args[1]
+	args, err = expandAlias(args, userConfig.aliases)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(2)
+	}
+
+	sub := args[0]
 	switch sub {
-	case "list":
-		if err := runList(os.Args[2:]); err != nil {
-			exitErr(err)
-		}
-	case "tui":
-		if err := runTUI(os.Args[2:]); err != nil {
-			exitErr(err)
-		}
-	case "reply":
-		if err := runReply(os.Args[2:]); err != nil {
+	case "help", "-h", "--help":
+		if err := runHelp(args[1:]); err != nil {
 			exitErr(err)
 		}
-	case "resolve":
-		if err := runResolve(os.Args[2:], true); err != nil {
+		return
+	case "--version":
+		printVersion(os.Stdout)
+		return
+	case "completion":
+		if err := runCompletion(args[1:]); err != nil {
 			exitErr(err)
 		}
-	case "unresolve":
-		if err := runResolve(os.Args[2:], false); err != nil {
+		return
+	case "alias":
+		if err := runAlias(args[1:]); err != nil {
 			exitErr(err)
 		}
-	case "help", "-h", "--help":
-		printUsage()
-	case "version", "--version":
-		printVersion(os.Stdout)
-	default:
+		return
+	}
+	cmd := findCommand(sub)
+	if cmd == nil {
 		fmt.Fprintf(os.Stderr, "unknown command: %s\n", sub)
+		if suggestion := suggestCommand(sub); suggestion != "" {
+			fmt.Fprintf(os.Stderr, "did you mean %q?\n", suggestion)
+		}
 		printUsage()
 		os.Exit(2)
 	}
+	if err := cmd.run(args[1:]); err != nil {
+		exitErr(err)
+	}
+}
+
+// defaultArgs is what a run without a command stands for: the TUI when
+// someone is at the terminal, and nothing (so usage is printed) when output
+// is piped or prompts are off.
+func defaultArgs() []string {
+	if canPrompt() && stdoutIsTTY() {
+		return []string{"tui"}
+	}
+	return nil
+}
+
+// parseGlobalFlags consumes flags that precede the subcommand and apply to
+// every command, returning the remaining arguments.
+func parseGlobalFlags(args []string) ([]string, error) {
+	if env := strings.TrimSpace(os.Getenv("GH_PR_REVIEW_TIMEOUT")); env != "" {
+		if err := setTimeout(env); err != nil {
+			return nil, fmt.Errorf("GH_PR_REVIEW_TIMEOUT: %w", err)
+		}
+	} else if value := configValue("timeout"); value != "" {
+		if err := setTimeout(value); err != nil {
+			return nil, fmt.Errorf("config timeout: %w", err)
+		}
+	}
+	loadPromptEnv()
+	for len(args) > 0 {
+		arg := args[0]
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "--") {
+			return args, nil
+		}
+		switch name {
+		case "yes":
+			assumeYes = true
+		case "no-input":
+			noInput = true
+		case "timeout":
+			if !hasValue {
+				if len(args) < 2 {
+					return nil, errors.New("--timeout requires a duration")
+				}
+				value = args[1]
+				args = args[1:]
+			}
+			if err := setTimeout(value); err != nil {
+				return nil, fmt.Errorf("--timeout: %w", err)
+			}
+		default:
+			return args, nil
+		}
+		args = args[1:]
+	}
+	return args, nil
+}
+
+// setTimeout applies a duration to gh subprocesses and API requests.
+func setTimeout(value string) error {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	if d < 0 {
+		return fmt.Errorf("invalid duration %q", value)
+	}
+	gh.Timeout = d
+	github.Timeout = d
+	return nil
 }
 
 func printUsage() {
 	fmt.Fprintln(os.Stdout, "gh-pr-review: manage GitHub PR review threads")
 	fmt.Fprintln(os.Stdout, "")
+	fmt.Fprintln(os.Stdout, "Global flags (before the command):")
+	fmt.Fprintln(os.Stdout, "  --timeout <duration>   Limit for each gh call and API request (default 30s/20s, env GH_PR_REVIEW_TIMEOUT)")
+	fmt.Fprintln(os.Stdout, "  --yes   Answer yes to every confirmation prompt (env GH_PR_REVIEW_YES=1)")
+	fmt.Fprintln(os.Stdout, "  --no-input   Never prompt; exit 4 where a prompt would be needed (env GH_PR_REVIEW_NO_INPUT=1)")
+	fmt.Fprintln(os.Stdout, "")
 	fmt.Fprintln(os.Stdout, "Usage:")
-	fmt.Fprintln(os.Stdout, "  gh-pr-review list [--pr <number>] [--repo owner/name] [--status all|resolved|unresolved|resolved-no-reply] [--host host] [--json]")
-	fmt.Fprintln(os.Stdout, "  gh-pr-review tui [--pr <number>] [--repo owner/name] [--status all|resolved|unresolved|resolved-no-reply] [--host host]")
-	fmt.Fprintln(os.Stdout, "  gh-pr-review reply --thread-id <id> --body <text> [--host host]")
-	fmt.Fprintln(os.Stdout, "  gh-pr-review reply --thread-id <id> --body-file <path> [--host host]")
-	fmt.Fprintln(os.Stdout, "  gh-pr-review resolve --thread-id <id> [--host host]")
-	fmt.Fprintln(os.Stdout, "  gh-pr-review unresolve --thread-id <id> [--host host]")
-	fmt.Fprintln(os.Stdout, "  gh-pr-review version")
-}
-
-func runList(args []string) error {
-	fs := flag.NewFlagSet("list", flag.ContinueOnError)
-	fs.SetOutput(os.Stderr)
-	fs.Usage = func() { printListUsage(fs.Output()) }
-	var repo string
-	var pr int
-	var status string
-	var jsonOut bool
-	var host string
-	fs.StringVar(&repo, "repo", "", "owner/name (defaults to gh repo view)")
-	fs.IntVar(&pr, "pr", 0, "PR number")
-	fs.StringVar(&status, "status", "all", "all|resolved|unresolved|resolved-no-reply")
-	fs.BoolVar(&jsonOut, "json", false, "output JSON")
-	fs.StringVar(&host, "host", gh.DefaultHost(), "GitHub host")
-	if err := fs.Parse(args); err != nil {
+	for _, cmd := range commands {
+		for _, line := range cmd.synopsis() {
+			fmt.Fprintf(os.Stdout, "  %s\n", line)
+		}
+	}
+	fmt.Fprintln(os.Stdout, "  gh-pr-review help [command]")
+	fmt.Fprintln(os.Stdout, "  gh-pr-review completion bash|zsh|fish")
+	fmt.Fprintln(os.Stdout, "  gh-pr-review alias set|list|delete [<name> ['<command line>']]")
+}
+
+type listFlags struct {
+	repo              string
+	pr                int
+	status            string
+	review            string
+	jsonOut           bool
+	count             bool
+	exitStatus        bool
+	sinceCommit       string
+	currentDiff       bool
+	sortOrder         string
+	maxLines          int
+	excludeBots       bool
+	onlyBots          bool
+	includePRComments bool
+	round             string
+	format            string
+	noIgnore          bool
+	noNotes           bool
+	markRead          bool
+	theme             string
+	noRenderCache     bool
+	host              string
+}
+
+func (o *listFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.repo, "repo", "", "owner/name (defaults to gh repo view)")
+	fs.IntVar(&o.pr, "pr", 0, "PR number")
+	fs.StringVar(&o.status, "status", "all", "all|resolved|unresolved|resolved-no-reply")
+	fs.StringVar(&o.review, "review", "", "review id|index|none|list")
+	fs.BoolVar(&o.jsonOut, "json", false, "output JSON")
+	fs.BoolVar(&o.count, "count", false, "print only the number of matching threads")
+	fs.BoolVar(&o.exitStatus, "exit-status", false, "exit 1 if any threads match")
+	fs.StringVar(&o.sinceCommit, "since-commit", "", "only threads on lines changed between <sha> and HEAD")
+	fs.BoolVar(&o.currentDiff, "current-diff", false, "only threads on lines changed in the PR's current diff")
+	fs.StringVar(&o.sortOrder, "sort", sortAPI, "api|diff|path|newest|oldest-unresolved")
+	fs.IntVar(&o.maxLines, "max-lines", 0, "truncate each comment body to N lines (0 = no limit)")
+	fs.BoolVar(&o.excludeBots, "exclude-bots", false, "drop threads where every comment is from a bot")
+	fs.BoolVar(&o.onlyBots, "only-bots", false, "only threads where every comment is from a bot")
+	fs.BoolVar(&o.includePRComments, "include-pr-comments", false, "also show PR conversation comments")
+	fs.StringVar(&o.round, "round", "", "only threads from review round latest|N|all")
+	fs.StringVar(&o.format, "format", formatText, "text|table")
+	fs.BoolVar(&o.noIgnore, "no-ignore", false, "show threads on paths in "+ignoreFileName)
+	fs.BoolVar(&o.noNotes, "no-notes", false, "don't show private thread notes")
+	fs.BoolVar(&o.markRead, "mark-all-read", false, "mark the listed threads read for the TUI")
+	fs.StringVar(&o.theme, "theme", "auto", "markdown style (auto|dark|light|...)")
+	fs.BoolVar(&o.noRenderCache, "no-render-cache", false, "disable the on-disk render cache")
+	fs.StringVar(&o.host, "host", gh.DefaultHost(), "GitHub host")
+}
+
+func runList(args []string) (err error) {
+	fs := newFlagSet("list", printListUsage)
+	var flags listFlags
+	flags.register(fs)
+	// With --exit-status, exit 1 is reserved for "threads matched"; anything
+	// that stops the check itself from running must be distinguishable in CI.
+	defer func() {
+		var ee *exitError
+		if flags.exitStatus && err != nil && !errors.As(err, &ee) {
+			err = &exitError{code: 2, err: err}
+		}
+	}()
+	if err := parseFlags(fs, args); err != nil {
 		if errors.Is(err, flag.ErrHelp) {
 			return nil
 		}
 		return err
 	}
+	if err := setTheme(flags.theme); err != nil {
+		return err
+	}
+	openRenderCache(flags.noRenderCache)
 	ctx := context.Background()
-	if pr <= 0 {
-		derived, err := gh.CurrentPrNumber(ctx)
-		if err != nil {
-			return fmt.Errorf("--pr is required (and could not be derived from current checkout): %w", err)
-		}
-		pr = derived
+	flags.pr, err = resolvePR(ctx, flags.pr)
+	if err != nil {
+		return err
+	}
+	flags.status = strings.ToLower(strings.TrimSpace(flags.status))
+	if flags.status == "" {
+		flags.status = "all"
+	}
+	if flags.status != "all" && flags.status != "resolved" && flags.status != "unresolved" && flags.status != "resolved-no-reply" {
+		return fmt.Errorf("invalid --status %q", flags.status)
+	}
+	if flags.sinceCommit != "" && flags.currentDiff {
+		return errors.New("provide only one of --since-commit or --current-diff")
 	}
-	status = strings.ToLower(strings.TrimSpace(status))
-	if status == "" {
-		status = "all"
+	if flags.maxLines < 0 {
+		return fmt.Errorf("invalid --max-lines %d", flags.maxLines)
 	}
-	if status != "all" && status != "resolved" && status != "unresolved" && status != "resolved-no-reply" {
-		return fmt.Errorf("invalid --status %q", status)
+	if flags.excludeBots && flags.onlyBots {
+		return errors.New("provide only one of --exclude-bots or --only-bots")
+	}
+	flags.sortOrder = strings.ToLower(strings.TrimSpace(flags.sortOrder))
+	if !validSort(flags.sortOrder) {
+		return fmt.Errorf("invalid --sort %q", flags.sortOrder)
+	}
+	if flags.round, err = parseRound(flags.round); err != nil {
+		return err
+	}
+	flags.format = strings.ToLower(strings.TrimSpace(flags.format))
+	if flags.format != formatText && flags.format != formatTable {
+		return fmt.Errorf("invalid --format %q (expected text|table)", flags.format)
+	}
+	if flags.format == formatTable && flags.jsonOut {
+		return errors.New("provide only one of --format table or --json")
 	}
 
-	owner, name, err := resolveRepo(ctx, repo)
+	owner, name, err := resolveRepo(ctx, flags.repo)
 	if err != nil {
 		return err
 	}
-	token, err := gh.AuthToken(ctx, host)
+	client, err := newClient(ctx, flags.host)
 	if err != nil {
-		return fmt.Errorf("failed to get gh auth token: %w", err)
+		return err
 	}
-	client := github.NewClient(github.GraphQLEndpoint(host), token)
 
-	threads, err := fetchAllThreads(ctx, client, owner, name, pr)
+	threads, err := fetchAllThreads(ctx, client, owner, name, flags.pr)
 	if err != nil {
 		return err
 	}
-	filtered := filterThreads(threads, status)
-	if jsonOut {
-		enc := json.NewEncoder(os.Stdout)
-		enc.SetIndent("", "  ")
-		return enc.Encode(filtered)
+	flags.review = strings.TrimSpace(flags.review)
+	var reviews []pullRequestReview
+	if flags.review != "" && flags.review != "none" {
+		if reviews, err = fetchReviews(ctx, client, owner, name, flags.pr); err != nil {
+			return err
+		}
 	}
-	printThreads(filtered)
-	return nil
-}
-
-func runReply(args []string) error {
-	fs := flag.NewFlagSet("reply", flag.ContinueOnError)
-	fs.SetOutput(os.Stderr)
-	fs.Usage = func() { printReplyUsage(fs.Output()) }
-	var threadID string
-	var body string
-	var bodyFile string
-	var host string
-	fs.StringVar(&threadID, "thread-id", "", "Review thread ID")
-	fs.StringVar(&body, "body", "", "Reply body")
-	fs.StringVar(&bodyFile, "body-file", "", "Read reply body from file")
-	fs.StringVar(&host, "host", gh.DefaultHost(), "GitHub host")
-	if err := fs.Parse(args); err != nil {
-		if errors.Is(err, flag.ErrHelp) {
-			return nil
+	if flags.review == "list" {
+		if flags.jsonOut {
+			return writeJSON(os.Stdout, reviews)
 		}
-		return err
+		printReviews(reviews)
+		return nil
 	}
-	if threadID == "" {
-		return errors.New("--thread-id is required")
+	var latestRound int
+	if flags.round != "" {
+		latestRound, err = labelRounds(ctx, client, owner, name, flags.pr, threads)
+		if err != nil {
+			return err
+		}
 	}
-	body, err := resolveBody(body, bodyFile)
+	filtered, err := applyIgnoreFile(ctx, filterThreads(threads, flags.status), flags.noIgnore)
 	if err != nil {
 		return err
 	}
-	if strings.TrimSpace(body) == "" {
-		return errors.New("reply body is empty")
+	if flags.round != "" {
+		filtered = filterByRound(filtered, flags.round, latestRound)
+	}
+	if flags.review != "" {
+		filtered, err = filterByReview(filtered, reviews, flags.review)
+		if err != nil {
+			return err
+		}
+	}
+	if flags.excludeBots || flags.onlyBots {
+		filtered = filterBotThreads(filtered, flags.onlyBots)
+	}
+	if flags.sinceCommit != "" || flags.currentDiff {
+		changed, err := changedLinesFor(ctx, client, owner, name, flags.pr, flags.sinceCommit)
+		if err != nil {
+			return err
+		}
+		filtered = filterByChangedLines(filtered, changed)
+	}
+	var files []string
+	if flags.sortOrder == sortDiff {
+		files, err = fetchChangedFiles(ctx, client, owner, name, flags.pr)
+		if err != nil {
+			return err
+		}
+	}
+	sortThreads(filtered, flags.sortOrder, files)
+	var prComments []prComment
+	if flags.includePRComments && !flags.count {
+		prComments, err = fetchPRComments(ctx, client, owner, name, flags.pr)
+		if err != nil {
+			return err
+		}
+	}
+	opts := printOptions{maxLines: flags.maxLines, numbered: true}
+	switch {
+	case flags.count && flags.jsonOut:
+		err = writeJSON(os.Stdout, map[string]int{"count": len(filtered)})
+	case flags.count:
+		fmt.Fprintln(os.Stdout, len(filtered))
+	case flags.jsonOut && flags.includePRComments:
+		if prComments == nil {
+			prComments = []prComment{}
+		}
+		err = writeJSON(os.Stdout, map[string]interface{}{
+			"threads":    filtered,
+			"prComments": prComments,
+		})
+	case flags.jsonOut:
+		err = writeJSON(os.Stdout, filtered)
+	default:
+		defer startPager()()
+		if flags.format == formatTable {
+			printThreadTable(os.Stdout, filtered, terminalWidth(), newStyler(os.Stdout))
+		} else {
+			if !flags.noNotes {
+				opts.notes = threadNotes()
+			}
+			printThreads(filtered, opts)
+		}
+		saveListIndex(flags.host, owner, name, flags.pr, filtered)
+		if flags.includePRComments {
+			printPRComments(prComments, opts)
+		}
 	}
-
-	ctx := context.Background()
-	token, err := gh.AuthToken(ctx, host)
 	if err != nil {
-		return fmt.Errorf("failed to get gh auth token: %w", err)
+		return err
 	}
-	client := github.NewClient(github.GraphQLEndpoint(host), token)
-	return replyToThread(ctx, client, threadID, body)
+	if flags.markRead {
+		seen, err := loadSeen(flags.host, owner, name, flags.pr)
+		if err != nil {
+			return err
+		}
+		marked, err := markAllRead(seen, filtered)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "marked %d threads read\n", marked)
+	}
+	if flags.exitStatus && len(filtered) > 0 {
+		return &exitError{code: 1}
+	}
+	return nil
 }
 
-func runResolve(args []string, resolve bool) error {
-	fs := flag.NewFlagSet("resolve", flag.ContinueOnError)
-	fs.SetOutput(os.Stderr)
-	fs.Usage = func() { printResolveUsage(fs.Output(), resolve) }
-	var threadID string
-	var host string
-	fs.StringVar(&threadID, "thread-id", "", "Review thread ID")
-	fs.StringVar(&host, "host", gh.DefaultHost(), "GitHub host")
-	if err := fs.Parse(args); err != nil {
-		if errors.Is(err, flag.ErrHelp) {
-			return nil
-		}
-		return err
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// resolvePR returns pr, or the number of the current branch's PR when pr is
+// unset.
+func resolvePR(ctx context.Context, pr int) (int, error) {
+	if pr > 0 {
+		return pr, nil
 	}
-	if threadID == "" {
-		return errors.New("--thread-id is required")
+	derived, err := gh.CurrentPrNumber(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("--pr is required (and could not be derived from current checkout): %w", err)
 	}
+	return derived, nil
+}
 
-	ctx := context.Background()
+// newClient builds an API client authenticated with the gh token for host.
+func newClient(ctx context.Context, host string) (*github.Client, error) {
 	token, err := gh.AuthToken(ctx, host)
 	if err != nil {
-		return fmt.Errorf("failed to get gh auth token: %w", err)
-	}
-	client := github.NewClient(github.GraphQLEndpoint(host), token)
-	if resolve {
-		return setThreadResolved(ctx, client, threadID, true)
+		return nil, fmt.Errorf("failed to get gh auth token: %w", err)
 	}
-	return setThreadResolved(ctx, client, threadID, false)
+	return github.NewClient(github.GraphQLEndpoint(host), token), nil
 }
 
 func resolveRepo(ctx context.Context, repo string) (string, string, error) {
@@ -245,20 +513,55 @@ func resolveRepo(ctx context.Context, repo string) (string, string, error) {
 		}
 		repo = view.NameWithOwner
 	}
-	parts := strings.Split(repo, "/")
+	return parseRepo(repo)
+}
+
+// parseRepo splits and validates an owner/name repository reference,
+// tolerating whitespace around the parts and a trailing slash.
+func parseRepo(repo string) (string, string, error) {
+	parts := strings.Split(strings.TrimSpace(repo), "/")
+	if len(parts) == 3 && strings.TrimSpace(parts[2]) == "" {
+		parts = parts[:2]
+	}
+	if len(parts) > 2 {
+		return "", "", fmt.Errorf("invalid repo %q: too many segments (expected owner/name)", repo)
+	}
 	if len(parts) != 2 {
 		return "", "", fmt.Errorf("invalid repo %q (expected owner/name)", repo)
 	}
-	return parts[0], parts[1], nil
+	owner := strings.TrimSpace(parts[0])
+	name := strings.TrimSpace(parts[1])
+	if owner == "" {
+		return "", "", fmt.Errorf("invalid repo %q: owner is empty", repo)
+	}
+	if name == "" {
+		return "", "", fmt.Errorf("invalid repo %q: name is empty", repo)
+	}
+	if !validRepoSegment(owner) {
+		return "", "", fmt.Errorf("invalid repo %q: owner %q may only contain letters, digits, '-', '_' and '.'", repo, owner)
+	}
+	if !validRepoSegment(name) || name == "." || name == ".." {
+		return "", "", fmt.Errorf("invalid repo %q: name %q may only contain letters, digits, '-', '_' and '.'", repo, name)
+	}
+	return owner, name, nil
 }
 
-func fetchAllThreads(ctx context.Context, client *github.Client, owner, name string, pr int) ([]reviewThread, error) {
-	query := `query($owner:String!, $name:String!, $number:Int!, $after:String) {
-  repository(owner:$owner, name:$name) {
-    pullRequest(number:$number) {
-      reviewThreads(first:100, after:$after) {
-        pageInfo { hasNextPage endCursor }
-        nodes {
+func validRepoSegment(s string) bool {
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// threadFields is the PullRequestReviewThread selection shared by every
+// query that decodes into reviewThread, minus fields the host lacks.
+func threadFields(skip map[string]bool) string {
+	return `
           id
           isResolved
           isOutdated
@@ -267,46 +570,202 @@ func fetchAllThreads(ctx context.Context, client *github.Client, owner, name str
           originalLine
           startLine
           originalStartLine
+          ` + optionalField(skip, "resolvedBy", "resolvedBy { login }") + `
+          viewerCanResolve
+          viewerCanUnresolve
+          ` + optionalField(skip, "viewerCanReply", "viewerCanReply") + `
           comments(first:100) {
-            nodes {
+            nodes {` + commentFields(skip) + `}
+          }
+          hunk: comments(first:1) {
+            nodes { diffHunk }
+          }
+`
+}
+
+// commentFields is the PullRequestReviewComment selection decoded into
+// reviewComment.
+func commentFields(skip map[string]bool) string {
+	return `
               id
+              databaseId
+              ` + optionalField(skip, "fullDatabaseId", "fullDatabaseId") + `
               body
               createdAt
+              ` + optionalField(skip, "lastEditedAt", "lastEditedAt") + `
+              ` + optionalField(skip, "includesCreatedEdit", "includesCreatedEdit") + `
               url
-              author { login }
-            }
-          }
-        }
-      }
-    }
+              state
+              author { login type: __typename }
+              ` + optionalField(skip, "originalCommit", "originalCommit { oid abbreviatedOid }") + `
+              pullRequestReview {
+                id
+                state
+                author { login }
+                submittedAt
+              }
+`
+}
+
+// fetchViewerLogin returns the login of the authenticated user.
+func fetchViewerLogin(ctx context.Context, client *github.Client) (string, error) {
+	var resp struct {
+		Viewer struct {
+			Login string `json:"login"`
+		} `json:"viewer"`
+	}
+	if err := client.Do(ctx, `query { viewer { login } }`, nil, &resp); err != nil {
+		return "", err
+	}
+	return resp.Viewer.Login, nil
+}
+
+// fetchThread loads a single review thread by node ID.
+func fetchThread(ctx context.Context, client *github.Client, threadID string) (reviewThread, error) {
+	query := func(skip map[string]bool) string {
+		return `query($id:ID!) {
+  node(id:$id) {
+    ... on PullRequestReviewThread {` + threadFields(skip) + `}
   }
 }`
+	}
+	var resp struct {
+		Node *reviewThread `json:"node"`
+	}
+	err := queryWithFallback(ctx, client, query, map[string]interface{}{"id": threadID}, &resp)
+	if err := threadLookupError(threadID, resp.Node, err); err != nil {
+		return reviewThread{}, err
+	}
+	annotateThread(resp.Node)
+	return *resp.Node, nil
+}
+
+// threadLookupError explains a failed or empty node(id:) lookup of a
+// thread.
+func threadLookupError(threadID string, node *reviewThread, err error) error {
+	if github.NotFound(err) || (err == nil && (node == nil || node.ID == "")) {
+		return fmt.Errorf("thread %s not found (check the ID from `gh-pr-review list`)", threadID)
+	}
+	if github.Forbidden(err) {
+		return fmt.Errorf("you don't have access to thread %s (check `gh auth status` for the right account and host)", threadID)
+	}
+	return err
+}
+
+// annotateThread fills in the fields derived from a thread's first comment:
+// whether it was opened in the viewer's unsubmitted review and the commit it
+// was originally anchored to. It also gives every comment the thread's hunk.
+func annotateThread(t *reviewThread) {
+	if len(t.Comments.Nodes) == 0 {
+		return
+	}
+	if t.DiffHunk != "" {
+		for i := range t.Comments.Nodes {
+			t.Comments.Nodes[i].DiffHunk = t.DiffHunk
+		}
+	}
+	first := t.Comments.Nodes[0]
+	t.IsPending = first.isPending()
+	t.OriginalCommit = first.OriginalCommit
+}
+
+func (c reviewComment) isPending() bool {
+	if c.State == "PENDING" {
+		return true
+	}
+	return c.PullRequestReview != nil && c.PullRequestReview.State == "PENDING"
+}
+
+// UnmarshalJSON treats a missing viewerCanReply as true: servers too old to
+// know the field are left to refuse a reply themselves rather than have
+// every thread look locked.
+func (t *reviewThread) UnmarshalJSON(data []byte) error {
+	type plain reviewThread
+	var raw struct {
+		plain
+		CanReply *bool `json:"viewerCanReply"`
+		Hunk     struct {
+			Nodes []struct {
+				DiffHunk string `json:"diffHunk"`
+			} `json:"nodes"`
+		} `json:"hunk"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*t = reviewThread(raw.plain)
+	t.CanReply = raw.CanReply == nil || *raw.CanReply
+	if len(raw.Hunk.Nodes) > 0 {
+		t.DiffHunk = raw.Hunk.Nodes[0].DiffHunk
+	}
+	return nil
+}
+
+// readOnly reports whether the viewer can neither reply to nor change the
+// resolution of the thread.
+func (t reviewThread) readOnly() bool {
+	return !t.CanReply && !t.CanResolve && !t.CanUnresolve
+}
+
+// errPendingThread explains why a thread from an unsubmitted review can't be
+// acted on.
+func errPendingThread(threadID string) error {
+	return fmt.Errorf("thread %s is part of your pending review and has not been submitted yet; submit the review on GitHub before replying to or resolving it", threadID)
+}
+
+func fetchAllThreads(ctx context.Context, client *github.Client, owner, name string, pr int) ([]reviewThread, error) {
 	var all []reviewThread
 	var after *string
 	for {
-		vars := map[string]interface{}{
-			"owner":  owner,
-			"name":   name,
-			"number": pr,
-			"after":  after,
-		}
-		var resp listResponse
-		if err := client.Do(ctx, query, vars, &resp); err != nil {
+		threads, next, err := fetchThreadPage(ctx, client, owner, name, pr, after)
+		if err != nil {
 			return nil, err
 		}
-		threads := resp.Repository.PullRequest.ReviewThreads.Nodes
 		all = append(all, threads...)
-		if !resp.Repository.PullRequest.ReviewThreads.PageInfo.HasNextPage {
-			break
-		}
-		after = resp.Repository.PullRequest.ReviewThreads.PageInfo.EndCursor
-		if after == nil || *after == "" {
+		if next == nil {
 			break
 		}
+		after = next
 	}
 	return all, nil
 }
 
+// fetchThreadPage fetches the page of threads after the cursor (nil for the
+// first page) and returns the cursor of the next page, nil after the last.
+func fetchThreadPage(ctx context.Context, client *github.Client, owner, name string, pr int, after *string) ([]reviewThread, *string, error) {
+	query := func(skip map[string]bool) string {
+		return `query($owner:String!, $name:String!, $number:Int!, $after:String) {
+  repository(owner:$owner, name:$name) {
+    pullRequest(number:$number) {
+      reviewThreads(first:100, after:$after) {
+        pageInfo { hasNextPage endCursor }
+        nodes {` + threadFields(skip) + `}
+      }
+    }
+  }
+}`
+	}
+	vars := map[string]interface{}{
+		"owner":  owner,
+		"name":   name,
+		"number": pr,
+		"after":  after,
+	}
+	var resp listResponse
+	if err := queryWithFallback(ctx, client, query, vars, &resp); err != nil {
+		return nil, nil, err
+	}
+	threads := resp.Repository.PullRequest.ReviewThreads.Nodes
+	for i := range threads {
+		annotateThread(&threads[i])
+	}
+	page := resp.Repository.PullRequest.ReviewThreads.PageInfo
+	if !page.HasNextPage || page.EndCursor == nil || *page.EndCursor == "" {
+		return threads, nil, nil
+	}
+	return threads, page.EndCursor, nil
+}
+
 func filterThreads(threads []reviewThread, status string) []reviewThread {
 	if status == "all" {
 		return threads
@@ -331,30 +790,322 @@ func filterThreads(threads []reviewThread, status string) []reviewThread {
 	return filtered
 }
 
-func printThreads(threads []reviewThread) {
+// threadReview returns the review the thread's first comment was submitted
+// with, or nil for comments that are not attached to a review.
+func threadReview(t reviewThread) *pullRequestReview {
+	if len(t.Comments.Nodes) == 0 {
+		return nil
+	}
+	return t.Comments.Nodes[0].PullRequestReview
+}
+
+// fetchReviews returns every review on the PR, including body-only reviews
+// without threads. Submitted reviews are ordered by submission time with
+// the viewer's pending review last, so indexes stay stable between runs.
+func fetchReviews(ctx context.Context, client *github.Client, owner, name string, pr int) ([]pullRequestReview, error) {
+	query := `query($owner:String!, $name:String!, $number:Int!, $after:String) {
+  repository(owner:$owner, name:$name) {
+    pullRequest(number:$number) {
+      reviews(first:100, after:$after) {
+        pageInfo { hasNextPage endCursor }
+        nodes { id state author { login } submittedAt }
+      }
+    }
+  }
+}`
+	var reviews []pullRequestReview
+	var after *string
+	for {
+		vars := map[string]interface{}{
+			"owner":  owner,
+			"name":   name,
+			"number": pr,
+			"after":  after,
+		}
+		var resp struct {
+			Repository struct {
+				PullRequest struct {
+					Reviews struct {
+						PageInfo struct {
+							HasNextPage bool    `json:"hasNextPage"`
+							EndCursor   *string `json:"endCursor"`
+						} `json:"pageInfo"`
+						Nodes []pullRequestReview `json:"nodes"`
+					} `json:"reviews"`
+				} `json:"pullRequest"`
+			} `json:"repository"`
+		}
+		if err := client.Do(ctx, query, vars, &resp); err != nil {
+			return nil, fmt.Errorf("failed to fetch PR reviews: %w", err)
+		}
+		page := resp.Repository.PullRequest.Reviews
+		reviews = append(reviews, page.Nodes...)
+		if !page.PageInfo.HasNextPage || page.PageInfo.EndCursor == nil || *page.PageInfo.EndCursor == "" {
+			break
+		}
+		after = page.PageInfo.EndCursor
+	}
+	sort.SliceStable(reviews, func(i, j int) bool {
+		a, b := reviews[i].SubmittedAt, reviews[j].SubmittedAt
+		if (a == "") != (b == "") {
+			return b == ""
+		}
+		if a != b {
+			return a < b
+		}
+		return reviews[i].ID < reviews[j].ID
+	})
+	return reviews, nil
+}
+
+// filterByReview keeps threads belonging to the review selected by id,
+// 1-based index from --review list, or "none" for threads without a review.
+func filterByReview(threads []reviewThread, reviews []pullRequestReview, selector string) ([]reviewThread, error) {
+	var reviewID string
+	switch {
+	case selector == "none":
+	case isDigits(selector):
+		idx, _ := strconv.Atoi(selector)
+		if idx < 1 || idx > len(reviews) {
+			return nil, fmt.Errorf("invalid --review index %d (PR has %d reviews, see --review list)", idx, len(reviews))
+		}
+		reviewID = reviews[idx-1].ID
+	default:
+		reviewID = selector
+	}
+	filtered := make([]reviewThread, 0, len(threads))
+	for _, t := range threads {
+		r := threadReview(t)
+		if reviewID == "" {
+			if r == nil || r.ID == "" {
+				filtered = append(filtered, t)
+			}
+			continue
+		}
+		if r != nil && r.ID == reviewID {
+			filtered = append(filtered, t)
+		}
+	}
+	return filtered, nil
+}
+
+// filterBotThreads drops threads whose comments all come from bots, or with
+// onlyBots keeps just those threads.
+func filterBotThreads(threads []reviewThread, onlyBots bool) []reviewThread {
+	filtered := make([]reviewThread, 0, len(threads))
+	for _, t := range threads {
+		if botOnlyThread(t) == onlyBots {
+			filtered = append(filtered, t)
+		}
+	}
+	return filtered
+}
+
+func botOnlyThread(t reviewThread) bool {
+	if len(t.Comments.Nodes) == 0 {
+		return false
+	}
+	for _, c := range t.Comments.Nodes {
+		if !c.Author.isBot() {
+			return false
+		}
+	}
+	return true
+}
+
+// filterByAuthor keeps threads opened by login (case-insensitive, leading @
+// optional).
+func filterByAuthor(threads []reviewThread, login string) []reviewThread {
+	login = strings.TrimPrefix(login, "@")
+	filtered := make([]reviewThread, 0, len(threads))
+	for _, t := range threads {
+		if len(t.Comments.Nodes) > 0 && strings.EqualFold(t.Comments.Nodes[0].Author.Login, login) {
+			filtered = append(filtered, t)
+		}
+	}
+	return filtered
+}
+
+// filterByPath keeps threads whose file matches pattern: a glob such as
+// "*.go" or "internal/*/x.go", or a plain path that also matches everything
+// below it.
+func filterByPath(threads []reviewThread, pattern string) []reviewThread {
+	pattern = strings.TrimSuffix(pattern, "/")
+	filtered := make([]reviewThread, 0, len(threads))
+	for _, t := range threads {
+		if t.Path == "" {
+			continue
+		}
+		matched, _ := path.Match(pattern, t.Path)
+		if !matched && !strings.ContainsAny(pattern, "*?[") {
+			matched = t.Path == pattern || strings.HasPrefix(t.Path, pattern+"/")
+		}
+		if !matched && !strings.Contains(pattern, "/") {
+			matched, _ = path.Match(pattern, path.Base(t.Path))
+		}
+		if matched {
+			filtered = append(filtered, t)
+		}
+	}
+	return filtered
+}
+
+// changedLinesFor computes the changed line ranges from the local checkout:
+// since the given commit, or the PR's full diff against its base when sha is
+// empty.
+func changedLinesFor(ctx context.Context, client *github.Client, owner, name string, pr int, sha string) (map[string][]git.LineRange, error) {
+	if sha != "" {
+		return git.ChangedLines(ctx, sha, "HEAD")
+	}
+	base, err := fetchBaseRefOid(ctx, client, owner, name, pr)
+	if err != nil {
+		return nil, err
+	}
+	changed, err := git.ChangedLines(ctx, base+"...HEAD", "")
+	if err != nil {
+		return nil, fmt.Errorf("%w (is the PR base commit fetched locally?)", err)
+	}
+	return changed, nil
+}
+
+func fetchBaseRefOid(ctx context.Context, client *github.Client, owner, name string, pr int) (string, error) {
+	query := `query($owner:String!, $name:String!, $number:Int!) {
+  repository(owner:$owner, name:$name) {
+    pullRequest(number:$number) { baseRefOid }
+  }
+}`
+	vars := map[string]interface{}{
+		"owner":  owner,
+		"name":   name,
+		"number": pr,
+	}
+	var resp struct {
+		Repository struct {
+			PullRequest struct {
+				BaseRefOid string `json:"baseRefOid"`
+			} `json:"pullRequest"`
+		} `json:"repository"`
+	}
+	if err := client.Do(ctx, query, vars, &resp); err != nil {
+		return "", err
+	}
+	if resp.Repository.PullRequest.BaseRefOid == "" {
+		return "", fmt.Errorf("could not determine base commit of PR #%d", pr)
+	}
+	return resp.Repository.PullRequest.BaseRefOid, nil
+}
+
+// filterByChangedLines keeps threads whose current line range falls inside a
+// changed hunk. Outdated threads and threads without a line never match.
+func filterByChangedLines(threads []reviewThread, changed map[string][]git.LineRange) []reviewThread {
+	filtered := make([]reviewThread, 0, len(threads))
+	for _, t := range threads {
+		if t.IsOutdated || t.Line == nil {
+			continue
+		}
+		start := *t.Line
+		if t.StartLine != nil && *t.StartLine < start {
+			start = *t.StartLine
+		}
+		for _, r := range changed[t.Path] {
+			if r.Overlaps(start, *t.Line) {
+				filtered = append(filtered, t)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func printReviews(reviews []pullRequestReview) {
+	if len(reviews) == 0 {
+		fmt.Fprintln(os.Stdout, "no reviews found")
+		return
+	}
+	styler := newStyler(os.Stdout)
+	for i, r := range reviews {
+		author := r.Author.Login
+		if author == "" {
+			author = "unknown"
+		}
+		fmt.Fprintf(os.Stdout, "%3d  %s  %s — %s\n",
+			i+1,
+			styler.threadID(r.ID),
+			styler.author(author),
+			styler.dim(r.SubmittedAt),
+		)
+	}
+}
+
+// printOptions tweaks how printThreads renders threads.
+type printOptions struct {
+	// maxLines truncates each comment body to this many display lines;
+	// zero keeps full bodies.
+	maxLines int
+	// numbered prefixes each thread with its 1-based index.
+	numbered bool
+	// diff shows the diff hunk the thread is attached to.
+	diff bool
+	// notes are private thread notes, keyed by thread ID, shown under each
+	// thread's header.
+	notes map[string]threadNote
+}
+
+func printThreads(threads []reviewThread, opts printOptions) {
 	if len(threads) == 0 {
 		fmt.Fprintln(os.Stdout, "no review threads found")
 		return
 	}
 	styler := newStyler(os.Stdout)
-	for _, t := range threads {
+	for i, t := range threads {
 		status := "unresolved"
 		if t.IsResolved {
 			status = "resolved"
 		}
 		lineInfo := formatLineInfo(t)
-		fmt.Fprintf(os.Stdout, "%s %s %s%s\n\n",
+		badges := threadBadges(t, styler)
+		index := ""
+		if opts.numbered {
+			index = styler.dim(fmt.Sprintf("%d.", i+1)) + " "
+		}
+		fmt.Fprintf(os.Stdout, "%s%s %s %s%s%s\n\n",
+			index,
 			styler.label("Thread"),
 			styler.threadID(t.ID),
 			styler.status(status),
+			badges,
 			lineInfo,
 		)
+		if note, ok := opts.notes[t.ID]; ok {
+			fmt.Fprintf(os.Stdout, "  %s\n\n", noteLine(note, styler))
+		}
+		if opts.diff && len(t.Comments.Nodes) > 0 && t.Comments.Nodes[0].DiffHunk != "" {
+			for _, line := range strings.Split(strings.TrimRight(t.Comments.Nodes[0].DiffHunk, "\n"), "\n") {
+				fmt.Fprintf(os.Stdout, "    %s\n", styler.diffLine(line))
+			}
+			fmt.Fprintln(os.Stdout, "")
+		}
 		for _, c := range t.Comments.Nodes {
 			author := c.Author.Login
 			if author == "" {
 				author = "unknown"
 			}
-			meta := styler.dim(c.CreatedAt)
+			meta := styler.dim(commentTimestamp(c, time.Now()))
+			if c.isPending() {
+				meta += " " + styler.pending()
+			}
 			fmt.Fprintf(os.Stdout, "  %s %s — %s\n",
 				styler.bullet(),
 				styler.author(author),
@@ -364,7 +1115,8 @@ func printThreads(threads []reviewThread) {
 				fmt.Fprintf(os.Stdout, "    %s\n", styler.dim(c.URL))
 			}
 			fmt.Fprintln(os.Stdout, "")
-			for _, line := range formatCommentBody(c.Body, "  ", 120, styler) {
+			lines := formatCommentBody(c.Body, "  ", 120, styler)
+			for _, line := range truncateBodyLines(lines, opts.maxLines, "  ", c.URL, styler) {
 				fmt.Fprintln(os.Stdout, line)
 			}
 		}
@@ -374,6 +1126,55 @@ func printThreads(threads []reviewThread) {
 	}
 }
 
+// threadBadges renders the markers shown after a thread's status.
+func threadBadges(t reviewThread, styler styler) string {
+	var b strings.Builder
+	// formatLineInfo marks outdated threads that have a location.
+	if t.IsOutdated && t.Path == "" {
+		b.WriteString(" " + styler.outdated())
+	}
+	if t.IsPending {
+		b.WriteString(" " + styler.pending())
+	}
+	if t.readOnly() && !t.IsPending {
+		b.WriteString(" " + styler.dim("(read-only)"))
+	}
+	if t.Round > 0 {
+		b.WriteString(" " + styler.dim(fmt.Sprintf("round %d", t.Round)))
+	}
+	return b.String()
+}
+
+// commentTimestamp renders when a comment was posted and, if it has been
+// edited since, when it was last edited: "2h ago (edited 20m ago)".
+func commentTimestamp(c reviewComment, now time.Time) string {
+	out := relativeTime(c.CreatedAt, now)
+	if c.LastEditedAt != "" && c.LastEditedAt != c.CreatedAt {
+		out += fmt.Sprintf(" (edited %s)", relativeTime(c.LastEditedAt, now))
+	}
+	return out
+}
+
+func relativeTime(ts string, now time.Time) string {
+	t, err := time.Parse(time.RFC3339, ts)
+	if err != nil {
+		return ts
+	}
+	d := now.Sub(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d/time.Minute))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d/time.Hour))
+	case d < 30*24*time.Hour:
+		return fmt.Sprintf("%dd ago", int(d/(24*time.Hour)))
+	default:
+		return t.Format("2006-01-02")
+	}
+}
+
 func formatLineInfo(t reviewThread) string {
 	if t.Path == "" {
 		return ""
@@ -383,85 +1184,47 @@ func formatLineInfo(t reviewThread) string {
 		parts = append(parts, fmt.Sprintf("%d-%d", *t.StartLine, *t.Line))
 	} else if t.Line != nil {
 		parts = append(parts, fmt.Sprintf("%d", *t.Line))
+	} else if t.IsOutdated && t.OriginalStart != nil && t.OriginalLine != nil && *t.OriginalStart != *t.OriginalLine {
+		parts = append(parts, fmt.Sprintf("%d-%d", *t.OriginalStart, *t.OriginalLine))
 	} else if t.OriginalLine != nil {
 		parts = append(parts, fmt.Sprintf("%d", *t.OriginalLine))
 	}
-	return fmt.Sprintf(" [%s]", strings.Join(parts, ":"))
-}
-
-func resolveBody(body, bodyFile string) (string, error) {
-	if body != "" && bodyFile != "" {
-		return "", errors.New("provide only one of --body or --body-file")
+	info := strings.Join(parts, ":")
+	if t.IsOutdated && t.OriginalCommit != nil && t.OriginalCommit.AbbreviatedOID != "" {
+		info += " @ " + t.OriginalCommit.AbbreviatedOID
 	}
-	if bodyFile == "" {
-		return body, nil
+	if t.IsOutdated {
+		info += " (outdated)"
 	}
-	data, err := os.ReadFile(bodyFile)
-	if err != nil {
-		return "", err
-	}
-	return string(data), nil
+	return fmt.Sprintf(" [%s]", info)
 }
 
-func replyToThread(ctx context.Context, client *github.Client, threadID, body string) error {
-	mutation := `mutation($threadId:ID!, $body:String!) {
-  addPullRequestReviewThreadReply(input:{pullRequestReviewThreadId:$threadId, body:$body}) {
-    comment { id }
-  }
-}`
-	vars := map[string]interface{}{
-		"threadId": threadID,
-		"body":     body,
-	}
-	var resp struct {
-		AddPullRequestReviewThreadReply struct {
-			Comment struct {
-				ID string `json:"id"`
-			} `json:"comment"`
-		} `json:"addPullRequestReviewThreadReply"`
-	}
-	if err := client.Do(ctx, mutation, vars, &resp); err != nil {
-		return err
-	}
-	fmt.Fprintf(os.Stdout, "replied with comment id %s\n", resp.AddPullRequestReviewThreadReply.Comment.ID)
-	return nil
+// exitError makes the process exit with a specific code. A nil err exits
+// without printing anything.
+type exitError struct {
+	code int
+	err  error
 }
 
-func setThreadResolved(ctx context.Context, client *github.Client, threadID string, resolved bool) error {
-	var mutation string
-	var op string
-	if resolved {
-		op = "resolveReviewThread"
-		mutation = `mutation($threadId:ID!) { resolveReviewThread(input:{threadId:$threadId}) { thread { id isResolved } } }`
-	} else {
-		op = "unresolveReviewThread"
-		mutation = `mutation($threadId:ID!) { unresolveReviewThread(input:{threadId:$threadId}) { thread { id isResolved } } }`
-	}
-	vars := map[string]interface{}{
-		"threadId": threadID,
-	}
-	var resp map[string]struct {
-		Thread struct {
-			ID         string `json:"id"`
-			IsResolved bool   `json:"isResolved"`
-		} `json:"thread"`
-	}
-	if err := client.Do(ctx, mutation, vars, &resp); err != nil {
-		return err
-	}
-	result, ok := resp[op]
-	if !ok {
-		return errors.New("missing mutation response")
+func (e *exitError) Error() string {
+	if e.err == nil {
+		return fmt.Sprintf("exit status %d", e.code)
 	}
-	state := "unresolved"
-	if result.Thread.IsResolved {
-		state = "resolved"
-	}
-	fmt.Fprintf(os.Stdout, "thread %s is now %s\n", result.Thread.ID, state)
-	return nil
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
 }
 
 func exitErr(err error) {
+	var ee *exitError
+	if errors.As(err, &ee) {
+		if ee.err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", ee.err)
+		}
+		os.Exit(ee.code)
+	}
 	fmt.Fprintf(os.Stderr, "error: %v\n", err)
 	os.Exit(1)
 }
@@ -474,8 +1237,14 @@ func newStyler(w io.Writer) styler {
 	if os.Getenv("NO_COLOR") != "" {
 		return styler{enabled: false}
 	}
+	switch configValue("color") {
+	case "always":
+		return styler{enabled: true}
+	case "never":
+		return styler{enabled: false}
+	}
 	if f, ok := w.(*os.File); ok {
-		return styler{enabled: term.IsTerminal(int(f.Fd()))}
+		return styler{enabled: term.IsTerminal(int(terminalFor(f).Fd()))}
 	}
 	return styler{enabled: false}
 }
@@ -502,6 +1271,14 @@ func (s styler) status(text string) string {
 	return s.wrap("31", text)
 }
 
+func (s styler) pending() string {
+	return s.wrap("33", "[pending – not yet submitted]")
+}
+
+func (s styler) outdated() string {
+	return s.wrap("2;38;5;208", "[outdated]") // dim orange
+}
+
 func (s styler) author(text string) string {
 	return s.wrap("34", text)
 }
@@ -510,6 +1287,19 @@ func (s styler) dim(text string) string {
 	return s.wrap("2", text)
 }
 
+// diffLine colours a unified diff line by its prefix.
+func (s styler) diffLine(line string) string {
+	switch {
+	case strings.HasPrefix(line, "@@"):
+		return s.wrap("36", line)
+	case strings.HasPrefix(line, "+"):
+		return s.wrap("32", line)
+	case strings.HasPrefix(line, "-"):
+		return s.wrap("31", line)
+	}
+	return line
+}
+
 func (s styler) bullet() string {
 	return s.wrap("2", "•")
 }
@@ -520,7 +1310,9 @@ func (s styler) separator() string {
 
 func formatCommentBody(body, indent string, width int, styler styler) []string {
 	if styler.enabled {
-		rendered, err := renderMarkdown(body, width-len(indent))
+		rendered, err := cachedRender(body, width-len(indent), func() (string, error) {
+			return renderMarkdown(body, width-len(indent))
+		})
 		if err == nil {
 			return indentRendered(rendered, indent)
 		}
@@ -528,12 +1320,41 @@ func formatCommentBody(body, indent string, width int, styler styler) []string {
 	return wrapPlainText(body, indent, width)
 }
 
+// truncateBodyLines keeps the first max display lines of a formatted body
+// and appends a marker with the number of hidden lines. Styling is reset and
+// open code fences are closed so nothing bleeds into the following output.
+func truncateBodyLines(lines []string, max int, indent, url string, styler styler) []string {
+	if max <= 0 || len(lines) <= max {
+		return lines
+	}
+	kept := append([]string{}, lines[:max]...)
+	if styler.enabled {
+		kept[len(kept)-1] += "\x1b[0m"
+	} else {
+		inFence := false
+		for _, line := range kept {
+			if strings.HasPrefix(strings.TrimSpace(line), "```") {
+				inFence = !inFence
+			}
+		}
+		if inFence {
+			kept = append(kept, indent+"```")
+		}
+	}
+	marker := fmt.Sprintf("… (+%d more lines", len(lines)-max)
+	if url != "" {
+		marker += ", see " + url
+	}
+	marker += ")"
+	return append(kept, indent+styler.dim(marker))
+}
+
 func renderMarkdown(body string, width int) (string, error) {
 	if width < 20 {
 		width = 20
 	}
 	renderer, err := glamour.NewTermRenderer(
-		glamour.WithAutoStyle(),
+		glamour.WithStandardStyle(markdownStyle()),
 		glamour.WithWordWrap(width),
 	)
 	if err != nil {
@@ -651,38 +1472,30 @@ func wrapText(text string, width int) []string {
 
 func printListUsage(w io.Writer) {
 	fmt.Fprintln(w, "Usage:")
-	fmt.Fprintln(w, "  gh-pr-review list [--pr <number>] [--repo owner/name] [--status all|resolved|unresolved|resolved-no-reply] [--host host] [--json]")
+	fmt.Fprintln(w, "  gh-pr-review list [--pr <number>] [--repo owner/name] [--status all|resolved|unresolved|resolved-no-reply] [--review id|index|none|list] [--since-commit sha|--current-diff] [--sort api|diff|path|newest|oldest-unresolved] [--max-lines N] [--exclude-bots|--only-bots] [--include-pr-comments] [--round latest|N|all] [--format text|table] [--no-ignore] [--no-notes] [--mark-all-read] [--theme style] [--no-render-cache] [--host host] [--json] [--count] [--exit-status]")
 	fmt.Fprintln(w, "")
 	fmt.Fprintln(w, "Flags:")
 	fmt.Fprintln(w, "  --pr <number>   PR number (defaults to current branch PR if available)")
 	fmt.Fprintln(w, "  --repo <owner/name>   Repository (defaults to gh repo view)")
 	fmt.Fprintln(w, "  --status <value>   all|resolved|unresolved|resolved-no-reply")
+	fmt.Fprintln(w, "  --review <value>   Only threads from a review (id, index from --review list, or none)")
+	fmt.Fprintln(w, "  --since-commit <sha>   Only threads on lines changed between <sha> and local HEAD")
+	fmt.Fprintln(w, "  --current-diff   Only threads on lines changed in the PR diff (local merge base with the PR base)")
+	fmt.Fprintln(w, "  --sort <order>   api (default), diff (changed-file order as on GitHub, then line), path (then line), newest (latest comment first) or oldest-unresolved")
+	fmt.Fprintln(w, "  --exclude-bots   Drop threads where every comment is from a bot")
+	fmt.Fprintln(w, "  --only-bots   Only threads where every comment is from a bot")
+	fmt.Fprintln(w, "  --include-pr-comments   Also show PR conversation comments (JSON: {threads, prComments}; not counted by --count/--exit-status)")
+	fmt.Fprintln(w, "  --format <f>   text (default) or table: one line per thread, fitted to the terminal width")
+	fmt.Fprintln(w, "  --no-ignore   Include threads on paths matched by .gh-pr-review-ignore")
+	fmt.Fprintln(w, "  --no-notes   Don't show notes saved with `gh-pr-review note`")
+	fmt.Fprintln(w, "  --mark-all-read   Mark the listed threads read, so the TUI only shows later comments as unread")
+	fmt.Fprintln(w, "  --round <r>   Only threads opened in review round latest, N or all; a round starts with the first push after a review")
+	fmt.Fprintln(w, "  --max-lines <n>   Truncate each comment body to n display lines (0 keeps full bodies)")
 	fmt.Fprintln(w, "  --json   Output JSON")
-	fmt.Fprintln(w, "  --host <host>   GitHub host")
-}
-
-func printReplyUsage(w io.Writer) {
-	fmt.Fprintln(w, "Usage:")
-	fmt.Fprintln(w, "  gh-pr-review reply --thread-id <id> --body <text> [--host host]")
-	fmt.Fprintln(w, "  gh-pr-review reply --thread-id <id> --body-file <path> [--host host]")
-	fmt.Fprintln(w, "")
-	fmt.Fprintln(w, "Flags:")
-	fmt.Fprintln(w, "  --thread-id <id>   Review thread ID (required)")
-	fmt.Fprintln(w, "  --body <text>   Reply body")
-	fmt.Fprintln(w, "  --body-file <path>   Read reply body from file")
-	fmt.Fprintln(w, "  --host <host>   GitHub host")
-}
-
-func printResolveUsage(w io.Writer, resolve bool) {
-	action := "resolve"
-	if !resolve {
-		action = "unresolve"
-	}
-	fmt.Fprintln(w, "Usage:")
-	fmt.Fprintf(w, "  gh-pr-review %s --thread-id <id> [--host host]\n", action)
-	fmt.Fprintln(w, "")
-	fmt.Fprintln(w, "Flags:")
-	fmt.Fprintln(w, "  --thread-id <id>   Review thread ID (required)")
+	fmt.Fprintln(w, "  --count   Print only the number of matching threads")
+	fmt.Fprintln(w, "  --exit-status   Exit 1 if any threads match, 0 if none, 2 or more if the check failed")
+	fmt.Fprintln(w, "  --theme <style>   Markdown style: auto, dark, light, notty, ... (auto honours GH_PR_REVIEW_BACKGROUND)")
+	fmt.Fprintln(w, "  --no-render-cache   Don't read or write the on-disk render cache")
 	fmt.Fprintln(w, "  --host <host>   GitHub host")
 }
 
@@ -0,0 +1,436 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"gh-pr-review/internal/github"
+
+	gitignore "github.com/sabhiram/go-gitignore"
+)
+
+func TestParseRepo(t *testing.T) {
+	cases := []struct {
+		input     string
+		owner     string
+		name      string
+		wantError string
+	}{
+		{input: "owner/name", owner: "owner", name: "name"},
+		{input: " owner / name ", owner: "owner", name: "name"},
+		{input: "owner/name/", owner: "owner", name: "name"},
+		{input: "my-org/my_repo.go", owner: "my-org", name: "my_repo.go"},
+		{input: "owner", wantError: "expected owner/name"},
+		{input: "", wantError: "expected owner/name"},
+		{input: "/name", wantError: "owner is empty"},
+		{input: "owner/", wantError: "name is empty"},
+		{input: "owner/ ", wantError: "name is empty"},
+		{input: "owner/name/extra", wantError: "too many segments"},
+		{input: "https://github.com/owner/name", wantError: "too many segments"},
+		{input: "own er/name", wantError: "owner \"own er\""},
+		{input: "owner/na me", wantError: "name \"na me\""},
+		{input: "owner/..", wantError: "name \"..\""},
+	}
+	for _, tc := range cases {
+		owner, name, err := parseRepo(tc.input)
+		if tc.wantError != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.wantError) {
+				t.Errorf("parseRepo(%q) error = %v, want containing %q", tc.input, err, tc.wantError)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseRepo(%q) unexpected error: %v", tc.input, err)
+			continue
+		}
+		if owner != tc.owner || name != tc.name {
+			t.Errorf("parseRepo(%q) = %q, %q; want %q, %q", tc.input, owner, name, tc.owner, tc.name)
+		}
+	}
+}
+
+func TestTruncateBodyLines(t *testing.T) {
+	plain := styler{}
+	lines := []string{"  one", "  ```go", "  code()", "  more()", "  ```", "  after"}
+
+	if got := truncateBodyLines(lines, 0, "  ", "", plain); len(got) != len(lines) {
+		t.Fatalf("expected no truncation with max 0, got %v", got)
+	}
+	if got := truncateBodyLines(lines, 10, "  ", "", plain); len(got) != len(lines) {
+		t.Fatalf("expected no truncation when under the limit, got %v", got)
+	}
+
+	got := truncateBodyLines(lines, 3, "  ", "https://example.com/c/1", plain)
+	want := []string{"  one", "  ```go", "  code()", "  ```", "  … (+3 more lines, see https://example.com/c/1)"}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+
+	got = truncateBodyLines(lines, 1, "  ", "", plain)
+	if got[len(got)-1] != "  … (+5 more lines)" || len(got) != 2 {
+		t.Fatalf("unexpected truncation without fence: %q", got)
+	}
+
+	styled := truncateBodyLines([]string{"\x1b[1ma", "b", "c"}, 1, "  ", "", styler{enabled: true})
+	if !strings.HasSuffix(styled[0], "\x1b[0m") {
+		t.Fatalf("expected styling reset after the last kept line, got %q", styled[0])
+	}
+}
+
+func TestFilterBotThreads(t *testing.T) {
+	comment := func(login, typ string) reviewComment {
+		return reviewComment{Author: actor{Login: login, Type: typ}}
+	}
+	threads := []reviewThread{
+		{ID: "bots", Comments: reviewThreadComment{Nodes: []reviewComment{comment("codecov", "Bot"), comment("dependabot[bot]", "")}}},
+		{ID: "mixed", Comments: reviewThreadComment{Nodes: []reviewComment{comment("codecov", "Bot"), comment("alice", "User")}}},
+		{ID: "human", Comments: reviewThreadComment{Nodes: []reviewComment{comment("bob", "User")}}},
+	}
+	if got := threadIDs(filterBotThreads(threads, false)); strings.Join(got, ",") != "mixed,human" {
+		t.Fatalf("exclude bots: got %v", got)
+	}
+	if got := threadIDs(filterBotThreads(threads, true)); strings.Join(got, ",") != "bots" {
+		t.Fatalf("only bots: got %v", got)
+	}
+}
+
+func TestPrintThreadTableFitsWidth(t *testing.T) {
+	thread := reviewThread{ID: "a", Path: "internal/server/handler.go", Line: intPtr(42)}
+	thread.Comments.Nodes = []reviewComment{
+		{Body: "\nPlease handle the error returned by Close here, otherwise we leak the descriptor", Author: actor{Login: "alice"}},
+		{Body: "Done", Author: actor{Login: "bob"}},
+	}
+	var buf bytes.Buffer
+	printThreadTable(&buf, []reviewThread{thread}, 100, styler{})
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected header and one row, got %q", buf.String())
+	}
+	row := lines[1]
+	if n := utf8.RuneCountInString(row); n > 100 {
+		t.Fatalf("row is %d runes wide: %q", n, row)
+	}
+	for _, want := range []string{"1  unresolved", "internal/server/handler.go:42", "bob", "Please handle", "…"} {
+		if !strings.Contains(row, want) {
+			t.Errorf("row %q missing %q", row, want)
+		}
+	}
+}
+
+func TestSplitIgnored(t *testing.T) {
+	matcher := gitignore.CompileIgnoreLines("*.snap", "vendor/", "!vendor/keep.go", "/package-lock.json")
+	threads := []reviewThread{
+		{ID: "snap", Path: "ui/__snapshots__/button.snap"},
+		{ID: "vendored", Path: "vendor/lib/a.go"},
+		{ID: "lock", Path: "package-lock.json"},
+		{ID: "nested-lock", Path: "web/package-lock.json"},
+		{ID: "code", Path: "main.go"},
+		{ID: "pr-level"},
+	}
+	kept, ignored := splitIgnored(threads, matcher)
+	if got := strings.Join(threadIDs(kept), ","); got != "nested-lock,code,pr-level" {
+		t.Fatalf("kept %s", got)
+	}
+	if ignored != 3 {
+		t.Fatalf("expected 3 ignored, got %d", ignored)
+	}
+	if kept, ignored := splitIgnored(threads, nil); len(kept) != len(threads) || ignored != 0 {
+		t.Fatal("expected no matcher to keep everything")
+	}
+}
+
+func TestFormatLineInfo(t *testing.T) {
+	commit := &commitRef{OID: "abc1234def", AbbreviatedOID: "abc1234"}
+	cases := []struct {
+		name   string
+		thread reviewThread
+		want   string
+	}{
+		{"no path", reviewThread{}, ""},
+		{"single line", reviewThread{Path: "a.go", Line: intPtr(12)}, " [a.go:12]"},
+		{"range", reviewThread{Path: "a.go", StartLine: intPtr(10), Line: intPtr(12)}, " [a.go:10-12]"},
+		{"outdated single line", reviewThread{Path: "a.go", IsOutdated: true, OriginalLine: intPtr(12), OriginalCommit: commit}, " [a.go:12 @ abc1234 (outdated)]"},
+		{"outdated range", reviewThread{Path: "a.go", IsOutdated: true, OriginalStart: intPtr(10), OriginalLine: intPtr(12)}, " [a.go:10-12 (outdated)]"},
+		{"outdated still anchored", reviewThread{Path: "a.go", IsOutdated: true, Line: intPtr(20), OriginalStart: intPtr(10), OriginalLine: intPtr(12), OriginalCommit: commit}, " [a.go:20 @ abc1234 (outdated)]"},
+		{"current thread ignores commit", reviewThread{Path: "a.go", Line: intPtr(12), OriginalCommit: commit}, " [a.go:12]"},
+	}
+	for _, tc := range cases {
+		if got := formatLineInfo(tc.thread); got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestThreadBadgesOutdated(t *testing.T) {
+	s := styler{}
+	if got := threadBadges(reviewThread{IsOutdated: true, CanReply: true}, s); got != " [outdated]" {
+		t.Errorf("got %q", got)
+	}
+	if got := threadBadges(reviewThread{CanReply: true}, s); got != "" {
+		t.Errorf("expected no badge for a current thread, got %q", got)
+	}
+	if got := threadBadges(reviewThread{Path: "a.go", IsOutdated: true, CanReply: true}, s); got != "" {
+		t.Errorf("expected the location to carry the outdated marker, got %q", got)
+	}
+	if got := threadBadges(reviewThread{IsOutdated: true, CanReply: true}, styler{enabled: true}); !strings.Contains(got, "\x1b[2;38;5;208m[outdated]") {
+		t.Errorf("expected dim orange badge, got %q", got)
+	}
+}
+
+func TestFilterByAuthorAndPath(t *testing.T) {
+	thread := func(id, path, author string) reviewThread {
+		t := reviewThread{ID: id, Path: path}
+		t.Comments.Nodes = []reviewComment{{Author: actor{Login: author}}, {Author: actor{Login: "me"}}}
+		return t
+	}
+	threads := []reviewThread{
+		thread("a", "internal/git/git.go", "alice"),
+		thread("b", "main.go", "Alice"),
+		thread("c", "internal/gitx/x.go", "bob"),
+		thread("d", "docs/README.md", "bob"),
+	}
+	if got := strings.Join(threadIDs(filterByAuthor(threads, "@alice")), ","); got != "a,b" {
+		t.Errorf("author: got %s", got)
+	}
+	cases := map[string]string{
+		"internal/git":  "a",
+		"internal/git/": "a",
+		"*.go":          "a,b,c",
+		"internal/*/*":  "a,c",
+		"main.go":       "b",
+		"*.md":          "d",
+	}
+	for pattern, want := range cases {
+		if got := strings.Join(threadIDs(filterByPath(threads, pattern)), ","); got != want {
+			t.Errorf("path %q: got %s, want %s", pattern, got, want)
+		}
+	}
+}
+
+func TestFilterOutdated(t *testing.T) {
+	threads := []reviewThread{
+		{ID: "a", IsOutdated: true},
+		{ID: "b"},
+		{ID: "c", IsOutdated: true},
+	}
+	got := filterOutdated(threads)
+	if len(got) != 2 || got[0].ID != "a" || got[1].ID != "c" {
+		t.Fatalf("filterOutdated = %+v, want a and c", got)
+	}
+}
+
+func TestFilterByLine(t *testing.T) {
+	n := func(v int) *int { return &v }
+	threads := []reviewThread{
+		{ID: "single", Line: n(88)},
+		{ID: "range", StartLine: n(80), Line: n(90)},
+		{ID: "elsewhere", Line: n(12)},
+		{ID: "outdated", IsOutdated: true, OriginalStart: n(86), OriginalLine: n(88)},
+		{ID: "file", Path: "README.md"},
+	}
+	got := filterByLine(threads, 88)
+	var ids []string
+	for _, th := range got {
+		ids = append(ids, th.ID)
+	}
+	if strings.Join(ids, ",") != "single,range,outdated" {
+		t.Fatalf("filterByLine(88) = %v", ids)
+	}
+	if got := filterByLine(threads, 81); len(got) != 1 || got[0].ID != "range" {
+		t.Fatalf("filterByLine(81) = %+v, want only range", got)
+	}
+}
+
+func TestFilterByResolverAndSince(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	thread := func(id, resolver, last string) reviewThread {
+		th := reviewThread{ID: id, IsResolved: resolver != ""}
+		if resolver != "" {
+			th.ResolvedBy = &actor{Login: resolver}
+		}
+		th.Comments.Nodes = []reviewComment{{CreatedAt: "2026-10-01T00:00:00Z"}, {CreatedAt: last}}
+		return th
+	}
+	threads := []reviewThread{
+		thread("bot-recent", "Some-Bot", "2026-10-17T06:00:00Z"),
+		thread("bot-old", "some-bot", "2026-10-10T06:00:00Z"),
+		thread("human", "alice", "2026-10-17T06:00:00Z"),
+		thread("open", "", "2026-10-17T06:00:00Z"),
+	}
+	got := filterByResolver(threads, "some-bot")
+	if len(got) != 2 || got[0].ID != "bot-recent" || got[1].ID != "bot-old" {
+		t.Fatalf("filterByResolver = %+v", got)
+	}
+	got = filterActiveSince(got, now.Add(-24*time.Hour))
+	if len(got) != 1 || got[0].ID != "bot-recent" {
+		t.Fatalf("filterActiveSince = %+v", got)
+	}
+}
+
+func TestStylerDiffLine(t *testing.T) {
+	s := styler{enabled: true}
+	cases := map[string]string{
+		"@@ -1,2 +1,3 @@": "\x1b[36m@@ -1,2 +1,3 @@\x1b[0m",
+		"+added":          "\x1b[32m+added\x1b[0m",
+		"-removed":        "\x1b[31m-removed\x1b[0m",
+		" context":        " context",
+	}
+	for in, want := range cases {
+		if got := s.diffLine(in); got != want {
+			t.Errorf("diffLine(%q) = %q, want %q", in, got, want)
+		}
+	}
+	if got := (styler{}).diffLine("+added"); got != "+added" {
+		t.Errorf("plain diffLine = %q", got)
+	}
+}
+
+func TestPRFilesURL(t *testing.T) {
+	if got := prFilesURL("github.com", "owner", "repo", 42); got != "https://github.com/owner/repo/pull/42/files" {
+		t.Fatalf("prFilesURL = %q", got)
+	}
+	if got := prFilesURL("ghe.example.com", "owner", "repo", 7); got != "https://ghe.example.com/owner/repo/pull/7/files" {
+		t.Fatalf("prFilesURL = %q", got)
+	}
+}
+
+func TestApplySuggestionDryRunAndOutdated(t *testing.T) {
+	root := t.TempDir()
+	original := "a\nb\nc\n"
+	if err := os.WriteFile(filepath.Join(root, "f.txt"), []byte(original), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := reviewSuggestion{
+		ThreadID:   "T1",
+		Path:       "f.txt",
+		StartLine:  2,
+		Line:       2,
+		Suggestion: "B\n",
+		Status:     suggestionApplicable,
+		localStart: 2,
+	}
+	var buf bytes.Buffer
+	if err := applySuggestion(context.Background(), &buf, root, &s, true, map[string][]string{}); err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if !strings.Contains(buf.String(), "-b\n+B\n") {
+		t.Fatalf("dry run diff = %q", buf.String())
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "f.txt")); string(data) != original {
+		t.Fatalf("dry run wrote the file: %q", data)
+	}
+
+	s.IsOutdated = true
+	if err := applySuggestion(context.Background(), &buf, root, &s, true, nil); err == nil || !strings.Contains(err.Error(), "outdated") {
+		t.Fatalf("expected outdated thread to be refused, got %v", err)
+	}
+}
+
+func TestEditorJumpArgs(t *testing.T) {
+	cases := []struct {
+		editor string
+		want   string
+		ok     bool
+	}{
+		{"vim", "+12 a.go", true},
+		{"/usr/local/bin/nvim", "+12 a.go", true},
+		{"code --wait", "-g a.go:12", true},
+		{"subl -w", "a.go:12", true},
+		{`"C:\Program Files\Microsoft VS Code\bin\code.cmd" --wait`, "-g a.go:12", true},
+		{`C:\tools\NVIM.EXE`, "+12 a.go", true},
+		{"ed", "", false},
+	}
+	for _, c := range cases {
+		got, ok := editorJumpArgs(c.editor, "a.go", 12)
+		if ok != c.ok || strings.Join(got, " ") != c.want {
+			t.Errorf("editorJumpArgs(%q) = %q, %v; want %q, %v", c.editor, got, ok, c.want, c.ok)
+		}
+	}
+	if got, ok := editorJumpArgs("ed", "a.go", 0); !ok || strings.Join(got, " ") != "a.go" {
+		t.Errorf("without a line, any editor should just open the file; got %q, %v", got, ok)
+	}
+}
+
+func TestEditorWords(t *testing.T) {
+	cases := map[string][]string{
+		"vim":         {"vim"},
+		"code --wait": {"code", "--wait"},
+		`"C:\Program Files\Sublime Text\subl.exe" -w`: {`C:\Program Files\Sublime Text\subl.exe`, "-w"},
+		`notepad++ 'a b'`:    {"notepad++", "a b"},
+		"  emacsclient  -t ": {"emacsclient", "-t"},
+	}
+	for input, want := range cases {
+		if got := editorWords(input); !reflect.DeepEqual(got, want) {
+			t.Errorf("editorWords(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestThreadHunkFetchedOnce(t *testing.T) {
+	if strings.Contains(commentFields(nil), "diffHunk") {
+		t.Error("commentFields selects diffHunk on every comment")
+	}
+	var thread reviewThread
+	data := `{"id":"T1","comments":{"nodes":[{"id":"C1"},{"id":"C2"}]},"hunk":{"nodes":[{"diffHunk":"@@ -1 +1 @@\n-a\n+b"}]}}`
+	if err := json.Unmarshal([]byte(data), &thread); err != nil {
+		t.Fatal(err)
+	}
+	annotateThread(&thread)
+	for _, c := range thread.Comments.Nodes {
+		if c.DiffHunk != "@@ -1 +1 @@\n-a\n+b" {
+			t.Errorf("comment %s hunk = %q", c.ID, c.DiffHunk)
+		}
+	}
+}
+
+func TestFetchReviewsKeepsThreadlessReviews(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":{"repository":{"pullRequest":{"reviews":{"pageInfo":{"hasNextPage":false},"nodes":[
+			{"id":"pending","state":"PENDING","author":{"login":"me"},"submittedAt":null},
+			{"id":"bodyOnly","state":"APPROVED","author":{"login":"bob"},"submittedAt":"2024-01-02T00:00:00Z"},
+			{"id":"first","state":"COMMENTED","author":{"login":"alice"},"submittedAt":"2024-01-01T00:00:00Z"},
+			{"id":"third","state":"COMMENTED","author":{"login":"alice"},"submittedAt":"2024-01-03T00:00:00Z"}
+		]}}}}}`)
+	}))
+	defer srv.Close()
+	client := github.NewClient(srv.URL+"/api/graphql", "token")
+
+	reviews, err := fetchReviews(context.Background(), client, "o", "r", 1)
+	if err != nil {
+		t.Fatalf("fetchReviews: %v", err)
+	}
+	var ids []string
+	for _, r := range reviews {
+		ids = append(ids, r.ID)
+	}
+	if got := strings.Join(ids, ","); got != "first,bodyOnly,third,pending" {
+		t.Fatalf("reviews = %s, want submission order with the pending review last", got)
+	}
+
+	thread := func(id, review string) reviewThread {
+		th := reviewThread{ID: id}
+		th.Comments.Nodes = []reviewComment{{PullRequestReview: &pullRequestReview{ID: review}}}
+		return th
+	}
+	threads := []reviewThread{thread("a", "first"), thread("b", "third")}
+	for selector, want := range map[string]string{"1": "a", "2": "", "3": "b"} {
+		got, err := filterByReview(threads, reviews, selector)
+		if err != nil {
+			t.Fatalf("--review %s: %v", selector, err)
+		}
+		if ids := strings.Join(threadIDs(got), ","); ids != want {
+			t.Errorf("--review %s = %q, want %q", selector, ids, want)
+		}
+	}
+}
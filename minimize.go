@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"gh-pr-review/internal/gh"
+	"gh-pr-review/internal/github"
+)
+
+// errMinimizeForbidden replaces GitHub's message when hiding a comment is
+// refused.
+var errMinimizeForbidden = errors.New("you don't have permission to hide this comment; minimizing other people's comments needs write access to the repository")
+
+// minimizeReasons maps --reason values to GitHub's ReportedContentClassifiers.
+var minimizeReasons = []struct {
+	name       string
+	classifier string
+}{
+	{"off_topic", "OFF_TOPIC"},
+	{"outdated", "OUTDATED"},
+	{"resolved", "RESOLVED"},
+	{"duplicate", "DUPLICATE"},
+	{"spam", "SPAM"},
+	{"abuse", "ABUSE"},
+}
+
+func minimizeReasonNames() []string {
+	var names []string
+	for _, r := range minimizeReasons {
+		names = append(names, r.name)
+	}
+	return names
+}
+
+// parseMinimizeReason accepts a reason case-insensitively, with - or _ (or
+// a space) between words.
+func parseMinimizeReason(value string) (string, error) {
+	v := strings.ToLower(strings.TrimSpace(value))
+	v = strings.NewReplacer("-", "_", " ", "_").Replace(v)
+	for _, r := range minimizeReasons {
+		if v == r.name {
+			return r.classifier, nil
+		}
+	}
+	return "", fmt.Errorf("invalid --reason %q (expected %s)", value, strings.Join(minimizeReasonNames(), "|"))
+}
+
+type minimizeResult struct {
+	CommentID       string `json:"commentId"`
+	IsMinimized     bool   `json:"isMinimized"`
+	MinimizedReason string `json:"minimizedReason,omitempty"`
+}
+
+type minimizeFlags struct {
+	// minimize is set for minimize, which also takes --reason.
+	minimize   bool
+	commentID  string
+	commentURL string
+	repo       string
+	pr         int
+	reason     string
+	jsonOut    bool
+	host       string
+}
+
+func (o *minimizeFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.commentID, "comment-id", "", "comment node ID (or numeric review comment ID)")
+	fs.StringVar(&o.commentURL, "url", "", "comment URL or numeric review comment ID")
+	fs.StringVar(&o.repo, "repo", "", "owner/name for a numeric ID (defaults to gh repo view)")
+	fs.IntVar(&o.pr, "pr", 0, "PR number for a numeric ID")
+	if o.minimize {
+		fs.StringVar(&o.reason, "reason", "", strings.Join(minimizeReasonNames(), "|"))
+	}
+	fs.BoolVar(&o.jsonOut, "json", false, "output JSON")
+	fs.StringVar(&o.host, "host", gh.DefaultHost(), "GitHub host")
+}
+
+func runMinimize(args []string, minimize bool) error {
+	action := "minimize"
+	printUsage := printMinimizeUsage
+	if !minimize {
+		action = "unminimize"
+		printUsage = printUnminimizeUsage
+	}
+	fs := newFlagSet(action, printUsage)
+	flags := minimizeFlags{minimize: minimize}
+	flags.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+	if flags.commentID != "" && flags.commentURL != "" {
+		return errors.New("provide only one of --comment-id or --url")
+	}
+	if flags.commentID == "" && flags.commentURL == "" {
+		return errors.New("--comment-id or --url is required")
+	}
+	var classifier string
+	if minimize {
+		if flags.reason == "" {
+			return fmt.Errorf("--reason is required (%s)", strings.Join(minimizeReasonNames(), "|"))
+		}
+		var err error
+		if classifier, err = parseMinimizeReason(flags.reason); err != nil {
+			return err
+		}
+	}
+	var refs []commentRef
+	if flags.commentURL != "" {
+		ref, err := parseCommentRef(flags.commentURL)
+		if err != nil {
+			return err
+		}
+		refs = append(refs, ref)
+	}
+
+	ctx := context.Background()
+	client, err := newClient(ctx, commentRefHost(refs, flags.host))
+	if err != nil {
+		return err
+	}
+	var id string
+	if len(refs) > 0 && refs[0].issueComment {
+		id, err = prCommentID(ctx, client, refs[0])
+	} else {
+		var ref *commentRef
+		if len(refs) > 0 {
+			ref = &refs[0]
+		}
+		id, err = reviewCommentID(ctx, client, flags.commentID, ref, flags.repo, flags.pr)
+	}
+	if err != nil {
+		return err
+	}
+	result, err := setMinimized(ctx, client, id, classifier, minimize)
+	if err != nil {
+		return err
+	}
+	if flags.jsonOut {
+		return writeJSON(os.Stdout, result)
+	}
+	if result.IsMinimized {
+		fmt.Fprintf(os.Stdout, "comment %s is minimized (%s)\n", result.CommentID, strings.ToLower(result.MinimizedReason))
+	} else {
+		fmt.Fprintf(os.Stdout, "comment %s is not minimized\n", result.CommentID)
+	}
+	return nil
+}
+
+// prCommentID looks up the node ID of a PR conversation comment from its
+// #issuecomment-<id> URL.
+func prCommentID(ctx context.Context, client *github.Client, ref commentRef) (string, error) {
+	comments, err := fetchPRComments(ctx, client, ref.owner, ref.name, ref.pr)
+	if err != nil {
+		return "", err
+	}
+	for _, c := range comments {
+		if strconv.FormatInt(c.DatabaseID, 10) == ref.id {
+			return c.ID, nil
+		}
+	}
+	return "", fmt.Errorf("comment %s not found on %s/%s#%d", ref.id, ref.owner, ref.name, ref.pr)
+}
+
+// setMinimized hides or unhides a comment and returns its state afterwards.
+func setMinimized(ctx context.Context, client *github.Client, id, classifier string, minimize bool) (minimizeResult, error) {
+	op, field := "unminimizeComment", "unminimizedComment"
+	input := "{subjectId:$id}"
+	vars := map[string]interface{}{"id": id}
+	params := "$id:ID!"
+	if minimize {
+		op, field = "minimizeComment", "minimizedComment"
+		input = "{subjectId:$id, classifier:$classifier}"
+		params += ", $classifier:ReportedContentClassifiers!"
+		vars["classifier"] = classifier
+	}
+	mutation := `mutation(` + params + `) {
+  ` + op + `(input:` + input + `) {
+    ` + field + ` { isMinimized minimizedReason }
+  }
+}`
+	var resp map[string]map[string]struct {
+		IsMinimized     bool   `json:"isMinimized"`
+		MinimizedReason string `json:"minimizedReason"`
+	}
+	err := client.Do(ctx, mutation, vars, &resp)
+	switch {
+	case github.NotFound(err):
+		return minimizeResult{}, fmt.Errorf("comment %s not found", id)
+	case github.Forbidden(err):
+		return minimizeResult{}, errMinimizeForbidden
+	case err != nil:
+		return minimizeResult{}, err
+	}
+	state := resp[op][field]
+	return minimizeResult{CommentID: id, IsMinimized: state.IsMinimized, MinimizedReason: state.MinimizedReason}, nil
+}
+
+func printMinimizeUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage:")
+	fmt.Fprintln(w, "  gh-pr-review minimize --comment-id <id> --reason <reason> [--json] [--host host]")
+	fmt.Fprintln(w, "  gh-pr-review minimize --url <comment-url|comment-id> [--pr <number>] [--repo owner/name] --reason <reason> [--json] [--host host]")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Hides a review comment or PR conversation comment behind a \"This comment was marked as ...\" notice.")
+	fmt.Fprintln(w, "Hiding other people's comments needs write access to the repository.")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Flags:")
+	fmt.Fprintln(w, "  --comment-id <id>   Comment node ID (PRRC_..., IC_...), or a numeric review comment ID with --pr")
+	fmt.Fprintln(w, "  --url <url>   Comment URL (#discussion_r123 or #issuecomment-123), or a numeric review comment ID with --pr")
+	fmt.Fprintln(w, "  --pr <number>   PR for a numeric ID (defaults to current branch PR if available)")
+	fmt.Fprintln(w, "  --repo <owner/name>   Repository for a numeric ID (defaults to gh repo view)")
+	fmt.Fprintln(w, "  --reason <reason>   off_topic, outdated, resolved, duplicate, spam or abuse")
+	fmt.Fprintln(w, "  --json   Print {commentId, isMinimized, minimizedReason}")
+	fmt.Fprintln(w, "  --host <host>   GitHub host")
+}
+
+func printUnminimizeUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage:")
+	fmt.Fprintln(w, "  gh-pr-review unminimize --comment-id <id> [--json] [--host host]")
+	fmt.Fprintln(w, "  gh-pr-review unminimize --url <comment-url|comment-id> [--pr <number>] [--repo owner/name] [--json] [--host host]")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Shows a comment that was minimized again.")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Flags:")
+	fmt.Fprintln(w, "  --comment-id <id>   Comment node ID (PRRC_..., IC_...), or a numeric review comment ID with --pr")
+	fmt.Fprintln(w, "  --url <url>   Comment URL (#discussion_r123 or #issuecomment-123), or a numeric review comment ID with --pr")
+	fmt.Fprintln(w, "  --pr <number>   PR for a numeric ID (defaults to current branch PR if available)")
+	fmt.Fprintln(w, "  --repo <owner/name>   Repository for a numeric ID (defaults to gh repo view)")
+	fmt.Fprintln(w, "  --json   Print {commentId, isMinimized, minimizedReason}")
+	fmt.Fprintln(w, "  --host <host>   GitHub host")
+}
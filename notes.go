@@ -0,0 +1,228 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// threadNote is a private annotation on a thread; it never leaves the
+// notes file.
+type threadNote struct {
+	Text    string `json:"text"`
+	Updated string `json:"updated"`
+}
+
+// notesPath is the notes file: notes_file from the config, or notes.json
+// in the user cache directory, which stays on this machine.
+func notesPath() (string, error) {
+	if path := configValue("notes_file"); path != "" {
+		if rest, ok := strings.CutPrefix(path, "~/"); ok {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return "", err
+			}
+			path = filepath.Join(home, rest)
+		}
+		return path, nil
+	}
+	dir, err := cacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "notes.json"), nil
+}
+
+// loadNotes reads the notes file, keyed by thread ID. A missing file has no
+// notes; an unreadable one is an error so that set and clear never
+// overwrite notes they couldn't parse.
+func loadNotes(path string) (map[string]threadNote, error) {
+	notes := map[string]threadNote{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return notes, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &notes); err != nil {
+		return nil, fmt.Errorf("reading notes from %s: %w", path, err)
+	}
+	return notes, nil
+}
+
+// saveNotes writes the notes file through a temporary file, so a synced
+// or shared notes file is never seen half-written.
+func saveNotes(path string, notes map[string]threadNote) error {
+	data, err := json.MarshalIndent(notes, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".notes-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// threadNotes loads the notes for display alongside threads. Problems are
+// a warning: a broken notes file shouldn't stop list or the TUI.
+func threadNotes() map[string]threadNote {
+	path, err := notesPath()
+	if err == nil {
+		var notes map[string]threadNote
+		if notes, err = loadNotes(path); err == nil {
+			return notes
+		}
+	}
+	fmt.Fprintf(os.Stderr, "warning: notes not shown: %v\n", err)
+	return nil
+}
+
+// noteLine renders a note as the single dim line shown under a thread's
+// header.
+func noteLine(note threadNote, styler styler) string {
+	text := strings.Join(strings.Fields(note.Text), " ")
+	return styler.dim("✎ note: " + text)
+}
+
+type noteFlags struct {
+	threadID string
+	text     string
+}
+
+func (o *noteFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.threadID, "thread-id", "", "Review thread ID")
+	fs.StringVar(&o.text, "text", "", "the note (with set)")
+}
+
+func runNote(args []string) error {
+	fs := newFlagSet("note", printNoteUsage)
+	var flags noteFlags
+	flags.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	// Allow flags after the action too: note set --thread-id X --text ...
+	var rest []string
+	for remaining := fs.Args(); len(remaining) > 0; remaining = fs.Args() {
+		rest = append(rest, remaining[0])
+		if err := parseFlags(fs, remaining[1:]); err != nil {
+			return err
+		}
+	}
+	if len(rest) == 0 {
+		return errors.New("provide an action: set, show or clear")
+	}
+	if len(rest) > 1 {
+		return fmt.Errorf("unexpected arguments: %v", rest[1:])
+	}
+	path, err := notesPath()
+	if err != nil {
+		return err
+	}
+	notes, err := loadNotes(path)
+	if err != nil {
+		return err
+	}
+	switch rest[0] {
+	case "set":
+		if flags.threadID == "" {
+			return errors.New("--thread-id is required")
+		}
+		if strings.TrimSpace(flags.text) == "" {
+			return errors.New("--text is required")
+		}
+		notes[flags.threadID] = threadNote{Text: flags.text, Updated: time.Now().UTC().Format(time.RFC3339)}
+		if err := saveNotes(path, notes); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "saved note for %s\n", flags.threadID)
+		return nil
+	case "show":
+		if flags.text != "" {
+			return errors.New("--text only applies to set")
+		}
+		if flags.threadID != "" {
+			note, ok := notes[flags.threadID]
+			if !ok {
+				return fmt.Errorf("no note for thread %s", flags.threadID)
+			}
+			fmt.Fprintln(os.Stdout, note.Text)
+			return nil
+		}
+		printNotes(os.Stdout, notes)
+		return nil
+	case "clear":
+		if flags.text != "" {
+			return errors.New("--text only applies to set")
+		}
+		if flags.threadID == "" {
+			return errors.New("--thread-id is required")
+		}
+		if _, ok := notes[flags.threadID]; !ok {
+			return fmt.Errorf("no note for thread %s", flags.threadID)
+		}
+		delete(notes, flags.threadID)
+		if err := saveNotes(path, notes); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "cleared note for %s\n", flags.threadID)
+		return nil
+	default:
+		return fmt.Errorf("unknown note action %q (expected set, show or clear)", rest[0])
+	}
+}
+
+func printNotes(w io.Writer, notes map[string]threadNote) {
+	if len(notes) == 0 {
+		fmt.Fprintln(w, "no notes")
+		return
+	}
+	ids := make([]string, 0, len(notes))
+	for id := range notes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		fmt.Fprintf(w, "%s: %s\n", id, notes[id].Text)
+	}
+}
+
+func printNoteUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage:")
+	fmt.Fprintln(w, "  gh-pr-review note set --thread-id <id> --text <note>")
+	fmt.Fprintln(w, "  gh-pr-review note show [--thread-id <id>]")
+	fmt.Fprintln(w, "  gh-pr-review note clear --thread-id <id>")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Keeps private notes on threads; nothing is posted to GitHub. list and tui show a thread's")
+	fmt.Fprintln(w, "note under its header unless --no-notes is given. Notes are stored in notes.json in the user")
+	fmt.Fprintln(w, "cache directory, which stays on this machine; set notes_file in the config to keep them")
+	fmt.Fprintln(w, "somewhere else, such as a synced folder.")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Flags:")
+	fmt.Fprintln(w, "  --thread-id <id>   Review thread ID (show without it lists every note)")
+	fmt.Fprintln(w, "  --text <note>   The note to save, replacing any earlier one")
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNotesRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes", "notes.json")
+	notes, err := loadNotes(path)
+	if err != nil || len(notes) != 0 {
+		t.Fatalf("loadNotes of a missing file = %v, %v", notes, err)
+	}
+	notes["PRRT_1"] = threadNote{Text: "waiting on perf numbers"}
+	if err := saveNotes(path, notes); err != nil {
+		t.Fatal(err)
+	}
+	got, err := loadNotes(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got["PRRT_1"].Text != "waiting on perf numbers" {
+		t.Errorf("loaded %v", got)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("expected only the notes file, found %d entries", len(entries))
+	}
+}
+
+func TestLoadNotesRejectsCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadNotes(path); err == nil {
+		t.Error("expected an error so set doesn't overwrite the file")
+	}
+}
+
+func TestNotesPathFromConfig(t *testing.T) {
+	saved := userConfig
+	defer func() { userConfig = saved }()
+	userConfig, _ = mustParseConfig(t, "notes_file: ~/sync/notes.json\n")
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip(err)
+	}
+	got, err := notesPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(home, "sync", "notes.json"); got != want {
+		t.Errorf("notesPath() = %q, want %q", got, want)
+	}
+}
+
+func TestNoteLineIsOneLine(t *testing.T) {
+	got := noteLine(threadNote{Text: "waiting on\n  perf numbers"}, styler{})
+	if got != "✎ note: waiting on perf numbers" {
+		t.Errorf("noteLine = %q", got)
+	}
+}
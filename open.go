@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"gh-pr-review/internal/gh"
+)
+
+type openFlags struct {
+	threadID  string
+	repo      string
+	pr        int
+	index     int
+	printOnly bool
+	host      string
+}
+
+func (o *openFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.threadID, "thread-id", "", "Review thread ID")
+	fs.StringVar(&o.repo, "repo", "", "owner/name (defaults to gh repo view)")
+	fs.IntVar(&o.pr, "pr", 0, "PR number (defaults to current branch PR if available)")
+	fs.IntVar(&o.index, "index", 0, "thread index from the last list of the PR")
+	fs.BoolVar(&o.printOnly, "print", false, "print the URL instead of opening it")
+	fs.StringVar(&o.host, "host", gh.DefaultHost(), "GitHub host")
+}
+
+func runOpen(args []string) error {
+	fs := newFlagSet("open", printOpenUsage)
+	var flags openFlags
+	flags.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+	switch {
+	case flags.threadID != "" && flags.index != 0:
+		return errors.New("provide only one of --thread-id or --index")
+	case flags.index < 0:
+		return fmt.Errorf("invalid --index %d", flags.index)
+	}
+
+	ctx := context.Background()
+	var url string
+	if flags.threadID == "" && flags.index == 0 {
+		pr, err := resolvePR(ctx, flags.pr)
+		if err != nil {
+			return err
+		}
+		owner, name, err := resolveRepo(ctx, flags.repo)
+		if err != nil {
+			return err
+		}
+		url = prFilesURL(flags.host, owner, name, pr)
+	} else {
+		if flags.index != 0 {
+			ids, err := threadIDsForIndexes(ctx, flags.host, flags.repo, flags.pr, []int{flags.index})
+			if err != nil {
+				return err
+			}
+			flags.threadID = ids[0]
+		}
+		client, err := newClient(ctx, flags.host)
+		if err != nil {
+			return err
+		}
+		thread, err := fetchThread(ctx, client, flags.threadID)
+		if err != nil {
+			return err
+		}
+		if len(thread.Comments.Nodes) == 0 || thread.Comments.Nodes[0].URL == "" {
+			return fmt.Errorf("thread %s has no comment URL to open", flags.threadID)
+		}
+		url = thread.Comments.Nodes[0].URL
+	}
+
+	if flags.printOnly {
+		fmt.Fprintln(os.Stdout, url)
+		return nil
+	}
+	fmt.Fprintf(os.Stderr, "Opening %s in your browser.\n", url)
+	if err := openBrowser(ctx, url); err != nil {
+		if os.Getenv("BROWSER") != "" {
+			return err
+		}
+		// Without $BROWSER there may be no opener at all (SSH sessions,
+		// containers), so hand the URL over instead of failing.
+		fmt.Fprintf(os.Stderr, "%v; set $BROWSER to choose a browser\n", err)
+		fmt.Fprintln(os.Stdout, url)
+	}
+	return nil
+}
+
+// prFilesURL is the "Files changed" tab of a pull request.
+func prFilesURL(host, owner, name string, pr int) string {
+	if host == "" {
+		host = "github.com"
+	}
+	return fmt.Sprintf("https://%s/%s/%s/pull/%d/files", host, owner, name, pr)
+}
+
+func printOpenUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage:")
+	fmt.Fprintln(w, "  gh-pr-review open --thread-id <id> [--print] [--host host]")
+	fmt.Fprintln(w, "  gh-pr-review open [--pr <number>] [--repo owner/name] [--index n] [--print] [--host host]")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Flags:")
+	fmt.Fprintln(w, "  --thread-id <id>   Open the thread's first comment")
+	fmt.Fprintln(w, "  --pr <number>   PR number (defaults to current branch PR if available); on its own opens the \"Files changed\" tab")
+	fmt.Fprintln(w, "  --repo <owner/name>   Repository (defaults to gh repo view)")
+	fmt.Fprintln(w, "  --index <n>   Open thread n from the last `list` of the PR")
+	fmt.Fprintln(w, "  --print   Print the URL instead of opening it")
+	fmt.Fprintln(w, "  --host <host>   GitHub host")
+}
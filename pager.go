@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+
+	"golang.org/x/term"
+)
+
+// pagedTerminal is the terminal stdout pointed at before startPager sent it
+// into the pager, so colour and width decisions still see the terminal.
+var pagedTerminal *os.File
+
+// terminalFor returns the file to probe for terminal properties in place of
+// f, which differs from f only for stdout while a pager runs.
+func terminalFor(f *os.File) *os.File {
+	if pagedTerminal != nil && f == os.Stdout {
+		return pagedTerminal
+	}
+	return f
+}
+
+// startPager sends stdout through the configured pager
+// (GH_PR_REVIEW_PAGER, then the config file's pager) when stdout is a
+// terminal. The returned function waits for the pager to exit and restores
+// stdout; it is a no-op when no pager was started.
+func startPager() func() {
+	pager := os.Getenv("GH_PR_REVIEW_PAGER")
+	if pager == "" {
+		pager = configValue("pager")
+	}
+	if pager == "" || pager == "cat" || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return func() {}
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		return func() {}
+	}
+	cmd := exec.Command("sh", "-c", pager)
+	cmd.Stdin = r
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	// Like git and gh: let less pass colours through and exit when the
+	// output fits on one screen.
+	if os.Getenv("LESS") == "" {
+		cmd.Env = append(os.Environ(), "LESS=FRX")
+	}
+	if err := cmd.Start(); err != nil {
+		r.Close()
+		w.Close()
+		fmt.Fprintf(os.Stderr, "warning: pager %q failed: %v\n", pager, err)
+		return func() {}
+	}
+	r.Close()
+	pagedTerminal, os.Stdout = os.Stdout, w
+	return func() {
+		w.Close()
+		_ = cmd.Wait()
+		os.Stdout, pagedTerminal = pagedTerminal, nil
+	}
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"gh-pr-review/internal/gh"
+	"gh-pr-review/internal/github"
+)
+
+type pendingFlags struct {
+	repo     string
+	pr       int
+	event    string
+	body     string
+	bodyFile string
+	jsonOut  bool
+	maxLines int
+	host     string
+}
+
+func (o *pendingFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.repo, "repo", "", "owner/name (defaults to gh repo view)")
+	fs.IntVar(&o.pr, "pr", 0, "PR number (defaults to current branch PR if available)")
+	fs.StringVar(&o.event, "event", "", "submit: APPROVE|REQUEST_CHANGES|COMMENT")
+	fs.StringVar(&o.body, "body", "", "submit: review body")
+	fs.StringVar(&o.bodyFile, "body-file", "", "submit: read the review body from file (- for stdin)")
+	fs.BoolVar(&o.jsonOut, "json", false, "output JSON")
+	fs.IntVar(&o.maxLines, "max-lines", 0, "show: truncate each comment body to N lines (0 = no limit)")
+	addPromptFlags(fs)
+	fs.StringVar(&o.host, "host", gh.DefaultHost(), "GitHub host")
+}
+
+func runPending(args []string) error {
+	fs := newFlagSet("pending", printPendingUsage)
+	var flags pendingFlags
+	flags.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	// The action may come before or after the flags.
+	action := "show"
+	if fs.NArg() > 0 {
+		action = fs.Arg(0)
+		if err := parseFlags(fs, fs.Args()[1:]); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil
+			}
+			return err
+		}
+		if fs.NArg() > 0 {
+			return fmt.Errorf("unexpected arguments: %v", fs.Args())
+		}
+	}
+	switch action {
+	case "show", "discard":
+		if flags.event != "" || flags.body != "" || flags.bodyFile != "" {
+			return fmt.Errorf("--event, --body and --body-file only apply to `pending submit`")
+		}
+	case "submit":
+		flags.event = strings.ToUpper(strings.TrimSpace(flags.event))
+		if flags.event != reviewEventApprove && flags.event != reviewEventRequestChanges && flags.event != reviewEventComment {
+			return fmt.Errorf("invalid --event %q (expected APPROVE|REQUEST_CHANGES|COMMENT)", flags.event)
+		}
+	default:
+		return fmt.Errorf("unknown action %q (expected show, submit or discard)", action)
+	}
+	if flags.maxLines < 0 {
+		return fmt.Errorf("invalid --max-lines %d", flags.maxLines)
+	}
+	var err error
+	flags.body, err = resolveBody(flags.body, flags.bodyFile)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	flags.pr, err = resolvePR(ctx, flags.pr)
+	if err != nil {
+		return err
+	}
+	owner, name, err := resolveRepo(ctx, flags.repo)
+	if err != nil {
+		return err
+	}
+	client, err := newClient(ctx, flags.host)
+	if err != nil {
+		return err
+	}
+	prID, pendingID, err := fetchReviewTarget(ctx, client, owner, name, flags.pr)
+	if err != nil {
+		return err
+	}
+	if pendingID == "" {
+		return fmt.Errorf("you have no pending review on %s/%s#%d", owner, name, flags.pr)
+	}
+
+	switch action {
+	case "submit":
+		review, err := submitReview(ctx, client, prID, pendingID, flags.event, strings.TrimSpace(flags.body))
+		if err != nil {
+			return err
+		}
+		if flags.jsonOut {
+			return writeJSON(os.Stdout, review)
+		}
+		fmt.Fprintf(os.Stdout, "%s %s\n", reviewStateText(review.State), review.URL)
+		return nil
+	case "discard":
+		threads, err := fetchAllThreads(ctx, client, owner, name, flags.pr)
+		if err != nil {
+			return err
+		}
+		drafts := countComments(pendingThreads(threads))
+		ok, err := confirm(fmt.Sprintf("Discard your pending review on %s/%s#%d and its %d draft comment(s)?", owner, name, flags.pr, drafts))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Fprintln(os.Stderr, "kept your pending review")
+			return nil
+		}
+		if err := deletePendingReview(ctx, client, pendingID); err != nil {
+			return err
+		}
+		if flags.jsonOut {
+			return writeJSON(os.Stdout, map[string]interface{}{"reviewId": pendingID, "discardedComments": drafts})
+		}
+		fmt.Fprintf(os.Stdout, "discarded pending review with %d draft comment(s)\n", drafts)
+		return nil
+	}
+
+	threads, err := fetchAllThreads(ctx, client, owner, name, flags.pr)
+	if err != nil {
+		return err
+	}
+	drafts := pendingThreads(threads)
+	if flags.jsonOut {
+		if drafts == nil {
+			drafts = []reviewThread{}
+		}
+		return writeJSON(os.Stdout, drafts)
+	}
+	if len(drafts) == 0 {
+		fmt.Fprintln(os.Stdout, "your pending review has no draft comments")
+		return nil
+	}
+	printThreads(drafts, printOptions{maxLines: flags.maxLines})
+	return nil
+}
+
+// pendingThreads keeps the threads holding the viewer's draft comments, with
+// only those comments, whether they start a new thread or reply to an
+// existing one.
+func pendingThreads(threads []reviewThread) []reviewThread {
+	var out []reviewThread
+	for _, t := range threads {
+		var drafts []reviewComment
+		for _, c := range t.Comments.Nodes {
+			if c.isPending() {
+				drafts = append(drafts, c)
+			}
+		}
+		if len(drafts) == 0 {
+			continue
+		}
+		t.Comments.Nodes = drafts
+		t.IsPending = true
+		out = append(out, t)
+	}
+	return out
+}
+
+func countComments(threads []reviewThread) int {
+	n := 0
+	for _, t := range threads {
+		n += len(t.Comments.Nodes)
+	}
+	return n
+}
+
+// deletePendingReview discards an unsubmitted review and its draft comments.
+func deletePendingReview(ctx context.Context, client *github.Client, reviewID string) error {
+	mutation := `mutation($id:ID!) {
+  deletePullRequestReview(input:{pullRequestReviewId:$id}) { pullRequestReview { id } }
+}`
+	return client.Do(ctx, mutation, map[string]interface{}{"id": reviewID}, nil)
+}
+
+func printPendingUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage:")
+	fmt.Fprintln(w, "  gh-pr-review pending [show] [--pr <number>] [--repo owner/name] [--max-lines n] [--json] [--host host]")
+	fmt.Fprintln(w, "  gh-pr-review pending submit --event APPROVE|REQUEST_CHANGES|COMMENT [--body <text>|--body-file <path>] [--pr <number>] [--repo owner/name] [--json] [--host host]")
+	fmt.Fprintln(w, "  gh-pr-review pending discard [--pr <number>] [--repo owner/name] [--yes] [--json] [--host host]")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Actions:")
+	fmt.Fprintln(w, "  show   List the draft comments in your pending review (default)")
+	fmt.Fprintln(w, "  submit   Submit the pending review with its draft comments")
+	fmt.Fprintln(w, "  discard   Delete the pending review and its draft comments, after confirming")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Flags:")
+	fmt.Fprintln(w, "  --pr <number>   PR number (defaults to current branch PR if available)")
+	fmt.Fprintln(w, "  --repo <owner/name>   Repository (defaults to gh repo view)")
+	fmt.Fprintln(w, "  --event <value>   submit: APPROVE, REQUEST_CHANGES or COMMENT")
+	fmt.Fprintln(w, "  --body <text>   submit: Review body")
+	fmt.Fprintln(w, "  --body-file <path>   submit: Read the review body from file (- for stdin)")
+	fmt.Fprintln(w, "  --max-lines <n>   show: Truncate each comment body to n display lines (0 keeps full bodies)")
+	fmt.Fprintln(w, "  --json   Output JSON")
+	fmt.Fprintln(w, "  --yes, -y   discard: Don't ask for confirmation")
+	fmt.Fprintln(w, "  --no-input   Fail instead of prompting")
+	fmt.Fprintln(w, "  --host <host>   GitHub host")
+}
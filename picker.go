@@ -0,0 +1,28 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// pickThread lists threads with their numbers and asks which one to use.
+func pickThread(w io.Writer, threads []reviewThread, question string) (reviewThread, error) {
+	if len(threads) == 0 {
+		return reviewThread{}, errors.New("no threads to choose from")
+	}
+	styler := newStyler(w)
+	for i, t := range threads {
+		fmt.Fprintf(w, "%3d. %s\n", i+1, threadSummary(t, styler))
+	}
+	answer, err := promptLine(fmt.Sprintf("%s [1-%d]: ", question, len(threads)))
+	if err != nil {
+		return reviewThread{}, err
+	}
+	n, err := strconv.Atoi(answer)
+	if err != nil || n < 1 || n > len(threads) {
+		return reviewThread{}, fmt.Errorf("invalid choice %q", answer)
+	}
+	return threads[n-1], nil
+}
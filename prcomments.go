@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"gh-pr-review/internal/github"
+)
+
+// prComment is a PR conversation comment. These have no path, line or
+// resolution state, so status filters never apply to them.
+type prComment struct {
+	ID           string `json:"id"`
+	DatabaseID   int64  `json:"databaseId"`
+	Body         string `json:"body"`
+	CreatedAt    string `json:"createdAt"`
+	LastEditedAt string `json:"lastEditedAt"`
+	URL          string `json:"url"`
+	Author       actor  `json:"author"`
+}
+
+func fetchPRComments(ctx context.Context, client *github.Client, owner, name string, pr int) ([]prComment, error) {
+	query := `query($owner:String!, $name:String!, $number:Int!, $after:String) {
+  repository(owner:$owner, name:$name) {
+    pullRequest(number:$number) {
+      comments(first:100, after:$after) {
+        pageInfo { hasNextPage endCursor }
+        nodes {
+          id
+          databaseId
+          body
+          createdAt
+          lastEditedAt
+          url
+          author { login type: __typename }
+        }
+      }
+    }
+  }
+}`
+	var all []prComment
+	var after *string
+	for {
+		vars := map[string]interface{}{
+			"owner":  owner,
+			"name":   name,
+			"number": pr,
+			"after":  after,
+		}
+		var resp struct {
+			Repository struct {
+				PullRequest struct {
+					Comments struct {
+						PageInfo struct {
+							HasNextPage bool    `json:"hasNextPage"`
+							EndCursor   *string `json:"endCursor"`
+						} `json:"pageInfo"`
+						Nodes []prComment `json:"nodes"`
+					} `json:"comments"`
+				} `json:"pullRequest"`
+			} `json:"repository"`
+		}
+		if err := client.Do(ctx, query, vars, &resp); err != nil {
+			return nil, err
+		}
+		all = append(all, resp.Repository.PullRequest.Comments.Nodes...)
+		page := resp.Repository.PullRequest.Comments.PageInfo
+		if !page.HasNextPage || page.EndCursor == nil || *page.EndCursor == "" {
+			break
+		}
+		after = page.EndCursor
+	}
+	return all, nil
+}
+
+func printPRComments(comments []prComment, opts printOptions) {
+	styler := newStyler(os.Stdout)
+	fmt.Fprintf(os.Stdout, "%s %s\n\n", styler.label("PR conversation"), styler.dim(fmt.Sprintf("(%d comments)", len(comments))))
+	if len(comments) == 0 {
+		fmt.Fprintln(os.Stdout, "no PR conversation comments found")
+		return
+	}
+	for _, c := range comments {
+		author := c.Author.Login
+		if author == "" {
+			author = "unknown"
+		}
+		meta := reviewComment{CreatedAt: c.CreatedAt, LastEditedAt: c.LastEditedAt}
+		fmt.Fprintf(os.Stdout, "  %s %s — %s\n",
+			styler.bullet(),
+			styler.author(author),
+			styler.dim(commentTimestamp(meta, time.Now())),
+		)
+		if c.URL != "" {
+			fmt.Fprintf(os.Stdout, "    %s\n", styler.dim(c.URL))
+		}
+		fmt.Fprintln(os.Stdout, "")
+		lines := formatCommentBody(c.Body, "  ", 120, styler)
+		for _, line := range truncateBodyLines(lines, opts.maxLines, "  ", c.URL, styler) {
+			fmt.Fprintln(os.Stdout, line)
+		}
+		fmt.Fprintln(os.Stdout, "")
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// exitCodeNoInput is returned when a prompt is needed but input is disabled
+// or unavailable, so scripts can tell it apart from ordinary failures.
+const exitCodeNoInput = 4
+
+// Every prompt goes through confirm/promptLine, which consult these settings
+// so no command can block a pipeline waiting for input.
+var (
+	assumeYes bool
+	noInput   bool
+
+	promptIn    io.Reader = os.Stdin
+	promptOut   io.Writer = os.Stderr
+	stdinIsTTY            = func() bool { return term.IsTerminal(int(os.Stdin.Fd())) }
+	stdoutIsTTY           = func() bool { return term.IsTerminal(int(os.Stdout.Fd())) }
+	promptInBuf *bufio.Reader
+)
+
+// loadPromptEnv applies GH_PR_REVIEW_YES / GH_PR_REVIEW_NO_INPUT so CI can
+// opt in without touching command lines.
+func loadPromptEnv() {
+	if envBool("GH_PR_REVIEW_YES") {
+		assumeYes = true
+	}
+	if envBool("GH_PR_REVIEW_NO_INPUT") {
+		noInput = true
+	}
+}
+
+func envBool(name string) bool {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv(name))) {
+	case "1", "true", "yes":
+		return true
+	}
+	return false
+}
+
+// addPromptFlags registers the per-command --yes and --no-input flags.
+func addPromptFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&assumeYes, "yes", assumeYes, "answer yes to all prompts")
+	fs.BoolVar(&assumeYes, "y", assumeYes, "answer yes to all prompts")
+	fs.BoolVar(&noInput, "no-input", noInput, "fail instead of prompting")
+}
+
+func errPromptUnavailable(question string) error {
+	reason := "stdin is not a terminal"
+	if noInput {
+		reason = "--no-input is set"
+	}
+	return &exitError{
+		code: exitCodeNoInput,
+		err:  fmt.Errorf("%q needs confirmation but %s (pass --yes to confirm)", question, reason),
+	}
+}
+
+// canPrompt reports whether interactive input is possible.
+func canPrompt() bool {
+	return !noInput && stdinIsTTY()
+}
+
+// confirm asks a yes/no question, defaulting to no. --yes answers yes
+// without reading input.
+func confirm(question string) (bool, error) {
+	if assumeYes {
+		return true, nil
+	}
+	if !canPrompt() {
+		return false, errPromptUnavailable(question)
+	}
+	answer, err := readPromptLine(question + " [y/N] ")
+	if err != nil {
+		return false, err
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	}
+	return false, nil
+}
+
+// promptLine asks for a line of free-form input. --yes can't answer these,
+// so they fail when prompting is impossible.
+func promptLine(question string) (string, error) {
+	if !canPrompt() {
+		return "", errPromptUnavailable(question)
+	}
+	answer, err := readPromptLine(question)
+	return strings.TrimSpace(answer), err
+}
+
+func readPromptLine(question string) (string, error) {
+	fmt.Fprint(promptOut, question)
+	line, err := promptReader().ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", fmt.Errorf("no answer to %q: %w", strings.TrimSpace(question), err)
+	}
+	return line, nil
+}
+
+// promptReader returns the buffered stdin shared by every reader of input, so
+// a prompt answered earlier doesn't swallow input meant for a later read.
+func promptReader() *bufio.Reader {
+	if promptInBuf == nil {
+		promptInBuf = bufio.NewReader(promptIn)
+	}
+	return promptInBuf
+}
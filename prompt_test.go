@@ -0,0 +1,133 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// withPrompt swaps the prompt settings for one test.
+func withPrompt(t *testing.T, in string, tty, yes, disabled bool) {
+	t.Helper()
+	origIn, origOut, origTTY := promptIn, promptOut, stdinIsTTY
+	origYes, origNoInput, origBuf := assumeYes, noInput, promptInBuf
+	t.Cleanup(func() {
+		promptIn, promptOut, stdinIsTTY = origIn, origOut, origTTY
+		assumeYes, noInput, promptInBuf = origYes, origNoInput, origBuf
+	})
+	promptIn = strings.NewReader(in)
+	promptOut = io.Discard
+	promptInBuf = nil
+	stdinIsTTY = func() bool { return tty }
+	assumeYes = yes
+	noInput = disabled
+}
+
+func exitCode(err error) int {
+	var ee *exitError
+	if errors.As(err, &ee) {
+		return ee.code
+	}
+	return 0
+}
+
+func TestConfirmWithClosedStdin(t *testing.T) {
+	t.Run("yes", func(t *testing.T) {
+		withPrompt(t, "", false, true, false)
+		ok, err := confirm("Resolve?")
+		if err != nil || !ok {
+			t.Fatalf("expected --yes to confirm, got %v (%v)", ok, err)
+		}
+	})
+	t.Run("yes-wins-over-no-input", func(t *testing.T) {
+		withPrompt(t, "", false, true, true)
+		ok, err := confirm("Resolve?")
+		if err != nil || !ok {
+			t.Fatalf("expected --yes to confirm, got %v (%v)", ok, err)
+		}
+	})
+	t.Run("no-input", func(t *testing.T) {
+		withPrompt(t, "", true, false, true)
+		_, err := confirm("Resolve?")
+		if exitCode(err) != exitCodeNoInput || !strings.Contains(err.Error(), "--no-input") {
+			t.Fatalf("expected no-input exit error, got %v", err)
+		}
+	})
+	t.Run("not-a-terminal", func(t *testing.T) {
+		withPrompt(t, "", false, false, false)
+		_, err := confirm("Resolve?")
+		if exitCode(err) != exitCodeNoInput || !strings.Contains(err.Error(), "not a terminal") {
+			t.Fatalf("expected not-a-terminal exit error, got %v", err)
+		}
+	})
+	t.Run("terminal-eof", func(t *testing.T) {
+		withPrompt(t, "", true, false, false)
+		if _, err := confirm("Resolve?"); err == nil {
+			t.Fatal("expected error when stdin closes without an answer")
+		}
+	})
+}
+
+func TestConfirmAnswers(t *testing.T) {
+	for input, want := range map[string]bool{"y\n": true, "YES\n": true, "n\n": false, "\n": false, "y": true} {
+		withPrompt(t, input, true, false, false)
+		ok, err := confirm("Resolve?")
+		if err != nil || ok != want {
+			t.Errorf("confirm with %q = %v (%v), want %v", input, ok, err, want)
+		}
+	}
+}
+
+func TestPromptLineNeedsInput(t *testing.T) {
+	withPrompt(t, "", false, true, false)
+	if _, err := promptLine("Thread number: "); exitCode(err) != exitCodeNoInput {
+		t.Fatalf("expected --yes not to answer free-form prompts, got %v", err)
+	}
+	withPrompt(t, " 3 \n", true, false, false)
+	answer, err := promptLine("Thread number: ")
+	if err != nil || answer != "3" {
+		t.Fatalf("expected answer 3, got %q (%v)", answer, err)
+	}
+}
+
+func TestResolveBodyStdin(t *testing.T) {
+	withPrompt(t, "Fixed in abc123\n\nThanks!\n", false, false, false)
+	body, err := resolveBody("", "-")
+	if err != nil || body != "Fixed in abc123\n\nThanks!\n" {
+		t.Fatalf("got %q (%v)", body, err)
+	}
+
+	withPrompt(t, "", true, false, false)
+	if _, err := resolveBody("", "-"); err == nil || !strings.Contains(err.Error(), "no body entered") {
+		t.Fatalf("expected empty terminal input to fail usefully, got %v", err)
+	}
+
+	if _, err := resolveBody("x", "-"); err == nil {
+		t.Fatal("expected --body and --body-file to conflict")
+	}
+}
+
+func TestPickThread(t *testing.T) {
+	threads := []reviewThread{{ID: "a", Path: "a.go", Line: intPtr(1)}, {ID: "b", Path: "b.go", Line: intPtr(2)}}
+	threads[1].Comments.Nodes = []reviewComment{{Author: actor{Login: "alice"}, Body: "\nPlease rename\nmore"}}
+
+	withPrompt(t, "2\n", true, false, false)
+	var out strings.Builder
+	got, err := pickThread(&out, threads, "Reply to thread")
+	if err != nil || got.ID != "b" {
+		t.Fatalf("expected thread b, got %q (%v)", got.ID, err)
+	}
+	if !strings.Contains(out.String(), "  2. [b.go:2] alice: Please rename\n") {
+		t.Fatalf("unexpected listing %q", out.String())
+	}
+
+	withPrompt(t, "3\n", true, false, false)
+	if _, err := pickThread(io.Discard, threads, "Reply to thread"); err == nil {
+		t.Fatal("expected out-of-range choice to fail")
+	}
+	withPrompt(t, "", false, false, false)
+	if _, err := pickThread(io.Discard, threads, "Reply to thread"); exitCode(err) != exitCodeNoInput {
+		t.Fatalf("expected picker to refuse without a terminal, got %v", err)
+	}
+}
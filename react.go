@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"gh-pr-review/internal/gh"
+	"gh-pr-review/internal/github"
+)
+
+// reaction is one of GitHub's reaction kinds.
+type reaction struct {
+	name    string
+	content string
+	emoji   string
+}
+
+var reactions = []reaction{
+	{"+1", "THUMBS_UP", "👍"},
+	{"-1", "THUMBS_DOWN", "👎"},
+	{"laugh", "LAUGH", "😄"},
+	{"hooray", "HOORAY", "🎉"},
+	{"confused", "CONFUSED", "😕"},
+	{"heart", "HEART", "❤️"},
+	{"rocket", "ROCKET", "🚀"},
+	{"eyes", "EYES", "👀"},
+}
+
+var reactionAliases = map[string]string{
+	"thumbsup":    "+1",
+	"thumbs_up":   "+1",
+	"thumbsdown":  "-1",
+	"thumbs_down": "-1",
+	"tada":        "hooray",
+	"smile":       "laugh",
+}
+
+// parseReaction accepts a reaction name, a common alias or the emoji itself,
+// with or without surrounding colons.
+func parseReaction(value string) (reaction, error) {
+	name := strings.ToLower(strings.Trim(strings.TrimSpace(value), ":"))
+	if alias, ok := reactionAliases[name]; ok {
+		name = alias
+	}
+	var names []string
+	for _, r := range reactions {
+		if r.name == name || r.emoji == value {
+			return r, nil
+		}
+		names = append(names, r.name)
+	}
+	return reaction{}, fmt.Errorf("unknown reaction %q (use %s)", value, strings.Join(names, "|"))
+}
+
+type reactionGroup struct {
+	Content  string `json:"content"`
+	Reactors struct {
+		TotalCount int `json:"totalCount"`
+	} `json:"reactors"`
+}
+
+type reactFlags struct {
+	commentID  string
+	commentURL string
+	repo       string
+	pr         int
+	emoji      string
+	remove     bool
+	host       string
+}
+
+func (o *reactFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.commentID, "comment-id", "", "review comment node ID (or numeric ID)")
+	fs.StringVar(&o.commentURL, "url", "", "review comment URL or numeric ID")
+	fs.StringVar(&o.repo, "repo", "", "owner/name for a numeric ID (defaults to gh repo view)")
+	fs.IntVar(&o.pr, "pr", 0, "PR number for a numeric ID")
+	fs.StringVar(&o.emoji, "emoji", "", "+1|-1|laugh|hooray|confused|heart|rocket|eyes")
+	fs.BoolVar(&o.remove, "remove", false, "remove your reaction instead of adding it")
+	fs.StringVar(&o.host, "host", gh.DefaultHost(), "GitHub host")
+}
+
+func runReact(args []string) error {
+	fs := newFlagSet("react", printReactUsage)
+	var flags reactFlags
+	flags.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if flags.commentID != "" && flags.commentURL != "" {
+		return errors.New("provide only one of --comment-id or --url")
+	}
+	if flags.commentID == "" && flags.commentURL == "" {
+		return errors.New("--comment-id or --url is required")
+	}
+	if flags.emoji == "" {
+		return errors.New("--emoji is required")
+	}
+	r, err := parseReaction(flags.emoji)
+	if err != nil {
+		return err
+	}
+	var refs []commentRef
+	if flags.commentURL != "" {
+		ref, err := parseCommentRef(flags.commentURL)
+		if err != nil {
+			return err
+		}
+		refs = append(refs, ref)
+	}
+
+	ctx := context.Background()
+	client, err := newClient(ctx, commentRefHost(refs, flags.host))
+	if err != nil {
+		return err
+	}
+	var ref *commentRef
+	if len(refs) > 0 {
+		ref = &refs[0]
+	}
+	id, err := reviewCommentID(ctx, client, flags.commentID, ref, flags.repo, flags.pr)
+	if err != nil {
+		return err
+	}
+	groups, err := setReaction(ctx, client, id, r, flags.remove)
+	if err != nil {
+		return err
+	}
+	counts := formatReactionCounts(groups)
+	if counts == "" {
+		counts = "no reactions"
+	}
+	fmt.Fprintln(os.Stdout, counts)
+	return nil
+}
+
+// setReaction adds or removes the viewer's reaction on a comment and returns
+// the comment's reaction counts afterwards.
+func setReaction(ctx context.Context, client *github.Client, subjectID string, r reaction, remove bool) ([]reactionGroup, error) {
+	op := "addReaction"
+	if remove {
+		op = "removeReaction"
+	}
+	mutation := `mutation($id:ID!, $content:ReactionContent!) {
+  ` + op + `(input:{subjectId:$id, content:$content}) {
+    subject { reactionGroups { content reactors { totalCount } } }
+  }
+}`
+	var resp map[string]struct {
+		Subject struct {
+			ReactionGroups []reactionGroup `json:"reactionGroups"`
+		} `json:"subject"`
+	}
+	err := client.Do(ctx, mutation, map[string]interface{}{"id": subjectID, "content": r.content}, &resp)
+	if github.NotFound(err) {
+		return nil, fmt.Errorf("comment %s not found", subjectID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resp[op].Subject.ReactionGroups, nil
+}
+
+// formatReactionCounts renders non-zero counts as "👍 2  🎉 1".
+func formatReactionCounts(groups []reactionGroup) string {
+	var parts []string
+	for _, g := range groups {
+		if g.Reactors.TotalCount == 0 {
+			continue
+		}
+		label := strings.ToLower(g.Content)
+		for _, r := range reactions {
+			if r.content == g.Content {
+				label = r.emoji
+			}
+		}
+		parts = append(parts, fmt.Sprintf("%s %d", label, g.Reactors.TotalCount))
+	}
+	return strings.Join(parts, "  ")
+}
+
+func printReactUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage:")
+	fmt.Fprintln(w, "  gh-pr-review react --comment-id <id> --emoji <reaction> [--remove] [--host host]")
+	fmt.Fprintln(w, "  gh-pr-review react --url <comment-url|comment-id> [--pr <number>] [--repo owner/name] --emoji <reaction> [--remove] [--host host]")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Flags:")
+	fmt.Fprintln(w, "  --comment-id <id>   Review comment node ID (PRRC_...), or a numeric comment ID with --pr")
+	fmt.Fprintln(w, "  --url <url>   Review comment URL (.../pull/42#discussion_r123), or a numeric comment ID with --pr")
+	fmt.Fprintln(w, "  --pr <number>   PR for a numeric ID (defaults to current branch PR if available)")
+	fmt.Fprintln(w, "  --repo <owner/name>   Repository for a numeric ID (defaults to gh repo view)")
+	fmt.Fprintln(w, "  --emoji <reaction>   +1, -1, laugh, hooray, confused, heart, rocket or eyes (thumbsup, thumbsdown and tada also work)")
+	fmt.Fprintln(w, "  --remove   Remove your reaction instead of adding it")
+	fmt.Fprintln(w, "  --host <host>   GitHub host")
+}
+
+func reactionNames() []string {
+	var names []string
+	for _, r := range reactions {
+		names = append(names, r.name)
+	}
+	return names
+}
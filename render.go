@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+
+	"gh-pr-review/internal/rendercache"
+	"github.com/charmbracelet/glamour/styles"
+	"github.com/muesli/termenv"
+)
+
+// renderCacheMaxBytes caps the on-disk render cache.
+const renderCacheMaxBytes = 64 << 20
+
+var (
+	// renderCache holds rendered comment bodies across runs; nil disables it.
+	renderCache *rendercache.Cache
+
+	// themeOverride pins the glamour style (set from --theme). When it is
+	// non-empty the terminal is never probed.
+	themeOverride string
+
+	backgroundOnce  sync.Once
+	backgroundStyle string
+)
+
+// setTheme validates and pins the markdown style. "auto" or "" keeps
+// background detection.
+func setTheme(theme string) error {
+	theme = strings.ToLower(strings.TrimSpace(theme))
+	if theme == "" || theme == styles.AutoStyle {
+		themeOverride = ""
+		return nil
+	}
+	if _, ok := styles.DefaultStyles[theme]; !ok {
+		return fmt.Errorf("invalid --theme %q (expected auto|%s)", theme, strings.Join(themeNames(), "|"))
+	}
+	themeOverride = theme
+	return nil
+}
+
+func themeNames() []string {
+	names := make([]string, 0, len(styles.DefaultStyles))
+	for name := range styles.DefaultStyles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// markdownStyle returns the glamour standard style used by every renderer.
+func markdownStyle() string {
+	if themeOverride != "" {
+		return themeOverride
+	}
+	return detectBackground()
+}
+
+// detectBackground decides between the dark and light styles once per
+// process. GH_PR_REVIEW_BACKGROUND skips the probe entirely; otherwise the
+// terminal is queried in place, before anything else reads the tty. termenv
+// follows the query with a cursor position request every terminal answers,
+// bounds each read with its own timeout, and falls back to dark when no
+// colour comes back.
+func detectBackground() string {
+	backgroundOnce.Do(func() {
+		switch strings.ToLower(strings.TrimSpace(os.Getenv("GH_PR_REVIEW_BACKGROUND"))) {
+		case styles.DarkStyle:
+			backgroundStyle = styles.DarkStyle
+			return
+		case styles.LightStyle:
+			backgroundStyle = styles.LightStyle
+			return
+		}
+		backgroundStyle = styles.LightStyle
+		if termenv.NewOutput(os.Stdout).HasDarkBackground() {
+			backgroundStyle = styles.DarkStyle
+		}
+	})
+	return backgroundStyle
+}
+
+// openRenderCache enables the on-disk render cache unless disabled. Failing
+// to open it only costs speed, so errors leave it off.
+func openRenderCache(disabled bool) {
+	if disabled {
+		return
+	}
+	dir, err := cacheDir()
+	if err != nil {
+		return
+	}
+	cache, err := rendercache.Open(filepath.Join(dir, "render"), renderCacheMaxBytes)
+	if err != nil {
+		return
+	}
+	renderCache = cache
+}
+
+// cachedRender returns the rendered body from the render cache, falling back
+// to render and storing its result.
+func cachedRender(body string, width int, render func() (string, error)) (string, error) {
+	if renderCache == nil {
+		return render()
+	}
+	key := rendercache.Key(body, width, markdownStyle(), rendererVersion())
+	if rendered, ok := renderCache.Get(key); ok {
+		return rendered, nil
+	}
+	rendered, err := render()
+	if err != nil {
+		return "", err
+	}
+	_ = renderCache.Put(key, rendered)
+	return rendered, nil
+}
+
+var (
+	rendererVersionOnce sync.Once
+	rendererVersionStr  string
+)
+
+// rendererVersion identifies the glamour build so upgrades don't serve output
+// rendered by an older version.
+func rendererVersion() string {
+	rendererVersionOnce.Do(func() {
+		rendererVersionStr = "glamour"
+		info, ok := debug.ReadBuildInfo()
+		if !ok || info == nil {
+			return
+		}
+		for _, dep := range info.Deps {
+			if dep.Path == "github.com/charmbracelet/glamour" {
+				rendererVersionStr = dep.Path + "@" + dep.Version
+			}
+		}
+	})
+	return rendererVersionStr
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"gh-pr-review/internal/rendercache"
+)
+
+func loadFixtureThreads(tb testing.TB, name string) []reviewThread {
+	tb.Helper()
+	data, err := os.ReadFile(name)
+	if err != nil {
+		tb.Fatalf("read fixture: %v", err)
+	}
+	var threads []reviewThread
+	if err := json.Unmarshal(data, &threads); err != nil {
+		tb.Fatalf("decode fixture: %v", err)
+	}
+	return threads
+}
+
+func renderAllBodies(threads []reviewThread) {
+	styler := styler{enabled: true}
+	for _, t := range threads {
+		for _, c := range t.Comments.Nodes {
+			formatCommentBody(c.Body, "  ", 120, styler)
+		}
+	}
+}
+
+// BenchmarkRenderLargePR compares a cold run against a second run served by
+// the on-disk render cache.
+func BenchmarkRenderLargePR(b *testing.B) {
+	threads := loadFixtureThreads(b, "testdata/large-pr.json")
+	themeOverride = "dark"
+	defer func() { themeOverride = ""; renderCache = nil }()
+
+	b.Run("uncached", func(b *testing.B) {
+		renderCache = nil
+		for i := 0; i < b.N; i++ {
+			renderAllBodies(threads)
+		}
+	})
+	b.Run("cached", func(b *testing.B) {
+		cache, err := rendercache.Open(b.TempDir(), 0)
+		if err != nil {
+			b.Fatalf("open cache: %v", err)
+		}
+		renderCache = cache
+		renderAllBodies(threads)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			renderAllBodies(threads)
+		}
+	})
+}
+
+func TestCachedRenderServesSecondRun(t *testing.T) {
+	cache, err := rendercache.Open(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("open cache: %v", err)
+	}
+	renderCache = cache
+	themeOverride = "dark"
+	defer func() { themeOverride = ""; renderCache = nil }()
+
+	calls := 0
+	render := func() (string, error) {
+		calls++
+		return "rendered", nil
+	}
+	for i := 0; i < 2; i++ {
+		got, err := cachedRender("**body**", 80, render)
+		if err != nil || got != "rendered" {
+			t.Fatalf("unexpected result %q (%v)", got, err)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("expected one render, got %d", calls)
+	}
+}
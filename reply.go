@@ -0,0 +1,721 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"gh-pr-review/internal/gh"
+	"gh-pr-review/internal/git"
+	"gh-pr-review/internal/github"
+	"golang.org/x/term"
+)
+
+type replyFlags struct {
+	threadIDs         stringList
+	commentURLs       stringList
+	body              string
+	bodyFile          string
+	useEditor         bool
+	resolve           bool
+	unresolve         bool
+	quote             bool
+	quoteFirst        bool
+	dryRun            bool
+	confirmPost       bool
+	templateName      string
+	templateVars      stringList
+	suggest           bool
+	jsonOut           bool
+	noResolvedWarning bool
+	mentions          stringList
+	all               bool
+	repo              string
+	pr                int
+	status            string
+	author            string
+	pathPattern       string
+	retries           int
+	retryWindow       time.Duration
+	host              string
+}
+
+func (o *replyFlags) register(fs *flag.FlagSet) {
+	fs.Var(&o.threadIDs, "thread-id", "Review thread ID (repeatable)")
+	fs.Var(&o.commentURLs, "url", "review comment URL or numeric ID (repeatable)")
+	fs.StringVar(&o.body, "body", "", "Reply body")
+	fs.StringVar(&o.bodyFile, "body-file", "", "Read reply body from file")
+	fs.BoolVar(&o.useEditor, "editor", false, "edit the reply in $EDITOR before posting")
+	fs.BoolVar(&o.resolve, "resolve", false, "resolve the thread after replying")
+	fs.BoolVar(&o.unresolve, "unresolve", false, "unresolve the thread after replying")
+	fs.BoolVar(&o.quote, "quote", false, "quote the thread's last comment above the reply")
+	fs.BoolVar(&o.quoteFirst, "quote-first", false, "quote the thread's opening comment above the reply")
+	fs.BoolVar(&o.dryRun, "dry-run", false, "show what would be posted without posting")
+	fs.BoolVar(&o.confirmPost, "confirm", false, "show a preview and ask before posting")
+	fs.StringVar(&o.templateName, "template", "", "use a saved reply template (list to show them)")
+	fs.Var(&o.templateVars, "var", "template variable key=value (repeatable)")
+	fs.BoolVar(&o.suggest, "suggest", false, "reply with a suggestion block of the thread's lines from the local file")
+	fs.BoolVar(&o.jsonOut, "json", false, "output the posted comment as JSON")
+	fs.Var(&o.mentions, "mention", "@mention a user at the start of the reply (repeatable)")
+	fs.BoolVar(&o.noResolvedWarning, "no-resolved-warning", false, "don't warn when replying to a resolved thread")
+	fs.BoolVar(&o.all, "all", false, "reply to every thread matching --status/--author/--path")
+	fs.StringVar(&o.repo, "repo", "", "owner/name for --all or numeric --url IDs (defaults to gh repo view)")
+	fs.IntVar(&o.pr, "pr", 0, "PR number for --all or numeric --url IDs")
+	fs.StringVar(&o.status, "status", "unresolved", "with --all: all|resolved|unresolved|resolved-no-reply")
+	fs.StringVar(&o.author, "author", "", "with --all: only threads opened by this login")
+	fs.StringVar(&o.pathPattern, "path", "", "with --all: only threads on files matching this glob or directory")
+	fs.IntVar(&o.retries, "retries", 2, "retry a reply this many times after a timeout or server error")
+	fs.DurationVar(&o.retryWindow, "retry-window", time.Minute, "treat an identical reply this recent as already posted")
+	addPromptFlags(fs)
+	fs.StringVar(&o.host, "host", gh.DefaultHost(), "GitHub host")
+}
+
+func runReply(args []string) error {
+	fs := newFlagSet("reply", printReplyUsage)
+	var flags replyFlags
+	flags.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if flags.templateName == "list" {
+		names, err := listTemplates()
+		if err != nil {
+			return err
+		}
+		return printTemplates(names)
+	}
+	ids := uniqueStrings(flags.threadIDs)
+	refs, err := parseCommentRefs(flags.commentURLs)
+	if err != nil {
+		return err
+	}
+	// Without a thread, pick one interactively when we can.
+	pick := !flags.all && len(ids) == 0 && len(refs) == 0 && canPrompt() && term.IsTerminal(int(os.Stdout.Fd()))
+	switch {
+	case flags.all && (len(ids) > 0 || len(refs) > 0):
+		return errors.New("provide only one of --thread-id/--url or --all")
+	case !flags.all && !pick && len(ids) == 0 && len(refs) == 0:
+		return errors.New("--thread-id or --url is required")
+	case !flags.all && (flags.author != "" || flags.pathPattern != ""):
+		return errors.New("--author and --path only apply with --all")
+	}
+	flags.status = strings.ToLower(strings.TrimSpace(flags.status))
+	if flags.status != "all" && flags.status != "resolved" && flags.status != "unresolved" && flags.status != "resolved-no-reply" {
+		return fmt.Errorf("invalid --status %q", flags.status)
+	}
+	if flags.resolve && flags.unresolve {
+		return errors.New("provide only one of --resolve or --unresolve")
+	}
+	if flags.retries < 0 {
+		return fmt.Errorf("invalid --retries %d", flags.retries)
+	}
+	if flags.quote && flags.quoteFirst {
+		return errors.New("provide only one of --quote or --quote-first")
+	}
+	if flags.suggest && (flags.all || len(ids)+len(refs) > 1) {
+		return errors.New("--suggest works on a single --thread-id")
+	}
+	mentionPrefix, err := formatMentions(flags.mentions)
+	if err != nil {
+		return err
+	}
+	if flags.templateName != "" {
+		if flags.body != "" || flags.bodyFile != "" {
+			return errors.New("provide only one of --template, --body or --body-file")
+		}
+		vars, err := parseVars(flags.templateVars)
+		if err != nil {
+			return err
+		}
+		if flags.body, err = renderTemplate(flags.templateName, vars); err != nil {
+			return err
+		}
+	} else if len(flags.templateVars) > 0 {
+		return errors.New("--var requires --template")
+	}
+	// With no body given, compose one in the editor when a terminal is
+	// available.
+	if flags.body == "" && flags.bodyFile == "" && flags.templateName == "" && !flags.suggest && canPrompt() {
+		flags.useEditor = true
+	}
+	flags.body, err = resolveBody(flags.body, flags.bodyFile)
+	if err != nil {
+		return err
+	}
+	if !flags.useEditor && !flags.suggest && strings.TrimSpace(flags.body) == "" {
+		return errors.New("reply body is empty")
+	}
+	flags.body = mentionPrefix + flags.body
+
+	ctx := context.Background()
+	client, err := newClient(ctx, commentRefHost(refs, flags.host))
+	if err != nil {
+		return err
+	}
+	for _, ref := range refs {
+		id, err := threadIDForComment(ctx, client, ref, flags.repo, flags.pr)
+		if err != nil {
+			return err
+		}
+		ids = uniqueStrings(append(ids, id))
+	}
+	var targets []threadTarget
+	if flags.all {
+		threads, err := matchingThreads(ctx, client, flags.repo, flags.pr, threadFilter{status: flags.status, author: flags.author, path: flags.pathPattern})
+		if err != nil {
+			return err
+		}
+		for _, t := range threads {
+			targets = append(targets, checkReplyTarget(t.ID, t, nil))
+		}
+		if len(targets) == 0 {
+			fmt.Fprintln(os.Stderr, "no threads match; nothing was posted")
+			return nil
+		}
+		printThreadTargets(targets)
+		if !flags.dryRun {
+			ok, err := confirm(fmt.Sprintf("Reply to %d threads?", len(targets)))
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return errors.New("aborted; nothing was posted")
+			}
+		}
+	} else if pick {
+		target, err := pickReplyTarget(ctx, client, flags.repo, flags.pr)
+		if err != nil {
+			return err
+		}
+		targets = append(targets, target)
+	} else {
+		for _, id := range ids {
+			targets = append(targets, loadReplyTarget(ctx, client, id))
+		}
+		if len(targets) == 1 && targets[0].err != nil {
+			return targets[0].err
+		}
+	}
+	if !flags.noResolvedWarning && !flags.resolve && !flags.unresolve {
+		for _, t := range targets {
+			if t.err == nil && t.thread.IsResolved {
+				fmt.Fprintf(os.Stderr, "warning: thread %s is already resolved; replying anyway (--no-resolved-warning silences this)\n", t.id)
+			}
+		}
+	}
+	if flags.suggest {
+		block, err := suggestionBlock(ctx, targets[0].thread)
+		if err != nil {
+			return err
+		}
+		if strings.TrimSpace(flags.body) == "" {
+			flags.body = block
+		} else {
+			flags.body = strings.TrimRight(flags.body, "\n") + "\n\n" + block
+		}
+	}
+	if flags.useEditor {
+		var quoted reviewThread
+		for _, t := range targets {
+			if t.err == nil {
+				quoted = t.thread
+				break
+			}
+		}
+		flags.body, err = editText(replyEditorTemplate(flags.body, quoted))
+		if err != nil {
+			return err
+		}
+		if flags.body == "" {
+			return errors.New("reply body is empty; nothing was posted")
+		}
+	}
+
+	// Bulk replies were confirmed when the targets were listed.
+	if flags.dryRun || (flags.confirmPost && !flags.all) {
+		for _, t := range targets {
+			if t.err == nil {
+				printReplyPreview(os.Stdout, t.thread, quotedReply(flags.body, t.thread, flags.quote, flags.quoteFirst))
+			}
+		}
+		if flags.dryRun {
+			fmt.Fprintln(os.Stdout, "dry run: nothing was posted")
+			return nil
+		}
+		ok, err := confirm("Post this reply?")
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errors.New("aborted; nothing was posted")
+		}
+	}
+
+	opts := replyOptions{
+		resolve:     flags.resolve,
+		unresolve:   flags.unresolve,
+		quote:       flags.quote,
+		quoteFirst:  flags.quoteFirst,
+		retries:     flags.retries,
+		retryWindow: flags.retryWindow,
+	}
+	if len(targets) == 1 && !flags.all {
+		result, err := postReply(ctx, client, targets[0], flags.body, opts)
+		if result.ID != "" {
+			printReplyResult(result, "", flags.jsonOut)
+		}
+		return err
+	}
+	var failed []string
+	var results []replyResult
+	for _, t := range targets {
+		err := t.err
+		if err == nil {
+			var result replyResult
+			result, err = postReply(ctx, client, t, flags.body, opts)
+			if result.ID != "" {
+				results = append(results, result)
+				if !flags.jsonOut {
+					printReplyResult(result, t.id+": ", false)
+				}
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", t.id, err)
+			failed = append(failed, t.id)
+		}
+	}
+	if flags.jsonOut {
+		if results == nil {
+			results = []replyResult{}
+		}
+		if err := writeJSON(os.Stdout, results); err != nil {
+			return err
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d replies failed: %s", len(failed), len(targets), strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// loginPattern matches GitHub logins: alphanumerics and single hyphens, not
+// at either end, at most 39 characters.
+var loginPattern = regexp.MustCompile(`^[A-Za-z0-9](?:[A-Za-z0-9]|-[A-Za-z0-9]){0,38}$`)
+
+// formatMentions validates logins (a leading @ is optional) and returns
+// them as "@a @b " to prepend to a body.
+func formatMentions(logins []string) (string, error) {
+	stripped := make([]string, len(logins))
+	for i, login := range logins {
+		stripped[i] = strings.TrimPrefix(strings.TrimSpace(login), "@")
+	}
+	var b strings.Builder
+	for _, login := range uniqueStrings(stripped) {
+		if !loginPattern.MatchString(login) {
+			return "", fmt.Errorf("invalid --mention %q: not a GitHub login", login)
+		}
+		b.WriteString("@" + login + " ")
+	}
+	return b.String(), nil
+}
+
+func loadReplyTarget(ctx context.Context, client *github.Client, threadID string) threadTarget {
+	thread, err := fetchThread(ctx, client, threadID)
+	return checkReplyTarget(threadID, thread, err)
+}
+
+func checkReplyTarget(threadID string, thread reviewThread, err error) threadTarget {
+	switch {
+	case err != nil:
+	case thread.IsPending:
+		err = errPendingThread(threadID)
+	case !thread.CanReply:
+		err = fmt.Errorf("you don't have permission to reply to thread %s (the conversation may be locked)", threadID)
+	}
+	return threadTarget{id: threadID, thread: thread, err: err}
+}
+
+// pickReplyTarget asks which of the PR's unresolved threads to answer.
+func pickReplyTarget(ctx context.Context, client *github.Client, repo string, pr int) (threadTarget, error) {
+	candidates, err := matchingThreads(ctx, client, repo, pr, threadFilter{status: "unresolved"})
+	if err != nil {
+		return threadTarget{}, err
+	}
+	var threads []reviewThread
+	for _, t := range candidates {
+		if checkReplyTarget(t.ID, t, nil).err == nil {
+			threads = append(threads, t)
+		}
+	}
+	if len(threads) == 0 {
+		return threadTarget{}, errors.New("no unresolved threads to reply to")
+	}
+	thread, err := pickThread(os.Stdout, threads, "Reply to thread")
+	if err != nil {
+		return threadTarget{}, err
+	}
+	return checkReplyTarget(thread.ID, thread, nil), nil
+}
+
+type replyOptions struct {
+	resolve     bool
+	unresolve   bool
+	quote       bool
+	quoteFirst  bool
+	retries     int
+	retryWindow time.Duration
+}
+
+// replyResult describes a posted reply; it is the --json output.
+type replyResult struct {
+	ID         string `json:"id"`
+	URL        string `json:"url"`
+	ThreadID   string `json:"threadId"`
+	CreatedAt  string `json:"createdAt"`
+	IsResolved *bool  `json:"isResolved,omitempty"`
+
+	location string
+}
+
+// postReply posts body to the target thread and then applies --resolve or
+// --unresolve. When only the resolve step fails the result is still
+// returned, since the reply was posted.
+func postReply(ctx context.Context, client *github.Client, t threadTarget, body string, opts replyOptions) (replyResult, error) {
+	comment, err := replyWithRetry(ctx, client, t.id, quotedReply(body, t.thread, opts.quote, opts.quoteFirst), opts)
+	if err != nil {
+		return replyResult{}, err
+	}
+	result := replyResult{
+		ID:        comment.ID,
+		URL:       comment.URL,
+		ThreadID:  t.id,
+		CreatedAt: comment.CreatedAt,
+		location:  formatLineInfo(t.thread),
+	}
+	if !opts.resolve && !opts.unresolve {
+		return result, nil
+	}
+	resolved, err := setThreadResolved(ctx, client, t.id, opts.resolve)
+	if err != nil {
+		action := "resolving"
+		if opts.unresolve {
+			action = "unresolving"
+		}
+		return result, fmt.Errorf("the reply was posted, but %s thread %s failed: %w", action, t.id, err)
+	}
+	result.IsResolved = &resolved
+	return result, nil
+}
+
+// printReplyResult reports a posted reply. prefix labels the lines when
+// replying to several threads.
+func printReplyResult(r replyResult, prefix string, jsonOut bool) {
+	if jsonOut {
+		_ = writeJSON(os.Stdout, r)
+		return
+	}
+	fmt.Fprintf(os.Stdout, "%sreplied with comment id %s%s\n", prefix, r.ID, r.location)
+	if r.URL != "" {
+		fmt.Fprintf(os.Stdout, "%s%s\n", prefix, r.URL)
+	}
+	if r.IsResolved != nil {
+		fmt.Fprintf(os.Stdout, "%sthread %s is now %s\n", prefix, r.ThreadID, resolutionState(*r.IsResolved))
+	}
+}
+
+// quotedReply prepends the thread's last (or, with first, opening) comment
+// to body as a blockquote when quoting was asked for.
+func quotedReply(body string, thread reviewThread, last, first bool) string {
+	comments := thread.Comments.Nodes
+	if (!last && !first) || len(comments) == 0 {
+		return body
+	}
+	c := comments[len(comments)-1]
+	if first {
+		c = comments[0]
+	}
+	return quoteMarkdown(c.Body) + "\n\n" + body
+}
+
+// quoteMarkdown turns text into a markdown blockquote. Every line is quoted,
+// blank ones included, so paragraphs and code fences stay inside the quote.
+func quoteMarkdown(text string) string {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	for i, line := range lines {
+		line = strings.TrimRight(line, "\r")
+		if line == "" {
+			lines[i] = ">"
+			continue
+		}
+		lines[i] = "> " + line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// suggestionBlock wraps the local lines a thread is anchored to in a
+// ```suggestion fence, ready to be edited into the proposed change.
+func suggestionBlock(ctx context.Context, thread reviewThread) (string, error) {
+	if thread.IsOutdated {
+		return "", fmt.Errorf("thread %s is outdated; the lines it refers to are no longer in the diff", thread.ID)
+	}
+	if thread.Path == "" || thread.Line == nil {
+		return "", fmt.Errorf("thread %s is not attached to lines of a file", thread.ID)
+	}
+	start, end := threadRange(thread)
+	root, err := git.TopLevel(ctx)
+	if err != nil {
+		return "", err
+	}
+	lines, err := readFileLines(filepath.Join(root, thread.Path))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("%s does not exist in the local checkout", thread.Path)
+	}
+	if err != nil {
+		return "", err
+	}
+	if end > len(lines) {
+		return "", fmt.Errorf("%s has %d lines locally; the thread refers to %s", thread.Path, len(lines), lineSpan(start, end))
+	}
+	return wrapSuggestion(strings.Join(lines[start-1:end], "\n")), nil
+}
+
+// wrapSuggestion fences code as a suggestion, using a fence longer than any
+// backtick run inside it.
+func wrapSuggestion(code string) string {
+	fence := "```"
+	for strings.Contains(code, fence) {
+		fence += "`"
+	}
+	return fence + "suggestion\n" + code + "\n" + fence
+}
+
+// printReplyPreview shows where a reply will go, the comment it answers and
+// the body as it will render.
+func printReplyPreview(w io.Writer, thread reviewThread, body string) {
+	styler := newStyler(w)
+	fmt.Fprintf(w, "%s %s%s\n\n", styler.label("Reply to thread"), styler.threadID(thread.ID), formatLineInfo(thread))
+	if n := len(thread.Comments.Nodes); n > 0 {
+		last := thread.Comments.Nodes[n-1]
+		fmt.Fprintf(w, "  %s %s — %s\n\n", styler.bullet(), styler.author(last.Author.Login), styler.dim(commentTimestamp(last, time.Now())))
+		for _, line := range formatCommentBody(last.Body, "  ", 120, styler) {
+			fmt.Fprintln(w, line)
+		}
+		fmt.Fprintln(w, "")
+	}
+	fmt.Fprintf(w, "  %s %s\n\n", styler.bullet(), styler.label("your reply"))
+	for _, line := range formatCommentBody(body, "  ", 120, styler) {
+		fmt.Fprintln(w, line)
+	}
+	fmt.Fprintln(w, "")
+}
+
+// replyEditorTemplate pre-fills the editor with the draft body and a
+// commented-out quote of the comment being answered.
+func replyEditorTemplate(body string, thread reviewThread) string {
+	var b strings.Builder
+	b.WriteString(strings.TrimRight(body, "\n"))
+	b.WriteString("\n\n# Write your reply above. Lines starting with '#' are ignored and an\n")
+	b.WriteString("# empty reply aborts without posting.\n")
+	if n := len(thread.Comments.Nodes); n > 0 {
+		b.WriteString("#\n")
+		b.WriteString(commentedQuote(thread.Comments.Nodes[n-1]))
+	}
+	return b.String()
+}
+
+// resolveBody returns the reply body from --body or --body-file; a body file
+// of "-" reads all of stdin.
+func resolveBody(body, bodyFile string) (string, error) {
+	if body != "" && bodyFile != "" {
+		return "", errors.New("provide only one of --body or --body-file")
+	}
+	if bodyFile == "" {
+		return body, nil
+	}
+	if bodyFile == "-" {
+		return readBodyStdin()
+	}
+	data, err := os.ReadFile(bodyFile)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func readBodyStdin() (string, error) {
+	tty := stdinIsTTY()
+	if tty {
+		fmt.Fprintln(promptOut, "Reading the body from stdin; press Ctrl-D on a new line to finish.")
+	}
+	data, err := io.ReadAll(promptReader())
+	if err != nil {
+		return "", fmt.Errorf("failed to read body from stdin: %w", err)
+	}
+	if tty && strings.TrimSpace(string(data)) == "" {
+		return "", errors.New("no body entered on stdin (pipe text in or use --body)")
+	}
+	return string(data), nil
+}
+
+// replyWithRetry posts a reply, retrying after timeouts and server errors.
+// Such a failure doesn't mean the comment wasn't created, so before each
+// retry the thread is checked for an identical reply from the viewer within
+// the retry window, and if one is there it is taken as the result.
+func replyWithRetry(ctx context.Context, client *github.Client, threadID, body string, opts replyOptions) (postedComment, error) {
+	for attempt := 1; ; attempt++ {
+		comment, err := replyToThread(ctx, client, threadID, body)
+		if err == nil || !github.Transient(err) || attempt > opts.retries {
+			return comment, err
+		}
+		// The check may itself hit the same trouble; retry it too.
+		existing, found, checkErr := findRecentReply(ctx, client, threadID, body, opts.retryWindow)
+		if checkErr == nil && found {
+			fmt.Fprintf(os.Stderr, "reply to %s failed (%v) but an identical reply was posted %s; not retrying\n",
+				threadID, err, relativeTime(existing.CreatedAt, time.Now()))
+			return existing, nil
+		}
+		if checkErr != nil {
+			return postedComment{}, fmt.Errorf("%w (not retried: couldn't check whether the reply was posted: %v)", err, checkErr)
+		}
+		fmt.Fprintf(os.Stderr, "reply to %s failed (%v); retrying (%d/%d)\n", threadID, err, attempt, opts.retries)
+		select {
+		case <-ctx.Done():
+			return postedComment{}, ctx.Err()
+		case <-time.After(time.Duration(attempt) * time.Second):
+		}
+	}
+}
+
+// findRecentReply looks among the thread's latest comments for one by the
+// viewer with exactly this body, created within window.
+func findRecentReply(ctx context.Context, client *github.Client, threadID, body string, window time.Duration) (postedComment, bool, error) {
+	query := `query($id:ID!) {
+  viewer { login }
+  node(id:$id) {
+    ... on PullRequestReviewThread {
+      comments(last:20) {
+        nodes { id url createdAt body author { login } }
+      }
+    }
+  }
+}`
+	var resp struct {
+		Viewer struct {
+			Login string `json:"login"`
+		} `json:"viewer"`
+		Node struct {
+			Comments struct {
+				Nodes []threadReply `json:"nodes"`
+			} `json:"comments"`
+		} `json:"node"`
+	}
+	if err := client.Do(ctx, query, map[string]interface{}{"id": threadID}, &resp); err != nil {
+		return postedComment{}, false, err
+	}
+	found, ok := matchRecentReply(resp.Node.Comments.Nodes, resp.Viewer.Login, body, window, time.Now())
+	return found, ok, nil
+}
+
+type threadReply struct {
+	postedComment
+	Body   string `json:"body"`
+	Author actor  `json:"author"`
+}
+
+func matchRecentReply(replies []threadReply, viewer, body string, window time.Duration, now time.Time) (postedComment, bool) {
+	want := strings.TrimSpace(body)
+	for i := len(replies) - 1; i >= 0; i-- {
+		r := replies[i]
+		created, err := time.Parse(time.RFC3339, r.CreatedAt)
+		if err != nil || now.Sub(created) > window {
+			continue
+		}
+		if strings.EqualFold(r.Author.Login, viewer) && strings.TrimSpace(r.Body) == want {
+			return r.postedComment, true
+		}
+	}
+	return postedComment{}, false
+}
+
+type postedComment struct {
+	ID        string `json:"id"`
+	URL       string `json:"url"`
+	CreatedAt string `json:"createdAt"`
+}
+
+// replyToThread posts a reply and returns the new comment.
+func replyToThread(ctx context.Context, client *github.Client, threadID, body string) (postedComment, error) {
+	mutation := `mutation($threadId:ID!, $body:String!) {
+  addPullRequestReviewThreadReply(input:{pullRequestReviewThreadId:$threadId, body:$body}) {
+    comment { id url createdAt }
+  }
+}`
+	vars := map[string]interface{}{
+		"threadId": threadID,
+		"body":     body,
+	}
+	var resp struct {
+		AddPullRequestReviewThreadReply struct {
+			Comment postedComment `json:"comment"`
+		} `json:"addPullRequestReviewThreadReply"`
+	}
+	if err := client.Do(ctx, mutation, vars, &resp); err != nil {
+		return postedComment{}, err
+	}
+	return resp.AddPullRequestReviewThreadReply.Comment, nil
+}
+
+func printReplyUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage:")
+	fmt.Fprintln(w, "  gh-pr-review reply --thread-id <id> --body <text> [--quote|--quote-first] [--resolve|--unresolve] [--dry-run|--confirm] [--json] [--host host]")
+	fmt.Fprintln(w, "  gh-pr-review reply --thread-id <id> --body-file <path> [--quote|--quote-first] [--resolve|--unresolve] [--dry-run|--confirm] [--json] [--host host]")
+	fmt.Fprintln(w, "  gh-pr-review reply --thread-id <id> --template <name> [--var key=value]... [--host host]")
+	fmt.Fprintln(w, "  gh-pr-review reply --thread-id <id> --suggest [--body <text>] [--editor] [--host host]")
+	fmt.Fprintln(w, "  gh-pr-review reply --url <comment-url> --body <text> [--host host]")
+	fmt.Fprintln(w, "  gh-pr-review reply --all [--pr <number>] [--repo owner/name] [--status <value>] [--author <login>] [--path <glob>] --body <text> [--yes] [--host host]")
+	fmt.Fprintln(w, "  gh-pr-review reply [--pr <number>] [--repo owner/name]   (on a terminal: pick a thread, then write the reply)")
+	fmt.Fprintln(w, "  gh-pr-review reply --template list")
+	fmt.Fprintln(w, "  gh-pr-review reply --thread-id <id> [--editor] [--quote|--quote-first] [--resolve|--unresolve] [--dry-run|--confirm] [--json] [--host host]")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Flags:")
+	fmt.Fprintln(w, "  --thread-id <id>   Review thread ID (repeat to post the same reply to several threads); on a terminal, omit it to pick from the unresolved threads")
+	fmt.Fprintln(w, "  --url <url>   Review comment URL (.../pull/42#discussion_r123), or a numeric comment ID with --pr; replies to its thread (repeatable)")
+	fmt.Fprintln(w, "  --all   Reply to every thread matching the filters below, after listing them and asking to confirm")
+	fmt.Fprintln(w, "  --pr <number>   PR for --all, the picker or numeric --url IDs (defaults to current branch PR if available)")
+	fmt.Fprintln(w, "  --repo <owner/name>   Repository for --all, the picker or numeric --url IDs (defaults to gh repo view)")
+	fmt.Fprintln(w, "  --status <value>   With --all: all|resolved|unresolved (default)|resolved-no-reply")
+	fmt.Fprintln(w, "  --author <login>   With --all: only threads opened by this user")
+	fmt.Fprintln(w, "  --path <glob>   With --all: only threads on matching files (glob, or a directory)")
+	fmt.Fprintln(w, "  --body <text>   Reply body")
+	fmt.Fprintln(w, "  --body-file <path>   Read reply body from file (- for stdin)")
+	fmt.Fprintln(w, "  --resolve   Resolve the thread after the reply is posted")
+	fmt.Fprintln(w, "  --unresolve   Unresolve the thread after the reply is posted")
+	fmt.Fprintln(w, "  --template <name>   Use the reply template <config dir>/gh-pr-review/templates/<name>.md (list to show them)")
+	fmt.Fprintln(w, "  --var <key=value>   Set a template variable, used as {{.key}} (repeatable)")
+	fmt.Fprintln(w, "  --suggest   Append a ```suggestion block of the thread's lines from the local file (combine with --editor to tweak it)")
+	fmt.Fprintln(w, "  --mention <login>   Start the reply with @login (repeatable)")
+	fmt.Fprintln(w, "  --quote   Quote the thread's last comment above the reply")
+	fmt.Fprintln(w, "  --quote-first   Quote the thread's opening comment instead")
+	fmt.Fprintln(w, "  --no-resolved-warning   Don't warn when replying to an already resolved thread")
+	fmt.Fprintln(w, "  --retries <n>   Retry after a timeout or server error (default 2); skipped if the reply turns out to have been posted")
+	fmt.Fprintln(w, "  --retry-window <duration>   How recent an identical reply from you must be to count as already posted (default 1m)")
+	fmt.Fprintln(w, "  --json   Print the posted comment as JSON: {id, url, threadId, createdAt} (an array with several threads)")
+	fmt.Fprintln(w, "  --dry-run   Show the target thread, its last comment and the rendered reply without posting")
+	fmt.Fprintln(w, "  --confirm   Show the same preview and ask before posting")
+	fmt.Fprintln(w, "  --yes, -y   Answer yes to the --confirm prompt")
+	fmt.Fprintln(w, "  --no-input   Fail instead of prompting")
+	fmt.Fprintln(w, "  --editor   Edit the reply in $GIT_EDITOR/$EDITOR before posting (default when no body is given on a terminal)")
+	fmt.Fprintln(w, "  --host <host>   GitHub host")
+}
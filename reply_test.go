@@ -0,0 +1,140 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReplyEditorTemplateRoundTrip(t *testing.T) {
+	thread := reviewThread{}
+	thread.Comments.Nodes = []reviewComment{
+		{Author: actor{Login: "alice"}, Body: "first"},
+		{Author: actor{Login: "bob"}, Body: "Can you rename this?\n\n```go\nfoo()\n```"},
+	}
+	template := replyEditorTemplate("Draft", thread)
+	want := "Draft\n\n" +
+		"# Write your reply above. Lines starting with '#' are ignored and an\n" +
+		"# empty reply aborts without posting.\n" +
+		"#\n" +
+		"# bob wrote:\n" +
+		"#\n" +
+		"#   Can you rename this?\n" +
+		"#\n" +
+		"#   ```go\n" +
+		"#   foo()\n" +
+		"#   ```\n"
+	if template != want {
+		t.Fatalf("template:\n%s\nwant:\n%s", template, want)
+	}
+	if got := stripCommentLines(template); got != "Draft" {
+		t.Fatalf("expected comments stripped, got %q", got)
+	}
+	if got := stripCommentLines(replyEditorTemplate("", thread)); got != "" {
+		t.Fatalf("expected an untouched empty template to abort, got %q", got)
+	}
+}
+
+func TestQuotedReply(t *testing.T) {
+	thread := reviewThread{}
+	thread.Comments.Nodes = []reviewComment{
+		{Body: "Why not a map?"},
+		{Body: "Also:\r\n\r\n```go\nm := map[string]int{}\n```\n"},
+	}
+	if got := quotedReply("Done", thread, false, false); got != "Done" {
+		t.Fatalf("expected no quote, got %q", got)
+	}
+	want := "> Also:\n>\n> ```go\n> m := map[string]int{}\n> ```\n\nDone"
+	if got := quotedReply("Done", thread, true, false); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	if got := quotedReply("Done", thread, false, true); got != "> Why not a map?\n\nDone" {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestRenderTemplate(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	if _, err := renderTemplate("done", nil); err == nil || !strings.Contains(err.Error(), "no templates") {
+		t.Fatalf("expected missing template error, got %v", err)
+	}
+	dir, err := templatesDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, body := range map[string]string{"done": "Done in {{.commit}}.", "thanks": "Fixed, thanks!"} {
+		if err := os.WriteFile(filepath.Join(dir, name+".md"), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	vars, err := parseVars([]string{"commit=abc123"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := renderTemplate("done", vars); err != nil || got != "Done in abc123." {
+		t.Fatalf("got %q (%v)", got, err)
+	}
+	if _, err := renderTemplate("done", nil); err == nil || !strings.Contains(err.Error(), "--var") {
+		t.Fatalf("expected missing variable error, got %v", err)
+	}
+	if _, err := renderTemplate("followup", nil); err == nil || !strings.Contains(err.Error(), "available: done, thanks") {
+		t.Fatalf("expected available templates in error, got %v", err)
+	}
+	if _, err := parseVars([]string{"commit"}); err == nil {
+		t.Fatal("expected --var without = to fail")
+	}
+}
+
+func TestWrapSuggestion(t *testing.T) {
+	if got := wrapSuggestion("return nil"); got != "```suggestion\nreturn nil\n```" {
+		t.Fatalf("got %q", got)
+	}
+	code := "// Example:\n// ```go\n// f()\n// ```"
+	if got := wrapSuggestion(code); got != "````suggestion\n"+code+"\n````" {
+		t.Fatalf("expected a longer fence around backticks, got %q", got)
+	}
+}
+
+func TestFormatMentions(t *testing.T) {
+	got, err := formatMentions([]string{"@alice", "bob-smith", "alice"})
+	if err != nil || got != "@alice @bob-smith " {
+		t.Fatalf("got %q (%v)", got, err)
+	}
+	for _, bad := range []string{"-alice", "alice-", "al--ice", "alice smith", "dependabot[bot]", strings.Repeat("a", 40)} {
+		if _, err := formatMentions([]string{bad}); err == nil {
+			t.Errorf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestMatchRecentReply(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	reply := func(id, login, body string, ago time.Duration) threadReply {
+		return threadReply{
+			postedComment: postedComment{ID: id, CreatedAt: now.Add(-ago).Format(time.RFC3339)},
+			Body:          body,
+			Author:        actor{Login: login},
+		}
+	}
+	replies := []threadReply{
+		reply("old", "me", "Fixed", 10*time.Minute),
+		reply("other", "alice", "Fixed", 10*time.Second),
+		reply("mine", "me", "Fixed\n", 20*time.Second),
+	}
+	got, ok := matchRecentReply(replies, "me", "Fixed", time.Minute, now)
+	if !ok || got.ID != "mine" {
+		t.Fatalf("expected the viewer's recent reply, got %+v (%v)", got, ok)
+	}
+	if _, ok := matchRecentReply(replies, "me", "Fixed", 10*time.Second, now); ok {
+		t.Fatal("expected replies outside the window to be ignored")
+	}
+	if _, ok := matchRecentReply(replies, "me", "Something else", time.Minute, now); ok {
+		t.Fatal("expected a different body not to match")
+	}
+}
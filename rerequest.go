@@ -0,0 +1,262 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"gh-pr-review/internal/gh"
+	"gh-pr-review/internal/github"
+)
+
+// reviewer is a user review can be requested from.
+type reviewer struct {
+	ID    string
+	Login string
+}
+
+type rerequestFlags struct {
+	repo   string
+	pr     int
+	logins stringList
+	host   string
+}
+
+func (o *rerequestFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.repo, "repo", "", "owner/name (defaults to gh repo view)")
+	fs.IntVar(&o.pr, "pr", 0, "PR number (defaults to current branch PR if available)")
+	fs.Var(&o.logins, "reviewer", "login to request review from (repeatable; default: everyone who reviewed)")
+	fs.StringVar(&o.host, "host", gh.DefaultHost(), "GitHub host")
+}
+
+func runRerequest(args []string) error {
+	fs := newFlagSet("rerequest", printRerequestUsage)
+	var flags rerequestFlags
+	flags.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+	var wanted []string
+	for _, l := range flags.logins {
+		for _, login := range strings.Split(l, ",") {
+			wanted = append(wanted, strings.TrimPrefix(strings.TrimSpace(login), "@"))
+		}
+	}
+	wanted = uniqueStrings(wanted)
+
+	ctx := context.Background()
+	var err error
+	flags.pr, err = resolvePR(ctx, flags.pr)
+	if err != nil {
+		return err
+	}
+	owner, name, err := resolveRepo(ctx, flags.repo)
+	if err != nil {
+		return err
+	}
+	client, err := newClient(ctx, flags.host)
+	if err != nil {
+		return err
+	}
+	prID, previous, err := fetchPreviousReviewers(ctx, client, owner, name, flags.pr)
+	if err != nil {
+		return err
+	}
+	reviewers := previous
+	if len(wanted) > 0 {
+		if reviewers, err = lookupUsers(ctx, client, wanted); err != nil {
+			return err
+		}
+	}
+	if len(reviewers) == 0 {
+		return fmt.Errorf("nobody has reviewed %s/%s#%d yet; name reviewers with --reviewer", owner, name, flags.pr)
+	}
+
+	requested, failed := requestReviews(ctx, client, prID, reviewers)
+	for _, f := range failed {
+		fmt.Fprintf(os.Stderr, "warning: couldn't request review from %s: %v\n", f.login, f.err)
+	}
+	if len(requested) == 0 {
+		return errors.New("no reviews were requested")
+	}
+	fmt.Fprintf(os.Stdout, "re-requested review from %s\n", strings.Join(requested, ", "))
+	if len(failed) > 0 {
+		return &exitError{code: 1}
+	}
+	return nil
+}
+
+// fetchPreviousReviewers returns the PR's node ID and the users with a
+// submitted review on it, leaving out the PR's author, the viewer and bots,
+// none of whom review can be requested from.
+func fetchPreviousReviewers(ctx context.Context, client *github.Client, owner, name string, pr int) (string, []reviewer, error) {
+	query := `query($owner:String!, $name:String!, $number:Int!) {
+  viewer { login }
+  repository(owner:$owner, name:$name) {
+    pullRequest(number:$number) {
+      id
+      author { login }
+      latestReviews(first:100) {
+        nodes { author { __typename login ... on User { id } } }
+      }
+    }
+  }
+}`
+	vars := map[string]interface{}{
+		"owner":  owner,
+		"name":   name,
+		"number": pr,
+	}
+	var resp struct {
+		Viewer     actor `json:"viewer"`
+		Repository struct {
+			PullRequest *struct {
+				ID            string `json:"id"`
+				Author        actor  `json:"author"`
+				LatestReviews struct {
+					Nodes []struct {
+						Author struct {
+							Typename string `json:"__typename"`
+							Login    string `json:"login"`
+							ID       string `json:"id"`
+						} `json:"author"`
+					} `json:"nodes"`
+				} `json:"latestReviews"`
+			} `json:"pullRequest"`
+		} `json:"repository"`
+	}
+	err := client.Do(ctx, query, vars, &resp)
+	if github.NotFound(err) || (err == nil && resp.Repository.PullRequest == nil) {
+		return "", nil, fmt.Errorf("PR %s/%s#%d not found", owner, name, pr)
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	pull := resp.Repository.PullRequest
+	var reviewers []reviewer
+	for _, n := range pull.LatestReviews.Nodes {
+		a := n.Author
+		if a.Typename != "User" || a.ID == "" || strings.EqualFold(a.Login, pull.Author.Login) || strings.EqualFold(a.Login, resp.Viewer.Login) {
+			continue
+		}
+		reviewers = append(reviewers, reviewer{ID: a.ID, Login: a.Login})
+	}
+	return pull.ID, reviewers, nil
+}
+
+// lookupUsers resolves logins to user node IDs in one request.
+func lookupUsers(ctx context.Context, client *github.Client, logins []string) ([]reviewer, error) {
+	var params, fields []string
+	vars := map[string]interface{}{}
+	for i, login := range logins {
+		params = append(params, fmt.Sprintf("$l%d:String!", i))
+		fields = append(fields, fmt.Sprintf("u%d: user(login:$l%d) { id login }", i, i))
+		vars[fmt.Sprintf("l%d", i)] = login
+	}
+	query := "query(" + strings.Join(params, ", ") + ") {\n  " + strings.Join(fields, "\n  ") + "\n}"
+	var resp map[string]*reviewer
+	err := client.Do(ctx, query, vars, &resp)
+	var gqlErr *github.Error
+	if err != nil && !errors.As(err, &gqlErr) {
+		return nil, err
+	}
+	var users []reviewer
+	var missing []string
+	for i, login := range logins {
+		u := resp[fmt.Sprintf("u%d", i)]
+		if u == nil || u.ID == "" {
+			missing = append(missing, login)
+			continue
+		}
+		users = append(users, *u)
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("no GitHub user named %s", strings.Join(missing, ", "))
+	}
+	return users, nil
+}
+
+type reviewRequestFailure struct {
+	login string
+	err   error
+}
+
+// requestReviews asks for reviews from all reviewers at once. GitHub
+// refuses the whole request if any of them lacks access to the repository,
+// so a refusal is retried one reviewer at a time to request the rest and
+// name the ones refused.
+func requestReviews(ctx context.Context, client *github.Client, prID string, reviewers []reviewer) ([]string, []reviewRequestFailure) {
+	err := requestReviewsFrom(ctx, client, prID, reviewers)
+	if err == nil {
+		var logins []string
+		for _, r := range reviewers {
+			logins = append(logins, r.Login)
+		}
+		return logins, nil
+	}
+	var gqlErr *github.Error
+	if len(reviewers) == 1 || !errors.As(err, &gqlErr) {
+		var failed []reviewRequestFailure
+		for _, r := range reviewers {
+			failed = append(failed, reviewRequestFailure{login: r.Login, err: reviewRequestError(err)})
+		}
+		return nil, failed
+	}
+	var requested []string
+	var failed []reviewRequestFailure
+	for _, r := range reviewers {
+		if err := requestReviewsFrom(ctx, client, prID, []reviewer{r}); err != nil {
+			failed = append(failed, reviewRequestFailure{login: r.Login, err: reviewRequestError(err)})
+			continue
+		}
+		requested = append(requested, r.Login)
+	}
+	return requested, failed
+}
+
+func requestReviewsFrom(ctx context.Context, client *github.Client, prID string, reviewers []reviewer) error {
+	mutation := `mutation($id:ID!, $users:[ID!]) {
+  requestReviews(input:{pullRequestId:$id, userIds:$users, union:true}) {
+    pullRequest { id }
+  }
+}`
+	var ids []string
+	for _, r := range reviewers {
+		ids = append(ids, r.ID)
+	}
+	return client.Do(ctx, mutation, map[string]interface{}{"id": prID, "users": ids}, nil)
+}
+
+// reviewRequestError explains GitHub's refusal to request a review from
+// someone who can't see the repository.
+func reviewRequestError(err error) error {
+	if strings.Contains(err.Error(), "collaborator") || github.Forbidden(err) {
+		return errors.New("they don't have access to the repository (reviews can only be requested from collaborators)")
+	}
+	return err
+}
+
+func printRerequestUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage:")
+	fmt.Fprintln(w, "  gh-pr-review rerequest [--pr <number>] [--repo owner/name] [--reviewer <login>]... [--host host]")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Re-requests review on a PR, by default from everyone who has reviewed it (except you, the")
+	fmt.Fprintln(w, "PR's author and bots). Reviewers who can't be requested because they lack access to the")
+	fmt.Fprintln(w, "repository are named in a warning, the others are still requested, and the exit status is 1.")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Flags:")
+	fmt.Fprintln(w, "  --pr <number>   PR number (defaults to current branch PR if available)")
+	fmt.Fprintln(w, "  --repo <owner/name>   Repository (defaults to gh repo view)")
+	fmt.Fprintln(w, "  --reviewer <login>   Request review from this user instead (repeatable, or comma-separated)")
+	fmt.Fprintln(w, "  --host <host>   GitHub host")
+}
@@ -0,0 +1,731 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"unicode"
+
+	"gh-pr-review/internal/gh"
+	"gh-pr-review/internal/github"
+)
+
+type resolveFlags struct {
+	// resolve is set for resolve; only unresolve takes --resolved-by.
+	resolve     bool
+	threadIDs   stringList
+	commentURLs stringList
+	repo        string
+	pr          int
+	all         bool
+	filter      threadFilter
+	comment     string
+	commentFile string
+	batch       bool
+	jsonOut     bool
+	strict      bool
+	dryRun      bool
+	resume      string
+	keepJournal bool
+	host        string
+}
+
+func (o *resolveFlags) register(fs *flag.FlagSet) {
+	fs.Var(&o.threadIDs, "thread-id", "Review thread ID (repeatable)")
+	fs.Var(&o.commentURLs, "url", "review comment URL or numeric ID (repeatable)")
+	fs.StringVar(&o.repo, "repo", "", "owner/name for --all or numeric --url IDs (defaults to gh repo view)")
+	fs.IntVar(&o.pr, "pr", 0, "PR number for --all or numeric --url IDs")
+	fs.BoolVar(&o.all, "all", false, "act on every thread matching --status/--outdated/--author/--path")
+	fs.StringVar(&o.filter.status, "status", "", "with --all: all|resolved|unresolved|resolved-no-reply")
+	fs.BoolVar(&o.filter.outdated, "outdated", false, "with --all: only outdated threads")
+	fs.StringVar(&o.filter.author, "author", "", "with --all: only threads opened by this login")
+	fs.StringVar(&o.filter.path, "path", "", "with --all: only threads on files matching this glob or directory; with --line: the thread's file")
+	if !o.resolve {
+		fs.StringVar(&o.filter.resolvedBy, "resolved-by", "", "unresolve threads resolved by this login (implies --all)")
+	}
+	fs.DurationVar(&o.filter.since, "since", 0, "with --all or --resolved-by: only threads with a comment in this window, e.g. 24h")
+	fs.IntVar(&o.filter.line, "line", 0, "with --path: the thread covering this line")
+	fs.StringVar(&o.comment, "comment", "", "reply with this text before changing the thread")
+	fs.StringVar(&o.commentFile, "comment-file", "", "read the reply from a file (- for stdin)")
+	fs.BoolVar(&o.batch, "batch", true, "send the mutations for several threads in batched requests")
+	fs.BoolVar(&o.jsonOut, "json", false, "print results as JSON; other output goes to stderr")
+	fs.BoolVar(&o.dryRun, "dry-run", false, "show which threads would change without changing them")
+	fs.BoolVar(&o.strict, "strict", false, "exit 3 if a thread was already in the requested state")
+	fs.StringVar(&o.resume, "resume", "", "with --all: skip the threads this progress journal records as done")
+	fs.BoolVar(&o.keepJournal, "keep-journal", false, "with --all: keep the progress journal after a clean run")
+	addPromptFlags(fs)
+	fs.StringVar(&o.host, "host", gh.DefaultHost(), "GitHub host")
+}
+
+func runResolve(args []string, resolve bool) error {
+	fs := newFlagSet("resolve", func(w io.Writer) { printResolveUsage(w, resolve) })
+	flags := resolveFlags{resolve: resolve}
+	flags.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	// Thread IDs may also be given as arguments, separated by spaces or
+	// commas; bare numbers are indexes from the last list.
+	var positional []string
+	for _, arg := range fs.Args() {
+		positional = append(positional, strings.FieldsFunc(arg, func(r rune) bool {
+			return r == ',' || unicode.IsSpace(r)
+		})...)
+	}
+	positional, indexes := splitIndexArgs(positional)
+	ids := uniqueStrings(append(flags.threadIDs, positional...))
+	refs, err := parseCommentRefs(flags.commentURLs)
+	if err != nil {
+		return err
+	}
+	if flags.filter.resolvedBy != "" {
+		flags.all = true
+	}
+	byLine := !flags.all && flags.filter.line > 0
+	switch {
+	case flags.filter.line < 0:
+		return errors.New("--line must be a positive line number")
+	case flags.filter.line > 0 && flags.filter.path == "":
+		return errors.New("--line requires --path")
+	case (flags.all || byLine) && (len(ids) > 0 || len(refs) > 0 || len(indexes) > 0):
+		return errors.New("provide only one of --thread-id/--url, --path/--line or --all")
+	case !flags.all && !byLine && len(ids) == 0 && len(refs) == 0 && len(indexes) == 0:
+		return errors.New("--thread-id, --url, --path/--line or --all is required")
+	case flags.filter.since < 0:
+		return errors.New("--since must be a positive duration")
+	case !flags.all && !byLine && (flags.filter.status != "" || flags.filter.outdated || flags.filter.author != "" || flags.filter.path != "" || flags.filter.since > 0):
+		return errors.New("--status, --outdated, --author, --path and --since only apply with --all or --line")
+	case !flags.all && (flags.resume != "" || flags.keepJournal):
+		return errors.New("--resume and --keep-journal only apply with --all")
+	case flags.dryRun && flags.resume != "":
+		return errors.New("--resume can't be combined with --dry-run")
+	}
+	if flags.comment != "" && flags.commentFile != "" {
+		return errors.New("provide only one of --comment or --comment-file")
+	}
+	if flags.comment, err = resolveBody(flags.comment, flags.commentFile); err != nil {
+		return err
+	}
+	if flags.commentFile != "" && strings.TrimSpace(flags.comment) == "" {
+		return errors.New("--comment-file is empty")
+	}
+	if flags.filter.status == "" {
+		// Only threads in the other state need changing.
+		flags.filter.status = resolutionState(!resolve)
+	}
+	if flags.filter.status != "all" && flags.filter.status != "resolved" && flags.filter.status != "unresolved" && flags.filter.status != "resolved-no-reply" {
+		return fmt.Errorf("invalid --status %q (use all, resolved, unresolved, or resolved-no-reply)", flags.filter.status)
+	}
+
+	ctx := context.Background()
+	client, err := newClient(ctx, commentRefHost(refs, flags.host))
+	if err != nil {
+		return err
+	}
+	for _, ref := range refs {
+		id, err := threadIDForComment(ctx, client, ref, flags.repo, flags.pr)
+		if err != nil {
+			return err
+		}
+		ids = uniqueStrings(append(ids, id))
+	}
+	if len(indexes) > 0 {
+		indexed, err := threadIDsForIndexes(ctx, flags.host, flags.repo, flags.pr, indexes)
+		if err != nil {
+			return err
+		}
+		ids = uniqueStrings(append(ids, indexed...))
+	}
+
+	var targets []threadTarget
+	if flags.all {
+		threads, err := matchingThreads(ctx, client, flags.repo, flags.pr, flags.filter)
+		if err != nil {
+			return err
+		}
+		if len(threads) == 0 {
+			fmt.Fprintf(os.Stderr, "no threads match; nothing was %s\n", resolutionState(resolve))
+			return nil
+		}
+		for _, t := range threads {
+			targets = append(targets, checkResolveTarget(t.ID, t, nil, resolve, flags.comment != ""))
+		}
+		printThreadTargets(targets)
+		question := fmt.Sprintf("Resolve %d threads?", len(targets))
+		if !resolve {
+			question = fmt.Sprintf("Unresolve %d threads?", len(targets))
+		}
+		if !flags.dryRun {
+			ok, err := confirm(question)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return fmt.Errorf("aborted; nothing was %s", resolutionState(resolve))
+			}
+		}
+	} else {
+		if byLine {
+			id, err := threadAtLine(ctx, client, flags.repo, flags.pr, flags.filter)
+			if err != nil {
+				return err
+			}
+			ids = []string{id}
+		}
+		for _, id := range ids {
+			targets = append(targets, loadResolveTarget(ctx, client, id, resolve, flags.comment != ""))
+		}
+	}
+
+	// Threads named one by one are confirmed when someone is still waiting
+	// on an answer; --all asked once already.
+	opts := resolveOptions{resolve: resolve, all: flags.all, comment: flags.comment, batch: flags.batch, jsonOut: flags.jsonOut, strict: flags.strict, dryRun: flags.dryRun}
+	if flags.all && !flags.dryRun {
+		action := "resolve"
+		if !resolve {
+			action = "unresolve"
+		}
+		if opts.journal, err = openJournal(action, flags.resume, flags.keepJournal); err != nil {
+			return err
+		}
+		// ctrl+c stops the run at the next request and still reports.
+		var stop context.CancelFunc
+		ctx, stop = signal.NotifyContext(ctx, os.Interrupt)
+		defer stop()
+	}
+	if resolve && !flags.all && !flags.dryRun && !assumeYes && canPrompt() {
+		if opts.viewer, err = fetchViewerLogin(ctx, client); err != nil {
+			return err
+		}
+	}
+	return resolveTargets(ctx, client, targets, opts)
+}
+
+type resolveOptions struct {
+	resolve bool
+	// all means targets came from --all and were confirmed as a group;
+	// ones that can't be changed are skipped rather than failed.
+	all bool
+	// viewer is the viewer's login, set only when threads still awaiting
+	// their reply should be confirmed first.
+	viewer  string
+	comment string
+	batch   bool
+	jsonOut bool
+	strict  bool
+	dryRun  bool
+	// journal records each thread's outcome for --resume; nil for dry
+	// runs and threads named one by one.
+	journal *journal
+}
+
+// resolveTargets changes the state of each target and reports the outcome.
+// Threads already in the requested state are left alone; with strict they
+// make the command exit 3. A dry run prints the same per-thread lines as a
+// real one, saying what would happen instead.
+func resolveTargets(ctx context.Context, client *github.Client, targets []threadTarget, opts resolveOptions) error {
+	resolve := opts.resolve
+	out := io.Writer(os.Stdout)
+	if opts.jsonOut {
+		out = os.Stderr
+	}
+	if len(targets) == 1 && !opts.all {
+		t := targets[0]
+		err := t.err
+		result := newResolveResult(t)
+		if err == nil && (t.thread.IsResolved == resolve || opts.dryRun) {
+			if t.thread.IsResolved == resolve {
+				posted, err := commentUnchanged(ctx, client, t.id, opts)
+				if err != nil {
+					return err
+				}
+				result.CommentURL = posted.URL
+				printResolution(out, "", t, "was already "+resolutionState(resolve), posted)
+			} else {
+				printResolution(out, "", t, "would be "+resolutionState(resolve), postedComment{})
+				result.WouldChange = true
+			}
+			if opts.jsonOut {
+				if err := writeJSON(os.Stdout, []resolveResult{result}); err != nil {
+					return err
+				}
+			}
+			if opts.strict && !result.WouldChange {
+				return &exitError{code: exitCodeUnchanged}
+			}
+			return nil
+		}
+		if err == nil && opts.viewer != "" && awaitingReply(t.thread, opts.viewer) {
+			var ok bool
+			if ok, err = confirmResolve(t.thread); err == nil && !ok {
+				err = fmt.Errorf("aborted; thread %s was not resolved", t.id)
+			}
+		}
+		if err == nil {
+			result, err = applyResolution(ctx, client, out, t, opts.comment, resolve)
+		}
+		if opts.jsonOut {
+			if err != nil {
+				result.Error = err.Error()
+			}
+			if err := writeJSON(os.Stdout, []resolveResult{result}); err != nil {
+				return err
+			}
+		}
+		return err
+	}
+
+	action := "resolve"
+	if !resolve {
+		action = "unresolve"
+	}
+	journal := opts.journal
+	var done, unchanged, skipped, wouldChange, resumed int
+	var failed []string
+	var ready []string
+	posted := map[string]postedComment{}
+	results := map[string]resolveResult{}
+	threads := map[string]threadTarget{}
+	for _, t := range targets {
+		result := newResolveResult(t)
+		results[t.id] = result
+		threads[t.id] = t
+		err := t.err
+		switch {
+		case journal.done(t.id, action):
+			printResolution(out, t.id+": ", t, "was "+resolutionState(resolve)+" by the resumed run", postedComment{})
+			resumed++
+			continue
+		case err != nil && opts.all:
+			// --all only skips what it isn't allowed to touch; those
+			// threads were listed before confirming.
+			fmt.Fprintf(os.Stderr, "warning: skipping %s: %v\n", t.id, err)
+			result.Error = "skipped: " + err.Error()
+			results[t.id] = result
+			journal.record(t.id, action, journalSkipped, err)
+			skipped++
+			continue
+		case err == nil && t.thread.IsResolved == resolve:
+			posted, err := commentUnchanged(ctx, client, t.id, opts)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", t.id, err)
+				result.Error = err.Error()
+				results[t.id] = result
+				journal.record(t.id, "comment", journalFailed, err)
+				failed = append(failed, t.id)
+				continue
+			}
+			result.CommentURL = posted.URL
+			results[t.id] = result
+			journal.record(t.id, action, "", nil)
+			printResolution(out, t.id+": ", t, "was already "+resolutionState(resolve), posted)
+			unchanged++
+			continue
+		case err == nil && opts.dryRun:
+			printResolution(out, t.id+": ", t, "would be "+resolutionState(resolve), postedComment{})
+			result.WouldChange = true
+			results[t.id] = result
+			wouldChange++
+			continue
+		case err == nil && opts.viewer != "" && awaitingReply(t.thread, opts.viewer):
+			var ok bool
+			if ok, err = confirmResolve(t.thread); err == nil && !ok {
+				fmt.Fprintf(os.Stderr, "skipping %s\n", t.id)
+				result.Error = "skipped"
+				results[t.id] = result
+				journal.record(t.id, action, journalSkipped, errors.New("declined"))
+				skipped++
+				continue
+			}
+		}
+		// A resumed run doesn't post the comment twice.
+		if err == nil && opts.comment != "" && !journal.done(t.id, "comment") {
+			posted[t.id], err = postResolveComment(ctx, client, t.id, opts.comment, resolve)
+			journal.record(t.id, "comment", journalFailed, err)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", t.id, err)
+			result.Error = err.Error()
+			results[t.id] = result
+			failed = append(failed, t.id)
+			continue
+		}
+		ready = append(ready, t.id)
+	}
+	report := func(threadID string, resolved bool, err error) {
+		result := results[threadID]
+		result.CommentURL = posted[threadID].URL
+		journal.record(threadID, action, journalFailed, err)
+		if err != nil {
+			err = resolutionFailed(posted[threadID], resolve, err)
+			fmt.Fprintf(os.Stderr, "%s: %v\n", threadID, err)
+			result.Error = err.Error()
+			results[threadID] = result
+			failed = append(failed, threadID)
+			return
+		}
+		result.IsResolved = resolved
+		result.Changed = true
+		results[threadID] = result
+		printResolution(out, threadID+": ", threads[threadID], "is now "+resolutionState(resolved), posted[threadID])
+		done++
+	}
+	if opts.batch {
+		setThreadsResolved(ctx, client, ready, resolve, report)
+	} else {
+		for _, id := range ready {
+			resolved, err := setThreadResolved(ctx, client, id, resolve)
+			report(id, resolved, err)
+		}
+	}
+	summary := fmt.Sprintf("%s %d, failed %d", resolutionState(resolve), done, len(failed))
+	if opts.dryRun {
+		verb := "resolve"
+		if !resolve {
+			verb = "unresolve"
+		}
+		summary = fmt.Sprintf("would %s %d, failed %d", verb, wouldChange, len(failed))
+	}
+	if unchanged > 0 {
+		summary += fmt.Sprintf(", already %s %d", resolutionState(resolve), unchanged)
+	}
+	if skipped > 0 {
+		summary += fmt.Sprintf(", skipped %d", skipped)
+	}
+	if resumed > 0 {
+		summary += fmt.Sprintf(", done before resuming %d", resumed)
+	}
+	fmt.Fprintln(out, summary)
+	journal.finish(len(failed) == 0 && ctx.Err() == nil)
+	if opts.jsonOut {
+		ordered := make([]resolveResult, 0, len(targets))
+		for _, t := range targets {
+			ordered = append(ordered, results[t.id])
+		}
+		if err := writeJSON(os.Stdout, ordered); err != nil {
+			return err
+		}
+	}
+	if len(failed) > 0 {
+		return &exitError{code: 1}
+	}
+	if unchanged > 0 && opts.strict {
+		return &exitError{code: exitCodeUnchanged}
+	}
+	return nil
+}
+
+// resolveResult is one thread's outcome in resolve --json output.
+type resolveResult struct {
+	ThreadID   string `json:"threadId"`
+	IsResolved bool   `json:"isResolved"`
+	// Changed is false when the thread was already in the requested state
+	// or couldn't be changed.
+	Changed bool `json:"changed"`
+	// WouldChange is set by --dry-run for threads a real run would change.
+	WouldChange bool   `json:"wouldChange,omitempty"`
+	Path        string `json:"path,omitempty"`
+	Line        *int   `json:"line,omitempty"`
+	Author      string `json:"author,omitempty"`
+	CommentURL  string `json:"commentUrl,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+func newResolveResult(t threadTarget) resolveResult {
+	result := resolveResult{ThreadID: t.id, IsResolved: t.thread.IsResolved, Path: t.thread.Path, Line: t.thread.Line}
+	if result.Line == nil {
+		result.Line = t.thread.OriginalLine
+	}
+	if len(t.thread.Comments.Nodes) > 0 {
+		result.Author = t.thread.Comments.Nodes[0].Author.Login
+	}
+	return result
+}
+
+// awaitingReply reports whether the thread's last comment is from someone
+// other than viewer, so resolving it would leave them unanswered.
+func awaitingReply(t reviewThread, viewer string) bool {
+	nodes := t.Comments.Nodes
+	if len(nodes) == 0 {
+		return false
+	}
+	return !strings.EqualFold(nodes[len(nodes)-1].Author.Login, viewer)
+}
+
+// confirmResolve shows the start of the thread and asks before resolving
+// it.
+func confirmResolve(t reviewThread) (bool, error) {
+	question := "Resolve thread"
+	if location := strings.TrimSpace(formatLineInfo(t)); location != "" {
+		question += " on " + strings.Trim(location, "[]")
+	}
+	if len(t.Comments.Nodes) > 0 {
+		first := t.Comments.Nodes[0]
+		if first.Author.Login != "" {
+			question += " by " + first.Author.Login
+		}
+		styler := newStyler(promptOut)
+		fmt.Fprintf(promptOut, "  %s\n", styler.dim(truncateRunes(firstLine(first.Body), 100)))
+	}
+	return confirm(question + "?")
+}
+
+// threadAtLine finds the one thread on filter.path covering filter.line.
+// When none or several match, the candidates are listed on stderr.
+func threadAtLine(ctx context.Context, client *github.Client, repo string, pr int, filter threadFilter) (string, error) {
+	line := filter.line
+	filter.line = 0
+	candidates, err := matchingThreads(ctx, client, repo, pr, filter)
+	if err != nil {
+		return "", err
+	}
+	matches := filterByLine(candidates, line)
+	if len(matches) == 1 {
+		return matches[0].ID, nil
+	}
+	shown := matches
+	if len(matches) == 0 {
+		shown = candidates
+	}
+	if len(shown) > 0 {
+		targets := make([]threadTarget, len(shown))
+		for i, t := range shown {
+			targets[i] = threadTarget{id: t.ID, thread: t}
+		}
+		printThreadTargets(targets)
+	}
+	if len(matches) == 0 {
+		return "", fmt.Errorf("no %s thread on %s covers line %d", filter.status, filter.path, line)
+	}
+	return "", fmt.Errorf("%d %s threads on %s cover line %d; pick one with --thread-id", len(matches), filter.status, filter.path, line)
+}
+
+func loadResolveTarget(ctx context.Context, client *github.Client, threadID string, resolve, comment bool) threadTarget {
+	thread, err := fetchThread(ctx, client, threadID)
+	return checkResolveTarget(threadID, thread, err, resolve, comment)
+}
+
+func checkResolveTarget(threadID string, thread reviewThread, err error, resolve, comment bool) threadTarget {
+	switch {
+	case err != nil:
+	case thread.IsPending:
+		err = errPendingThread(threadID)
+	case resolve && !thread.CanResolve && !thread.IsResolved:
+		err = fmt.Errorf("you don't have permission to resolve thread %s (write access to the repository is required)", threadID)
+	case !resolve && !thread.CanUnresolve && thread.IsResolved:
+		err = fmt.Errorf("you don't have permission to unresolve thread %s (write access to the repository is required)", threadID)
+	case comment && !thread.CanReply:
+		err = fmt.Errorf("you don't have permission to reply to thread %s (the conversation may be locked)", threadID)
+	}
+	return threadTarget{id: threadID, thread: thread, err: err}
+}
+
+// applyResolution posts comment, if any, then runs the mutation and prints
+// the thread's new state. The comment goes first so a thread is never
+// resolved without it.
+func applyResolution(ctx context.Context, client *github.Client, w io.Writer, t threadTarget, comment string, resolve bool) (resolveResult, error) {
+	threadID := t.id
+	result := newResolveResult(t)
+	var posted postedComment
+	if comment != "" {
+		var err error
+		if posted, err = postResolveComment(ctx, client, threadID, comment, resolve); err != nil {
+			return result, err
+		}
+		result.CommentURL = posted.URL
+	}
+	resolved, err := setThreadResolved(ctx, client, threadID, resolve)
+	if err != nil {
+		return result, resolutionFailed(posted, resolve, err)
+	}
+	result.IsResolved = resolved
+	result.Changed = true
+	printResolution(w, "", t, "is now "+resolutionState(resolved), posted)
+	return result, nil
+}
+
+// commentUnchanged posts the --comment on a thread that is already in the
+// requested state and is left alone, so the comment isn't lost with the
+// mutation. Dry runs post nothing.
+func commentUnchanged(ctx context.Context, client *github.Client, threadID string, opts resolveOptions) (postedComment, error) {
+	if opts.comment == "" || opts.dryRun {
+		return postedComment{}, nil
+	}
+	posted, err := replyToThread(ctx, client, threadID, opts.comment)
+	if err != nil {
+		return postedComment{}, fmt.Errorf("posting comment: %w (thread was already %s)", err, resolutionState(opts.resolve))
+	}
+	return posted, nil
+}
+
+func postResolveComment(ctx context.Context, client *github.Client, threadID, comment string, resolve bool) (postedComment, error) {
+	posted, err := replyToThread(ctx, client, threadID, comment)
+	if err != nil {
+		return postedComment{}, fmt.Errorf("posting comment: %w (thread was left %s)", err, resolutionState(!resolve))
+	}
+	return posted, nil
+}
+
+// resolutionFailed explains a failed mutation, pointing at the comment if
+// one was already posted.
+func resolutionFailed(posted postedComment, resolve bool, err error) error {
+	if posted.ID != "" {
+		return fmt.Errorf("comment %s was posted but the thread could not be %s: %w", posted.URL, resolutionState(resolve), err)
+	}
+	return err
+}
+
+// printResolution prints a thread's line in resolve output, e.g.
+// "thread X is now resolved [main.go:10] alice: Handle nil". Dry runs print
+// the same line with "would be resolved".
+func printResolution(w io.Writer, prefix string, t threadTarget, state string, posted postedComment) {
+	line := fmt.Sprintf("%sthread %s %s", prefix, t.id, state)
+	if summary := threadSummary(t.thread, newStyler(w)); summary != "" {
+		line += " " + summary
+	}
+	if posted.ID != "" {
+		line += "; commented " + posted.URL
+	}
+	fmt.Fprintln(w, line)
+}
+
+// setThreadResolved resolves or unresolves a thread and returns whether it
+// is now resolved.
+func setThreadResolved(ctx context.Context, client *github.Client, threadID string, resolved bool) (bool, error) {
+	var mutation string
+	var op string
+	if resolved {
+		op = "resolveReviewThread"
+		mutation = `mutation($threadId:ID!) { resolveReviewThread(input:{threadId:$threadId}) { thread { id isResolved } } }`
+	} else {
+		op = "unresolveReviewThread"
+		mutation = `mutation($threadId:ID!) { unresolveReviewThread(input:{threadId:$threadId}) { thread { id isResolved } } }`
+	}
+	vars := map[string]interface{}{
+		"threadId": threadID,
+	}
+	var resp map[string]struct {
+		Thread struct {
+			ID         string `json:"id"`
+			IsResolved bool   `json:"isResolved"`
+		} `json:"thread"`
+	}
+	if err := client.Do(ctx, mutation, vars, &resp); err != nil {
+		return false, err
+	}
+	result, ok := resp[op]
+	if !ok {
+		return false, errors.New("missing mutation response")
+	}
+	return result.Thread.IsResolved, nil
+}
+
+// resolveBatchSize caps how many aliased mutations setThreadsResolved sends
+// in one request.
+const resolveBatchSize = 20
+
+// setThreadsResolved resolves or unresolves threads with one request per
+// batch, each thread's mutation under its own alias, and calls report with
+// every thread's outcome. An error on one alias is reported against its
+// thread only.
+func setThreadsResolved(ctx context.Context, client *github.Client, threadIDs []string, resolved bool, report func(threadID string, resolved bool, err error)) {
+	op := "resolveReviewThread"
+	if !resolved {
+		op = "unresolveReviewThread"
+	}
+	for start := 0; start < len(threadIDs); start += resolveBatchSize {
+		chunk := threadIDs[start:min(start+resolveBatchSize, len(threadIDs))]
+		var params, fields []string
+		vars := map[string]interface{}{}
+		for i, id := range chunk {
+			params = append(params, fmt.Sprintf("$t%d:ID!", i))
+			fields = append(fields, fmt.Sprintf("r%d: %s(input:{threadId:$t%d}) { thread { id isResolved } }", i, op, i))
+			vars[fmt.Sprintf("t%d", i)] = id
+		}
+		mutation := fmt.Sprintf("mutation(%s) {\n  %s\n}", strings.Join(params, ", "), strings.Join(fields, "\n  "))
+		var resp map[string]*struct {
+			Thread struct {
+				ID         string `json:"id"`
+				IsResolved bool   `json:"isResolved"`
+			} `json:"thread"`
+		}
+		err := client.Do(ctx, mutation, vars, &resp)
+		var gqlErr *github.Error
+		errors.As(err, &gqlErr)
+		for i, id := range chunk {
+			alias := fmt.Sprintf("r%d", i)
+			if aliasErr := gqlErr.For(alias); aliasErr != nil {
+				report(id, false, aliasErr)
+			} else if result := resp[alias]; result != nil {
+				report(id, result.Thread.IsResolved, nil)
+			} else if err != nil {
+				report(id, false, err)
+			} else {
+				report(id, false, errors.New("missing mutation response"))
+			}
+		}
+	}
+}
+
+// exitCodeUnchanged is the --strict exit status when a thread already had
+// the requested state.
+const exitCodeUnchanged = 3
+
+func resolutionState(resolved bool) string {
+	if resolved {
+		return "resolved"
+	}
+	return "unresolved"
+}
+
+func printResolveUsage(w io.Writer, resolve bool) {
+	action := "resolve"
+	if !resolve {
+		action = "unresolve"
+	}
+	fmt.Fprintln(w, "Usage:")
+	fmt.Fprintf(w, "  gh-pr-review %s --thread-id <id> [--thread-id <id>]... [--comment <text>|--comment-file <path>] [--json] [--host host]\n", action)
+	fmt.Fprintf(w, "  gh-pr-review %s [--pr <number>] [--repo owner/name] [--host host] <id|index>[,<id|index>]...\n", action)
+	fmt.Fprintf(w, "  gh-pr-review %s --url <comment-url|comment-id> [--pr <number>] [--repo owner/name] [--host host]\n", action)
+	fmt.Fprintf(w, "  gh-pr-review %s --path <file> --line <n> [--pr <number>] [--repo owner/name] [--status <value>] [--comment <text>|--comment-file <path>] [--host host]\n", action)
+	if !resolve {
+		fmt.Fprintln(w, "  gh-pr-review unresolve --resolved-by <login> [--since <duration>] [--pr <number>] [--repo owner/name] [--yes] [--dry-run] [--json] [--host host]")
+	}
+	fmt.Fprintf(w, "  gh-pr-review %s --all [--pr <number>] [--repo owner/name] [--status <value>] [--outdated] [--author <login>] [--path <glob>] [--comment <text>|--comment-file <path>] [--yes] [--dry-run] [--resume <journal>] [--keep-journal] [--json] [--host host]\n", action)
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Flags:")
+	fmt.Fprintln(w, "  --thread-id <id>   Review thread ID (repeatable; IDs may also be given as arguments)")
+	fmt.Fprintln(w, "  <index>   A number printed by the last `list` of the same PR, e.g. `resolve 3 5 7`")
+	fmt.Fprintln(w, "  --url <url>   Review comment URL (.../pull/42#discussion_r123), or a numeric comment ID with --pr (repeatable)")
+	fmt.Fprintln(w, "  --all   Act on every thread matching the filters below, after listing them and asking to confirm")
+	if !resolve {
+		fmt.Fprintln(w, "  --resolved-by <login>   Reopen threads this user resolved; implies --all")
+	}
+	fmt.Fprintln(w, "  --since <duration>   With --all: only threads with a comment created or edited in this window, e.g. 24h (GitHub doesn't record when a thread was resolved)")
+	fmt.Fprintln(w, "  --line <n>   With --path: act on the one thread whose lines include n; candidates are listed if none or several match")
+	fmt.Fprintln(w, "  --pr <number>   PR for --all, --line, indexes or numeric --url IDs (defaults to current branch PR if available)")
+	fmt.Fprintln(w, "  --repo <owner/name>   Repository for --all, --line, indexes or numeric --url IDs (defaults to gh repo view)")
+	fmt.Fprintf(w, "  --status <value>   With --all or --line: all|resolved|unresolved|resolved-no-reply (default %s)\n", resolutionState(!resolve))
+	fmt.Fprintln(w, "  --outdated   With --all: only outdated threads")
+	fmt.Fprintln(w, "  --author <login>   With --all: only threads opened by this user")
+	fmt.Fprintln(w, "  --path <glob>   With --all: only threads on matching files (glob, or a directory); with --line: the file")
+	fmt.Fprintln(w, "  --comment <text>   Reply to the thread first, then change its state")
+	fmt.Fprintln(w, "  --comment-file <path>   Read the --comment reply from a file (use - for stdin)")
+	fmt.Fprintln(w, "  --dry-run   Print the threads that would change, in the same format as a real run, and change nothing")
+	fmt.Fprintf(w, "  --strict   Exit %d if a thread was already %s (such threads are otherwise left alone and the exit status is 0)\n", exitCodeUnchanged, resolutionState(resolve))
+	fmt.Fprintln(w, "  --json   Print a JSON array of {threadId, isResolved, changed, path, line, author, commentUrl, error} results; other output goes to stderr")
+	fmt.Fprintln(w, "  --batch   Send the mutations for several threads in batched requests of 20 (default true; --batch=false sends one at a time)")
+	fmt.Fprintln(w, "  --resume <journal>   With --all: skip the threads a progress journal from an interrupted or failed run records as done")
+	fmt.Fprintln(w, "  --keep-journal   With --all: keep the progress journal (under the cache directory) even when every thread succeeded")
+	fmt.Fprintln(w, "  --yes, -y   Skip confirmation (asked for --all, and on a terminal for threads still awaiting your reply)")
+	fmt.Fprintln(w, "  --no-input   Fail instead of prompting")
+	fmt.Fprintln(w, "  --host <host>   GitHub host")
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"gh-pr-review/internal/gh"
+)
+
+// staleThread is an unresolved thread whose code is gone, with why it
+// counts as stale.
+type staleThread struct {
+	thread reviewThread
+	reason string
+}
+
+type resolveStaleFlags struct {
+	repo        string
+	pr          int
+	olderThan   dayDuration
+	comment     string
+	commentFile string
+	batch       bool
+	jsonOut     bool
+	dryRun      bool
+	resume      string
+	keepJournal bool
+	host        string
+}
+
+func (o *resolveStaleFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.repo, "repo", "", "owner/name (defaults to gh repo view)")
+	fs.IntVar(&o.pr, "pr", 0, "PR number (defaults to current branch PR if available)")
+	fs.Var(&o.olderThan, "older-than", "only threads with no comment in this window, e.g. 14d or 72h")
+	fs.StringVar(&o.comment, "comment", "", "reply with this text before resolving each thread")
+	fs.StringVar(&o.commentFile, "comment-file", "", "read the reply from a file (- for stdin)")
+	fs.BoolVar(&o.batch, "batch", true, "send the mutations in batched requests")
+	fs.BoolVar(&o.jsonOut, "json", false, "print results as JSON; other output goes to stderr")
+	fs.BoolVar(&o.dryRun, "dry-run", false, "list the stale threads without resolving them")
+	fs.StringVar(&o.resume, "resume", "", "skip the threads this progress journal records as done")
+	fs.BoolVar(&o.keepJournal, "keep-journal", false, "keep the progress journal after a clean run")
+	addPromptFlags(fs)
+	fs.StringVar(&o.host, "host", gh.DefaultHost(), "GitHub host")
+}
+
+func runResolveStale(args []string) error {
+	fs := newFlagSet("resolve-stale", printResolveStaleUsage)
+	var flags resolveStaleFlags
+	flags.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+	if flags.olderThan < 0 {
+		return errors.New("--older-than must be a positive duration")
+	}
+	if flags.dryRun && flags.resume != "" {
+		return errors.New("--resume can't be combined with --dry-run")
+	}
+	if flags.comment != "" && flags.commentFile != "" {
+		return errors.New("provide only one of --comment or --comment-file")
+	}
+	var err error
+	flags.comment, err = resolveBody(flags.comment, flags.commentFile)
+	if err != nil {
+		return err
+	}
+	if flags.commentFile != "" && strings.TrimSpace(flags.comment) == "" {
+		return errors.New("--comment-file is empty")
+	}
+
+	ctx := context.Background()
+	flags.pr, err = resolvePR(ctx, flags.pr)
+	if err != nil {
+		return err
+	}
+	owner, name, err := resolveRepo(ctx, flags.repo)
+	if err != nil {
+		return err
+	}
+	client, err := newClient(ctx, flags.host)
+	if err != nil {
+		return err
+	}
+	threads, err := fetchAllThreads(ctx, client, owner, name, flags.pr)
+	if err != nil {
+		return err
+	}
+	files, err := fetchChangedFiles(ctx, client, owner, name, flags.pr)
+	if err != nil {
+		return err
+	}
+	var cutoff time.Time
+	if flags.olderThan > 0 {
+		cutoff = time.Now().Add(-time.Duration(flags.olderThan))
+	}
+	stale := staleThreads(threads, files, cutoff)
+	if len(stale) == 0 {
+		fmt.Fprintln(os.Stderr, "no stale threads; nothing was resolved")
+		return nil
+	}
+
+	targets := make([]threadTarget, 0, len(stale))
+	for _, s := range stale {
+		targets = append(targets, checkResolveTarget(s.thread.ID, s.thread, nil, true, flags.comment != ""))
+	}
+	printStaleThreads(os.Stderr, stale, targets)
+	if !flags.dryRun {
+		ok, err := confirm(fmt.Sprintf("Resolve %d threads?", len(targets)))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errors.New("aborted; nothing was resolved")
+		}
+	}
+	opts := resolveOptions{resolve: true, all: true, comment: flags.comment, batch: flags.batch, jsonOut: flags.jsonOut, dryRun: flags.dryRun}
+	if !flags.dryRun {
+		if opts.journal, err = openJournal("resolve-stale", flags.resume, flags.keepJournal); err != nil {
+			return err
+		}
+		var stop context.CancelFunc
+		ctx, stop = signal.NotifyContext(ctx, os.Interrupt)
+		defer stop()
+	}
+	return resolveTargets(ctx, client, targets, opts)
+}
+
+// staleThreads picks the unresolved, outdated threads whose code is gone:
+// either the file is no longer part of the PR's diff, or GitHub could not
+// carry the commented lines forward to the current head. With a non-zero
+// cutoff, threads with a comment created or edited after it are kept open.
+func staleThreads(threads []reviewThread, changedFiles []string, cutoff time.Time) []staleThread {
+	inDiff := map[string]bool{}
+	for _, f := range changedFiles {
+		inDiff[f] = true
+	}
+	var out []staleThread
+	for _, t := range threads {
+		if t.IsResolved || !t.IsOutdated || t.IsPending {
+			continue
+		}
+		var reason string
+		switch {
+		case !inDiff[t.Path]:
+			reason = "file no longer in the diff"
+		case t.Line == nil:
+			reason = "commented lines were removed"
+		default:
+			continue
+		}
+		if !cutoff.IsZero() && len(filterActiveSince([]reviewThread{t}, cutoff)) > 0 {
+			continue
+		}
+		out = append(out, staleThread{thread: t, reason: reason})
+	}
+	return out
+}
+
+func printStaleThreads(w io.Writer, stale []staleThread, targets []threadTarget) {
+	styler := newStyler(w)
+	fmt.Fprintf(w, "%d stale threads:\n", len(stale))
+	for i, s := range stale {
+		author := ""
+		if len(s.thread.Comments.Nodes) > 0 {
+			author = " " + styler.author(s.thread.Comments.Nodes[0].Author.Login)
+		}
+		note := s.reason
+		if err := targets[i].err; err != nil {
+			note += "; will be skipped: " + err.Error()
+		}
+		fmt.Fprintf(w, "  %s%s%s %s\n", styler.threadID(s.thread.ID), formatLineInfo(s.thread), author, styler.dim("("+note+")"))
+	}
+}
+
+func printResolveStaleUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage:")
+	fmt.Fprintln(w, "  gh-pr-review resolve-stale [--pr <number>] [--repo owner/name] [--older-than <duration>] [--comment <text>|--comment-file <path>] [--yes] [--dry-run] [--resume <journal>] [--keep-journal] [--json] [--host host]")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Resolves unresolved, outdated threads on code that no longer exists: the file has left the")
+	fmt.Fprintln(w, "PR's diff, or the commented lines were removed. The threads are listed with the reason and")
+	fmt.Fprintln(w, "resolved after confirming.")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Flags:")
+	fmt.Fprintln(w, "  --pr <number>   PR number (defaults to current branch PR if available)")
+	fmt.Fprintln(w, "  --repo <owner/name>   Repository (defaults to gh repo view)")
+	fmt.Fprintln(w, "  --older-than <duration>   Only threads with no comment created or edited in this window, e.g. 14d or 72h")
+	fmt.Fprintln(w, "  --comment <text>   Reply to each thread first, e.g. \"Resolving: code was removed/refactored\"")
+	fmt.Fprintln(w, "  --comment-file <path>   Read the --comment reply from a file (use - for stdin)")
+	fmt.Fprintln(w, "  --dry-run   List the stale threads and change nothing")
+	fmt.Fprintln(w, "  --json   Print a JSON array of {threadId, isResolved, changed, path, line, author, commentUrl, error} results; other output goes to stderr")
+	fmt.Fprintln(w, "  --batch   Send the mutations in batched requests of 20 (default true)")
+	fmt.Fprintln(w, "  --resume <journal>   Skip the threads a progress journal from an interrupted or failed run records as done")
+	fmt.Fprintln(w, "  --keep-journal   Keep the progress journal (under the cache directory) even when every thread succeeded")
+	fmt.Fprintln(w, "  --yes, -y   Skip confirmation")
+	fmt.Fprintln(w, "  --no-input   Fail instead of prompting")
+	fmt.Fprintln(w, "  --host <host>   GitHub host")
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"gh-pr-review/internal/github"
+)
+
+func TestCheckResolveTarget(t *testing.T) {
+	tests := []struct {
+		name    string
+		thread  reviewThread
+		err     error
+		resolve bool
+		comment bool
+		want    string
+	}{
+		{name: "ok", thread: reviewThread{CanResolve: true}, resolve: true},
+		{name: "fetch error", err: errors.New("thread T not found"), resolve: true, want: "not found"},
+		{name: "pending", thread: reviewThread{IsPending: true, CanResolve: true}, resolve: true, want: "pending"},
+		{name: "no permission", thread: reviewThread{}, resolve: true, want: "permission to resolve"},
+		{name: "already resolved", thread: reviewThread{IsResolved: true}, resolve: true},
+		{name: "locked with comment", thread: reviewThread{CanResolve: true}, resolve: true, comment: true, want: "permission to reply"},
+		{name: "no permission to unresolve", thread: reviewThread{IsResolved: true}, want: "permission to unresolve"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := checkResolveTarget("T", tt.thread, tt.err, tt.resolve, tt.comment)
+			if tt.want == "" {
+				if got.err != nil {
+					t.Fatalf("unexpected error: %v", got.err)
+				}
+				return
+			}
+			if got.err == nil || !strings.Contains(got.err.Error(), tt.want) {
+				t.Fatalf("error = %v, want it to mention %q", got.err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAwaitingReply(t *testing.T) {
+	thread := func(logins ...string) reviewThread {
+		var th reviewThread
+		for _, l := range logins {
+			th.Comments.Nodes = append(th.Comments.Nodes, reviewComment{Author: actor{Login: l}})
+		}
+		return th
+	}
+	if awaitingReply(thread(), "me") {
+		t.Error("empty thread should not be awaiting a reply")
+	}
+	if !awaitingReply(thread("alice"), "me") {
+		t.Error("thread opened by someone else should be awaiting a reply")
+	}
+	if awaitingReply(thread("alice", "Me"), "me") {
+		t.Error("thread the viewer answered last should not be awaiting a reply")
+	}
+	if !awaitingReply(thread("alice", "me", "alice"), "me") {
+		t.Error("follow-up after the viewer's reply should be awaiting a reply")
+	}
+}
+
+func TestConfirmResolve(t *testing.T) {
+	line := 88
+	th := reviewThread{Path: "graphql.go", Line: &line}
+	th.Comments.Nodes = []reviewComment{{Author: actor{Login: "alice"}, Body: "Handle the nil case\nmore"}}
+
+	var out bytes.Buffer
+	withPrompt(t, "y\n", true, false, false)
+	promptOut = &out
+	ok, err := confirmResolve(th)
+	if err != nil || !ok {
+		t.Fatalf("confirmResolve = %v, %v; want true", ok, err)
+	}
+	for _, want := range []string{"Handle the nil case", "Resolve thread on graphql.go:88 by alice? [y/N]"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("prompt %q missing %q", out.String(), want)
+		}
+	}
+
+	withPrompt(t, "\n", true, false, false)
+	if ok, err := confirmResolve(th); err != nil || ok {
+		t.Fatalf("confirmResolve with empty answer = %v, %v; want false", ok, err)
+	}
+}
+
+func TestSetThreadsResolvedBatches(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		var req github.GraphQLRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		data := map[string]interface{}{}
+		var errs []map[string]interface{}
+		for i := 0; i < len(req.Variables); i++ {
+			alias := fmt.Sprintf("r%d", i)
+			id := req.Variables[fmt.Sprintf("t%d", i)].(string)
+			if !strings.Contains(req.Query, alias+": resolveReviewThread(input:{threadId:$t"+strconv.Itoa(i)+"})") {
+				t.Fatalf("query missing alias %s:\n%s", alias, req.Query)
+			}
+			if id == "T7" {
+				data[alias] = nil
+				errs = append(errs, map[string]interface{}{"path": []string{alias}, "message": "Could not resolve to a node with the global id of 'T7'"})
+				continue
+			}
+			data[alias] = map[string]interface{}{"thread": map[string]interface{}{"id": id, "isResolved": true}}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": data, "errors": errs})
+	}))
+	defer srv.Close()
+
+	var ids []string
+	for i := 0; i < 25; i++ {
+		ids = append(ids, fmt.Sprintf("T%d", i))
+	}
+	got := map[string]string{}
+	setThreadsResolved(context.Background(), github.NewClient(srv.URL, "token"), ids, true, func(id string, resolved bool, err error) {
+		switch {
+		case err != nil:
+			got[id] = err.Error()
+		case resolved:
+			got[id] = "resolved"
+		}
+	})
+	if requests != 2 {
+		t.Fatalf("expected 2 batched requests for 25 threads, got %d", requests)
+	}
+	if len(got) != 25 {
+		t.Fatalf("expected an outcome for every thread, got %d", len(got))
+	}
+	for id, outcome := range got {
+		want := "resolved"
+		if id == "T7" {
+			want = "graphql error: Could not resolve to a node with the global id of 'T7'"
+		}
+		if outcome != want {
+			t.Errorf("%s: got %q, want %q", id, outcome, want)
+		}
+	}
+}
+
+// fakeThreadServer answers thread lookups from states and records the
+// mutations it receives.
+func fakeThreadServer(t *testing.T, states map[string]bool) (*github.Client, *[]string) {
+	t.Helper()
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	var mutations []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req github.GraphQLRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+			return
+		}
+		if strings.HasPrefix(req.Query, "mutation") {
+			mutations = append(mutations, req.Query)
+			data := map[string]interface{}{}
+			for i := 0; i < len(req.Variables); i++ {
+				id := req.Variables[fmt.Sprintf("t%d", i)]
+				data[fmt.Sprintf("r%d", i)] = map[string]interface{}{"thread": map[string]interface{}{"id": id, "isResolved": true}}
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+			return
+		}
+		id := req.Variables["id"].(string)
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"node": map[string]interface{}{
+			"id": id, "isResolved": states[id], "viewerCanResolve": true, "viewerCanUnresolve": true, "viewerCanReply": true,
+		}}})
+	}))
+	t.Cleanup(srv.Close)
+	return github.NewClient(srv.URL, "token"), &mutations
+}
+
+func TestResolveTargetsAlreadyResolved(t *testing.T) {
+	ctx := context.Background()
+	load := func(client *github.Client, ids ...string) []threadTarget {
+		var targets []threadTarget
+		for _, id := range ids {
+			targets = append(targets, loadResolveTarget(ctx, client, id, true, false))
+		}
+		return targets
+	}
+
+	client, mutations := fakeThreadServer(t, map[string]bool{"A": true})
+	if err := resolveTargets(ctx, client, load(client, "A"), resolveOptions{resolve: true, batch: true}); err != nil {
+		t.Fatalf("already resolved thread: got %v, want success", err)
+	}
+	if err := resolveTargets(ctx, client, load(client, "A"), resolveOptions{resolve: true, batch: true, strict: true}); exitCode(err) != exitCodeUnchanged {
+		t.Fatalf("already resolved thread with --strict: got %v, want exit %d", err, exitCodeUnchanged)
+	}
+	if len(*mutations) != 0 {
+		t.Fatalf("expected no mutations for an already resolved thread, got %d", len(*mutations))
+	}
+
+	client, mutations = fakeThreadServer(t, map[string]bool{"A": true})
+	err := resolveTargets(ctx, client, load(client, "A", "B", "C"), resolveOptions{resolve: true, batch: true, strict: true})
+	if exitCode(err) != exitCodeUnchanged {
+		t.Fatalf("mixed threads with --strict: got %v, want exit %d", err, exitCodeUnchanged)
+	}
+	if len(*mutations) != 1 || strings.Contains((*mutations)[0], "r2:") || !strings.Contains((*mutations)[0], "r1:") {
+		t.Fatalf("expected one batched mutation for B and C only, got %q", *mutations)
+	}
+}
+
+func TestResolveTargetsAlreadyResolvedStillComments(t *testing.T) {
+	ctx := context.Background()
+	for _, ids := range [][]string{{"A"}, {"A", "B"}} {
+		client, mutations := fakeThreadServer(t, map[string]bool{"A": true, "B": true})
+		var targets []threadTarget
+		for _, id := range ids {
+			targets = append(targets, loadResolveTarget(ctx, client, id, true, true))
+		}
+		if err := resolveTargets(ctx, client, targets, resolveOptions{resolve: true, comment: "Fixed"}); err != nil {
+			t.Fatalf("%v: %v", ids, err)
+		}
+		if len(*mutations) != len(ids) {
+			t.Fatalf("%v: expected one comment per thread, got %q", ids, *mutations)
+		}
+		for _, m := range *mutations {
+			if !strings.Contains(m, "addPullRequestReviewThreadReply") {
+				t.Errorf("%v: expected only comments, got %q", ids, m)
+			}
+		}
+	}
+}
+
+func TestResolveTargetsDryRun(t *testing.T) {
+	ctx := context.Background()
+	client, mutations := fakeThreadServer(t, map[string]bool{"A": true})
+	var targets []threadTarget
+	for _, id := range []string{"A", "B", "C"} {
+		targets = append(targets, loadResolveTarget(ctx, client, id, true, false))
+	}
+	if err := resolveTargets(ctx, client, targets, resolveOptions{resolve: true, all: true, batch: true, dryRun: true}); err != nil {
+		t.Fatalf("dry run: got %v, want success", err)
+	}
+	if len(*mutations) != 0 {
+		t.Fatalf("dry run sent %d mutations", len(*mutations))
+	}
+}
+
+func TestPrintResolutionDryRunMatchesRealRun(t *testing.T) {
+	line := 10
+	target := threadTarget{id: "T", thread: reviewThread{Path: "main.go", Line: &line}}
+	target.thread.Comments.Nodes = []reviewComment{{Author: actor{Login: "alice"}, Body: "Handle nil"}}
+	var real, dry bytes.Buffer
+	printResolution(&real, "T: ", target, "is now resolved", postedComment{})
+	printResolution(&dry, "T: ", target, "would be resolved", postedComment{})
+	if got := real.String(); got != "T: thread T is now resolved [main.go:10] alice: Handle nil\n" {
+		t.Fatalf("real run line = %q", got)
+	}
+	if got := dry.String(); got != strings.Replace(real.String(), "is now", "would be", 1) {
+		t.Fatalf("dry run line = %q", got)
+	}
+}
+
+func TestStaleThreads(t *testing.T) {
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	thread := func(id, path string, line *int, outdated bool, lastComment time.Time) reviewThread {
+		th := reviewThread{ID: id, Path: path, Line: line, OriginalLine: intPtr(10), IsOutdated: outdated}
+		th.Comments.Nodes = []reviewComment{{CreatedAt: lastComment.Format(time.RFC3339)}}
+		return th
+	}
+	old, recent := now.AddDate(0, 0, -30), now.AddDate(0, 0, -1)
+	resolved := thread("resolved", "gone.go", nil, true, old)
+	resolved.IsResolved = true
+	threads := []reviewThread{
+		thread("current", "kept.go", intPtr(10), false, old),
+		thread("moved", "kept.go", intPtr(12), true, old),
+		thread("removed-file", "gone.go", intPtr(10), true, old),
+		thread("removed-lines", "kept.go", nil, true, old),
+		thread("recent", "gone.go", nil, true, recent),
+		resolved,
+	}
+	files := []string{"kept.go"}
+
+	var got []string
+	for _, s := range staleThreads(threads, files, time.Time{}) {
+		got = append(got, s.thread.ID+": "+s.reason)
+	}
+	want := []string{
+		"removed-file: file no longer in the diff",
+		"removed-lines: commented lines were removed",
+		"recent: file no longer in the diff",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("staleThreads = %q, want %q", got, want)
+	}
+
+	got = nil
+	for _, s := range staleThreads(threads, files, now.AddDate(0, 0, -14)) {
+		got = append(got, s.thread.ID)
+	}
+	if strings.Join(got, ",") != "removed-file,removed-lines" {
+		t.Errorf("with a cutoff got %q, want the threads untouched for 14 days", got)
+	}
+}
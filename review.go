@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"gh-pr-review/internal/gh"
+	"gh-pr-review/internal/github"
+)
+
+// Review events accepted by addPullRequestReview and submitPullRequestReview.
+const (
+	reviewEventApprove        = "APPROVE"
+	reviewEventRequestChanges = "REQUEST_CHANGES"
+	reviewEventComment        = "COMMENT"
+)
+
+type submittedReview struct {
+	ID    string `json:"id"`
+	URL   string `json:"url"`
+	State string `json:"state"`
+}
+
+type reviewFlags struct {
+	repo           string
+	pr             int
+	approve        bool
+	requestChanges bool
+	comment        bool
+	body           string
+	bodyFile       string
+	jsonOut        bool
+	host           string
+}
+
+func (o *reviewFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.repo, "repo", "", "owner/name (defaults to gh repo view)")
+	fs.IntVar(&o.pr, "pr", 0, "PR number (defaults to current branch PR if available)")
+	fs.BoolVar(&o.approve, "approve", false, "approve the PR")
+	fs.BoolVar(&o.requestChanges, "request-changes", false, "request changes (needs a body)")
+	fs.BoolVar(&o.comment, "comment", false, "leave a review comment without approving")
+	fs.StringVar(&o.body, "body", "", "Review body")
+	fs.StringVar(&o.bodyFile, "body-file", "", "Read the review body from file (- for stdin)")
+	fs.BoolVar(&o.jsonOut, "json", false, "output the submitted review as JSON")
+	fs.StringVar(&o.host, "host", gh.DefaultHost(), "GitHub host")
+}
+
+func runReview(args []string) error {
+	fs := newFlagSet("review", printReviewUsage)
+	var flags reviewFlags
+	flags.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+	var event string
+	chosen := 0
+	for _, opt := range []struct {
+		set   bool
+		event string
+	}{{flags.approve, reviewEventApprove}, {flags.requestChanges, reviewEventRequestChanges}, {flags.comment, reviewEventComment}} {
+		if opt.set {
+			chosen++
+			event = opt.event
+		}
+	}
+	if chosen != 1 {
+		return errors.New("provide exactly one of --approve, --request-changes or --comment")
+	}
+	var err error
+	flags.body, err = resolveBody(flags.body, flags.bodyFile)
+	if err != nil {
+		return err
+	}
+	flags.body = strings.TrimSpace(flags.body)
+	if event == reviewEventRequestChanges && flags.body == "" {
+		return errors.New("--request-changes needs a --body or --body-file explaining what to change")
+	}
+
+	ctx := context.Background()
+	flags.pr, err = resolvePR(ctx, flags.pr)
+	if err != nil {
+		return err
+	}
+	owner, name, err := resolveRepo(ctx, flags.repo)
+	if err != nil {
+		return err
+	}
+	client, err := newClient(ctx, flags.host)
+	if err != nil {
+		return err
+	}
+	prID, pendingID, err := fetchReviewTarget(ctx, client, owner, name, flags.pr)
+	if err != nil {
+		return err
+	}
+	if event == reviewEventComment && flags.body == "" && pendingID == "" {
+		return errors.New("--comment needs a --body or --body-file")
+	}
+	review, err := submitReview(ctx, client, prID, pendingID, event, flags.body)
+	if err != nil {
+		return err
+	}
+	if flags.jsonOut {
+		return writeJSON(os.Stdout, review)
+	}
+	if pendingID != "" {
+		fmt.Fprintln(os.Stderr, "Submitted your pending review along with its comments.")
+	}
+	fmt.Fprintf(os.Stdout, "%s %s\n", reviewStateText(review.State), review.URL)
+	return nil
+}
+
+// fetchReviewTarget returns the PR's node ID and the viewer's pending
+// review on it, if there is one. Pending reviews are only visible to their
+// author, so any review returned is the viewer's.
+func fetchReviewTarget(ctx context.Context, client *github.Client, owner, name string, pr int) (string, string, error) {
+	query := `query($owner:String!, $name:String!, $number:Int!) {
+  repository(owner:$owner, name:$name) {
+    pullRequest(number:$number) {
+      id
+      reviews(states:[PENDING], first:1) { nodes { id } }
+    }
+  }
+}`
+	vars := map[string]interface{}{
+		"owner":  owner,
+		"name":   name,
+		"number": pr,
+	}
+	var resp struct {
+		Repository struct {
+			PullRequest *struct {
+				ID      string `json:"id"`
+				Reviews struct {
+					Nodes []struct {
+						ID string `json:"id"`
+					} `json:"nodes"`
+				} `json:"reviews"`
+			} `json:"pullRequest"`
+		} `json:"repository"`
+	}
+	err := client.Do(ctx, query, vars, &resp)
+	if github.NotFound(err) || (err == nil && resp.Repository.PullRequest == nil) {
+		return "", "", fmt.Errorf("PR %s/%s#%d not found", owner, name, pr)
+	}
+	if err != nil {
+		return "", "", err
+	}
+	var pendingID string
+	if nodes := resp.Repository.PullRequest.Reviews.Nodes; len(nodes) > 0 {
+		pendingID = nodes[0].ID
+	}
+	return resp.Repository.PullRequest.ID, pendingID, nil
+}
+
+// submitReview submits the viewer's pending review if there is one, since
+// GitHub allows only one per user, and otherwise creates and submits a new
+// review in one step.
+func submitReview(ctx context.Context, client *github.Client, prID, pendingID, event, body string) (submittedReview, error) {
+	vars := map[string]interface{}{"event": event}
+	if body != "" {
+		vars["body"] = body
+	}
+	var op, mutation string
+	if pendingID != "" {
+		op = "submitPullRequestReview"
+		vars["id"] = pendingID
+		mutation = `mutation($id:ID!, $event:PullRequestReviewEvent!, $body:String) {
+  submitPullRequestReview(input:{pullRequestReviewId:$id, event:$event, body:$body}) {
+    pullRequestReview { id url state }
+  }
+}`
+	} else {
+		op = "addPullRequestReview"
+		vars["id"] = prID
+		mutation = `mutation($id:ID!, $event:PullRequestReviewEvent!, $body:String) {
+  addPullRequestReview(input:{pullRequestId:$id, event:$event, body:$body}) {
+    pullRequestReview { id url state }
+  }
+}`
+	}
+	var resp map[string]struct {
+		PullRequestReview submittedReview `json:"pullRequestReview"`
+	}
+	if err := client.Do(ctx, mutation, vars, &resp); err != nil {
+		return submittedReview{}, err
+	}
+	review := resp[op].PullRequestReview
+	if review.ID == "" {
+		return submittedReview{}, errors.New("missing mutation response")
+	}
+	return review, nil
+}
+
+func reviewStateText(state string) string {
+	switch state {
+	case "CHANGES_REQUESTED":
+		return "requested changes"
+	case "COMMENTED":
+		return "commented"
+	default:
+		return strings.ToLower(state)
+	}
+}
+
+func printReviewUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage:")
+	fmt.Fprintln(w, "  gh-pr-review review [--pr <number>] [--repo owner/name] --approve|--request-changes|--comment [--body <text>|--body-file <path>] [--json] [--host host]")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Flags:")
+	fmt.Fprintln(w, "  --pr <number>   PR number (defaults to current branch PR if available)")
+	fmt.Fprintln(w, "  --repo <owner/name>   Repository (defaults to gh repo view)")
+	fmt.Fprintln(w, "  --approve   Approve the PR")
+	fmt.Fprintln(w, "  --request-changes   Request changes (a body is required)")
+	fmt.Fprintln(w, "  --comment   Comment without approving (a body is required unless you have a pending review)")
+	fmt.Fprintln(w, "  --body <text>   Review body")
+	fmt.Fprintln(w, "  --body-file <path>   Read the review body from file (- for stdin)")
+	fmt.Fprintln(w, "  --json   Print the submitted review as JSON ({id, url, state})")
+	fmt.Fprintln(w, "  --host <host>   GitHub host")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "If you have a pending review on the PR, it is submitted with its comments.")
+}
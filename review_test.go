@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gh-pr-review/internal/github"
+)
+
+func TestSubmitReviewUsesPendingReview(t *testing.T) {
+	var queries []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req github.GraphQLRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+			return
+		}
+		queries = append(queries, req.Query)
+		if _, ok := req.Variables["body"]; ok != strings.Contains(req.Query, "submit") {
+			t.Errorf("unexpected body variable in %q: %v", req.Query, req.Variables)
+		}
+		review := map[string]interface{}{"pullRequestReview": map[string]string{"id": "R1", "url": "https://example.com/r1", "state": "APPROVED"}}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{
+			"addPullRequestReview":    review,
+			"submitPullRequestReview": review,
+		}})
+	}))
+	defer srv.Close()
+	client := github.NewClient(srv.URL, "token")
+
+	ctx := context.Background()
+	if _, err := submitReview(ctx, client, "PR_1", "", reviewEventApprove, ""); err != nil {
+		t.Fatal(err)
+	}
+	review, err := submitReview(ctx, client, "PR_1", "PRR_pending", reviewEventComment, "Looks good")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if review.URL != "https://example.com/r1" || review.State != "APPROVED" {
+		t.Fatalf("review = %+v", review)
+	}
+	if len(queries) != 2 || !strings.Contains(queries[0], "addPullRequestReview(") || !strings.Contains(queries[1], "submitPullRequestReview(") {
+		t.Fatalf("queries = %q", queries)
+	}
+}
+
+func TestPendingThreads(t *testing.T) {
+	draft := reviewComment{ID: "D", State: "PENDING"}
+	posted := reviewComment{ID: "P", State: "SUBMITTED"}
+	threads := []reviewThread{
+		{ID: "new", Comments: reviewThreadComment{Nodes: []reviewComment{draft}}},
+		{ID: "reply", Comments: reviewThreadComment{Nodes: []reviewComment{posted, draft}}},
+		{ID: "other", Comments: reviewThreadComment{Nodes: []reviewComment{posted}}},
+	}
+	got := pendingThreads(threads)
+	if strings.Join(threadIDs(got), ",") != "new,reply" {
+		t.Fatalf("pendingThreads = %v", threadIDs(got))
+	}
+	for _, th := range got {
+		if !th.IsPending || len(th.Comments.Nodes) != 1 || th.Comments.Nodes[0].ID != "D" {
+			t.Fatalf("thread %s = %+v, want only the draft, marked pending", th.ID, th)
+		}
+	}
+	if countComments(got) != 2 || len(threads[1].Comments.Nodes) != 2 {
+		t.Fatal("pendingThreads modified its input or miscounted")
+	}
+}
+
+func TestRequestReviewsRetriesEachReviewer(t *testing.T) {
+	var calls [][]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req github.GraphQLRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+			return
+		}
+		users, _ := req.Variables["users"].([]interface{})
+		calls = append(calls, users)
+		for _, u := range users {
+			if u == "U_outsider" {
+				w.Write([]byte(`{"data":{"requestReviews":null},"errors":[{"type":"UNPROCESSABLE","message":"Reviews may only be requested from collaborators."}]}`))
+				return
+			}
+		}
+		w.Write([]byte(`{"data":{"requestReviews":{"pullRequest":{"id":"PR_1"}}}}`))
+	}))
+	defer srv.Close()
+	client := github.NewClient(srv.URL, "token")
+
+	reviewers := []reviewer{{ID: "U_alice", Login: "alice"}, {ID: "U_outsider", Login: "outsider"}, {ID: "U_bob", Login: "bob"}}
+	requested, failed := requestReviews(context.Background(), client, "PR_1", reviewers)
+	if strings.Join(requested, ",") != "alice,bob" {
+		t.Errorf("requested = %v", requested)
+	}
+	if len(failed) != 1 || failed[0].login != "outsider" || !strings.Contains(failed[0].err.Error(), "access") {
+		t.Errorf("failed = %+v", failed)
+	}
+	if len(calls) != 4 || len(calls[0]) != 3 {
+		t.Errorf("calls = %v, want one for everyone then one each", calls)
+	}
+
+	calls = nil
+	requested, failed = requestReviews(context.Background(), client, "PR_1", reviewers[:1])
+	if len(requested) != 1 || len(failed) != 0 || len(calls) != 1 {
+		t.Errorf("single reviewer: requested %v, failed %v, %d calls", requested, failed, len(calls))
+	}
+}
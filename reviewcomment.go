@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"gh-pr-review/internal/github"
+)
+
+// ownedComment is a review comment with what the viewer may do to it.
+type ownedComment struct {
+	ID              string `json:"id"`
+	Body            string `json:"body"`
+	URL             string `json:"url"`
+	Author          actor  `json:"author"`
+	ViewerDidAuthor bool   `json:"viewerDidAuthor"`
+	ViewerCanUpdate bool   `json:"viewerCanUpdate"`
+	ViewerCanDelete bool   `json:"viewerCanDelete"`
+	PullRequest     struct {
+		Number     int `json:"number"`
+		Repository struct {
+			Name  string `json:"name"`
+			Owner struct {
+				Login string `json:"login"`
+			} `json:"owner"`
+		} `json:"repository"`
+	} `json:"pullRequest"`
+}
+
+func fetchOwnedComment(ctx context.Context, client *github.Client, commentID string) (ownedComment, error) {
+	query := `query($id:ID!) {
+  node(id:$id) {
+    ... on PullRequestReviewComment {
+      id
+      body
+      url
+      author { login type: __typename }
+      viewerDidAuthor
+      viewerCanUpdate
+      viewerCanDelete
+      pullRequest { number repository { name owner { login } } }
+    }
+  }
+}`
+	var resp struct {
+		Node *ownedComment `json:"node"`
+	}
+	err := client.Do(ctx, query, map[string]interface{}{"id": commentID}, &resp)
+	if github.NotFound(err) || (err == nil && (resp.Node == nil || resp.Node.ID == "")) {
+		return ownedComment{}, fmt.Errorf("review comment %s not found (pass its URL with --url, or the node ID from `gh-pr-review list --json`)", commentID)
+	}
+	if err != nil {
+		return ownedComment{}, err
+	}
+	return *resp.Node, nil
+}
+
+// reviewCommentID returns the node ID of the comment named by --comment-id
+// or --url. A numeric --comment-id is treated as a database ID, like --url.
+func reviewCommentID(ctx context.Context, client *github.Client, commentID string, ref *commentRef, repo string, pr int) (string, error) {
+	if ref == nil && !isDigits(commentID) {
+		return commentID, nil
+	}
+	if ref == nil {
+		r, err := parseCommentRef(commentID)
+		if err != nil {
+			return "", err
+		}
+		ref = &r
+	}
+	_, c, err := commentForRef(ctx, client, *ref, repo, pr)
+	if err != nil {
+		return "", err
+	}
+	return c.ID, nil
+}
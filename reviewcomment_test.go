@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gh-pr-review/internal/github"
+)
+
+func TestEditCommentRequests(t *testing.T) {
+	var response string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(response))
+	}))
+	defer srv.Close()
+	client := github.NewClient(srv.URL, "token")
+	ctx := context.Background()
+
+	response = `{"data":{"node":{"id":"PRRC_1","body":"teh fix","url":"https://github.com/o/r/pull/1#discussion_r1","author":{"login":"alice"},"viewerDidAuthor":true,"viewerCanUpdate":true}}}`
+	c, err := fetchOwnedComment(ctx, client, "PRRC_1")
+	if err != nil || c.Body != "teh fix" || !c.ViewerDidAuthor {
+		t.Fatalf("fetchOwnedComment = %+v, %v", c, err)
+	}
+
+	response = `{"data":{"node":null}}`
+	if _, err := fetchOwnedComment(ctx, client, "PRRC_typo"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected a not-found error, got %v", err)
+	}
+
+	response = `{"data":{"updatePullRequestReviewComment":{"pullRequestReviewComment":{"id":"PRRC_1","url":"https://github.com/o/r/pull/1#discussion_r1"}}}}`
+	if url, err := updateReviewComment(ctx, client, "PRRC_1", "the fix"); err != nil || url != "https://github.com/o/r/pull/1#discussion_r1" {
+		t.Fatalf("updateReviewComment = %q, %v", url, err)
+	}
+
+	response = `{"data":{"updatePullRequestReviewComment":null},"errors":[{"type":"FORBIDDEN","message":"Resource not accessible by integration"}]}`
+	if _, err := updateReviewComment(ctx, client, "PRRC_2", "x"); err == nil || err.Error() != "you can only edit your own comments" {
+		t.Fatalf("expected a friendly permission error, got %v", err)
+	}
+}
+
+func TestDeleteCommentForbidden(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"deletePullRequestReviewComment":null},"errors":[{"type":"FORBIDDEN","message":"Resource not accessible"}]}`))
+	}))
+	defer srv.Close()
+	err := deleteReviewComment(context.Background(), github.NewClient(srv.URL, "token"), "PRRC_1")
+	if err != errDeleteForbidden {
+		t.Fatalf("expected the friendly permission error, got %v", err)
+	}
+}
+
+func TestThreadWithComment(t *testing.T) {
+	threads := []reviewThread{{ID: "T1"}, {ID: "T2"}}
+	threads[1].Comments.Nodes = []reviewComment{{ID: "C1"}, {ID: "C2"}}
+	if th, ok := threadWithComment(threads, "C2"); !ok || th.ID != "T2" {
+		t.Fatalf("threadWithComment = %v, %v", th.ID, ok)
+	}
+	if _, ok := threadWithComment(threads, "C3"); ok {
+		t.Fatal("expected no thread for an unknown comment")
+	}
+}
+
+func TestParseReaction(t *testing.T) {
+	for in, want := range map[string]string{
+		"+1":         "THUMBS_UP",
+		"thumbsup":   "THUMBS_UP",
+		":tada:":     "HOORAY",
+		"Heart":      "HEART",
+		"👀":          "EYES",
+		"thumbsdown": "THUMBS_DOWN",
+	} {
+		r, err := parseReaction(in)
+		if err != nil || r.content != want {
+			t.Errorf("parseReaction(%q) = %v, %v; want %s", in, r.content, err, want)
+		}
+	}
+	if _, err := parseReaction("party"); err == nil || !strings.Contains(err.Error(), "+1|-1|laugh") {
+		t.Fatalf("expected an error listing the reactions, got %v", err)
+	}
+}
+
+func TestFormatReactionCounts(t *testing.T) {
+	var groups []reactionGroup
+	for content, n := range map[string]int{"THUMBS_UP": 2, "HOORAY": 1, "EYES": 0} {
+		g := reactionGroup{Content: content}
+		g.Reactors.TotalCount = n
+		groups = append(groups, g)
+	}
+	got := formatReactionCounts(groups)
+	if !strings.Contains(got, "👍 2") || !strings.Contains(got, "🎉 1") || strings.Contains(got, "👀") {
+		t.Fatalf("formatReactionCounts = %q", got)
+	}
+}
+
+func TestParseMinimizeReason(t *testing.T) {
+	cases := map[string]string{
+		"off_topic": "OFF_TOPIC",
+		"Off-Topic": "OFF_TOPIC",
+		"outdated":  "OUTDATED",
+		"resolved":  "RESOLVED",
+		"spam":      "SPAM",
+		" abuse ":   "ABUSE",
+	}
+	for input, want := range cases {
+		if got, err := parseMinimizeReason(input); err != nil || got != want {
+			t.Errorf("parseMinimizeReason(%q) = %q, %v; want %q", input, got, err, want)
+		}
+	}
+	if _, err := parseMinimizeReason("rude"); err == nil || !strings.Contains(err.Error(), "off_topic|outdated") {
+		t.Errorf("expected an error listing the reasons, got %v", err)
+	}
+}
+
+func TestSetMinimized(t *testing.T) {
+	var response, request string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		request = string(body)
+		w.Write([]byte(response))
+	}))
+	defer srv.Close()
+	client := github.NewClient(srv.URL, "token")
+	ctx := context.Background()
+
+	response = `{"data":{"minimizeComment":{"minimizedComment":{"isMinimized":true,"minimizedReason":"OUTDATED"}}}}`
+	got, err := setMinimized(ctx, client, "PRRC_1", "OUTDATED", true)
+	if err != nil || !got.IsMinimized || got.MinimizedReason != "OUTDATED" {
+		t.Fatalf("setMinimized = %+v, %v", got, err)
+	}
+	if !strings.Contains(request, `"classifier":"OUTDATED"`) {
+		t.Fatalf("request %s lacks the classifier", request)
+	}
+
+	response = `{"data":{"unminimizeComment":{"unminimizedComment":{"isMinimized":false,"minimizedReason":null}}}}`
+	if got, err := setMinimized(ctx, client, "PRRC_1", "", false); err != nil || got.IsMinimized {
+		t.Fatalf("unminimize = %+v, %v", got, err)
+	}
+
+	response = `{"data":{"minimizeComment":null},"errors":[{"type":"FORBIDDEN","message":"Resource not accessible by integration"}]}`
+	if _, err := setMinimized(ctx, client, "PRRC_1", "SPAM", true); err != errMinimizeForbidden {
+		t.Fatalf("expected the friendly permission error, got %v", err)
+	}
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gh-pr-review/internal/github"
+)
+
+// A review round starts with the first push after a review was submitted, so
+// round N holds the feedback on the code as it stood after the Nth iteration.
+// Only reviewers' reviews count: the PR author's replies to threads create
+// reviews too, but they aren't feedback to push for.
+
+// timelineEvent is a push or a review submission on the PR timeline.
+type timelineEvent struct {
+	push bool
+	at   time.Time
+}
+
+// roundStarts returns when each round began, given the PR creation time and
+// its timeline in order. ok is false when push times go backwards, which
+// happens when history was rebased with rewritten commit dates and the
+// boundaries can't be trusted.
+func roundStarts(created time.Time, events []timelineEvent) (starts []time.Time, ok bool) {
+	starts = []time.Time{created}
+	ok = true
+	reviewed := false
+	var lastPush, lastReview time.Time
+	for _, e := range events {
+		if !e.push {
+			reviewed = true
+			lastReview = e.at
+			continue
+		}
+		if e.at.Before(lastPush) {
+			ok = false
+		}
+		lastPush = e.at
+		if !reviewed {
+			continue
+		}
+		// A commit made before the review but pushed after it carries an
+		// older date; the round can't start before the review it answers.
+		start := e.at
+		if !start.After(lastReview) {
+			start = lastReview.Add(time.Second)
+		}
+		starts = append(starts, start)
+		reviewed = false
+	}
+	return starts, ok
+}
+
+// dayRoundStarts buckets by calendar day (UTC): each day on which a thread
+// was opened starts a round.
+func dayRoundStarts(threads []reviewThread) []time.Time {
+	seen := map[time.Time]bool{}
+	var starts []time.Time
+	for _, t := range threads {
+		at, ok := threadStarted(t)
+		if !ok {
+			continue
+		}
+		day := at.UTC().Truncate(24 * time.Hour)
+		if !seen[day] {
+			seen[day] = true
+			starts = append(starts, day)
+		}
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i].Before(starts[j]) })
+	return starts
+}
+
+func threadStarted(t reviewThread) (time.Time, bool) {
+	if len(t.Comments.Nodes) == 0 {
+		return time.Time{}, false
+	}
+	at, err := time.Parse(time.RFC3339, t.Comments.Nodes[0].CreatedAt)
+	return at, err == nil
+}
+
+// assignRounds sets Round on each thread from the round boundaries and
+// returns the number of the latest round. Threads without a timestamp (and
+// pending ones) belong to the latest round.
+func assignRounds(threads []reviewThread, starts []time.Time) int {
+	latest := len(starts)
+	if latest == 0 {
+		latest = 1
+	}
+	for i := range threads {
+		at, ok := threadStarted(threads[i])
+		if !ok || threads[i].IsPending {
+			threads[i].Round = latest
+			continue
+		}
+		round := sort.Search(len(starts), func(n int) bool { return starts[n].After(at) })
+		if round == 0 {
+			round = 1
+		}
+		threads[i].Round = round
+	}
+	return latest
+}
+
+// parseRound validates a --round value: latest, all, or a round number.
+func parseRound(value string) (string, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	switch value {
+	case "", "all", "latest":
+		return value, nil
+	}
+	if n, err := strconv.Atoi(value); err == nil && n > 0 {
+		return value, nil
+	}
+	return "", fmt.Errorf("invalid --round %q (expected latest|all|N)", value)
+}
+
+func filterByRound(threads []reviewThread, round string, latest int) []reviewThread {
+	want := latest
+	switch round {
+	case "all":
+		return threads
+	case "latest":
+	default:
+		want, _ = strconv.Atoi(round)
+	}
+	var out []reviewThread
+	for _, t := range threads {
+		if t.Round == want {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// labelRounds fetches the PR timeline and assigns review rounds to threads,
+// falling back to one round per day when the push history is unreliable.
+// It returns the latest round number.
+func labelRounds(ctx context.Context, client *github.Client, owner, name string, pr int, threads []reviewThread) (int, error) {
+	created, events, err := fetchTimeline(ctx, client, owner, name, pr)
+	if err != nil {
+		return 0, err
+	}
+	starts, ok := roundStarts(created, events)
+	if !ok {
+		fmt.Fprintln(os.Stderr, "note: review rounds can't be determined from the push history (rebased?); grouping threads by day")
+		starts = dayRoundStarts(threads)
+	}
+	return assignRounds(threads, starts), nil
+}
+
+func fetchTimeline(ctx context.Context, client *github.Client, owner, name string, pr int) (time.Time, []timelineEvent, error) {
+	query := `query($owner:String!, $name:String!, $number:Int!, $after:String) {
+  repository(owner:$owner, name:$name) {
+    pullRequest(number:$number) {
+      createdAt
+      author { login }
+      timelineItems(first:100, after:$after, itemTypes:[PULL_REQUEST_COMMIT, HEAD_REF_FORCE_PUSHED_EVENT, PULL_REQUEST_REVIEW]) {
+        pageInfo { hasNextPage endCursor }
+        nodes {
+          __typename
+          ... on PullRequestCommit { commit { committedDate } }
+          ... on HeadRefForcePushedEvent { createdAt }
+          ... on PullRequestReview { submittedAt author { login } }
+        }
+      }
+    }
+  }
+}`
+	var created time.Time
+	var events []timelineEvent
+	var after *string
+	for {
+		vars := map[string]interface{}{
+			"owner":  owner,
+			"name":   name,
+			"number": pr,
+			"after":  after,
+		}
+		var resp struct {
+			Repository struct {
+				PullRequest struct {
+					CreatedAt     time.Time `json:"createdAt"`
+					Author        actor     `json:"author"`
+					TimelineItems struct {
+						PageInfo struct {
+							HasNextPage bool    `json:"hasNextPage"`
+							EndCursor   *string `json:"endCursor"`
+						} `json:"pageInfo"`
+						Nodes []struct {
+							Typename string `json:"__typename"`
+							Commit   struct {
+								CommittedDate time.Time `json:"committedDate"`
+							} `json:"commit"`
+							CreatedAt   time.Time  `json:"createdAt"`
+							SubmittedAt *time.Time `json:"submittedAt"`
+							Author      actor      `json:"author"`
+						} `json:"nodes"`
+					} `json:"timelineItems"`
+				} `json:"pullRequest"`
+			} `json:"repository"`
+		}
+		if err := client.Do(ctx, query, vars, &resp); err != nil {
+			return time.Time{}, nil, fmt.Errorf("failed to fetch PR timeline: %w", err)
+		}
+		created = resp.Repository.PullRequest.CreatedAt
+		prAuthor := resp.Repository.PullRequest.Author.Login
+		items := resp.Repository.PullRequest.TimelineItems
+		for _, n := range items.Nodes {
+			switch n.Typename {
+			case "PullRequestCommit":
+				events = append(events, timelineEvent{push: true, at: n.Commit.CommittedDate})
+			case "HeadRefForcePushedEvent":
+				events = append(events, timelineEvent{push: true, at: n.CreatedAt})
+			case "PullRequestReview":
+				// Pending reviews have no submission time yet.
+				if n.SubmittedAt != nil && (prAuthor == "" || n.Author.Login != prAuthor) {
+					events = append(events, timelineEvent{at: *n.SubmittedAt})
+				}
+			}
+		}
+		if !items.PageInfo.HasNextPage || items.PageInfo.EndCursor == nil || *items.PageInfo.EndCursor == "" {
+			break
+		}
+		after = items.PageInfo.EndCursor
+	}
+	return created, events, nil
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gh-pr-review/internal/github"
+)
+
+func at(hour int) time.Time {
+	return time.Date(2024, 5, 1, hour, 0, 0, 0, time.UTC)
+}
+
+func threadAt(id string, hour int) reviewThread {
+	t := reviewThread{ID: id}
+	t.Comments.Nodes = []reviewComment{{CreatedAt: at(hour).Format(time.RFC3339)}}
+	return t
+}
+
+func TestRoundStarts(t *testing.T) {
+	events := []timelineEvent{
+		{push: true, at: at(1)},
+		{at: at(2)},             // review of round 1
+		{push: true, at: at(3)}, // starts round 2
+		{push: true, at: at(4)}, // same round, no review in between
+		{at: at(6)},
+		{push: true, at: at(5)}, // committed before the review, pushed after
+	}
+	starts, ok := roundStarts(at(0), events)
+	if !ok {
+		t.Fatal("expected rounds to be determined")
+	}
+	want := []time.Time{at(0), at(3), at(6).Add(time.Second)}
+	if len(starts) != len(want) {
+		t.Fatalf("expected %d rounds, got %v", len(want), starts)
+	}
+	for i := range want {
+		if !starts[i].Equal(want[i]) {
+			t.Fatalf("round %d starts at %v, want %v", i+1, starts[i], want[i])
+		}
+	}
+
+	rebased := []timelineEvent{{push: true, at: at(5)}, {at: at(6)}, {push: true, at: at(2)}}
+	if _, ok := roundStarts(at(0), rebased); ok {
+		t.Fatal("expected push dates going backwards to be reported")
+	}
+}
+
+func TestAssignAndFilterRounds(t *testing.T) {
+	threads := []reviewThread{threadAt("a", 2), threadAt("b", 4), threadAt("c", 7), {ID: "d", IsPending: true}}
+	latest := assignRounds(threads, []time.Time{at(0), at(3), at(6)})
+	if latest != 3 {
+		t.Fatalf("expected latest round 3, got %d", latest)
+	}
+	for i, want := range []int{1, 2, 3, 3} {
+		if threads[i].Round != want {
+			t.Errorf("thread %s in round %d, want %d", threads[i].ID, threads[i].Round, want)
+		}
+	}
+	if got := strings.Join(threadIDs(filterByRound(threads, "latest", latest)), ","); got != "c,d" {
+		t.Errorf("latest round: got %s", got)
+	}
+	if got := strings.Join(threadIDs(filterByRound(threads, "2", latest)), ","); got != "b" {
+		t.Errorf("round 2: got %s", got)
+	}
+	if _, err := parseRound("0"); err == nil {
+		t.Error("expected round 0 to be rejected")
+	}
+}
+
+func TestFetchTimelineIgnoresAuthorReviews(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		review := func(login string, hour int) map[string]interface{} {
+			return map[string]interface{}{"__typename": "PullRequestReview", "submittedAt": at(hour), "author": map[string]string{"login": login}}
+		}
+		push := func(hour int) map[string]interface{} {
+			return map[string]interface{}{"__typename": "PullRequestCommit", "commit": map[string]interface{}{"committedDate": at(hour)}}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"repository": map[string]interface{}{"pullRequest": map[string]interface{}{
+			"createdAt": at(0),
+			"author":    map[string]string{"login": "alice"},
+			"timelineItems": map[string]interface{}{
+				"pageInfo": map[string]interface{}{"hasNextPage": false},
+				"nodes":    []interface{}{push(1), review("bob", 2), push(3), review("alice", 4), push(5)},
+			},
+		}}}})
+	}))
+	defer srv.Close()
+
+	created, events, err := fetchTimeline(context.Background(), github.NewClient(srv.URL, "token"), "o", "r", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	starts, _ := roundStarts(created, events)
+	// alice replying between the pushes doesn't start a third round.
+	if len(starts) != 2 || !starts[1].Equal(at(3)) {
+		t.Errorf("round starts %v", starts)
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// seenStore remembers, per thread, the newest comment you have seen, so
+// threads with anything newer can be shown as unread. There is one file per
+// PR, shared by the TUI and list --mark-all-read.
+type seenStore struct {
+	path string
+	// seen maps thread IDs to the creation time of the newest comment seen.
+	seen map[string]time.Time
+}
+
+func seenPath(host, owner, name string, pr int) (string, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "seen", host, owner, name, strconv.Itoa(pr)+".json"), nil
+}
+
+// loadSeen reads a PR's read state. A missing file means nothing has been
+// read yet.
+func loadSeen(host, owner, name string, pr int) (*seenStore, error) {
+	path, err := seenPath(host, owner, name, pr)
+	if err != nil {
+		return nil, err
+	}
+	s := &seenStore{path: path, seen: map[string]time.Time{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &s.seen); err != nil {
+		return nil, fmt.Errorf("reading read state from %s: %w", path, err)
+	}
+	return s, nil
+}
+
+func (s *seenStore) save() error {
+	data, err := json.MarshalIndent(s.seen, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, append(data, '\n'), 0o644)
+}
+
+// unread reports whether the thread has a comment newer than the last one
+// seen. Threads never seen are unread.
+func (s *seenStore) unread(t reviewThread) bool {
+	latest, ok := latestCommentTime(t)
+	if !ok {
+		return false
+	}
+	seen, ok := s.seen[t.ID]
+	return !ok || latest.After(seen)
+}
+
+// markSeen records the thread's comments as read and reports whether that
+// changed anything.
+func (s *seenStore) markSeen(t reviewThread) bool {
+	if !s.unread(t) {
+		return false
+	}
+	s.seen[t.ID], _ = latestCommentTime(t)
+	return true
+}
+
+// latestCommentTime is when the thread's newest comment was made. Comments
+// still being sent from the TUI have their local time, which is fine for
+// this.
+func latestCommentTime(t reviewThread) (time.Time, bool) {
+	var latest time.Time
+	found := false
+	for _, c := range t.Comments.Nodes {
+		created, err := time.Parse(time.RFC3339, c.CreatedAt)
+		if err != nil {
+			continue
+		}
+		if !found || created.After(latest) {
+			latest, found = created, true
+		}
+	}
+	return latest, found
+}
+
+// markAllRead marks every thread as read, saving the store if that changed
+// anything, and returns how many threads were unread.
+func markAllRead(s *seenStore, threads []reviewThread) (int, error) {
+	marked := 0
+	for _, t := range threads {
+		if s.markSeen(t) {
+			marked++
+		}
+	}
+	if marked == 0 {
+		return 0, nil
+	}
+	return marked, s.save()
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestSeenStore(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	comment := func(at string) reviewComment { return reviewComment{CreatedAt: at} }
+	a := reviewThread{ID: "A", Comments: reviewThreadComment{Nodes: []reviewComment{comment("2024-05-01T10:00:00Z"), comment("2024-05-02T10:00:00Z")}}}
+	b := reviewThread{ID: "B", Comments: reviewThreadComment{Nodes: []reviewComment{comment("2024-05-01T12:00:00Z")}}}
+
+	s, err := loadSeen("github.com", "o", "r", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.unread(a) || !s.unread(b) {
+		t.Fatal("threads never seen should be unread")
+	}
+	marked, err := markAllRead(s, []reviewThread{a})
+	if err != nil || marked != 1 {
+		t.Fatalf("markAllRead = %d, %v", marked, err)
+	}
+
+	// The state is kept per PR across loads.
+	s, err = loadSeen("github.com", "o", "r", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.unread(a) || !s.unread(b) {
+		t.Errorf("after reload: A unread %v, B unread %v", s.unread(a), s.unread(b))
+	}
+	if other, _ := loadSeen("github.com", "o", "r", 2); !other.unread(a) {
+		t.Error("another PR shares the read state")
+	}
+
+	// A reply makes the thread unread again.
+	a.Comments.Nodes = append(a.Comments.Nodes, comment("2024-05-03T09:00:00Z"))
+	if !s.unread(a) {
+		t.Error("a new comment didn't make the thread unread")
+	}
+	if marked, _ := markAllRead(s, []reviewThread{a, b}); marked != 2 {
+		t.Errorf("marked %d threads, want 2", marked)
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"gh-pr-review/internal/github"
+)
+
+const (
+	sortAPI              = "api"
+	sortDiff             = "diff"
+	sortPath             = "path"
+	sortNewest           = "newest"
+	sortOldestUnresolved = "oldest-unresolved"
+)
+
+// tuiSortOrders are the orders s cycles through in the TUI. diff is left
+// out because it needs the changed files fetched.
+var tuiSortOrders = []string{sortAPI, sortPath, sortNewest, sortOldestUnresolved}
+
+func validSort(order string) bool {
+	switch order {
+	case sortAPI, sortDiff, sortPath, sortNewest, sortOldestUnresolved:
+		return true
+	}
+	return false
+}
+
+// sortThreads orders threads in place. For diff order, files follows the
+// PR's changed-file order as GitHub displays it; threads on files outside
+// the current diff sort last. The other orders ignore files.
+func sortThreads(threads []reviewThread, order string, files []string) {
+	switch order {
+	case sortPath:
+		sort.SliceStable(threads, func(i, j int) bool {
+			if threads[i].Path != threads[j].Path {
+				return threads[i].Path < threads[j].Path
+			}
+			return threadLine(threads[i]) < threadLine(threads[j])
+		})
+	case sortNewest:
+		// Threads without a comment time keep their place after the rest.
+		sort.SliceStable(threads, func(i, j int) bool {
+			ti, iok := latestCommentTime(threads[i])
+			tj, jok := latestCommentTime(threads[j])
+			if iok != jok {
+				return iok
+			}
+			return ti.After(tj)
+		})
+	case sortOldestUnresolved:
+		sort.SliceStable(threads, func(i, j int) bool {
+			if threads[i].IsResolved != threads[j].IsResolved {
+				return !threads[i].IsResolved
+			}
+			ti, iok := threadOpened(threads[i])
+			tj, jok := threadOpened(threads[j])
+			if iok != jok {
+				return iok
+			}
+			return ti.Before(tj)
+		})
+	case sortDiff:
+		rank := make(map[string]int, len(files))
+		for i, f := range files {
+			rank[f] = i
+		}
+		fileRank := func(path string) int {
+			if r, ok := rank[path]; ok {
+				return r
+			}
+			return len(files)
+		}
+		sort.SliceStable(threads, func(i, j int) bool {
+			ri, rj := fileRank(threads[i].Path), fileRank(threads[j].Path)
+			if ri != rj {
+				return ri < rj
+			}
+			if threads[i].Path != threads[j].Path {
+				return threads[i].Path < threads[j].Path
+			}
+			return threadLine(threads[i]) < threadLine(threads[j])
+		})
+	}
+}
+
+// threadLine is the line a thread is anchored to, preferring the current
+// line over the original one.
+func threadLine(t reviewThread) int {
+	if t.Line != nil {
+		return *t.Line
+	}
+	if t.OriginalLine != nil {
+		return *t.OriginalLine
+	}
+	return 0
+}
+
+// threadOpened is when the thread's first comment was made.
+func threadOpened(t reviewThread) (time.Time, bool) {
+	if len(t.Comments.Nodes) == 0 {
+		return time.Time{}, false
+	}
+	created, err := time.Parse(time.RFC3339, t.Comments.Nodes[0].CreatedAt)
+	return created, err == nil
+}
+
+// fetchChangedFiles returns the PR's changed file paths in GitHub's order.
+func fetchChangedFiles(ctx context.Context, client *github.Client, owner, name string, pr int) ([]string, error) {
+	query := `query($owner:String!, $name:String!, $number:Int!, $after:String) {
+  repository(owner:$owner, name:$name) {
+    pullRequest(number:$number) {
+      files(first:100, after:$after) {
+        pageInfo { hasNextPage endCursor }
+        nodes { path }
+      }
+    }
+  }
+}`
+	var files []string
+	var after *string
+	for {
+		vars := map[string]interface{}{
+			"owner":  owner,
+			"name":   name,
+			"number": pr,
+			"after":  after,
+		}
+		var resp struct {
+			Repository struct {
+				PullRequest struct {
+					Files struct {
+						PageInfo struct {
+							HasNextPage bool    `json:"hasNextPage"`
+							EndCursor   *string `json:"endCursor"`
+						} `json:"pageInfo"`
+						Nodes []struct {
+							Path string `json:"path"`
+						} `json:"nodes"`
+					} `json:"files"`
+				} `json:"pullRequest"`
+			} `json:"repository"`
+		}
+		if err := client.Do(ctx, query, vars, &resp); err != nil {
+			return nil, fmt.Errorf("failed to fetch changed files: %w", err)
+		}
+		for _, n := range resp.Repository.PullRequest.Files.Nodes {
+			files = append(files, n.Path)
+		}
+		page := resp.Repository.PullRequest.Files.PageInfo
+		if !page.HasNextPage || page.EndCursor == nil || *page.EndCursor == "" {
+			break
+		}
+		after = page.EndCursor
+	}
+	return files, nil
+}
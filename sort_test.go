@@ -0,0 +1,85 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func threadIDs(threads []reviewThread) []string {
+	ids := make([]string, 0, len(threads))
+	for _, t := range threads {
+		ids = append(ids, t.ID)
+	}
+	return ids
+}
+
+func TestSortThreadsDiff(t *testing.T) {
+	threads := []reviewThread{
+		{ID: "gone", Path: "removed.go", OriginalLine: intPtr(3)},
+		{ID: "b-20", Path: "b.go", Line: intPtr(20)},
+		{ID: "a-outdated", Path: "a.go", OriginalLine: intPtr(5)},
+		{ID: "b-4", Path: "b.go", Line: intPtr(4)},
+		{ID: "a-10", Path: "a.go", Line: intPtr(10)},
+	}
+	sortThreads(threads, sortDiff, []string{"b.go", "a.go"})
+	want := []string{"b-4", "b-20", "a-outdated", "a-10", "gone"}
+	if got := threadIDs(threads); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestSortThreadsAPIKeepsOrder(t *testing.T) {
+	threads := []reviewThread{{ID: "2", Path: "b.go"}, {ID: "1", Path: "a.go"}}
+	sortThreads(threads, sortAPI, nil)
+	if got := threadIDs(threads); !reflect.DeepEqual(got, []string{"2", "1"}) {
+		t.Fatalf("expected API order to be preserved, got %v", got)
+	}
+}
+
+// sortFixture is one thread per way the orders can disagree: opened and
+// last commented at different times, resolved or not, with and without
+// comment times.
+func sortFixture() []reviewThread {
+	thread := func(id, path string, line int, resolved bool, times ...string) reviewThread {
+		t := reviewThread{ID: id, Path: path, Line: intPtr(line), IsResolved: resolved}
+		for _, at := range times {
+			t.Comments.Nodes = append(t.Comments.Nodes, reviewComment{CreatedAt: at})
+		}
+		return t
+	}
+	return []reviewThread{
+		thread("b-9", "b.go", 9, false, "2026-10-03T00:00:00Z"),
+		thread("a-20", "a.go", 20, true, "2026-10-01T00:00:00Z", "2026-10-06T00:00:00Z"),
+		thread("pending", "c.go", 1, false),
+		thread("a-3", "a.go", 3, false, "2026-10-02T00:00:00Z", "2026-10-05T00:00:00Z"),
+		thread("b-1", "b.go", 1, true, "2026-10-04T00:00:00Z"),
+	}
+}
+
+func TestSortThreadsOrders(t *testing.T) {
+	tests := []struct {
+		order string
+		want  []string
+	}{
+		{sortAPI, []string{"b-9", "a-20", "pending", "a-3", "b-1"}},
+		{sortPath, []string{"a-3", "a-20", "b-1", "b-9", "pending"}},
+		{sortNewest, []string{"a-20", "a-3", "b-1", "b-9", "pending"}},
+		{sortOldestUnresolved, []string{"a-3", "b-9", "pending", "a-20", "b-1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.order, func(t *testing.T) {
+			if !validSort(tt.order) {
+				t.Fatalf("%q is not a valid --sort", tt.order)
+			}
+			threads := sortFixture()
+			sortThreads(threads, tt.order, nil)
+			if got := threadIDs(threads); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
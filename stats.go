@@ -0,0 +1,306 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gh-pr-review/internal/gh"
+	"gh-pr-review/internal/github"
+)
+
+// authorStats are one person's review activity across the scanned threads.
+// Medians are in seconds and cover the threads the person opened.
+type authorStats struct {
+	Login                   string `json:"login"`
+	Opened                  int    `json:"opened"`
+	Replies                 int    `json:"replies"`
+	Resolved                int    `json:"resolved"`
+	MedianFirstReplySeconds *int64 `json:"medianFirstReplySeconds"`
+	MedianResolutionSeconds *int64 `json:"medianResolutionSeconds"`
+
+	firstReplies []time.Duration
+	resolutions  []time.Duration
+}
+
+type statsReport struct {
+	Repo    string        `json:"repo"`
+	PRs     []int         `json:"prs"`
+	Threads int           `json:"threads"`
+	Authors []authorStats `json:"authors"`
+}
+
+type statsFlags struct {
+	repo        string
+	pr          int
+	allPRs      bool
+	since       dayDuration
+	includeBots bool
+	jsonOut     bool
+	host        string
+}
+
+func (o *statsFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.repo, "repo", "", "owner/name (defaults to gh repo view)")
+	fs.IntVar(&o.pr, "pr", 0, "PR number (defaults to current branch PR if available)")
+	fs.BoolVar(&o.allPRs, "all-prs", false, "every PR in the repository updated within --since")
+	o.since = dayDuration(30 * 24 * time.Hour)
+	fs.Var(&o.since, "since", "with --all-prs: how far back to look, e.g. 30d or 72h")
+	fs.BoolVar(&o.includeBots, "include-bots", false, "count comments and resolutions by bots")
+	fs.BoolVar(&o.jsonOut, "json", false, "output JSON")
+	fs.StringVar(&o.host, "host", gh.DefaultHost(), "GitHub host")
+}
+
+func runStats(args []string) error {
+	fs := newFlagSet("stats", printStatsUsage)
+	var flags statsFlags
+	flags.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+	if flags.allPRs && flags.pr != 0 {
+		return errors.New("provide only one of --pr or --all-prs")
+	}
+	if flags.since <= 0 {
+		return errors.New("--since must be a positive duration")
+	}
+
+	ctx := context.Background()
+	if !flags.allPRs {
+		var err error
+		if flags.pr, err = resolvePR(ctx, flags.pr); err != nil {
+			return err
+		}
+	}
+	owner, name, err := resolveRepo(ctx, flags.repo)
+	if err != nil {
+		return err
+	}
+	client, err := newClient(ctx, flags.host)
+	if err != nil {
+		return err
+	}
+	prs := []int{flags.pr}
+	if flags.allPRs {
+		if prs, err = fetchPRsUpdatedSince(ctx, client, owner, name, time.Now().Add(-time.Duration(flags.since))); err != nil {
+			return err
+		}
+	}
+
+	perPR := make([][]reviewThread, len(prs))
+	errs := make([]error, len(prs))
+	forEachConcurrently(len(prs), inboxConcurrency, func(i int) {
+		threads, err := fetchAllThreads(ctx, client, owner, name, prs[i])
+		if err == nil {
+			err = fetchRemainingComments(ctx, client, threads)
+		}
+		perPR[i], errs[i] = threads, err
+	})
+	var threads []reviewThread
+	for i, err := range errs {
+		if err != nil {
+			return fmt.Errorf("PR #%d: %w", prs[i], err)
+		}
+		threads = append(threads, perPR[i]...)
+	}
+
+	report := statsReport{Repo: owner + "/" + name, PRs: prs, Threads: len(threads), Authors: computeStats(threads, flags.includeBots)}
+	if report.PRs == nil {
+		report.PRs = []int{}
+	}
+	if flags.jsonOut {
+		return writeJSON(os.Stdout, report)
+	}
+	printStats(os.Stdout, report)
+	return nil
+}
+
+// fetchPRsUpdatedSince returns the numbers of the repository's PRs, in any
+// state, updated after cutoff.
+func fetchPRsUpdatedSince(ctx context.Context, client *github.Client, owner, name string, cutoff time.Time) ([]int, error) {
+	query := `query($owner:String!, $name:String!, $after:String) {
+  repository(owner:$owner, name:$name) {
+    pullRequests(first:50, after:$after, orderBy:{field:UPDATED_AT, direction:DESC}) {
+      pageInfo { hasNextPage endCursor }
+      nodes { number updatedAt }
+    }
+  }
+}`
+	var prs []int
+	var after *string
+	for {
+		var resp struct {
+			Repository struct {
+				PullRequests struct {
+					PageInfo struct {
+						HasNextPage bool    `json:"hasNextPage"`
+						EndCursor   *string `json:"endCursor"`
+					} `json:"pageInfo"`
+					Nodes []struct {
+						Number    int       `json:"number"`
+						UpdatedAt time.Time `json:"updatedAt"`
+					} `json:"nodes"`
+				} `json:"pullRequests"`
+			} `json:"repository"`
+		}
+		vars := map[string]interface{}{"owner": owner, "name": name, "after": after}
+		if err := client.Do(ctx, query, vars, &resp); err != nil {
+			return nil, err
+		}
+		for _, n := range resp.Repository.PullRequests.Nodes {
+			if n.UpdatedAt.Before(cutoff) {
+				return prs, nil
+			}
+			prs = append(prs, n.Number)
+		}
+		info := resp.Repository.PullRequests.PageInfo
+		if !info.HasNextPage || info.EndCursor == nil || *info.EndCursor == "" {
+			return prs, nil
+		}
+		after = info.EndCursor
+	}
+}
+
+// computeStats aggregates threads per person. Time to first reply runs from
+// a thread's first comment to the first comment by someone else. GitHub
+// doesn't record when a thread was resolved, so time to resolution runs to
+// the resolved thread's last comment.
+func computeStats(threads []reviewThread, includeBots bool) []authorStats {
+	byLogin := map[string]*authorStats{}
+	person := func(login string) *authorStats {
+		if byLogin[login] == nil {
+			byLogin[login] = &authorStats{Login: login}
+		}
+		return byLogin[login]
+	}
+	for _, t := range threads {
+		var comments []reviewComment
+		for _, c := range t.Comments.Nodes {
+			if includeBots || !c.Author.isBot() {
+				comments = append(comments, c)
+			}
+		}
+		if t.IsResolved && t.ResolvedBy != nil && (includeBots || !t.ResolvedBy.isBot()) {
+			person(t.ResolvedBy.Login).Resolved++
+		}
+		if len(comments) == 0 {
+			continue
+		}
+		opener := person(comments[0].Author.Login)
+		opener.Opened++
+		opened, err := time.Parse(time.RFC3339, comments[0].CreatedAt)
+		replied := false
+		for _, c := range comments[1:] {
+			person(c.Author.Login).Replies++
+			if replied || c.Author.Login == opener.Login || err != nil {
+				continue
+			}
+			if at, perr := time.Parse(time.RFC3339, c.CreatedAt); perr == nil {
+				opener.firstReplies = append(opener.firstReplies, at.Sub(opened))
+				replied = true
+			}
+		}
+		if t.IsResolved && err == nil {
+			if at, perr := time.Parse(time.RFC3339, comments[len(comments)-1].CreatedAt); perr == nil {
+				opener.resolutions = append(opener.resolutions, at.Sub(opened))
+			}
+		}
+	}
+	out := []authorStats{}
+	for _, s := range byLogin {
+		s.MedianFirstReplySeconds = medianSeconds(s.firstReplies)
+		s.MedianResolutionSeconds = medianSeconds(s.resolutions)
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.Opened+a.Replies != b.Opened+b.Replies {
+			return a.Opened+a.Replies > b.Opened+b.Replies
+		}
+		return a.Login < b.Login
+	})
+	return out
+}
+
+func medianSeconds(ds []time.Duration) *int64 {
+	if len(ds) == 0 {
+		return nil
+	}
+	sorted := append([]time.Duration(nil), ds...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	m := sorted[len(sorted)/2]
+	if len(sorted)%2 == 0 {
+		m = (sorted[len(sorted)/2-1] + m) / 2
+	}
+	secs := int64(m / time.Second)
+	return &secs
+}
+
+func printStats(w io.Writer, report statsReport) {
+	var scope string
+	if len(report.PRs) == 1 {
+		scope = fmt.Sprintf("%s#%d", report.Repo, report.PRs[0])
+	} else {
+		scope = fmt.Sprintf("%s, %d PRs", report.Repo, len(report.PRs))
+	}
+	fmt.Fprintf(w, "%s: %d threads\n", scope, report.Threads)
+	if len(report.Authors) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "AUTHOR\tOPENED\tREPLIES\tRESOLVED\tFIRST REPLY\tRESOLUTION")
+	for _, a := range report.Authors {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%s\t%s\n", a.Login, a.Opened, a.Replies, a.Resolved,
+			shortDuration(a.MedianFirstReplySeconds), shortDuration(a.MedianResolutionSeconds))
+	}
+	tw.Flush()
+}
+
+// shortDuration renders a number of seconds as "3d 4h", "2h 5m" or "12m".
+func shortDuration(secs *int64) string {
+	if secs == nil {
+		return "-"
+	}
+	d := time.Duration(*secs) * time.Second
+	days, hours, minutes := int(d/(24*time.Hour)), int(d%(24*time.Hour)/time.Hour), int(d%time.Hour/time.Minute)
+	switch {
+	case days > 0:
+		return strings.TrimSuffix(fmt.Sprintf("%dd %dh", days, hours), " 0h")
+	case hours > 0:
+		return strings.TrimSuffix(fmt.Sprintf("%dh %dm", hours, minutes), " 0m")
+	default:
+		return fmt.Sprintf("%dm", minutes)
+	}
+}
+
+func printStatsUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage:")
+	fmt.Fprintln(w, "  gh-pr-review stats [--pr <number>] [--repo owner/name] [--include-bots] [--json] [--host host]")
+	fmt.Fprintln(w, "  gh-pr-review stats --all-prs [--since 30d] [--repo owner/name] [--include-bots] [--json] [--host host]")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Flags:")
+	fmt.Fprintln(w, "  --pr <number>   PR number (defaults to current branch PR if available)")
+	fmt.Fprintln(w, "  --repo <owner/name>   Repository (defaults to gh repo view)")
+	fmt.Fprintln(w, "  --all-prs   Every PR in the repository updated within --since")
+	fmt.Fprintln(w, "  --since <duration>   With --all-prs: how far back to look, e.g. 30d (default) or 72h")
+	fmt.Fprintln(w, "  --include-bots   Count comments and resolutions by bots (left out by default)")
+	fmt.Fprintln(w, "  --json   Output JSON (median times in seconds)")
+	fmt.Fprintln(w, "  --host <host>   GitHub host")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "FIRST REPLY and RESOLUTION are medians over the threads each person opened.")
+	fmt.Fprintln(w, "GitHub doesn't record when a thread was resolved, so RESOLUTION runs to the thread's last comment.")
+}
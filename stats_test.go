@@ -0,0 +1,88 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func statsComment(login, at string) reviewComment {
+	c := reviewComment{CreatedAt: at}
+	c.Author.Login = login
+	return c
+}
+
+func TestComputeStats(t *testing.T) {
+	threads := []reviewThread{
+		{
+			ID: "T1", IsResolved: true, ResolvedBy: &actor{Login: "alice"},
+			Comments: reviewThreadComment{Nodes: []reviewComment{
+				statsComment("alice", "2024-05-01T10:00:00Z"),
+				statsComment("ci[bot]", "2024-05-01T10:05:00Z"),
+				statsComment("bob", "2024-05-01T12:00:00Z"),
+				statsComment("alice", "2024-05-02T10:00:00Z"),
+			}},
+		},
+		{
+			ID: "T2",
+			Comments: reviewThreadComment{Nodes: []reviewComment{
+				statsComment("alice", "2024-05-03T10:00:00Z"),
+				statsComment("bob", "2024-05-03T14:00:00Z"),
+			}},
+		},
+		{
+			ID: "T3", IsResolved: true, ResolvedBy: &actor{Login: "ci[bot]"},
+			Comments: reviewThreadComment{Nodes: []reviewComment{statsComment("ci[bot]", "2024-05-03T10:00:00Z")}},
+		},
+	}
+	got := computeStats(threads, false)
+	if len(got) != 2 || got[0].Login != "alice" || got[1].Login != "bob" {
+		t.Fatalf("authors = %+v", got)
+	}
+	alice, bob := got[0], got[1]
+	if alice.Opened != 2 || alice.Replies != 1 || alice.Resolved != 1 {
+		t.Fatalf("alice = %+v", alice)
+	}
+	if bob.Opened != 0 || bob.Replies != 2 || bob.MedianFirstReplySeconds != nil {
+		t.Fatalf("bob = %+v", bob)
+	}
+	// First replies after 2h and 4h; the bot's reply doesn't count.
+	if s := alice.MedianFirstReplySeconds; s == nil || *s != int64(3*time.Hour/time.Second) {
+		t.Fatalf("alice median first reply = %v", s)
+	}
+	if s := alice.MedianResolutionSeconds; s == nil || *s != int64(24*time.Hour/time.Second) {
+		t.Fatalf("alice median resolution = %v", s)
+	}
+
+	withBots := computeStats(threads, true)
+	if len(withBots) != 3 {
+		t.Fatalf("with bots = %+v", withBots)
+	}
+}
+
+func TestShortDuration(t *testing.T) {
+	for secs, want := range map[int64]string{
+		90:                                 "1m",
+		int64(2 * time.Hour / time.Second): "2h",
+		int64((26*time.Hour + 5*time.Minute) / time.Second): "1d 2h",
+	} {
+		if got := shortDuration(&secs); got != want {
+			t.Errorf("shortDuration(%d) = %q, want %q", secs, got, want)
+		}
+	}
+	if got := shortDuration(nil); got != "-" {
+		t.Errorf("shortDuration(nil) = %q", got)
+	}
+}
+
+func TestDayDuration(t *testing.T) {
+	var d dayDuration
+	if err := d.Set("30d"); err != nil || time.Duration(d) != 30*24*time.Hour {
+		t.Fatalf("30d = %v, %v", time.Duration(d), err)
+	}
+	if err := d.Set("90m"); err != nil || time.Duration(d) != 90*time.Minute {
+		t.Fatalf("90m = %v, %v", time.Duration(d), err)
+	}
+	if err := d.Set("xd"); err == nil {
+		t.Fatal("expected an error for xd")
+	}
+}
@@ -0,0 +1,325 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"gh-pr-review/internal/gh"
+	"gh-pr-review/internal/github"
+)
+
+// prStatus summarizes the review state of a pull request.
+type prStatus struct {
+	Repo             string            `json:"repo"`
+	PR               int               `json:"pr"`
+	Title            string            `json:"title"`
+	URL              string            `json:"url"`
+	ReviewDecision   string            `json:"reviewDecision"`
+	Threads          int               `json:"threads"`
+	Unresolved       int               `json:"unresolved"`
+	Outdated         int               `json:"outdated"`
+	Reviewers        []reviewerStatus  `json:"reviewers"`
+	OldestUnresolved *unresolvedStatus `json:"oldestUnresolved,omitempty"`
+	// Rounds counts threads per review round, to show whether feedback is
+	// converging; empty if the timeline couldn't be fetched.
+	Rounds []roundStatus `json:"rounds,omitempty"`
+}
+
+// roundStatus is how many threads a review round opened and how many of
+// them are still unresolved.
+type roundStatus struct {
+	Round   int `json:"round"`
+	Threads int `json:"threads"`
+	Open    int `json:"open"`
+}
+
+// reviewerStatus counts the threads a person opened and the threads in which
+// theirs is the latest comment.
+type reviewerStatus struct {
+	Login       string `json:"login"`
+	Opened      int    `json:"opened"`
+	LastComment int    `json:"lastComment"`
+}
+
+type unresolvedStatus struct {
+	ThreadID   string `json:"threadId"`
+	Location   string `json:"location,omitempty"`
+	CreatedAt  string `json:"createdAt"`
+	AgeSeconds int64  `json:"ageSeconds"`
+}
+
+type statusResponse struct {
+	Repository struct {
+		PullRequest struct {
+			Title          string `json:"title"`
+			URL            string `json:"url"`
+			ReviewDecision string `json:"reviewDecision"`
+			ReviewThreads  struct {
+				PageInfo struct {
+					HasNextPage bool    `json:"hasNextPage"`
+					EndCursor   *string `json:"endCursor"`
+				} `json:"pageInfo"`
+				Nodes []reviewThread `json:"nodes"`
+			} `json:"reviewThreads"`
+		} `json:"pullRequest"`
+	} `json:"repository"`
+}
+
+type statusFlags struct {
+	repo    string
+	pr      int
+	jsonOut bool
+	host    string
+}
+
+func (o *statusFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.repo, "repo", "", "owner/name (defaults to gh repo view)")
+	fs.IntVar(&o.pr, "pr", 0, "PR number (defaults to current branch PR if available)")
+	fs.BoolVar(&o.jsonOut, "json", false, "output JSON")
+	fs.StringVar(&o.host, "host", gh.DefaultHost(), "GitHub host")
+}
+
+func runStatus(args []string) error {
+	fs := newFlagSet("status", printStatusUsage)
+	var flags statusFlags
+	flags.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+
+	ctx := context.Background()
+	var err error
+	flags.pr, err = resolvePR(ctx, flags.pr)
+	if err != nil {
+		return err
+	}
+	owner, name, err := resolveRepo(ctx, flags.repo)
+	if err != nil {
+		return err
+	}
+	client, err := newClient(ctx, flags.host)
+	if err != nil {
+		return err
+	}
+	status, err := fetchStatus(ctx, client, owner, name, flags.pr)
+	if err != nil {
+		return err
+	}
+	if flags.jsonOut {
+		return writeJSON(os.Stdout, status)
+	}
+	printStatus(os.Stdout, status, time.Now())
+	return nil
+}
+
+// fetchStatus loads the PR's metadata together with its threads, paging
+// through the threads, and summarizes them.
+func fetchStatus(ctx context.Context, client *github.Client, owner, name string, pr int) (prStatus, error) {
+	query := func(skip map[string]bool) string {
+		return `query($owner:String!, $name:String!, $number:Int!, $after:String) {
+  repository(owner:$owner, name:$name) {
+    pullRequest(number:$number) {
+      title
+      url
+      reviewDecision
+      reviewThreads(first:100, after:$after) {
+        pageInfo { hasNextPage endCursor }
+        nodes {` + threadFields(skip) + `}
+      }
+    }
+  }
+}`
+	}
+	var threads []reviewThread
+	var first statusResponse
+	var after *string
+	for page := 0; ; page++ {
+		vars := map[string]interface{}{
+			"owner":  owner,
+			"name":   name,
+			"number": pr,
+			"after":  after,
+		}
+		var resp statusResponse
+		if err := queryWithFallback(ctx, client, query, vars, &resp); err != nil {
+			return prStatus{}, err
+		}
+		if page == 0 {
+			first = resp
+		}
+		nodes := resp.Repository.PullRequest.ReviewThreads.Nodes
+		for i := range nodes {
+			annotateThread(&nodes[i])
+		}
+		threads = append(threads, nodes...)
+		info := resp.Repository.PullRequest.ReviewThreads.PageInfo
+		if !info.HasNextPage || info.EndCursor == nil || *info.EndCursor == "" {
+			break
+		}
+		after = info.EndCursor
+	}
+	meta := first.Repository.PullRequest
+	status := summarizeStatus(threads, time.Now())
+	if latest, err := labelRounds(ctx, client, owner, name, pr, threads); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: review rounds not shown: %v\n", err)
+	} else {
+		status.Rounds = countRounds(threads, latest)
+	}
+	status.Repo = owner + "/" + name
+	status.PR = pr
+	status.Title = meta.Title
+	status.URL = meta.URL
+	status.ReviewDecision = meta.ReviewDecision
+	return status, nil
+}
+
+// summarizeStatus aggregates thread counts, per-person activity and the
+// oldest unresolved thread.
+func summarizeStatus(threads []reviewThread, now time.Time) prStatus {
+	status := prStatus{Threads: len(threads), Reviewers: []reviewerStatus{}}
+	byLogin := map[string]*reviewerStatus{}
+	person := func(login string) *reviewerStatus {
+		if login == "" {
+			login = "unknown"
+		}
+		if byLogin[login] == nil {
+			byLogin[login] = &reviewerStatus{Login: login}
+		}
+		return byLogin[login]
+	}
+	var oldest time.Time
+	for _, t := range threads {
+		if t.IsOutdated {
+			status.Outdated++
+		}
+		comments := t.Comments.Nodes
+		if len(comments) > 0 {
+			person(comments[0].Author.Login).Opened++
+			person(comments[len(comments)-1].Author.Login).LastComment++
+		}
+		if t.IsResolved {
+			continue
+		}
+		status.Unresolved++
+		if len(comments) == 0 {
+			continue
+		}
+		created, err := time.Parse(time.RFC3339, comments[0].CreatedAt)
+		if err != nil || (!oldest.IsZero() && !created.Before(oldest)) {
+			continue
+		}
+		oldest = created
+		status.OldestUnresolved = &unresolvedStatus{
+			ThreadID:   t.ID,
+			Location:   strings.Trim(strings.TrimSpace(formatLineInfo(t)), "[]"),
+			CreatedAt:  comments[0].CreatedAt,
+			AgeSeconds: int64(now.Sub(created) / time.Second),
+		}
+	}
+	for _, r := range byLogin {
+		status.Reviewers = append(status.Reviewers, *r)
+	}
+	sort.Slice(status.Reviewers, func(i, j int) bool {
+		a, b := status.Reviewers[i], status.Reviewers[j]
+		if a.Opened != b.Opened {
+			return a.Opened > b.Opened
+		}
+		return a.Login < b.Login
+	})
+	return status
+}
+
+// countRounds tallies threads labelled by labelRounds into rounds 1 to
+// latest, including rounds that opened none.
+func countRounds(threads []reviewThread, latest int) []roundStatus {
+	rounds := make([]roundStatus, latest)
+	for i := range rounds {
+		rounds[i].Round = i + 1
+	}
+	for _, t := range threads {
+		if t.Round < 1 || t.Round > latest {
+			continue
+		}
+		r := &rounds[t.Round-1]
+		r.Threads++
+		if !t.IsResolved {
+			r.Open++
+		}
+	}
+	return rounds
+}
+
+func printStatus(w io.Writer, status prStatus, now time.Time) {
+	styler := newStyler(w)
+	fmt.Fprintf(w, "%s %s\n", styler.label(fmt.Sprintf("%s#%d", status.Repo, status.PR)), status.Title)
+	if status.URL != "" {
+		fmt.Fprintln(w, styler.dim(status.URL))
+	}
+	fmt.Fprintf(w, "Review decision: %s\n", reviewDecisionText(status.ReviewDecision))
+	fmt.Fprintf(w, "Threads: %d total, %d unresolved, %d outdated\n", status.Threads, status.Unresolved, status.Outdated)
+	if o := status.OldestUnresolved; o != nil {
+		location := ""
+		if o.Location != "" {
+			location = " " + styler.dim("["+o.Location+"]")
+		}
+		fmt.Fprintf(w, "Oldest unresolved: %s %s%s\n", relativeTime(o.CreatedAt, now), styler.threadID(o.ThreadID), location)
+	}
+	if len(status.Rounds) > 1 {
+		var rounds []string
+		for _, r := range status.Rounds {
+			rounds = append(rounds, fmt.Sprintf("%d: %d of %d", r.Round, r.Open, r.Threads))
+		}
+		fmt.Fprintf(w, "Open by round: %s\n", strings.Join(rounds, ", "))
+	}
+	if len(status.Reviewers) == 0 {
+		return
+	}
+	width := 0
+	for _, r := range status.Reviewers {
+		if len(r.Login) > width {
+			width = len(r.Login)
+		}
+	}
+	fmt.Fprintln(w, "Reviewers:")
+	for _, r := range status.Reviewers {
+		pad := strings.Repeat(" ", width-len(r.Login))
+		fmt.Fprintf(w, "  %s%s  opened %d, last comment on %d\n", styler.author(r.Login), pad, r.Opened, r.LastComment)
+	}
+}
+
+func reviewDecisionText(decision string) string {
+	switch decision {
+	case "":
+		return "none"
+	case "CHANGES_REQUESTED":
+		return "changes requested"
+	case "REVIEW_REQUIRED":
+		return "review required"
+	default:
+		return strings.ToLower(decision)
+	}
+}
+
+func printStatusUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage:")
+	fmt.Fprintln(w, "  gh-pr-review status [--pr <number>] [--repo owner/name] [--json] [--host host]")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Flags:")
+	fmt.Fprintln(w, "  --pr <number>   PR number (defaults to current branch PR if available)")
+	fmt.Fprintln(w, "  --repo <owner/name>   Repository (defaults to gh repo view)")
+	fmt.Fprintln(w, "  --json   Output JSON (thread counts, reviewers, reviewDecision, oldestUnresolved, open threads per review round)")
+	fmt.Fprintln(w, "  --host <host>   GitHub host")
+}
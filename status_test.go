@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func statusThread(id string, resolved, outdated bool, created string, authors ...string) reviewThread {
+	t := reviewThread{ID: id, IsResolved: resolved, IsOutdated: outdated, Path: "main.go", Line: intPtr(10)}
+	for _, a := range authors {
+		c := reviewComment{CreatedAt: created}
+		c.Author.Login = a
+		t.Comments.Nodes = append(t.Comments.Nodes, c)
+	}
+	return t
+}
+
+func TestSummarizeStatus(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	threads := []reviewThread{
+		statusThread("T1", false, false, "2024-05-08T12:00:00Z", "alice", "bob"),
+		statusThread("T2", true, true, "2024-05-01T12:00:00Z", "alice"),
+		statusThread("T3", false, true, "2024-05-09T12:00:00Z", "carol", "bob", "carol"),
+	}
+	got := summarizeStatus(threads, now)
+	if got.Threads != 3 || got.Unresolved != 2 || got.Outdated != 2 {
+		t.Fatalf("counts = %d/%d/%d", got.Threads, got.Unresolved, got.Outdated)
+	}
+	want := []reviewerStatus{
+		{Login: "alice", Opened: 2, LastComment: 1},
+		{Login: "carol", Opened: 1, LastComment: 1},
+		{Login: "bob", Opened: 0, LastComment: 1},
+	}
+	if len(got.Reviewers) != len(want) {
+		t.Fatalf("reviewers = %+v", got.Reviewers)
+	}
+	for i := range want {
+		if got.Reviewers[i] != want[i] {
+			t.Fatalf("reviewers = %+v, want %+v", got.Reviewers, want)
+		}
+	}
+	o := got.OldestUnresolved
+	if o == nil || o.ThreadID != "T1" || o.AgeSeconds != int64(48*time.Hour/time.Second) || o.Location != "main.go:10" {
+		t.Fatalf("oldest unresolved = %+v", o)
+	}
+
+	threads[0].Round, threads[1].Round, threads[2].Round = 1, 1, 3
+	got.Rounds = countRounds(threads, 3)
+	if want := []roundStatus{{1, 2, 1}, {2, 0, 0}, {3, 1, 1}}; !reflect.DeepEqual(got.Rounds, want) {
+		t.Errorf("rounds = %+v, want %+v", got.Rounds, want)
+	}
+
+	var buf bytes.Buffer
+	got.Repo, got.PR, got.ReviewDecision = "owner/repo", 42, "CHANGES_REQUESTED"
+	printStatus(&buf, got, now)
+	for _, line := range []string{
+		"Review decision: changes requested",
+		"Threads: 3 total, 2 unresolved, 2 outdated",
+		"Oldest unresolved: 2d ago T1 [main.go:10]",
+		"Open by round: 1: 1 of 2, 2: 0 of 0, 3: 1 of 1",
+		"  alice  opened 2, last comment on 1",
+	} {
+		if !strings.Contains(buf.String(), line) {
+			t.Errorf("output missing %q:\n%s", line, buf.String())
+		}
+	}
+}
+
+func TestSummarizeStatusEmpty(t *testing.T) {
+	got := summarizeStatus(nil, time.Now())
+	if got.OldestUnresolved != nil || got.Reviewers == nil || len(got.Reviewers) != 0 {
+		t.Fatalf("summarizeStatus(nil) = %+v", got)
+	}
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"gh-pr-review/internal/gh"
+	"gh-pr-review/internal/github"
+)
+
+// subscribable is a node notifications can be turned on or off for.
+type subscribable struct {
+	ID                 string `json:"id"`
+	ViewerCanSubscribe bool   `json:"viewerCanSubscribe"`
+	ViewerSubscription string `json:"viewerSubscription"`
+}
+
+// subscriptionTarget is what a subscribe or unsubscribe applies to, with
+// a name for it in messages.
+type subscriptionTarget struct {
+	subscribable
+	label string
+	// fellBack is set when a thread was asked for but only its PR is
+	// subscribable.
+	fellBack bool
+}
+
+type subscriptionResult struct {
+	SubscribableID     string `json:"subscribableId"`
+	Target             string `json:"target"`
+	ThreadID           string `json:"threadId,omitempty"`
+	ViewerSubscription string `json:"viewerSubscription"`
+}
+
+type subscribeFlags struct {
+	threadID string
+	repo     string
+	pr       int
+	jsonOut  bool
+	host     string
+}
+
+func (o *subscribeFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.threadID, "thread-id", "", "Review thread ID")
+	fs.StringVar(&o.repo, "repo", "", "owner/name (defaults to gh repo view)")
+	fs.IntVar(&o.pr, "pr", 0, "PR number (defaults to current branch PR if available)")
+	fs.BoolVar(&o.jsonOut, "json", false, "output JSON")
+	fs.StringVar(&o.host, "host", gh.DefaultHost(), "GitHub host")
+}
+
+func runSubscribe(args []string, subscribe bool) error {
+	action := "subscribe"
+	if !subscribe {
+		action = "unsubscribe"
+	}
+	fs := newFlagSet(action, func(w io.Writer) { printSubscribeUsage(w, subscribe) })
+	var flags subscribeFlags
+	flags.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+	if flags.threadID != "" && (flags.pr != 0 || flags.repo != "") {
+		return errors.New("provide either --thread-id or --pr/--repo, not both")
+	}
+
+	ctx := context.Background()
+	client, err := newClient(ctx, flags.host)
+	if err != nil {
+		return err
+	}
+	var target subscriptionTarget
+	if flags.threadID != "" {
+		target, err = fetchThreadSubscribable(ctx, client, flags.threadID)
+	} else {
+		if flags.pr, err = resolvePR(ctx, flags.pr); err != nil {
+			return err
+		}
+		var owner, name string
+		if owner, name, err = resolveRepo(ctx, flags.repo); err != nil {
+			return err
+		}
+		target, err = fetchPRSubscribable(ctx, client, owner, name, flags.pr)
+	}
+	if err != nil {
+		return err
+	}
+	if target.fellBack {
+		fmt.Fprintf(os.Stderr, "note: GitHub manages notifications per pull request, not per thread; %s all of %s\n", action, target.label)
+	}
+	if !target.ViewerCanSubscribe {
+		return fmt.Errorf("you can't change your subscription to %s", target.label)
+	}
+	state := "UNSUBSCRIBED"
+	if subscribe {
+		state = "SUBSCRIBED"
+	}
+	if target.ViewerSubscription, err = updateSubscription(ctx, client, target.ID, state); err != nil {
+		return err
+	}
+	if flags.jsonOut {
+		return writeJSON(os.Stdout, subscriptionResult{
+			SubscribableID:     target.ID,
+			Target:             target.label,
+			ThreadID:           flags.threadID,
+			ViewerSubscription: target.ViewerSubscription,
+		})
+	}
+	fmt.Fprintf(os.Stdout, "%s: %s\n", target.label, subscriptionStateText(target.ViewerSubscription))
+	return nil
+}
+
+// fetchThreadSubscribable returns the thread itself if GitHub lets you
+// subscribe to it, and otherwise the PR it belongs to.
+func fetchThreadSubscribable(ctx context.Context, client *github.Client, threadID string) (subscriptionTarget, error) {
+	query := `query($id:ID!) {
+  node(id:$id) {
+    ... on Subscribable { id viewerCanSubscribe viewerSubscription }
+    ... on PullRequestReviewThread {
+      id
+      pullRequest {
+        id
+        number
+        viewerCanSubscribe
+        viewerSubscription
+        repository { nameWithOwner }
+      }
+    }
+  }
+}`
+	var resp struct {
+		Node *struct {
+			subscribable
+			PullRequest *struct {
+				subscribable
+				Number     int `json:"number"`
+				Repository struct {
+					NameWithOwner string `json:"nameWithOwner"`
+				} `json:"repository"`
+			} `json:"pullRequest"`
+		} `json:"node"`
+	}
+	err := client.Do(ctx, query, map[string]interface{}{"id": threadID}, &resp)
+	if github.NotFound(err) || (err == nil && (resp.Node == nil || resp.Node.PullRequest == nil)) {
+		return subscriptionTarget{}, fmt.Errorf("thread %s not found (check the ID from `gh-pr-review list`)", threadID)
+	}
+	if err != nil {
+		return subscriptionTarget{}, err
+	}
+	if resp.Node.ViewerSubscription != "" {
+		return subscriptionTarget{subscribable: resp.Node.subscribable, label: "thread " + threadID}, nil
+	}
+	pull := resp.Node.PullRequest
+	return subscriptionTarget{
+		subscribable: pull.subscribable,
+		label:        fmt.Sprintf("%s#%d", pull.Repository.NameWithOwner, pull.Number),
+		fellBack:     true,
+	}, nil
+}
+
+func fetchPRSubscribable(ctx context.Context, client *github.Client, owner, name string, pr int) (subscriptionTarget, error) {
+	query := `query($owner:String!, $name:String!, $number:Int!) {
+  repository(owner:$owner, name:$name) {
+    pullRequest(number:$number) { id viewerCanSubscribe viewerSubscription }
+  }
+}`
+	vars := map[string]interface{}{
+		"owner":  owner,
+		"name":   name,
+		"number": pr,
+	}
+	var resp struct {
+		Repository struct {
+			PullRequest *subscribable `json:"pullRequest"`
+		} `json:"repository"`
+	}
+	err := client.Do(ctx, query, vars, &resp)
+	if github.NotFound(err) || (err == nil && resp.Repository.PullRequest == nil) {
+		return subscriptionTarget{}, fmt.Errorf("PR %s/%s#%d not found", owner, name, pr)
+	}
+	if err != nil {
+		return subscriptionTarget{}, err
+	}
+	return subscriptionTarget{subscribable: *resp.Repository.PullRequest, label: fmt.Sprintf("%s/%s#%d", owner, name, pr)}, nil
+}
+
+// updateSubscription sets the viewer's subscription and returns the state
+// GitHub reports afterwards.
+func updateSubscription(ctx context.Context, client *github.Client, id, state string) (string, error) {
+	mutation := `mutation($id:ID!, $state:SubscriptionState!) {
+  updateSubscription(input:{subscribableId:$id, state:$state}) {
+    subscribable { viewerSubscription }
+  }
+}`
+	var resp struct {
+		UpdateSubscription struct {
+			Subscribable *subscribable `json:"subscribable"`
+		} `json:"updateSubscription"`
+	}
+	if err := client.Do(ctx, mutation, map[string]interface{}{"id": id, "state": state}, &resp); err != nil {
+		return "", err
+	}
+	if resp.UpdateSubscription.Subscribable == nil {
+		return "", errors.New("missing mutation response")
+	}
+	return resp.UpdateSubscription.Subscribable.ViewerSubscription, nil
+}
+
+func subscriptionStateText(state string) string {
+	switch state {
+	case "SUBSCRIBED":
+		return "subscribed"
+	case "UNSUBSCRIBED":
+		return "not subscribed (you are still notified when mentioned or participating)"
+	case "IGNORED":
+		return "ignored"
+	}
+	return state
+}
+
+func printSubscribeUsage(w io.Writer, subscribe bool) {
+	action := "subscribe"
+	if !subscribe {
+		action = "unsubscribe"
+	}
+	fmt.Fprintln(w, "Usage:")
+	fmt.Fprintf(w, "  gh-pr-review %s --thread-id <id> [--json] [--host host]\n", action)
+	fmt.Fprintf(w, "  gh-pr-review %s [--pr <number>] [--repo owner/name] [--json] [--host host]\n", action)
+	fmt.Fprintln(w, "")
+	if subscribe {
+		fmt.Fprintln(w, "Turns on notifications for all activity on a PR, then prints your subscription state.")
+	} else {
+		fmt.Fprintln(w, "Turns off notifications for a PR's activity, then prints your subscription state. You are")
+		fmt.Fprintln(w, "still notified when mentioned or when you take part in a thread.")
+	}
+	fmt.Fprintln(w, "GitHub manages notifications per pull request, so --thread-id applies to the thread's PR,")
+	fmt.Fprintln(w, "with a note saying so.")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Flags:")
+	fmt.Fprintln(w, "  --thread-id <id>   Review thread ID")
+	fmt.Fprintln(w, "  --pr <number>   PR number (defaults to current branch PR if available)")
+	fmt.Fprintln(w, "  --repo <owner/name>   Repository (defaults to gh repo view)")
+	fmt.Fprintln(w, "  --json   Output the subscription state as JSON")
+	fmt.Fprintln(w, "  --host <host>   GitHub host")
+}
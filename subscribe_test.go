@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gh-pr-review/internal/github"
+)
+
+func TestFetchThreadSubscribableFallsBackToPR(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Threads aren't Subscribable, so the Subscribable fragment adds
+		// nothing but the ID.
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{
+			"node": map[string]interface{}{
+				"id": "PRRT_1",
+				"pullRequest": map[string]interface{}{
+					"id":                 "PR_1",
+					"number":             42,
+					"viewerCanSubscribe": true,
+					"viewerSubscription": "SUBSCRIBED",
+					"repository":         map[string]string{"nameWithOwner": "owner/repo"},
+				},
+			},
+		}})
+	}))
+	defer srv.Close()
+	client := github.NewClient(srv.URL, "token")
+
+	target, err := fetchThreadSubscribable(context.Background(), client, "PRRT_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !target.fellBack || target.ID != "PR_1" || target.label != "owner/repo#42" || !target.ViewerCanSubscribe {
+		t.Fatalf("target = %+v", target)
+	}
+}
+
+func TestUpdateSubscription(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req github.GraphQLRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+			return
+		}
+		if req.Variables["id"] != "PR_1" || req.Variables["state"] != "UNSUBSCRIBED" {
+			t.Errorf("variables = %v", req.Variables)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{
+			"updateSubscription": map[string]interface{}{"subscribable": map[string]string{"viewerSubscription": "UNSUBSCRIBED"}},
+		}})
+	}))
+	defer srv.Close()
+	client := github.NewClient(srv.URL, "token")
+
+	state, err := updateSubscription(context.Background(), client, "PR_1", "UNSUBSCRIBED")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state != "UNSUBSCRIBED" {
+		t.Fatalf("state = %q", state)
+	}
+}
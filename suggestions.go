@@ -0,0 +1,613 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+
+	"gh-pr-review/internal/gh"
+	"gh-pr-review/internal/git"
+	"gh-pr-review/internal/suggest"
+)
+
+// Suggestion applicability against the local working tree.
+const (
+	suggestionApplicable = "applicable"
+	suggestionApplied    = "applied"
+	suggestionConflict   = "conflict"
+	suggestionMissing    = "missing-file"
+	suggestionUnknown    = "unknown"
+)
+
+type reviewSuggestion struct {
+	ThreadID   string `json:"threadId"`
+	CommentID  string `json:"commentId"`
+	URL        string `json:"url"`
+	Author     string `json:"author"`
+	Path       string `json:"path"`
+	StartLine  int    `json:"startLine"`
+	Line       int    `json:"line"`
+	IsResolved bool   `json:"isResolved"`
+	IsOutdated bool   `json:"isOutdated"`
+	Suggestion string `json:"suggestion"`
+	Status     string `json:"status"`
+	Reason     string `json:"reason,omitempty"`
+
+	// localStart is where the targeted lines were found in the local file.
+	localStart int
+	diffHunk   string
+}
+
+type suggestionsFlags struct {
+	repo           string
+	pr             int
+	unresolvedOnly bool
+	format         string
+	jsonOut        bool
+	host           string
+}
+
+func (o *suggestionsFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.repo, "repo", "", "owner/name (defaults to gh repo view)")
+	fs.IntVar(&o.pr, "pr", 0, "PR number")
+	fs.BoolVar(&o.unresolvedOnly, "unresolved-only", false, "only suggestions on unresolved threads")
+	fs.StringVar(&o.format, "format", "text", "text|patch")
+	fs.BoolVar(&o.jsonOut, "json", false, "output JSON")
+	fs.StringVar(&o.host, "host", gh.DefaultHost(), "GitHub host")
+}
+
+func runSuggestions(args []string) error {
+	if len(args) > 0 && args[0] == "apply" {
+		return runApply(args[1:])
+	}
+	fs := newFlagSet("suggestions", printSuggestionsUsage)
+	var flags suggestionsFlags
+	flags.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if flags.format != "text" && flags.format != "patch" {
+		return fmt.Errorf("invalid --format %q", flags.format)
+	}
+
+	ctx := context.Background()
+	var err error
+	flags.pr, err = resolvePR(ctx, flags.pr)
+	if err != nil {
+		return err
+	}
+	owner, name, err := resolveRepo(ctx, flags.repo)
+	if err != nil {
+		return err
+	}
+	client, err := newClient(ctx, flags.host)
+	if err != nil {
+		return err
+	}
+	threads, err := fetchAllThreads(ctx, client, owner, name, flags.pr)
+	if err != nil {
+		return err
+	}
+	if flags.unresolvedOnly {
+		threads = filterThreads(threads, "unresolved")
+	}
+	suggestions := collectSuggestions(threads)
+	checkSuggestions(ctx, suggestions)
+
+	switch {
+	case flags.jsonOut:
+		if suggestions == nil {
+			suggestions = []reviewSuggestion{}
+		}
+		return writeJSON(os.Stdout, suggestions)
+	case flags.format == "patch":
+		return printSuggestionPatches(os.Stdout, suggestions)
+	default:
+		printSuggestions(suggestions)
+		return nil
+	}
+}
+
+// collectSuggestions extracts every suggestion block from the threads'
+// comments, targeting the thread's line range.
+func collectSuggestions(threads []reviewThread) []reviewSuggestion {
+	var out []reviewSuggestion
+	for _, t := range threads {
+		start, end := threadRange(t)
+		for _, c := range t.Comments.Nodes {
+			for _, text := range suggest.Parse(c.Body) {
+				out = append(out, reviewSuggestion{
+					ThreadID:   t.ID,
+					CommentID:  c.ID,
+					URL:        c.URL,
+					Author:     c.Author.Login,
+					Path:       t.Path,
+					StartLine:  start,
+					Line:       end,
+					IsResolved: t.IsResolved,
+					IsOutdated: t.IsOutdated,
+					Suggestion: text,
+					diffHunk:   c.DiffHunk,
+				})
+			}
+		}
+	}
+	return out
+}
+
+// threadRange returns the first and last line a thread covers, using the
+// original lines for outdated threads. Both are zero when unknown.
+func threadRange(t reviewThread) (int, int) {
+	end, start := t.Line, t.StartLine
+	if end == nil {
+		end, start = t.OriginalLine, t.OriginalStart
+	}
+	if end == nil {
+		return 0, 0
+	}
+	if start == nil || *start > *end {
+		return *end, *end
+	}
+	return *start, *end
+}
+
+// checkSuggestions sets each suggestion's status by matching the lines it
+// targets (taken from the comment's diff hunk) against the local checkout.
+func checkSuggestions(ctx context.Context, suggestions []reviewSuggestion) {
+	root, err := git.TopLevel(ctx)
+	for i := range suggestions {
+		s := &suggestions[i]
+		if err != nil {
+			s.Status, s.Reason = suggestionUnknown, err.Error()
+			continue
+		}
+		checkSuggestion(root, s)
+	}
+}
+
+func checkSuggestion(root string, s *reviewSuggestion) {
+	if s.Path == "" || s.Line == 0 {
+		s.Status, s.Reason = suggestionUnknown, "thread has no line range"
+		return
+	}
+	file, err := readFileLines(filepath.Join(root, s.Path))
+	if err != nil {
+		s.Status, s.Reason = suggestionMissing, err.Error()
+		return
+	}
+	checkSuggestionLines(s, file)
+}
+
+// checkSuggestionLines sets the suggestion's status against the given
+// content of its file.
+func checkSuggestionLines(s *reviewSuggestion, file []string) {
+	count := s.Line - s.StartLine + 1
+	original, ok := suggest.OriginalLines(s.diffHunk, count)
+	if !ok {
+		s.Status, s.Reason = suggestionUnknown, "diff hunk does not cover the commented lines"
+		return
+	}
+	if start, ok := suggest.Locate(file, original, s.StartLine); ok {
+		s.Status, s.localStart = suggestionApplicable, start
+		return
+	}
+	if replacement := suggest.Lines(s.Suggestion); len(replacement) > 0 {
+		if _, ok := suggest.Locate(file, replacement, s.StartLine); ok {
+			s.Status, s.Reason = suggestionApplied, "local file already contains the suggestion"
+			return
+		}
+	}
+	s.Status, s.Reason = suggestionConflict, "commented lines no longer match the local file"
+}
+
+// readFileLines reads a file as lines without the trailing newline.
+func readFileLines(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	text := strings.TrimSuffix(string(data), "\n")
+	if text == "" {
+		return []string{}, nil
+	}
+	return strings.Split(text, "\n"), nil
+}
+
+func printSuggestions(suggestions []reviewSuggestion) {
+	if len(suggestions) == 0 {
+		fmt.Fprintln(os.Stdout, "no suggestions found")
+		return
+	}
+	styler := newStyler(os.Stdout)
+	for i, s := range suggestions {
+		author := s.Author
+		if author == "" {
+			author = "unknown"
+		}
+		fmt.Fprintf(os.Stdout, "%s %d %s %s %s\n",
+			styler.label("Suggestion"),
+			i+1,
+			styler.threadID(s.ThreadID),
+			styler.dim(suggestionLocation(s)),
+			suggestionStatus(styler, s.Status),
+		)
+		fmt.Fprintf(os.Stdout, "  %s %s\n", styler.author(author), styler.dim(s.URL))
+		if s.Reason != "" && s.Status != suggestionApplicable {
+			fmt.Fprintf(os.Stdout, "  %s\n", styler.dim(s.Reason))
+		}
+		fmt.Fprintln(os.Stdout, "")
+		lines := suggest.Lines(s.Suggestion)
+		if len(lines) == 0 {
+			fmt.Fprintf(os.Stdout, "    %s\n", styler.dim("(delete lines)"))
+		}
+		for _, line := range lines {
+			fmt.Fprintf(os.Stdout, "    %s\n", styler.wrap("32", "+"+line))
+		}
+		fmt.Fprintln(os.Stdout, "")
+	}
+}
+
+func suggestionLocation(s reviewSuggestion) string {
+	if s.StartLine != s.Line {
+		return fmt.Sprintf("[%s:%d-%d]", s.Path, s.StartLine, s.Line)
+	}
+	return fmt.Sprintf("[%s:%d]", s.Path, s.Line)
+}
+
+func suggestionStatus(styler styler, status string) string {
+	switch status {
+	case suggestionApplicable:
+		return styler.wrap("32", status)
+	case suggestionApplied:
+		return styler.dim(status)
+	default:
+		return styler.wrap("31", status)
+	}
+}
+
+// printSuggestionPatches writes a unified diff for each applicable suggestion
+// and reports the rest on stderr so the patch stays valid.
+func printSuggestionPatches(w io.Writer, suggestions []reviewSuggestion) error {
+	root, err := git.TopLevel(context.Background())
+	if err != nil {
+		return err
+	}
+	for _, s := range suggestions {
+		if s.Status != suggestionApplicable {
+			fmt.Fprintf(os.Stderr, "skipping %s %s: %s (%s)\n", s.ThreadID, suggestionLocation(s), s.Status, s.Reason)
+			continue
+		}
+		file, err := readFileLines(filepath.Join(root, s.Path))
+		if err != nil {
+			return err
+		}
+		count := s.Line - s.StartLine + 1
+		fmt.Fprint(w, suggest.UnifiedDiff(s.Path, file, s.localStart, count, suggest.Lines(s.Suggestion)))
+	}
+	return nil
+}
+
+type applyFlags struct {
+	threadID    string
+	all         bool
+	repo        string
+	pr          int
+	force       bool
+	dryRun      bool
+	resolve     bool
+	ack         bool
+	resume      string
+	keepJournal bool
+	host        string
+}
+
+func (o *applyFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.threadID, "thread-id", "", "Review thread ID")
+	fs.BoolVar(&o.all, "all", false, "apply every applicable suggestion on unresolved threads")
+	fs.StringVar(&o.repo, "repo", "", "owner/name (defaults to gh repo view)")
+	fs.IntVar(&o.pr, "pr", 0, "PR number")
+	fs.BoolVar(&o.force, "force", false, "apply even over uncommitted local changes to the same lines")
+	fs.BoolVar(&o.dryRun, "dry-run", false, "print unified diffs instead of writing files")
+	fs.BoolVar(&o.resolve, "resolve", false, "resolve each thread after applying its suggestion")
+	fs.BoolVar(&o.ack, "ack", false, "reply to each applied thread")
+	fs.StringVar(&o.resume, "resume", "", "with --all: skip what this progress journal records as done")
+	fs.BoolVar(&o.keepJournal, "keep-journal", false, "with --all: keep the progress journal after a clean run")
+	fs.StringVar(&o.host, "host", gh.DefaultHost(), "GitHub host")
+}
+
+// runApply applies suggestion blocks to the local working tree. It backs
+// both `apply` and `suggestions apply`.
+func runApply(args []string) error {
+	fs := newFlagSet("apply", printApplyUsage)
+	var flags applyFlags
+	flags.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if (flags.threadID == "") == !flags.all {
+		return errors.New("provide exactly one of --thread-id or --all")
+	}
+	switch {
+	case !flags.all && (flags.resume != "" || flags.keepJournal):
+		return errors.New("--resume and --keep-journal only apply with --all")
+	case flags.dryRun && flags.resume != "":
+		return errors.New("--resume can't be combined with --dry-run")
+	}
+
+	ctx := context.Background()
+	client, err := newClient(ctx, flags.host)
+	if err != nil {
+		return err
+	}
+	var suggestions []reviewSuggestion
+	if flags.threadID != "" {
+		thread, err := fetchThread(ctx, client, flags.threadID)
+		if err != nil {
+			return err
+		}
+		suggestions = latestSuggestions(collectSuggestions([]reviewThread{thread}))
+		if len(suggestions) == 0 {
+			return fmt.Errorf("thread %s has no suggestion block", flags.threadID)
+		}
+	} else {
+		pr, err := resolvePR(ctx, flags.pr)
+		if err != nil {
+			return err
+		}
+		owner, name, err := resolveRepo(ctx, flags.repo)
+		if err != nil {
+			return err
+		}
+		threads, err := fetchAllThreads(ctx, client, owner, name, pr)
+		if err != nil {
+			return err
+		}
+		suggestions = latestSuggestions(collectSuggestions(filterThreads(threads, "unresolved")))
+	}
+
+	root, err := git.TopLevel(ctx)
+	if err != nil {
+		return err
+	}
+	var journal *journal
+	if flags.all && !flags.dryRun {
+		if journal, err = openJournal("apply", flags.resume, flags.keepJournal); err != nil {
+			return err
+		}
+		// ctrl+c stops before the next suggestion and still reports.
+		var stop context.CancelFunc
+		ctx, stop = signal.NotifyContext(ctx, os.Interrupt)
+		defer stop()
+	}
+	// A dry run writes nothing, so plan holds what each file would contain
+	// after the suggestions shown so far, and later ones are checked
+	// against that.
+	var plan map[string][]string
+	if flags.dryRun {
+		plan = map[string][]string{}
+	}
+	// skipped counts the routine skips (outdated threads, suggestions
+	// already in the file), unapplied the suggestions that should have
+	// applied but didn't, and failed the acks and resolves that didn't go
+	// through after the suggestion was applied.
+	applied, skipped, unapplied, failed, resumed, left := 0, 0, 0, 0, 0, 0
+	for i := range suggestions {
+		if ctx.Err() != nil {
+			left = len(suggestions) - i
+			break
+		}
+		s := &suggestions[i]
+		if journal.done(s.ThreadID, "apply") {
+			fmt.Fprintf(os.Stderr, "%s %s was applied by the resumed run\n", s.ThreadID, suggestionLocation(*s))
+			resumed++
+		} else {
+			// Re-check against the file as left by earlier applications.
+			if file, ok := plan[s.Path]; ok {
+				checkSuggestionLines(s, file)
+			} else {
+				checkSuggestion(root, s)
+			}
+			if err := applySuggestion(ctx, os.Stdout, root, s, flags.force, plan); err != nil {
+				if !flags.all {
+					return err
+				}
+				if routineSkip(*s) {
+					skipped++
+					journal.record(s.ThreadID, "apply", journalSkipped, err)
+					fmt.Fprintf(os.Stderr, "skipped %s %s: %v\n", s.ThreadID, suggestionLocation(*s), err)
+				} else {
+					unapplied++
+					journal.record(s.ThreadID, "apply", journalFailed, err)
+					fmt.Fprintf(os.Stderr, "could not apply %s %s: %v\n", s.ThreadID, suggestionLocation(*s), err)
+				}
+				continue
+			}
+			journal.record(s.ThreadID, "apply", "", nil)
+			applied++
+		}
+		if flags.dryRun {
+			if flags.resolve {
+				fmt.Fprintf(os.Stderr, "would resolve thread %s\n", s.ThreadID)
+			}
+			continue
+		}
+		if flags.ack && !journal.done(s.ThreadID, "ack") {
+			comment, err := replyToThread(ctx, client, s.ThreadID, "Applied locally, will be in the next push.")
+			journal.record(s.ThreadID, "ack", journalFailed, err)
+			if err != nil {
+				failed++
+				fmt.Fprintf(os.Stderr, "failed to acknowledge %s: %v\n", s.ThreadID, err)
+			} else {
+				fmt.Fprintf(os.Stdout, "replied with comment id %s\n", comment.ID)
+			}
+		}
+		if flags.resolve && !journal.done(s.ThreadID, "resolve") {
+			_, err := setThreadResolved(ctx, client, s.ThreadID, true)
+			journal.record(s.ThreadID, "resolve", journalFailed, err)
+			if err != nil {
+				failed++
+				fmt.Fprintf(os.Stderr, "failed to resolve %s: %v\n", s.ThreadID, err)
+			} else {
+				fmt.Fprintf(os.Stdout, "resolved thread %s\n", s.ThreadID)
+			}
+		}
+	}
+	if flags.all {
+		summary := fmt.Sprintf("applied %d, skipped %d", applied, skipped)
+		if flags.dryRun {
+			summary = fmt.Sprintf("would apply %d, skipped %d", applied, skipped)
+		}
+		if unapplied > 0 {
+			summary += fmt.Sprintf(", could not apply %d", unapplied)
+		}
+		if failed > 0 {
+			summary += fmt.Sprintf(", follow-ups failed %d", failed)
+		}
+		if resumed > 0 {
+			summary += fmt.Sprintf(", applied before resuming %d", resumed)
+		}
+		if left > 0 {
+			summary += fmt.Sprintf(", interrupted with %d left", left)
+		}
+		if flags.dryRun {
+			fmt.Fprintln(os.Stderr, summary)
+		} else {
+			fmt.Fprintln(os.Stdout, summary)
+		}
+	}
+	journal.finish(unapplied == 0 && failed == 0 && left == 0)
+	if unapplied > 0 || failed > 0 || left > 0 {
+		return &exitError{code: 1}
+	}
+	return nil
+}
+
+// routineSkip reports whether a suggestion that didn't apply was never
+// meant to: its thread is outdated or the file already contains it.
+func routineSkip(s reviewSuggestion) bool {
+	return s.IsOutdated || s.Status == suggestionApplied
+}
+
+// latestSuggestions keeps the last suggestion of each thread: a reviewer
+// revising a suggestion supersedes the earlier one rather than adding to it.
+func latestSuggestions(suggestions []reviewSuggestion) []reviewSuggestion {
+	last := map[string]int{}
+	for i, s := range suggestions {
+		last[s.ThreadID] = i
+	}
+	var kept []reviewSuggestion
+	for i, s := range suggestions {
+		if last[s.ThreadID] == i {
+			kept = append(kept, s)
+		}
+	}
+	return kept
+}
+
+// applySuggestion rewrites the local file with the suggestion in place of
+// the lines it targets. Nothing is staged or committed. With plan set it is
+// a dry run: the change is written to w as a unified diff against the file
+// as plan holds it, and plan records the result instead of the disk.
+func applySuggestion(ctx context.Context, w io.Writer, root string, s *reviewSuggestion, force bool, plan map[string][]string) error {
+	if s.IsOutdated {
+		return errors.New("thread is outdated; the lines it was left on have changed since")
+	}
+	if s.Status != suggestionApplicable {
+		return fmt.Errorf("%s: %s", s.Status, s.Reason)
+	}
+	count := s.Line - s.StartLine + 1
+	if !force {
+		uncommitted, err := git.UncommittedChangedLines(ctx, s.Path)
+		if err != nil {
+			return err
+		}
+		for _, r := range uncommitted {
+			if r.Overlaps(s.localStart, s.localStart+count-1) {
+				return errors.New("file has uncommitted changes to these lines (use --force to apply anyway)")
+			}
+		}
+	}
+	path := filepath.Join(root, s.Path)
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	file, planned := plan[s.Path]
+	if !planned {
+		if file, err = readFileLines(path); err != nil {
+			return err
+		}
+	}
+	replacement := suggest.Lines(s.Suggestion)
+	if plan != nil {
+		fmt.Fprint(w, suggest.UnifiedDiff(s.Path, file, s.localStart, count, replacement))
+		plan[s.Path] = suggest.Apply(file, s.localStart, count, replacement)
+		return nil
+	}
+	updated := strings.Join(suggest.Apply(file, s.localStart, count, replacement), "\n")
+	if strings.HasSuffix(string(data), "\n") && updated != "" {
+		updated += "\n"
+	}
+	if err := os.WriteFile(path, []byte(updated), info.Mode().Perm()); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "applied suggestion from %s to %s:%s (%d lines -> %d lines)\n",
+		s.Author, s.Path, lineSpan(s.localStart, s.localStart+count-1), count, len(replacement))
+	return nil
+}
+
+func lineSpan(start, end int) string {
+	if start == end {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d-%d", start, end)
+}
+
+func printSuggestionsUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage:")
+	fmt.Fprintln(w, "  gh-pr-review suggestions [--pr <number>] [--repo owner/name] [--unresolved-only] [--format text|patch] [--json] [--host host]")
+	fmt.Fprintln(w, "  gh-pr-review suggestions apply ...   Same as `gh-pr-review apply`")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Flags:")
+	fmt.Fprintln(w, "  --pr <number>   PR number (defaults to current branch PR if available)")
+	fmt.Fprintln(w, "  --repo <owner/name>   Repository (defaults to gh repo view)")
+	fmt.Fprintln(w, "  --unresolved-only   Only suggestions on unresolved threads")
+	fmt.Fprintln(w, "  --format <value>   text (default) or patch: unified diff against the local working tree")
+	fmt.Fprintln(w, "  --json   Output JSON (includes applicability status)")
+	fmt.Fprintln(w, "  --host <host>   GitHub host")
+}
+
+func printApplyUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage:")
+	fmt.Fprintln(w, "  gh-pr-review apply --thread-id <id> [--dry-run] [--force] [--resolve] [--ack] [--host host]")
+	fmt.Fprintln(w, "  gh-pr-review apply --all [--pr <number>] [--repo owner/name] [--dry-run] [--force] [--resolve] [--ack] [--resume <journal>] [--keep-journal] [--host host]")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Flags:")
+	fmt.Fprintln(w, "  --thread-id <id>   Apply the latest suggestion in this thread")
+	fmt.Fprintln(w, "  --all   Apply the latest suggestion of every unresolved thread, in order; exits 1 if one could not be applied, while outdated and already-applied ones are only counted as skipped")
+	fmt.Fprintln(w, "  --pr <number>   PR number for --all (defaults to current branch PR if available)")
+	fmt.Fprintln(w, "  --repo <owner/name>   Repository for --all (defaults to gh repo view)")
+	fmt.Fprintln(w, "  --dry-run   Print unified diffs of the changes instead of writing files")
+	fmt.Fprintln(w, "  --force   Apply even when the lines have uncommitted local changes")
+	fmt.Fprintln(w, "  --resolve   Resolve each thread after its suggestion is applied (a failure exits 1)")
+	fmt.Fprintln(w, "  --ack   Reply \"Applied locally, will be in the next push.\" to each applied thread")
+	fmt.Fprintln(w, "  --resume <journal>   With --all: skip the applies, acks and resolves a progress journal from an interrupted or failed run records as done")
+	fmt.Fprintln(w, "  --keep-journal   With --all: keep the progress journal (under the cache directory) even when everything succeeded")
+	fmt.Fprintln(w, "  --host <host>   GitHub host")
+}
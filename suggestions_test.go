@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// gitCheckout makes a repository with one commit of files and changes into
+// dir inside it for the rest of the test.
+func gitCheckout(t *testing.T, files map[string]string, dir string) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	root := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = root
+		cmd.Env = append(os.Environ(), "GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	for name, content := range files {
+		writeTestFile(t, filepath.Join(root, name), content)
+	}
+	git("init", "-q")
+	git("add", "-A")
+	git("-c", "user.name=t", "-c", "user.email=t@example.com", "commit", "-q", "-m", "init")
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(filepath.Join(root, dir)); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	return root
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestApplySuggestion(t *testing.T) {
+	const original = "package a\n\nfunc a() int {\n\treturn 1\n}\n"
+	root := gitCheckout(t, map[string]string{"pkg/a.go": original, "cmd/main.go": "package main\n"}, "cmd")
+	path := filepath.Join(root, "pkg/a.go")
+	suggestion := func() *reviewSuggestion {
+		s := &reviewSuggestion{
+			ThreadID:   "T",
+			Author:     "bob",
+			Path:       "pkg/a.go",
+			StartLine:  4,
+			Line:       4,
+			Suggestion: "\treturn 2",
+			diffHunk:   "@@ -0,0 +1,4 @@\n+package a\n+\n+func a() int {\n+\treturn 1",
+		}
+		checkSuggestion(root, s)
+		return s
+	}
+	ctx := context.Background()
+
+	var out bytes.Buffer
+	plan := map[string][]string{}
+	if err := applySuggestion(ctx, &out, root, suggestion(), false, plan); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "-\treturn 1\n+\treturn 2") {
+		t.Errorf("dry run diff:\n%s", out.String())
+	}
+	if data, _ := os.ReadFile(path); string(data) != original {
+		t.Errorf("dry run wrote the file:\n%s", data)
+	}
+	// A later suggestion on the same lines is checked against the planned
+	// content, where they no longer say "return 1".
+	later := suggestion()
+	later.Suggestion = "\treturn 3"
+	checkSuggestionLines(later, plan["pkg/a.go"])
+	if later.Status != suggestionConflict {
+		t.Errorf("later suggestion status in the dry run = %s, want %s", later.Status, suggestionConflict)
+	}
+
+	// Run from cmd/, the uncommitted edit to pkg/a.go must still be seen.
+	edited := strings.Replace(original, "return 1", "return 1 // keep", 1)
+	writeTestFile(t, path, edited)
+	s := suggestion()
+	s.Status, s.localStart = suggestionApplicable, 4
+	err := applySuggestion(ctx, &out, root, s, false, nil)
+	if err == nil || !strings.Contains(err.Error(), "uncommitted changes") {
+		t.Fatalf("expected the uncommitted changes to block it, got %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != edited {
+		t.Errorf("blocked apply wrote the file:\n%s", data)
+	}
+
+	writeTestFile(t, path, original)
+	out.Reset()
+	if err := applySuggestion(ctx, &out, root, suggestion(), false, nil); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != strings.Replace(original, "return 1", "return 2", 1) {
+		t.Errorf("applied file:\n%s", data)
+	}
+	if !strings.Contains(out.String(), "applied suggestion from bob to pkg/a.go:4 (1 lines -> 1 lines)") {
+		t.Errorf("output %q", out.String())
+	}
+
+	s = suggestion()
+	if s.Status != suggestionApplied {
+		t.Errorf("status after applying = %s", s.Status)
+	}
+}
+
+func TestLatestSuggestions(t *testing.T) {
+	thread := func(id string, bodies ...string) reviewThread {
+		th := reviewThread{ID: id, Path: "a.go", Line: intPtr(1)}
+		for _, b := range bodies {
+			th.Comments.Nodes = append(th.Comments.Nodes, reviewComment{Body: "```suggestion\n" + b + "\n```"})
+		}
+		return th
+	}
+	got := latestSuggestions(collectSuggestions([]reviewThread{
+		thread("A", "first", "revised"),
+		thread("B", "only"),
+		thread("C"),
+	}))
+	var texts []string
+	for _, s := range got {
+		texts = append(texts, s.ThreadID+"="+s.Suggestion)
+	}
+	if want := "A=revised B=only"; strings.Join(texts, " ") != want {
+		t.Errorf("got %q, want %q", strings.Join(texts, " "), want)
+	}
+}
+
+func TestRoutineSkip(t *testing.T) {
+	cases := []struct {
+		s    reviewSuggestion
+		want bool
+	}{
+		{reviewSuggestion{IsOutdated: true, Status: suggestionApplicable}, true},
+		{reviewSuggestion{Status: suggestionApplied}, true},
+		{reviewSuggestion{Status: suggestionConflict}, false},
+		{reviewSuggestion{Status: suggestionMissing}, false},
+		{reviewSuggestion{Status: suggestionApplicable}, false},
+	}
+	for _, c := range cases {
+		if got := routineSkip(c.s); got != c.want {
+			t.Errorf("routineSkip(outdated %v, %s) = %v, want %v", c.s.IsOutdated, c.s.Status, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+const (
+	formatText  = "text"
+	formatTable = "table"
+)
+
+// minSnippetWidth is the narrowest the snippet column gets before the
+// fixed columns themselves are truncated.
+const minSnippetWidth = 10
+
+// terminalWidth returns the width of stdout, or 0 when it isn't a terminal.
+func terminalWidth() int {
+	width, _, err := term.GetSize(int(terminalFor(os.Stdout).Fd()))
+	if err != nil {
+		return 0
+	}
+	return width
+}
+
+// printThreadTable prints one line per thread: index, status, location,
+// comment count, last author, last activity and the start of the first
+// comment. The index is the thread's position in this output. With width 0
+// the snippet is not truncated.
+func printThreadTable(w io.Writer, threads []reviewThread, width int, styler styler) {
+	if len(threads) == 0 {
+		fmt.Fprintln(w, "no review threads found")
+		return
+	}
+	now := time.Now()
+	header := []string{"#", "STATUS", "LOCATION", "COMMENTS", "AUTHOR", "ACTIVITY", "COMMENT"}
+	rows := make([][]string, 0, len(threads))
+	for i, t := range threads {
+		status := "unresolved"
+		if t.IsResolved {
+			status = "resolved"
+		}
+		var author, activity, snippet string
+		if n := len(t.Comments.Nodes); n > 0 {
+			last := t.Comments.Nodes[n-1]
+			author = last.Author.Login
+			activity = relativeTime(lastActivity(last), now)
+			snippet = firstLine(t.Comments.Nodes[0].Body)
+		}
+		location := strings.TrimSuffix(strings.TrimPrefix(formatLineInfo(t), " ["), "]")
+		rows = append(rows, []string{strconv.Itoa(i + 1), status, location, strconv.Itoa(len(t.Comments.Nodes)), author, activity, snippet})
+	}
+
+	widths := make([]int, len(header))
+	for _, row := range append([][]string{header}, rows...) {
+		for i, cell := range row {
+			if n := utf8.RuneCountInString(cell); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+	if width > 0 {
+		fixed := 0
+		for _, n := range widths[:len(widths)-1] {
+			fixed += n + 2
+		}
+		snippet := &widths[len(widths)-1]
+		*snippet = min(*snippet, max(width-fixed, minSnippetWidth))
+		// Only once the snippet is at its minimum does the location give way.
+		if over := fixed + *snippet - width; over > 0 {
+			widths[2] = max(widths[2]-over, len(header[2]))
+		}
+	}
+
+	printRow := func(row []string, status bool) {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cell = truncateRunes(cell, widths[i])
+			pad := ""
+			if i < len(row)-1 {
+				pad = strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell))
+			}
+			if i == 1 && status {
+				cell = styler.status(cell)
+			}
+			cells[i] = cell + pad
+		}
+		fmt.Fprintln(w, strings.TrimRight(strings.Join(cells, "  "), " "))
+	}
+	printRow(header, false)
+	for _, row := range rows {
+		printRow(row, true)
+	}
+}
+
+func lastActivity(c reviewComment) string {
+	if c.LastEditedAt != "" {
+		return c.LastEditedAt
+	}
+	return c.CreatedAt
+}
+
+// firstLine returns the first non-blank line of a comment body.
+func firstLine(body string) string {
+	for _, line := range strings.Split(body, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// truncateRunes shortens s to n runes, marking the cut with an ellipsis.
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	if n <= 1 {
+		return string([]rune(s)[:n])
+	}
+	return string([]rune(s)[:n-1]) + "…"
+}
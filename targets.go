@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"gh-pr-review/internal/github"
+)
+
+// threadTarget is a thread a command will act on, or the reason it can't.
+type threadTarget struct {
+	id     string
+	thread reviewThread
+	err    error
+}
+
+// threadFilter narrows a PR's threads for the bulk --all modes.
+type threadFilter struct {
+	status   string
+	author   string
+	path     string
+	line     int
+	outdated bool
+	// resolvedBy keeps resolved threads resolved by this login.
+	resolvedBy string
+	// since keeps threads whose last comment is newer than this.
+	since time.Duration
+}
+
+// matchingThreads fetches the PR's threads and applies filter.
+func matchingThreads(ctx context.Context, client *github.Client, repo string, pr int, filter threadFilter) ([]reviewThread, error) {
+	pr, err := resolvePR(ctx, pr)
+	if err != nil {
+		return nil, err
+	}
+	owner, name, err := resolveRepo(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+	threads, err := fetchAllThreads(ctx, client, owner, name, pr)
+	if err != nil {
+		return nil, err
+	}
+	// Without resolvedBy no thread would match, which looks like success.
+	if filter.resolvedBy != "" {
+		if err := requireField(ctx, client, "resolvedBy", "--resolved-by"); err != nil {
+			return nil, err
+		}
+	}
+	threads = filterThreads(threads, filter.status)
+	if filter.author != "" {
+		threads = filterByAuthor(threads, filter.author)
+	}
+	if filter.path != "" {
+		threads = filterByPath(threads, filter.path)
+	}
+	if filter.line > 0 {
+		threads = filterByLine(threads, filter.line)
+	}
+	if filter.outdated {
+		threads = filterOutdated(threads)
+	}
+	if filter.resolvedBy != "" {
+		threads = filterByResolver(threads, filter.resolvedBy)
+	}
+	if filter.since > 0 {
+		threads = filterActiveSince(threads, time.Now().Add(-filter.since))
+	}
+	return threads, nil
+}
+
+func filterOutdated(threads []reviewThread) []reviewThread {
+	var out []reviewThread
+	for _, t := range threads {
+		if t.IsOutdated {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+func filterByResolver(threads []reviewThread, login string) []reviewThread {
+	var out []reviewThread
+	for _, t := range threads {
+		if t.IsResolved && t.ResolvedBy != nil && strings.EqualFold(t.ResolvedBy.Login, login) {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// filterActiveSince keeps threads whose latest comment was created or edited
+// at or after cutoff. GitHub doesn't say when a thread was resolved, so this
+// is the closest available signal.
+func filterActiveSince(threads []reviewThread, cutoff time.Time) []reviewThread {
+	var out []reviewThread
+	for _, t := range threads {
+		nodes := t.Comments.Nodes
+		if len(nodes) == 0 {
+			continue
+		}
+		at, err := time.Parse(time.RFC3339, lastActivity(nodes[len(nodes)-1]))
+		if err == nil && !at.Before(cutoff) {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// filterByLine keeps threads whose commented lines include line. Threads
+// no longer anchored to the diff are matched on their original lines.
+func filterByLine(threads []reviewThread, line int) []reviewThread {
+	var out []reviewThread
+	for _, t := range threads {
+		start, end, ok := threadLines(t)
+		if ok && start <= line && line <= end {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+func threadLines(t reviewThread) (start, end int, ok bool) {
+	last, first := t.Line, t.StartLine
+	if last == nil {
+		last, first = t.OriginalLine, t.OriginalStart
+	}
+	if last == nil {
+		return 0, 0, false
+	}
+	if first == nil || *first > *last {
+		return *last, *last, true
+	}
+	return *first, *last, true
+}
+
+// threadSummary describes a thread in one line as "[path:line] author:
+// snippet".
+func threadSummary(t reviewThread, styler styler) string {
+	var author, snippet string
+	if len(t.Comments.Nodes) > 0 {
+		first := t.Comments.Nodes[0]
+		author = first.Author.Login
+		snippet = truncateRunes(firstLine(first.Body), 60)
+	}
+	parts := strings.TrimPrefix(formatLineInfo(t), " ")
+	if author != "" {
+		parts = strings.TrimSpace(parts + " " + styler.author(author) + ": " + snippet)
+	}
+	return parts
+}
+
+// printThreadTargets lists the threads a bulk command matched on stderr,
+// noting any that will be skipped.
+func printThreadTargets(targets []threadTarget) {
+	styler := newStyler(os.Stderr)
+	fmt.Fprintf(os.Stderr, "%d matching threads:\n", len(targets))
+	for _, t := range targets {
+		author := ""
+		if len(t.thread.Comments.Nodes) > 0 {
+			author = " " + styler.author(t.thread.Comments.Nodes[0].Author.Login)
+		}
+		skip := ""
+		if t.err != nil {
+			skip = " " + styler.dim("(will be skipped: "+t.err.Error()+")")
+		}
+		fmt.Fprintf(os.Stderr, "  %s%s%s%s\n", styler.threadID(t.id), formatLineInfo(t.thread), author, skip)
+	}
+}
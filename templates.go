@@ -0,0 +1,113 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+func configDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gh-pr-review"), nil
+}
+
+// templatesDir holds reply templates, one <name>.md file each.
+func templatesDir() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "templates"), nil
+}
+
+// listTemplates returns the names of the available reply templates.
+func listTemplates() ([]string, error) {
+	dir, err := templatesDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".md") {
+			names = append(names, strings.TrimSuffix(e.Name(), ".md"))
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// renderTemplate executes the named template with vars. Referencing a
+// variable that wasn't given is an error rather than "<no value>".
+func renderTemplate(name string, vars map[string]string) (string, error) {
+	dir, err := templatesDir()
+	if err != nil {
+		return "", err
+	}
+	if strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("invalid template name %q", name)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, name+".md"))
+	if errors.Is(err, fs.ErrNotExist) {
+		names, _ := listTemplates()
+		if len(names) == 0 {
+			return "", fmt.Errorf("template %q not found; no templates in %s", name, dir)
+		}
+		return "", fmt.Errorf("template %q not found (available: %s)", name, strings.Join(names, ", "))
+	}
+	if err != nil {
+		return "", err
+	}
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(string(data))
+	if err != nil {
+		return "", fmt.Errorf("template %q: %w", name, err)
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, vars); err != nil {
+		return "", fmt.Errorf("template %q: %w (pass it with --var key=value)", name, err)
+	}
+	return b.String(), nil
+}
+
+// parseVars turns repeated key=value flags into a map.
+func parseVars(values []string) (map[string]string, error) {
+	vars := map[string]string{}
+	for _, v := range values {
+		key, value, ok := strings.Cut(v, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid --var %q (expected key=value)", v)
+		}
+		vars[key] = value
+	}
+	return vars, nil
+}
+
+func printTemplates(names []string) error {
+	dir, err := templatesDir()
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		fmt.Fprintf(os.Stdout, "no templates found in %s\n", dir)
+		return nil
+	}
+	for _, name := range names {
+		fmt.Fprintln(os.Stdout, name)
+	}
+	return nil
+}
@@ -7,13 +7,18 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"gh-pr-review/internal/gh"
 	"gh-pr-review/internal/github"
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/glamour"
+	gitignore "github.com/sabhiram/go-gitignore"
 	"golang.org/x/term"
 )
 
@@ -29,80 +34,219 @@ type tuiModel struct {
 	owner  string
 	name   string
 	pr     int
-	status string
+	filter tuiFilter
+	// order is the sort order, one of tuiSortOrders; s cycles it.
+	order string
+	// authors are the comment authors in allThreads, counted once per
+	// load for the author picker; picker is set while it is open.
+	authors []authorCount
+	picker  *authorPicker
+	// notes are private thread notes keyed by thread ID; nil with
+	// --no-notes.
+	notes map[string]threadNote
+	// seen is the PR's read state, updated as threads are shown; nil if it
+	// couldn't be loaded.
+	seen *seenStore
 
 	contentCache  map[string]map[int]string
 	rendererCache map[int]*glamour.TermRenderer
+
+	// client makes the TUI's changes and refreshes; nil leaves it
+	// read-only.
+	client *github.Client
+	// ignore hides threads again after a refresh; nil with --no-ignore.
+	ignore *gitignore.GitIgnore
+	// loading is set while the first fetch is still bringing in pages.
+	// loadCursor is where the next page starts and loadErr why the last
+	// one failed; R retries from there.
+	loading    bool
+	loadCursor *string
+	loadErr    error
+	// ignored counts the threads the ignore file has hidden while loading.
+	ignored int
+	// refreshing is set while threads are being fetched again.
+	refreshing bool
+	// status is the message in the footer; statusSeq numbers them.
+	status    tuiStatus
+	statusSeq int
+	// resolving holds the threads with a resolve or unresolve in flight.
+	resolving map[string]bool
+	// composer is the reply being written to composerThread, kept while
+	// hidden until it is sent or discarded.
+	composer       textarea.Model
+	composerThread string
+	// sending counts replies on their way to GitHub.
+	sending int
+	// confirmingQuit is set while asking whether to quit with a draft or
+	// changes still in flight. stashedDraft is where a draft thrown away
+	// by quitting was saved, or stashErr why it couldn't be.
+	confirmingQuit bool
+	stashedDraft   string
+	stashErr       error
+	// confirmingDiscard is set while asking whether esc should throw the
+	// draft in the composer away.
+	confirmingDiscard bool
+	// query is the active search, highlighted in the comments. With
+	// searchFilter only matching threads are listed; without, n and N step
+	// through them.
+	query        string
+	searchFilter bool
+	searchInput  textinput.Model
+	// sidebarToggled flips whether the thread list is shown from the
+	// default for the terminal's width; sidebarTop is the first thread it
+	// shows.
+	sidebarToggled bool
+	sidebarTop     int
+	// jumpDigits is a thread number being typed, after a ":" when
+	// jumpPrompt is set; enter or G goes to it.
+	jumpDigits string
+	jumpPrompt bool
+	// showHelp is set while the key bindings are shown; helpTop is how
+	// far they are scrolled.
+	showHelp bool
+	helpTop  int
+	// fullHunk shows the whole diff hunk above the comments rather than
+	// its last lines.
+	fullHunk bool
+}
+
+// threadPageMsg delivers a page of the first fetch, with the cursor of the
+// next page or nil after the last.
+type threadPageMsg struct {
+	threads []reviewThread
+	next    *string
+	err     error
+}
+
+// threadsLoadedMsg delivers the PR's threads, fetched again.
+type threadsLoadedMsg struct {
+	threads []reviewThread
+	err     error
+}
+
+// actionDoneMsg reports a background action with nothing to update but
+// the footer.
+type actionDoneMsg struct {
+	message string
+	err     error
+}
+
+// threadResolvedMsg reports the outcome of toggling a thread's resolution.
+type threadResolvedMsg struct {
+	threadID string
+	resolved bool
+	err      error
+}
+
+type tuiFlags struct {
+	repo          string
+	pr            int
+	status        string
+	noIgnore      bool
+	noNotes       bool
+	theme         string
+	noRenderCache bool
+	host          string
+}
+
+func (o *tuiFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.repo, "repo", "", "owner/name (defaults to gh repo view)")
+	fs.IntVar(&o.pr, "pr", 0, "PR number")
+	fs.StringVar(&o.status, "status", "all", "all|resolved|unresolved|resolved-no-reply")
+	fs.BoolVar(&o.noIgnore, "no-ignore", false, "show threads on paths in "+ignoreFileName)
+	fs.BoolVar(&o.noNotes, "no-notes", false, "don't show private thread notes")
+	fs.StringVar(&o.theme, "theme", "auto", "markdown style (auto|dark|light|...)")
+	fs.BoolVar(&o.noRenderCache, "no-render-cache", false, "disable the on-disk render cache")
+	fs.StringVar(&o.host, "host", gh.DefaultHost(), "GitHub host")
 }
 
 func runTUI(args []string) error {
-	fs := flag.NewFlagSet("tui", flag.ContinueOnError)
-	fs.SetOutput(os.Stderr)
-	fs.Usage = func() { printTUIUsage(fs.Output()) }
-	var repo string
-	var pr int
-	var status string
-	var host string
-	fs.StringVar(&repo, "repo", "", "owner/name (defaults to gh repo view)")
-	fs.IntVar(&pr, "pr", 0, "PR number")
-	fs.StringVar(&status, "status", "all", "all|resolved|unresolved|resolved-no-reply")
-	fs.StringVar(&host, "host", gh.DefaultHost(), "GitHub host")
-	if err := fs.Parse(args); err != nil {
+	fs := newFlagSet("tui", printTUIUsage)
+	var flags tuiFlags
+	flags.register(fs)
+	if err := parseFlags(fs, args); err != nil {
 		if errors.Is(err, flag.ErrHelp) {
 			return nil
 		}
 		return err
 	}
-	status = strings.ToLower(strings.TrimSpace(status))
-	if status == "" {
-		status = "all"
+	if err := setTheme(flags.theme); err != nil {
+		return err
 	}
-	if status != "all" && status != "resolved" && status != "unresolved" && status != "resolved-no-reply" {
-		return fmt.Errorf("invalid --status %q", status)
+	openRenderCache(flags.noRenderCache)
+	flags.status = strings.ToLower(strings.TrimSpace(flags.status))
+	if flags.status == "" {
+		flags.status = "all"
 	}
-
-	ctx := context.Background()
-	if pr <= 0 {
-		derived, err := gh.CurrentPrNumber(ctx)
-		if err != nil {
-			return fmt.Errorf("--pr is required (and could not be derived from current checkout): %w", err)
-		}
-		pr = derived
+	if flags.status != "all" && flags.status != "resolved" && flags.status != "unresolved" && flags.status != "resolved-no-reply" {
+		return fmt.Errorf("invalid --status %q", flags.status)
 	}
 
-	owner, name, err := resolveRepo(ctx, repo)
+	ctx := context.Background()
+	var err error
+	flags.pr, err = resolvePR(ctx, flags.pr)
 	if err != nil {
 		return err
 	}
-	token, err := gh.AuthToken(ctx, host)
+
+	owner, name, err := resolveRepo(ctx, flags.repo)
 	if err != nil {
-		return fmt.Errorf("failed to get gh auth token: %w", err)
+		return err
 	}
-	client := github.NewClient(github.GraphQLEndpoint(host), token)
-
-	threads, err := fetchAllThreads(ctx, client, owner, name, pr)
+	client, err := newClient(ctx, flags.host)
 	if err != nil {
 		return err
 	}
-	filtered := filterThreads(threads, status)
 
-	model := newTUIModel(owner, name, pr, status, filtered)
+	// Threads are fetched once the program is running, so big PRs show
+	// progress instead of a frozen terminal.
+	model := newTUIModel(owner, name, flags.pr, flags.status, nil)
+	model.client = client
+	model.loading = true
+	if !flags.noIgnore {
+		if model.ignore, err = loadIgnoreFile(ctx); err != nil {
+			return err
+		}
+	}
+	if !flags.noNotes {
+		model.notes = threadNotes()
+	}
+	if model.seen, err = loadSeen(flags.host, owner, name, flags.pr); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: unread threads not tracked: %v\n", err)
+	}
+	// Probe the background now: once the program owns the tty the
+	// terminal's answer would arrive as keystrokes.
+	markdownStyle()
 	program := tea.NewProgram(model, tea.WithAltScreen())
-	_, err = program.Run()
+	final, err := program.Run()
+	if m, ok := final.(*tuiModel); ok {
+		switch {
+		case m.stashedDraft != "":
+			fmt.Fprintf(os.Stderr, "unsent reply saved to %s\n", m.stashedDraft)
+		case m.stashErr != nil:
+			fmt.Fprintf(os.Stderr, "couldn't save the unsent reply (%v); here it is:\n\n%s\n", m.stashErr, m.composer.Value())
+		}
+	}
 	return err
 }
 
 func newTUIModel(owner, name string, pr int, status string, threads []reviewThread) *tuiModel {
+	filter := tuiFilter{status: status}
 	return &tuiModel{
 		allThreads:    threads,
-		threads:       threads,
+		threads:       filter.apply(threads),
 		index:         0,
 		owner:         owner,
 		name:          name,
 		pr:            pr,
-		status:        status,
+		filter:        filter,
+		order:         sortAPI,
+		authors:       countAuthors(threads),
 		contentCache:  map[string]map[int]string{},
 		rendererCache: map[int]*glamour.TermRenderer{},
+		resolving:     map[string]bool{},
+		composer:      newComposer(),
+		searchInput:   newSearchInput(),
 	}
 }
 
@@ -116,14 +260,28 @@ func (m *tuiModel) Init() tea.Cmd {
 		}
 		m.width = width
 		m.height = height
-		m.viewport = viewport.New(width, viewportHeight)
+		m.viewport = newThreadViewport(m.paneWidth(), viewportHeight)
 		m.viewport.SetContent(m.threadContent())
 		m.ready = true
 	}
+	if m.loading {
+		return m.loadPage()
+	}
 	return nil
 }
 
 func (m *tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	seq := m.status.seq
+	model, cmd := m.update(msg)
+	m.scrollSidebar()
+	m.markCurrentSeen()
+	if expire := m.expireStatus(seq); expire != nil {
+		cmd = tea.Batch(cmd, expire)
+	}
+	return model, cmd
+}
+
+func (m *tuiModel) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		if m.ready && msg.Width == m.width && msg.Height == m.height {
@@ -131,28 +289,137 @@ func (m *tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		m.width = msg.Width
 		m.height = msg.Height
-		header := m.headerLines()
-		footer := m.footerLines()
-		viewportHeight := msg.Height - header - footer
-		if viewportHeight < 1 {
-			viewportHeight = 1
-		}
 		if !m.ready {
-			m.viewport = viewport.New(msg.Width, viewportHeight)
+			m.viewport = newThreadViewport(m.paneWidth(), 1)
 			m.ready = true
 		} else {
-			m.viewport.Width = msg.Width
-			m.viewport.Height = viewportHeight
+			m.viewport.Width = m.paneWidth()
 		}
+		m.resizeViewport()
+		m.composer.SetWidth(msg.Width)
 		m.viewport.SetContent(m.threadContent())
 		return m, nil
+	case threadResolvedMsg:
+		m.applyResolved(msg)
+		return m, nil
+	case replyPostedMsg:
+		m.applyReply(msg)
+		return m, nil
+	case threadPageMsg:
+		return m, m.applyPage(msg)
+	case threadsLoadedMsg:
+		m.applyRefresh(msg)
+		return m, nil
+	case statusExpiredMsg:
+		if msg.seq == m.status.seq {
+			m.clearStatus()
+		}
+		return m, nil
+	case actionDoneMsg:
+		if msg.err != nil {
+			m.setStatus(statusError, msg.err.Error())
+		} else {
+			m.setStatus(statusSuccess, msg.message)
+		}
+		return m, nil
 	case tea.KeyMsg:
+		if m.status.level == statusError {
+			m.clearStatus()
+		}
+		if msg.String() == "ctrl+c" {
+			m.confirmingDiscard = false
+			// A second ctrl+c quits without asking again.
+			if m.confirmingQuit {
+				return m, m.quit()
+			}
+			return m, m.requestQuit()
+		}
+		if m.confirmingQuit {
+			m.confirmingQuit = false
+			if msg.String() == "y" || msg.String() == "Y" {
+				return m, m.quit()
+			}
+			return m, nil
+		}
+		if m.confirmingDiscard {
+			m.confirmingDiscard = false
+			if msg.String() == "y" || msg.String() == "Y" {
+				m.discardDraft()
+			}
+			return m, nil
+		}
+		if m.composing() {
+			return m, m.updateComposer(msg)
+		}
+		if m.searchPrompting() {
+			return m, m.updateSearch(msg)
+		}
+		if m.picker != nil {
+			m.updatePicker(msg)
+			return m, nil
+		}
+		if m.showHelp {
+			m.updateHelp(msg)
+			return m, nil
+		}
+		if m.updateJump(msg) {
+			return m, nil
+		}
 		switch msg.String() {
-		case "q", "esc", "ctrl+c":
-			return m, tea.Quit
+		case "esc":
+			if m.query != "" {
+				m.clearSearch()
+				return m, nil
+			}
+			return m, m.requestQuit()
+		case "q":
+			return m, m.requestQuit()
+		case "u":
+			m.nextUnread()
+			return m, nil
+		case "?":
+			m.showHelp = true
+			return m, nil
+		case "/":
+			return m, m.openSearch()
+		case "tab":
+			m.toggleSidebar()
+			return m, nil
+		case "a":
+			m.openAuthorPicker()
+			return m, nil
+		case "d":
+			m.fullHunk = !m.fullHunk
+			m.viewport.SetContent(m.threadContent())
+			return m, nil
+		case "n":
+			m.nextMatch(true)
+			return m, nil
+		case "N":
+			m.nextMatch(false)
+			return m, nil
+		case "x":
+			return m, m.toggleResolved()
+		case "r":
+			return m, m.openComposer()
+		case "R", "ctrl+r":
+			return m, m.refresh()
+		case "o":
+			return m, m.openURL(false)
+		case "O":
+			return m, m.openURL(true)
+		case "e":
+			return m, m.openEditor()
+		case "y":
+			return m, m.copyThreadID()
+		case "Y":
+			return m, m.copyLatestBody()
 		case "f":
 			m.cycleFilter()
 			return m, nil
+		case "s":
+			m.cycleSort()
+			return m, nil
 		case "j":
 			m.nextThread()
 			return m, nil
@@ -166,10 +433,24 @@ func (m *tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.lastThread()
 			return m, nil
 		}
+		if m.scrollThread(msg.String()) {
+			return m, nil
+		}
 		var cmd tea.Cmd
 		m.viewport, cmd = m.viewport.Update(msg)
 		return m, cmd
 	}
+	if m.composing() {
+		// Cursor blinks and the like.
+		var cmd tea.Cmd
+		m.composer, cmd = m.composer.Update(msg)
+		return m, cmd
+	}
+	if m.searchPrompting() {
+		var cmd tea.Cmd
+		m.searchInput, cmd = m.searchInput.Update(msg)
+		return m, cmd
+	}
 	var cmd tea.Cmd
 	m.viewport, cmd = m.viewport.Update(msg)
 	return m, cmd
@@ -182,7 +463,17 @@ func (m *tuiModel) View() string {
 	var b strings.Builder
 	b.WriteString(m.headerView())
 	b.WriteString("\n")
-	b.WriteString(m.viewport.View())
+	pane := m.viewport.View()
+	switch {
+	case m.showHelp:
+		pane = m.helpView(m.viewport.Height)
+	case m.picker != nil:
+		pane = m.pickerView(m.viewport.Height)
+	}
+	if m.sidebarVisible() {
+		pane = m.withSidebar(pane)
+	}
+	b.WriteString(pane)
 	b.WriteString("\n")
 	b.WriteString(m.footerView())
 	return b.String()
@@ -193,9 +484,21 @@ func (m *tuiModel) headerLines() int {
 }
 
 func (m *tuiModel) footerLines() int {
+	if m.composing() {
+		return 2 + composerHeight
+	}
 	return 1
 }
 
+// resizeViewport gives the viewport the rows the header and footer leave.
+func (m *tuiModel) resizeViewport() {
+	height := m.height - m.headerLines() - m.footerLines()
+	if height < 1 {
+		height = 1
+	}
+	m.viewport.Height = height
+}
+
 func (m *tuiModel) headerView() string {
 	styler := newStyler(os.Stdout)
 	repo := fmt.Sprintf("%s/%s", m.owner, m.name)
@@ -207,38 +510,315 @@ func (m *tuiModel) headerView() string {
 			status = "resolved"
 		}
 		threadLine = fmt.Sprintf(
-			"%s %d/%d  %s%s",
+			"%s %d/%d  %s%s%s",
 			styler.label("Thread"),
 			m.index+1,
 			len(m.threads),
 			styler.status(status),
+			threadBadges(current, styler),
 			styler.dim(formatLineInfo(current)),
 		)
 	}
-	return strings.Join([]string{
-		fmt.Sprintf("%s %s  %s #%d  %s %d (filter: %s)",
-			styler.label("Repo:"),
-			repo,
-			styler.label("PR:"),
-			m.pr,
-			styler.label("Threads:"),
-			len(m.threads),
-			m.status,
-		),
-		threadLine,
-	}, "\n")
+	summary := fmt.Sprintf("%s %s  %s #%d  %s %d (filter: %s)  %s %s",
+		styler.label("Repo:"),
+		repo,
+		styler.label("PR:"),
+		m.pr,
+		styler.label("Threads:"),
+		len(m.threads),
+		m.filter,
+		styler.label("Sort:"),
+		m.order,
+	)
+	if unread := m.unreadCount(); unread > 0 {
+		summary += "  " + styler.wrap("33", fmt.Sprintf("● %d unread", unread))
+	}
+	switch {
+	case m.loadErr != nil:
+		summary += "  " + styler.wrap("31", "loading failed (R retries)")
+	case m.loading:
+		summary += "  " + styler.dim(fmt.Sprintf("loaded %d threads…", len(m.allThreads)+m.ignored))
+	}
+	if search := m.searchSummary(); search != "" {
+		summary += "  " + search
+	}
+	if m.refreshing {
+		summary += "  " + styler.dim("refreshing…")
+	}
+	return strings.Join([]string{summary, threadLine}, "\n")
 }
 
 func (m *tuiModel) footerView() string {
 	styler := newStyler(os.Stdout)
-	return fmt.Sprintf(
-		"%s next/prev  %s first/last  %s filter  %s scroll  %s quit",
-		styler.label("j/k"),
-		styler.label("g/G"),
-		styler.label("f"),
-		styler.label("up/down"),
-		styler.label("q"),
-	)
+	var line string
+	switch {
+	case m.confirmingQuit:
+		line = styler.wrap("33", m.quitQuestion()) + "  " + styler.dim("ctrl+c again quits")
+	case m.confirmingDiscard:
+		line = styler.wrap("33", "discard unsent reply? y/n") + "  " + styler.dim("it is saved under the cache directory")
+	case m.jumpPrompt || m.jumpDigits != "":
+		prefix := ""
+		if m.jumpPrompt {
+			prefix = ":"
+		}
+		line = prefix + m.jumpDigits + "  " + styler.dim("enter or G goes to the thread, esc cancels")
+	case m.searchPrompting():
+		line = m.searchInput.View() + "  " + styler.dim("enter filters, alt+enter jumps, esc cancels")
+	case m.status.text != "":
+		line = m.statusView(styler)
+	default:
+		// The rest are in the help, which fits more than a line.
+		line = fmt.Sprintf(
+			"%s next/prev  %s filter  %s search  %s resolve  %s reply  %s scroll  %s all keys  %s quit",
+			styler.label("j/k"),
+			styler.label("f"),
+			styler.label("/"),
+			styler.label("x"),
+			styler.label("r"),
+			styler.label("space/b"),
+			styler.label("?"),
+			styler.label("q"),
+		)
+	}
+	if m.composing() {
+		return m.composerView() + "\n" + line
+	}
+	return line
+}
+
+// loadPage fetches the next page of the first load in the background.
+func (m *tuiModel) loadPage() tea.Cmd {
+	client, owner, name, pr, after := m.client, m.owner, m.name, m.pr, m.loadCursor
+	return func() tea.Msg {
+		threads, next, err := fetchThreadPage(context.Background(), client, owner, name, pr, after)
+		return threadPageMsg{threads: threads, next: next, err: err}
+	}
+}
+
+// applyPage lists a page of the first load as it arrives and asks for the
+// next. A failed page stops the load where it is, to be retried with R.
+func (m *tuiModel) applyPage(msg threadPageMsg) tea.Cmd {
+	if msg.err != nil {
+		m.loadErr = msg.err
+		m.setStatus(statusError, "loading threads failed: "+msg.err.Error())
+		m.viewport.SetContent(m.threadContent())
+		return nil
+	}
+	kept, ignored := splitIgnored(msg.threads, m.ignore)
+	m.ignored += ignored
+	m.allThreads = append(m.allThreads, kept...)
+	m.authors = countAuthors(m.allThreads)
+	m.setThreads(m.listedThreads())
+	if msg.next != nil {
+		m.loadCursor = msg.next
+		return m.loadPage()
+	}
+	m.loading = false
+	m.loadCursor = nil
+	// The last page may change nothing setThreads redraws for.
+	m.viewport.SetContent(m.threadContent())
+	text := fmt.Sprintf("loaded %d threads", len(m.allThreads))
+	if m.ignored > 0 {
+		text += fmt.Sprintf(" (%d ignored by %s)", m.ignored, ignoreFileName)
+	}
+	m.setStatus(statusSuccess, text)
+	return nil
+}
+
+// refresh fetches the PR's threads again in the background, or retries a
+// first load that failed.
+func (m *tuiModel) refresh() tea.Cmd {
+	switch {
+	case m.client == nil:
+		m.setStatus(statusError, "not connected to GitHub")
+		return nil
+	case m.loadErr != nil:
+		m.loadErr = nil
+		m.viewport.SetContent(m.threadContent())
+		return m.loadPage()
+	case m.loading, m.refreshing:
+		return nil
+	}
+	m.refreshing = true
+	client, owner, name, pr := m.client, m.owner, m.name, m.pr
+	return func() tea.Msg {
+		threads, err := fetchAllThreads(context.Background(), client, owner, name, pr)
+		return threadsLoadedMsg{threads: threads, err: err}
+	}
+}
+
+// applyRefresh swaps in freshly fetched threads, keeping the selected thread
+// if it is still listed. A failed refresh keeps what is on screen.
+func (m *tuiModel) applyRefresh(msg threadsLoadedMsg) {
+	m.refreshing = false
+	if msg.err != nil {
+		m.setStatus(statusError, "refresh failed: "+msg.err.Error())
+		return
+	}
+	m.allThreads, _ = splitIgnored(msg.threads, m.ignore)
+	m.authors = countAuthors(m.allThreads)
+	m.contentCache = map[string]map[int]string{}
+	m.setThreads(m.listedThreads())
+	m.viewport.SetContent(m.threadContent())
+	m.setStatus(statusSuccess, fmt.Sprintf("refreshed: %d threads", len(m.allThreads)))
+}
+
+// openURL opens the current thread's first comment in the browser, or
+// copies its URL when copy is set.
+func (m *tuiModel) openURL(copy bool) tea.Cmd {
+	if len(m.threads) == 0 {
+		return nil
+	}
+	nodes := m.threads[m.index].Comments.Nodes
+	if len(nodes) == 0 || nodes[0].URL == "" {
+		m.setStatus(statusError, "this thread has no URL yet (pending comments get one when the review is submitted)")
+		return nil
+	}
+	url := nodes[0].URL
+	if copy {
+		return copyCmd(url, url)
+	}
+	m.setStatus(statusInfo, "opening "+url+"…")
+	return func() tea.Msg {
+		err := openBrowser(context.Background(), url)
+		return actionDoneMsg{message: "opened " + url, err: err}
+	}
+}
+
+// copyThreadID copies the current thread's ID, for pasting into the other
+// commands.
+func (m *tuiModel) copyThreadID() tea.Cmd {
+	if len(m.threads) == 0 {
+		return nil
+	}
+	id := m.threads[m.index].ID
+	return copyCmd(id, "thread ID "+id)
+}
+
+// copyLatestBody copies the markdown of the thread's latest comment.
+func (m *tuiModel) copyLatestBody() tea.Cmd {
+	if len(m.threads) == 0 {
+		return nil
+	}
+	text, label, err := copyValue(m.threads[m.index], "body")
+	if err != nil {
+		m.setStatus(statusError, err.Error())
+		return nil
+	}
+	return copyCmd(text, label)
+}
+
+// copyCmd puts text on the clipboard in the background.
+func copyCmd(text, label string) tea.Cmd {
+	return func() tea.Msg {
+		how, err := copyToClipboard(text)
+		return actionDoneMsg{message: fmt.Sprintf("copied %s to the clipboard (%s)", label, how), err: err}
+	}
+}
+
+// toggleResolved starts resolving the current thread, or unresolving it if
+// it is resolved, in the background.
+func (m *tuiModel) toggleResolved() tea.Cmd {
+	if len(m.threads) == 0 {
+		return nil
+	}
+	thread := m.threads[m.index]
+	resolve := !thread.IsResolved
+	if err := checkResolveTarget(thread.ID, thread, nil, resolve, false).err; err != nil {
+		m.setStatus(statusError, err.Error())
+		return nil
+	}
+	switch {
+	case m.client == nil:
+		m.setStatus(statusError, "not connected to GitHub")
+		return nil
+	case m.resolving[thread.ID]:
+		m.setStatus(statusInfo, "still working on this thread")
+		return nil
+	}
+	m.resolving[thread.ID] = true
+	if resolve {
+		m.setStatus(statusInfo, "resolving "+thread.ID+"…")
+	} else {
+		m.setStatus(statusInfo, "unresolving "+thread.ID+"…")
+	}
+	client := m.client
+	return func() tea.Msg {
+		resolved, err := setThreadResolved(context.Background(), client, thread.ID, resolve)
+		return threadResolvedMsg{threadID: thread.ID, resolved: resolved, err: err}
+	}
+}
+
+// applyResolved records a finished resolve or unresolve. If the filter no
+// longer shows the thread it drops out of the list, leaving the selection on
+// the thread that took its place.
+func (m *tuiModel) applyResolved(msg threadResolvedMsg) {
+	delete(m.resolving, msg.threadID)
+	if msg.err != nil {
+		m.setStatus(statusError, msg.err.Error())
+		return
+	}
+	m.eachCopy(msg.threadID, func(t *reviewThread) { t.IsResolved = msg.resolved })
+	m.setStatus(statusSuccess, resolutionState(msg.resolved)+" "+msg.threadID)
+	m.setThreads(m.listedThreads())
+}
+
+// setThreads replaces the listed threads, keeping the selection on the same
+// thread if it is still listed and otherwise on the one now at its position.
+func (m *tuiModel) setThreads(threads []reviewThread) {
+	var currentID string
+	if len(m.threads) > 0 {
+		currentID = m.threads[m.index].ID
+	}
+	m.threads = threads
+	for i, t := range threads {
+		if t.ID == currentID {
+			m.index = i
+			return
+		}
+	}
+	if m.index >= len(m.threads) {
+		m.index = len(m.threads) - 1
+	}
+	if m.index < 0 {
+		m.index = 0
+	}
+	m.viewport.SetContent(m.threadContent())
+	m.viewport.GotoTop()
+}
+
+// updateThread applies change to the thread in both thread lists and
+// redraws it if it is on screen.
+func (m *tuiModel) updateThread(threadID string, change func(*reviewThread)) {
+	m.invalidateThread(threadID)
+	m.eachCopy(threadID, change)
+	if len(m.threads) > 0 && m.threads[m.index].ID == threadID {
+		m.viewport.SetContent(m.threadContent())
+		m.viewport.GotoBottom()
+	}
+}
+
+// eachCopy calls change on every copy of the thread: the one in allThreads
+// and, when a filter has made a separate list, the one in threads.
+func (m *tuiModel) eachCopy(threadID string, change func(*reviewThread)) {
+	seen := map[*reviewThread]bool{}
+	for _, list := range [][]reviewThread{m.allThreads, m.threads} {
+		for i := range list {
+			if list[i].ID == threadID && !seen[&list[i]] {
+				seen[&list[i]] = true
+				change(&list[i])
+			}
+		}
+	}
+}
+
+// invalidateThread drops the thread's rendered content from the cache.
+func (m *tuiModel) invalidateThread(threadID string) {
+	for key := range m.contentCache {
+		if key == threadID || strings.HasPrefix(key, threadID+"|") {
+			delete(m.contentCache, key)
+		}
+	}
 }
 
 func (m *tuiModel) nextThread() {
@@ -286,9 +866,118 @@ func (m *tuiModel) lastThread() {
 	}
 }
 
+// updateJump collects a thread number typed as a count ("12" then enter or
+// G) or vi-style (":12" then enter) and reports whether it used the key.
+// Any other key drops the number and does what it usually does.
+func (m *tuiModel) updateJump(msg tea.KeyMsg) bool {
+	key := msg.String()
+	pending := m.jumpPrompt || m.jumpDigits != ""
+	if len(key) == 1 && key[0] >= '0' && key[0] <= '9' && (key != "0" || pending) {
+		if len(m.jumpDigits) < 6 {
+			m.jumpDigits += key
+		}
+		return true
+	}
+	if key == ":" && !pending {
+		m.jumpPrompt = true
+		return true
+	}
+	if !pending {
+		return false
+	}
+	switch key {
+	case "enter", "G":
+		if m.jumpDigits != "" {
+			n, _ := strconv.Atoi(m.jumpDigits)
+			m.jumpTo(n)
+		}
+		m.cancelJump()
+		return true
+	case "backspace":
+		if m.jumpDigits == "" {
+			m.jumpPrompt = false
+		} else {
+			m.jumpDigits = m.jumpDigits[:len(m.jumpDigits)-1]
+		}
+		return true
+	case "esc":
+		m.cancelJump()
+		return true
+	}
+	m.cancelJump()
+	return false
+}
+
+func (m *tuiModel) cancelJump() {
+	m.jumpDigits = ""
+	m.jumpPrompt = false
+}
+
+// jumpTo selects thread n, counting from 1. Numbers past the end go to the
+// last thread.
+func (m *tuiModel) jumpTo(n int) {
+	if len(m.threads) == 0 {
+		return
+	}
+	index := n - 1
+	if index < 0 {
+		index = 0
+	}
+	if index >= len(m.threads) {
+		index = len(m.threads) - 1
+		m.setStatus(statusInfo, fmt.Sprintf("there are only %d threads; showing the last", len(m.threads)))
+	}
+	if index != m.index {
+		m.index = index
+		m.viewport.SetContent(m.threadContent())
+		m.viewport.GotoTop()
+	}
+}
+
+// markCurrentSeen records the thread on screen as read.
+func (m *tuiModel) markCurrentSeen() {
+	if m.seen == nil || len(m.threads) == 0 || !m.seen.markSeen(m.threads[m.index]) {
+		return
+	}
+	if err := m.seen.save(); err != nil {
+		m.setStatus(statusError, "unread threads not tracked: "+err.Error())
+		m.seen = nil
+	}
+}
+
+func (m *tuiModel) unreadCount() int {
+	if m.seen == nil {
+		return 0
+	}
+	n := 0
+	for _, t := range m.threads {
+		if m.seen.unread(t) {
+			n++
+		}
+	}
+	return n
+}
+
+// nextUnread moves to the next unread thread, going round the end of the
+// list.
+func (m *tuiModel) nextUnread() {
+	if m.seen != nil {
+		for i := 1; i < len(m.threads); i++ {
+			index := (m.index + i) % len(m.threads)
+			if m.seen.unread(m.threads[index]) {
+				m.index = index
+				m.viewport.SetContent(m.threadContent())
+				m.viewport.GotoTop()
+				return
+			}
+		}
+	}
+	m.setStatus(statusInfo, "no unread threads")
+}
+
 func (m *tuiModel) cycleFilter() {
 	next := "all"
-	switch m.status {
+	switch m.filter.status {
 	case "all":
 		next = "unresolved"
 	case "unresolved":
@@ -298,8 +987,8 @@ func (m *tuiModel) cycleFilter() {
 	case "resolved-no-reply":
 		next = "all"
 	}
-	m.status = next
-	m.threads = filterThreads(m.allThreads, m.status)
+	m.filter.status = next
+	m.threads = m.listedThreads()
 	if len(m.threads) == 0 {
 		m.index = 0
 		m.viewport.SetContent(m.threadContent())
@@ -313,8 +1002,27 @@ func (m *tuiModel) cycleFilter() {
 	m.viewport.GotoTop()
 }
 
+// cycleSort moves to the next sort order, keeping the same thread
+// selected.
+func (m *tuiModel) cycleSort() {
+	next := 0
+	for i, order := range tuiSortOrders {
+		if order == m.order {
+			next = (i + 1) % len(tuiSortOrders)
+		}
+	}
+	m.order = tuiSortOrders[next]
+	m.setThreads(m.listedThreads())
+}
+
 func (m *tuiModel) threadContent() string {
 	if len(m.threads) == 0 {
+		switch {
+		case m.loadErr != nil:
+			return fmt.Sprintf("couldn't load the threads: %v\n\npress R to try again", m.loadErr)
+		case m.loading:
+			return "loading threads…"
+		}
 		return "no review threads found"
 	}
 	thread := m.threads[m.index]
@@ -322,20 +1030,43 @@ func (m *tuiModel) threadContent() string {
 	if width <= 0 {
 		width = 120
 	}
-	if cached := m.cachedContent(thread.ID, width); cached != "" {
-		return cached
+	// The note stays out of the content cache, which is keyed on the thread
+	// alone.
+	var note string
+	if n, ok := m.notes[thread.ID]; ok {
+		note = noteLine(n, newStyler(os.Stdout)) + "\n\n"
+	}
+	key := threadCacheKey(thread)
+	if m.fullHunk && key != "" {
+		key += "|+hunk"
+	}
+	if m.query != "" && key != "" {
+		key += "|/" + m.query
+	}
+	if cached := m.cachedContent(key, width); cached != "" {
+		return note + cached
 	}
 	metaStyler := newStyler(os.Stdout)
 	bodyStyler := newStyler(os.Stdout)
 	renderer := m.rendererForWidth(width)
 
 	var b strings.Builder
+	if len(thread.Comments.Nodes) > 0 {
+		if block := hunkBlock(thread.Comments.Nodes[0].DiffHunk, width, m.fullHunk, metaStyler); block != "" {
+			b.WriteString(block)
+			b.WriteString("\n")
+		}
+	}
 	for i, c := range thread.Comments.Nodes {
 		author := c.Author.Login
 		if author == "" {
 			author = "unknown"
 		}
-		b.WriteString(fmt.Sprintf("%s %s — %s\n", metaStyler.bullet(), metaStyler.author(author), metaStyler.dim(c.CreatedAt)))
+		meta := metaStyler.dim(commentTimestamp(c, time.Now()))
+		if c.isPending() {
+			meta += " " + metaStyler.pending()
+		}
+		b.WriteString(fmt.Sprintf("%s %s — %s\n", metaStyler.bullet(), metaStyler.author(author), meta))
 		if c.URL != "" {
 			b.WriteString(fmt.Sprintf("  %s\n", metaStyler.dim(c.URL)))
 		}
@@ -349,24 +1080,33 @@ func (m *tuiModel) threadContent() string {
 		}
 	}
 	content := b.String()
-	m.storeContent(thread.ID, width, content)
-	return content
+	if m.query != "" && bodyStyler.enabled {
+		content = highlightMatches(content, m.query)
+	}
+	m.storeContent(key, width, content)
+	return note + content
 }
 
 func printTUIUsage(w io.Writer) {
 	fmt.Fprintln(w, "Usage:")
-	fmt.Fprintln(w, "  gh-pr-review tui [--pr <number>] [--repo owner/name] [--status all|resolved|unresolved|resolved-no-reply] [--host host]")
+	fmt.Fprintln(w, "  gh-pr-review tui [--pr <number>] [--repo owner/name] [--status all|resolved|unresolved|resolved-no-reply] [--no-ignore] [--no-notes] [--theme style] [--no-render-cache] [--host host]")
 	fmt.Fprintln(w, "")
 	fmt.Fprintln(w, "Flags:")
 	fmt.Fprintln(w, "  --pr <number>   PR number (defaults to current branch PR if available)")
 	fmt.Fprintln(w, "  --repo <owner/name>   Repository (defaults to gh repo view)")
 	fmt.Fprintln(w, "  --status <value>   all|resolved|unresolved|resolved-no-reply")
+	fmt.Fprintln(w, "  --no-ignore   Include threads on paths matched by .gh-pr-review-ignore")
+	fmt.Fprintln(w, "  --no-notes   Don't show notes saved with `gh-pr-review note`")
+	fmt.Fprintln(w, "  --theme <style>   Markdown style: auto, dark, light, notty, ... (auto honours GH_PR_REVIEW_BACKGROUND)")
+	fmt.Fprintln(w, "  --no-render-cache   Don't read or write the on-disk render cache")
 	fmt.Fprintln(w, "  --host <host>   GitHub host")
 }
 
 func formatCommentBodyWithRenderer(body, indent string, width int, styler styler, renderer *glamour.TermRenderer) []string {
 	if styler.enabled && renderer != nil {
-		rendered, err := renderer.Render(body)
+		rendered, err := cachedRender(body, rendererWrapWidth(width), func() (string, error) {
+			return renderer.Render(body)
+		})
 		if err == nil {
 			return indentRendered(rendered, indent)
 		}
@@ -374,6 +1114,15 @@ func formatCommentBodyWithRenderer(body, indent string, width int, styler styler
 	return wrapPlainText(body, indent, width)
 }
 
+// rendererWrapWidth is the word-wrap width of the renderer for a viewport
+// width, leaving room for the body indent.
+func rendererWrapWidth(width int) int {
+	if width < 20 {
+		width = 20
+	}
+	return width - 2
+}
+
 func (m *tuiModel) rendererForWidth(width int) *glamour.TermRenderer {
 	if width < 20 {
 		width = 20
@@ -382,8 +1131,8 @@ func (m *tuiModel) rendererForWidth(width int) *glamour.TermRenderer {
 		return cached
 	}
 	renderer, err := glamour.NewTermRenderer(
-		glamour.WithAutoStyle(),
-		glamour.WithWordWrap(width-2),
+		glamour.WithStandardStyle(markdownStyle()),
+		glamour.WithWordWrap(rendererWrapWidth(width)),
 	)
 	if err != nil {
 		return nil
@@ -392,11 +1141,28 @@ func (m *tuiModel) rendererForWidth(width int) *glamour.TermRenderer {
 	return renderer
 }
 
-func (m *tuiModel) cachedContent(threadID string, width int) string {
-	if threadID == "" {
+// threadCacheKey identifies a thread's rendered content. It includes each
+// comment's edit time so edited or new comments never hit stale entries.
+func threadCacheKey(t reviewThread) string {
+	if t.ID == "" {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(t.ID)
+	for _, c := range t.Comments.Nodes {
+		b.WriteString("|")
+		b.WriteString(c.ID)
+		b.WriteString("@")
+		b.WriteString(c.LastEditedAt)
+	}
+	return b.String()
+}
+
+func (m *tuiModel) cachedContent(key string, width int) string {
+	if key == "" {
 		return ""
 	}
-	if perThread, ok := m.contentCache[threadID]; ok {
+	if perThread, ok := m.contentCache[key]; ok {
 		if content, ok := perThread[width]; ok {
 			return content
 		}
@@ -404,14 +1170,14 @@ func (m *tuiModel) cachedContent(threadID string, width int) string {
 	return ""
 }
 
-func (m *tuiModel) storeContent(threadID string, width int, content string) {
-	if threadID == "" {
+func (m *tuiModel) storeContent(key string, width int, content string) {
+	if key == "" {
 		return
 	}
-	perThread := m.contentCache[threadID]
+	perThread := m.contentCache[key]
 	if perThread == nil {
 		perThread = map[int]string{}
-		m.contentCache[threadID] = perThread
+		m.contentCache[key] = perThread
 	}
 	perThread[width] = content
 }
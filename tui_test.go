@@ -0,0 +1,868 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mattn/go-runewidth"
+
+	"gh-pr-review/internal/git"
+	"gh-pr-review/internal/github"
+)
+
+func init() {
+	// Status messages expire at once, so commands batched with their
+	// expiry don't hold tests up.
+	statusTTL = 0
+}
+
+func keyMsg(key string) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+}
+
+// run runs cmd as the program would and returns the message it produces,
+// leaving out status message expiries.
+func run(cmd tea.Cmd) tea.Msg {
+	if cmd == nil {
+		return nil
+	}
+	switch msg := cmd().(type) {
+	case statusExpiredMsg:
+		return nil
+	case tea.BatchMsg:
+		for _, c := range msg {
+			if msg := run(c); msg != nil {
+				return msg
+			}
+		}
+		return nil
+	default:
+		return msg
+	}
+}
+
+func TestTUIToggleResolved(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req github.GraphQLRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+			return
+		}
+		if !strings.Contains(req.Query, "resolveReviewThread(") || req.Variables["threadId"] != "PRRT_1" {
+			t.Errorf("unexpected request %q %v", req.Query, req.Variables)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{
+			"resolveReviewThread": map[string]interface{}{"thread": map[string]interface{}{"id": "PRRT_1", "isResolved": true}},
+		}})
+	}))
+	defer srv.Close()
+
+	threads := []reviewThread{
+		{ID: "PRRT_1", CanResolve: true},
+		{ID: "PRRT_2", CanResolve: true},
+	}
+	m := newTUIModel("o", "r", 1, "unresolved", threads)
+	m.client = github.NewClient(srv.URL, "token")
+
+	_, cmd := m.Update(keyMsg("x"))
+	if cmd == nil {
+		t.Fatal("x didn't start a resolve")
+	}
+	if !strings.Contains(m.status.text, "resolving PRRT_1") {
+		t.Errorf("message while in flight = %q", m.status.text)
+	}
+	if _, again := m.Update(keyMsg("x")); run(again) != nil {
+		t.Error("a second x started another resolve while one was in flight")
+	}
+	m.Update(run(cmd))
+
+	if !m.allThreads[0].IsResolved {
+		t.Error("allThreads not updated")
+	}
+	if len(m.threads) != 1 || m.threads[0].ID != "PRRT_2" || m.index != 0 {
+		t.Errorf("the unresolved filter still shows %v (index %d)", threadIDs(m.threads), m.index)
+	}
+	if m.status.text != "resolved PRRT_1" {
+		t.Errorf("message = %q", m.status.text)
+	}
+}
+
+func TestTUIToggleResolvedReportsErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"errors": []map[string]string{{"message": "Resource not accessible by integration"}}})
+	}))
+	defer srv.Close()
+
+	m := newTUIModel("o", "r", 1, "all", []reviewThread{{ID: "PRRT_1", CanResolve: true}})
+	m.client = github.NewClient(srv.URL, "token")
+	_, cmd := m.Update(keyMsg("x"))
+	m.Update(run(cmd))
+	if m.threads[0].IsResolved || !strings.Contains(m.status.text, "Resource not accessible") {
+		t.Errorf("thread %+v, message %q", m.threads[0], m.status.text)
+	}
+
+	// Threads the viewer can't resolve never reach GitHub.
+	m = newTUIModel("o", "r", 1, "all", []reviewThread{{ID: "PRRT_2"}})
+	m.client = github.NewClient(srv.URL, "token")
+	if _, cmd := m.Update(keyMsg("x")); cmd != nil || !strings.Contains(m.status.text, "permission") {
+		t.Errorf("cmd %v, message %q", cmd, m.status.text)
+	}
+}
+
+func TestTUIOpenURL(t *testing.T) {
+	opened := filepath.Join(t.TempDir(), "opened")
+	t.Setenv("BROWSER", "echo >"+opened)
+	url := "https://github.com/o/r/pull/1#discussion_r1"
+	m := newTUIModel("o", "r", 1, "all", []reviewThread{
+		{ID: "PRRT_1", Comments: reviewThreadComment{Nodes: []reviewComment{{URL: url}}}},
+		{ID: "PRRT_2", Comments: reviewThreadComment{Nodes: []reviewComment{{Body: "pending"}}}},
+	})
+	_, cmd := m.Update(keyMsg("o"))
+	m.Update(run(cmd))
+	if m.status.text != "opened "+url {
+		t.Errorf("message = %q", m.status.text)
+	}
+	if got, _ := os.ReadFile(opened); strings.TrimSpace(string(got)) != url {
+		t.Errorf("browser got %q", got)
+	}
+
+	m.Update(keyMsg("j"))
+	if _, cmd := m.Update(keyMsg("o")); cmd != nil || !strings.Contains(m.status.text, "no URL") {
+		t.Errorf("cmd %v, message %q", cmd, m.status.text)
+	}
+}
+
+func TestTUIReplyComposer(t *testing.T) {
+	fail := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req github.GraphQLRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+			return
+		}
+		if req.Variables["threadId"] != "PRRT_1" || req.Variables["body"] != "Fixed in abc123" {
+			t.Errorf("variables = %v", req.Variables)
+		}
+		if fail {
+			json.NewEncoder(w).Encode(map[string]interface{}{"errors": []map[string]string{{"message": "conversation is locked"}}})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{
+			"addPullRequestReviewThreadReply": map[string]interface{}{"comment": map[string]string{"id": "PRRC_new", "url": "https://example.com/c", "createdAt": "2024-05-01T10:00:00Z"}},
+		}})
+	}))
+	defer srv.Close()
+
+	first := reviewComment{ID: "PRRC_1", Body: "Please fix"}
+	threads := []reviewThread{{ID: "PRRT_1", CanReply: true, Comments: reviewThreadComment{Nodes: []reviewComment{first}}}}
+	m := newTUIModel("o", "r", 1, "all", threads)
+	m.client = github.NewClient(srv.URL, "token")
+	m.width, m.height = 80, 30
+
+	m.Update(keyMsg("r"))
+	if !m.composing() {
+		t.Fatal("r didn't open the composer")
+	}
+	// Navigation keys are typed into the reply instead.
+	for _, key := range []string{"j", "q"} {
+		if _, cmd := m.Update(keyMsg(key)); cmd != nil && cmd() == tea.Quit() {
+			t.Fatalf("%s quit while composing", key)
+		}
+	}
+	m.composer.SetValue("Fixed in abc123")
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlS})
+	if cmd == nil || m.composing() {
+		t.Fatal("ctrl+s didn't send the reply")
+	}
+	if nodes := m.threads[0].Comments.Nodes; len(nodes) != 2 || nodes[1].Body != "Fixed in abc123" {
+		t.Fatalf("the reply wasn't shown while sending: %+v", nodes)
+	}
+	m.Update(run(cmd))
+	if nodes := m.allThreads[0].Comments.Nodes; len(nodes) != 2 || nodes[1].ID != "PRRC_new" || nodes[1].URL != "https://example.com/c" {
+		t.Fatalf("allThreads comments = %+v", nodes)
+	}
+
+	// A refused reply is taken back out and returned to the composer.
+	fail = true
+	m.Update(keyMsg("r"))
+	m.composer.SetValue("Fixed in abc123")
+	_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyCtrlS})
+	m.Update(run(cmd))
+	if n := len(m.threads[0].Comments.Nodes); n != 2 {
+		t.Errorf("thread has %d comments after a failed reply, want 2", n)
+	}
+	if !strings.Contains(m.status.text, "conversation is locked") || m.composer.Value() != "Fixed in abc123" {
+		t.Errorf("message %q, draft %q", m.status.text, m.composer.Value())
+	}
+}
+
+func TestTUIRefreshKeepsSelection(t *testing.T) {
+	m := newTUIModel("o", "r", 1, "unresolved", []reviewThread{{ID: "A"}, {ID: "B"}, {ID: "C"}})
+	m.client = github.NewClient("http://127.0.0.1:0", "token")
+	m.index = 1
+
+	if _, cmd := m.Update(keyMsg("R")); cmd == nil || !m.refreshing {
+		t.Fatal("R didn't start a refresh")
+	}
+	if !strings.Contains(m.headerView(), "refreshing…") {
+		t.Errorf("header = %q", m.headerView())
+	}
+	// A is resolved elsewhere and D is new; B stays selected.
+	m.Update(threadsLoadedMsg{threads: []reviewThread{{ID: "A", IsResolved: true}, {ID: "B"}, {ID: "C"}, {ID: "D"}}})
+	if m.refreshing || len(m.allThreads) != 4 || strings.Join(threadIDs(m.threads), ",") != "B,C,D" {
+		t.Fatalf("after refresh: all %v, listed %v", threadIDs(m.allThreads), threadIDs(m.threads))
+	}
+	if m.threads[m.index].ID != "B" {
+		t.Errorf("selected %s, want B", m.threads[m.index].ID)
+	}
+
+	// A selected thread that disappears leaves the index in range.
+	m.index = 2
+	m.Update(threadsLoadedMsg{threads: []reviewThread{{ID: "B"}}})
+	if m.index != 0 || m.threads[0].ID != "B" {
+		t.Errorf("index %d of %v", m.index, threadIDs(m.threads))
+	}
+
+	// A failed refresh keeps the threads on screen.
+	m.refreshing = true
+	m.Update(threadsLoadedMsg{err: errors.New("timeout")})
+	if len(m.threads) != 1 || !strings.Contains(m.status.text, "refresh failed: timeout") {
+		t.Errorf("threads %v, message %q", threadIDs(m.threads), m.status.text)
+	}
+}
+
+func TestTUISearch(t *testing.T) {
+	comment := func(body string) reviewThreadComment {
+		return reviewThreadComment{Nodes: []reviewComment{{Body: body}}}
+	}
+	m := newTUIModel("o", "r", 1, "all", []reviewThread{
+		{ID: "A", Path: "main.go", Comments: comment("Looks good")},
+		{ID: "B", Path: "cache.go", Comments: comment("Needs a TIMEOUT here")},
+		{ID: "C", Path: "timeout.go", Comments: comment("nit")},
+		{ID: "D", Path: "README.md", Comments: comment("typo")},
+	})
+	search := func(query string, key tea.KeyMsg) {
+		m.Update(keyMsg("/"))
+		if !m.searchPrompting() {
+			t.Fatal("/ didn't open the search prompt")
+		}
+		m.searchInput.SetValue(query)
+		m.Update(key)
+	}
+
+	// enter lists only the matches, by path or body and ignoring case.
+	search("timeout", tea.KeyMsg{Type: tea.KeyEnter})
+	if got := strings.Join(threadIDs(m.threads), ","); got != "B,C" {
+		t.Fatalf("listed %s, want B,C", got)
+	}
+	if !strings.Contains(m.headerView(), `search: "timeout" (2 matches)`) {
+		t.Errorf("header = %q", m.headerView())
+	}
+
+	// esc clears the search and brings the other threads back.
+	m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.query != "" || len(m.threads) != 4 {
+		t.Fatalf("after esc: query %q, %d threads", m.query, len(m.threads))
+	}
+
+	// alt+enter keeps every thread and moves to the first match; n and N
+	// step through the matches, going round the ends.
+	search("timeout", tea.KeyMsg{Type: tea.KeyEnter, Alt: true})
+	if len(m.threads) != 4 || m.threads[m.index].ID != "B" {
+		t.Fatalf("%d threads, selected %s", len(m.threads), m.threads[m.index].ID)
+	}
+	for _, step := range []struct{ key, want string }{{"n", "C"}, {"n", "B"}, {"N", "C"}} {
+		m.Update(keyMsg(step.key))
+		if got := m.threads[m.index].ID; got != step.want {
+			t.Errorf("after %s selected %s, want %s", step.key, got, step.want)
+		}
+	}
+
+	search("nothing like this", tea.KeyMsg{Type: tea.KeyEnter})
+	if len(m.threads) != 0 || !strings.Contains(m.status.text, "no threads match") {
+		t.Errorf("%d threads, message %q", len(m.threads), m.status.text)
+	}
+}
+
+func TestHighlightMatches(t *testing.T) {
+	cases := []struct {
+		name, text, query, want string
+	}{
+		{"plain", "a Timeout here", "timeout", "a \x1b[7mTimeout\x1b[27m here"},
+		{"twice", "ab ab", "ab", "\x1b[7mab\x1b[27m \x1b[7mab\x1b[27m"},
+		{"through escapes", "\x1b[1mti\x1b[0mme", "time", "\x1b[1m\x1b[7mti\x1b[0m\x1b[7mme\x1b[27m"},
+		{"not across lines", "ti\nme", "time", "ti\nme"},
+		{"multibyte", "café au lait", "É", "caf\x1b[7mé\x1b[27m au lait"},
+	}
+	for _, tc := range cases {
+		if got := highlightMatches(tc.text, tc.query); got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestTUISidebar(t *testing.T) {
+	var threads []reviewThread
+	for i := 1; i <= 10; i++ {
+		threads = append(threads, reviewThread{ID: fmt.Sprintf("T%d", i), Path: "internal/github/graphql.go", Line: intPtr(i * 10)})
+	}
+	threads[0].IsResolved = true
+	m := newTUIModel("o", "r", 1, "all", threads)
+
+	// Wide terminals show the list and give the thread what is left.
+	m.Update(tea.WindowSizeMsg{Width: 140, Height: 8})
+	if !m.sidebarVisible() || m.viewport.Width != 140-m.sidebarWidth()-1 {
+		t.Fatalf("sidebar %v, pane width %d", m.sidebarVisible(), m.viewport.Width)
+	}
+	lines := strings.Split(m.View(), "\n")
+	if len(lines) != m.height {
+		t.Fatalf("view has %d lines, want %d", len(lines), m.height)
+	}
+	if !strings.HasPrefix(lines[2], "> …") || !strings.Contains(lines[2], "/graphql.go:10 [resolved]") {
+		t.Errorf("first row = %q", lines[2])
+	}
+
+	// The list follows the selection down.
+	m.Update(keyMsg("G"))
+	rows := m.sidebarLines(m.viewport.Height)
+	if last := rows[len(rows)-1]; !strings.HasPrefix(last, "> ") || !strings.Contains(last, ":100 ") {
+		t.Errorf("rows after G = %q", rows)
+	}
+
+	// tab hides it, and narrow terminals never show it.
+	m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	if m.sidebarVisible() || m.viewport.Width != 140 {
+		t.Errorf("after tab: sidebar %v, pane width %d", m.sidebarVisible(), m.viewport.Width)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	m.Update(tea.WindowSizeMsg{Width: 70, Height: 8})
+	if m.sidebarVisible() || m.viewport.Width != 70 {
+		t.Errorf("narrow: sidebar %v, pane width %d", m.sidebarVisible(), m.viewport.Width)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	if m.sidebarVisible() || !strings.Contains(m.status.text, "at least 80 columns") {
+		t.Errorf("tab when narrow: message %q", m.status.text)
+	}
+}
+
+func TestTUILoadsInPages(t *testing.T) {
+	failures := 1
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req github.GraphQLRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+			return
+		}
+		page := map[string]interface{}{
+			"pageInfo": map[string]interface{}{"hasNextPage": true, "endCursor": "c1"},
+			"nodes":    []map[string]string{{"id": "A"}, {"id": "B"}},
+		}
+		if req.Variables["after"] == "c1" {
+			if failures > 0 {
+				failures--
+				json.NewEncoder(w).Encode(map[string]interface{}{"errors": []map[string]string{{"message": "something went wrong"}}})
+				return
+			}
+			page = map[string]interface{}{
+				"pageInfo": map[string]interface{}{"hasNextPage": false},
+				"nodes":    []map[string]string{{"id": "C"}},
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{
+			"repository": map[string]interface{}{"pullRequest": map[string]interface{}{"reviewThreads": page}},
+		}})
+	}))
+	defer srv.Close()
+
+	m := newTUIModel("o", "r", 1, "all", nil)
+	m.client = github.NewClient(srv.URL, "token")
+	m.loading = true
+	if !strings.Contains(m.threadContent(), "loading threads") {
+		t.Errorf("content while loading = %q", m.threadContent())
+	}
+
+	// The first page is listed before the second is asked for.
+	_, cmd := m.Update(m.Init()())
+	if len(m.threads) != 2 || !strings.Contains(m.headerView(), "loaded 2 threads…") {
+		t.Fatalf("threads %v, header %q", threadIDs(m.threads), m.headerView())
+	}
+
+	// A failed page keeps what has loaded and waits for R.
+	_, cmd = m.Update(run(cmd))
+	if cmd != nil || m.loadErr == nil || len(m.threads) != 2 || !strings.Contains(m.headerView(), "R retries") {
+		t.Fatalf("after failure: cmd %v, err %v, threads %v", cmd, m.loadErr, threadIDs(m.threads))
+	}
+	_, cmd = m.Update(keyMsg("R"))
+	if cmd == nil {
+		t.Fatal("R didn't retry")
+	}
+	m.Update(run(cmd))
+	if m.loading || m.loadErr != nil || strings.Join(threadIDs(m.threads), ",") != "A,B,C" {
+		t.Errorf("loading %v, err %v, threads %v", m.loading, m.loadErr, threadIDs(m.threads))
+	}
+	if m.status.text != "loaded 3 threads" {
+		t.Errorf("message = %q", m.status.text)
+	}
+}
+
+func TestTUICopy(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("fakes xclip")
+	}
+	dir := t.TempDir()
+	copied := filepath.Join(dir, "copied")
+	if err := os.WriteFile(filepath.Join(dir, "xclip"), []byte("#!/bin/sh\ncat >"+copied+"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("WAYLAND_DISPLAY", "")
+
+	m := newTUIModel("o", "r", 1, "all", []reviewThread{{ID: "PRRT_1", Comments: reviewThreadComment{Nodes: []reviewComment{
+		{Author: actor{Login: "alice"}, Body: "first"},
+		{Author: actor{Login: "bob"}, Body: "Use `ctx` here"},
+	}}}})
+	for _, tc := range []struct{ key, want, message string }{
+		{"y", "PRRT_1", "copied thread ID PRRT_1 to the clipboard (xclip)"},
+		{"Y", "Use `ctx` here", "copied bob's comment (14 characters) to the clipboard (xclip)"},
+	} {
+		_, cmd := m.Update(keyMsg(tc.key))
+		m.Update(run(cmd))
+		if got, _ := os.ReadFile(copied); string(got) != tc.want || m.status.text != tc.message {
+			t.Errorf("%s: copied %q, message %q", tc.key, got, m.status.text)
+		}
+	}
+}
+
+func TestTUIJumpToThread(t *testing.T) {
+	var threads []reviewThread
+	for i := 1; i <= 15; i++ {
+		threads = append(threads, reviewThread{ID: fmt.Sprintf("T%d", i)})
+	}
+	m := newTUIModel("o", "r", 1, "all", threads)
+	enter := tea.KeyMsg{Type: tea.KeyEnter}
+	esc := tea.KeyMsg{Type: tea.KeyEsc}
+	press := func(keys ...tea.KeyMsg) {
+		for _, k := range keys {
+			m.Update(k)
+		}
+	}
+
+	press(keyMsg("1"), keyMsg("2"))
+	if !strings.HasPrefix(m.footerView(), "12  ") {
+		t.Errorf("footer while typing = %q", m.footerView())
+	}
+	press(enter)
+	if m.threads[m.index].ID != "T12" {
+		t.Errorf("12 enter selected %s", m.threads[m.index].ID)
+	}
+
+	press(keyMsg(":"), keyMsg("3"), enter)
+	if m.threads[m.index].ID != "T3" {
+		t.Errorf(":3 enter selected %s", m.threads[m.index].ID)
+	}
+
+	press(keyMsg("7"), keyMsg("G"))
+	if m.threads[m.index].ID != "T7" {
+		t.Errorf("7G selected %s", m.threads[m.index].ID)
+	}
+
+	// esc cancels without quitting; a plain G still goes to the end.
+	press(keyMsg("2"), esc, keyMsg("G"))
+	if m.jumpDigits != "" || m.threads[m.index].ID != "T15" {
+		t.Errorf("after 2 esc G: pending %q, selected %s", m.jumpDigits, m.threads[m.index].ID)
+	}
+
+	press(keyMsg("9"), keyMsg("9"), enter)
+	if m.threads[m.index].ID != "T15" || !strings.Contains(m.status.text, "only 15 threads") {
+		t.Errorf("99 enter: selected %s, message %q", m.threads[m.index].ID, m.status.text)
+	}
+
+	// Another key drops the number and does its own thing.
+	press(keyMsg("4"), keyMsg("k"))
+	if m.jumpDigits != "" || m.threads[m.index].ID != "T14" {
+		t.Errorf("4k: pending %q, selected %s", m.jumpDigits, m.threads[m.index].ID)
+	}
+}
+
+func TestTUIDiffHunk(t *testing.T) {
+	var hunk []string
+	hunk = append(hunk, "@@ -1,12 +1,13 @@ func main()")
+	for i := 1; i <= 11; i++ {
+		hunk = append(hunk, fmt.Sprintf(" line %d", i))
+	}
+	hunk = append(hunk, "+\tveryLongFunctionName(withAnArgument, andAnother, andYetAnotherOneThatRunsOffTheEdge)")
+	m := newTUIModel("o", "r", 1, "all", []reviewThread{{ID: "PRRT_1", Comments: reviewThreadComment{Nodes: []reviewComment{
+		{ID: "C1", Body: "why?", DiffHunk: strings.Join(hunk, "\n") + "\n"},
+	}}}})
+	m.viewport.Width = 40
+
+	content := m.threadContent()
+	if !strings.Contains(content, "… 5 earlier lines (d shows them)") || strings.Contains(content, "line 4 ") || !strings.Contains(content, "line 5 ") {
+		t.Errorf("collapsed hunk:\n%s", content)
+	}
+	for _, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(line, "  │") && runewidth.StringWidth(line) != 40 {
+			t.Errorf("hunk line %q is %d columns, want 40", line, runewidth.StringWidth(line))
+		}
+	}
+	if !strings.Contains(content, "│ +    veryLongFunctionName(withAnA… │") {
+		t.Errorf("long line isn't cut at the box:\n%s", content)
+	}
+	if strings.Index(content, "┘") > strings.Index(content, "why?") {
+		t.Errorf("hunk isn't above the comments:\n%s", content)
+	}
+
+	// d shows the whole hunk, and the collapsed rendering isn't reused.
+	m.Update(keyMsg("d"))
+	content = m.threadContent()
+	if strings.Contains(content, "earlier lines") || !strings.Contains(content, "@@ -1,12 +1,13 @@") {
+		t.Errorf("expanded hunk:\n%s", content)
+	}
+}
+
+func TestTUIAuthorFilter(t *testing.T) {
+	by := func(logins ...string) reviewThreadComment {
+		var c reviewThreadComment
+		for _, l := range logins {
+			c.Nodes = append(c.Nodes, reviewComment{Author: actor{Login: l}})
+		}
+		return c
+	}
+	m := newTUIModel("o", "r", 1, "all", []reviewThread{
+		{ID: "A", Comments: by("alice", "bob")},
+		{ID: "B", Comments: by("bob", "bob"), IsResolved: true},
+		{ID: "C", Comments: by("carol")},
+	})
+	if got := fmt.Sprint(m.authors); got != "[{bob 2} {alice 1} {carol 1}]" {
+		t.Errorf("authors = %s", got)
+	}
+
+	// a opens the picker on "(all)"; the first author is bob.
+	m.Update(keyMsg("a"))
+	if m.picker == nil || !strings.Contains(m.pickerView(10), "> (all)") {
+		t.Fatalf("picker:\n%s", m.pickerView(10))
+	}
+	m.Update(keyMsg("j"))
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.picker != nil || strings.Join(threadIDs(m.threads), ",") != "A,B" {
+		t.Fatalf("after picking bob: %v", threadIDs(m.threads))
+	}
+	if !strings.Contains(m.headerView(), "(filter: all, author: bob)") {
+		t.Errorf("header = %q", m.headerView())
+	}
+
+	// The status filter composes with it and keeps it.
+	m.Update(keyMsg("f"))
+	if m.filter.author != "bob" || strings.Join(threadIDs(m.threads), ",") != "A" {
+		t.Errorf("unresolved by bob: %v", threadIDs(m.threads))
+	}
+
+	// The picker reopens on bob, and "(all)" clears the author.
+	m.Update(keyMsg("a"))
+	if m.picker.index != 1 {
+		t.Errorf("picker reopened on row %d", m.picker.index)
+	}
+	m.Update(keyMsg("k"))
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.filter.author != "" || strings.Join(threadIDs(m.threads), ",") != "A,C" {
+		t.Errorf("after (all): author %q, threads %v", m.filter.author, threadIDs(m.threads))
+	}
+}
+
+func TestTUIStatusExpiry(t *testing.T) {
+	m := newTUIModel("o", "r", 1, "all", []reviewThread{{ID: "A"}})
+
+	// Each message gets its own expiry, and an expiry only clears the
+	// message it was started for.
+	m.Update(actionDoneMsg{message: "first"})
+	first := m.status.seq
+	_, cmd := m.Update(actionDoneMsg{message: "second"})
+	if cmd == nil {
+		t.Fatal("no expiry started for a success message")
+	}
+	m.Update(statusExpiredMsg{seq: first})
+	if m.status.text != "second" {
+		t.Fatalf("the first message's expiry cleared %q", m.status.text)
+	}
+	m.Update(cmd())
+	if m.status.text != "" {
+		t.Errorf("status %q didn't expire", m.status.text)
+	}
+
+	// Key presses leave info and success messages to expire.
+	m.Update(actionDoneMsg{message: "copied"})
+	m.Update(keyMsg("j"))
+	if m.status.text != "copied" {
+		t.Errorf("a key press cleared %q", m.status.text)
+	}
+
+	// Errors don't expire, and go at the next key press.
+	_, cmd = m.Update(actionDoneMsg{err: errors.New("boom")})
+	if cmd != nil {
+		t.Error("an error message was given an expiry")
+	}
+	if footer := m.footerView(); footer != "error: boom" {
+		t.Errorf("footer = %q", footer)
+	}
+	m.Update(keyMsg("j"))
+	if m.status.text != "" {
+		t.Errorf("status %q survived a key press", m.status.text)
+	}
+}
+
+func TestTUIConfirmQuit(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	quits := func(cmd tea.Cmd) bool { return run(cmd) == tea.Quit() }
+	ctrlC := tea.KeyMsg{Type: tea.KeyCtrlC}
+
+	m := newTUIModel("o", "r", 1, "all", []reviewThread{{ID: "PRRT_1", CanReply: true}})
+	m.client = github.NewClient("http://127.0.0.1:0", "token")
+	m.Update(keyMsg("r"))
+	m.composer.SetValue("half a reply")
+
+	// ctrl+c asks first; anything but y goes back to the draft.
+	if _, cmd := m.Update(ctrlC); quits(cmd) || !strings.Contains(m.footerView(), "discard unsent reply? y/n") {
+		t.Fatalf("ctrl+c with a draft: footer %q", m.footerView())
+	}
+	if _, cmd := m.Update(keyMsg("n")); quits(cmd) || !m.composing() || m.composer.Value() != "half a reply" {
+		t.Fatalf("n: composing %v, draft %q", m.composing(), m.composer.Value())
+	}
+
+	// A second ctrl+c quits anyway, saving the draft.
+	m.Update(ctrlC)
+	if _, cmd := m.Update(ctrlC); !quits(cmd) {
+		t.Fatal("a second ctrl+c didn't quit")
+	}
+	if saved, err := os.ReadFile(m.stashedDraft); err != nil || string(saved) != "half a reply\n" {
+		t.Errorf("stashed draft %q: %q, %v", m.stashedDraft, saved, err)
+	}
+
+	// Changes in flight ask too; with nothing pending q just quits.
+	m = newTUIModel("o", "r", 1, "all", []reviewThread{{ID: "PRRT_1"}})
+	m.resolving["PRRT_1"] = true
+	if _, cmd := m.Update(keyMsg("q")); quits(cmd) || !strings.Contains(m.footerView(), "still being sent") {
+		t.Fatalf("q while resolving: footer %q", m.footerView())
+	}
+	if _, cmd := m.Update(keyMsg("y")); !quits(cmd) || m.stashedDraft != "" {
+		t.Errorf("y didn't quit (stashed %q)", m.stashedDraft)
+	}
+	delete(m.resolving, "PRRT_1")
+	if _, cmd := m.Update(keyMsg("q")); !quits(cmd) {
+		t.Error("q with nothing pending didn't quit")
+	}
+}
+
+func TestTUIConfirmDiscard(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	m := newTUIModel("o", "r", 1, "all", []reviewThread{{ID: "PRRT_1", CanReply: true}})
+	m.client = github.NewClient("http://127.0.0.1:0", "token")
+	m.Update(keyMsg("r"))
+	m.composer.SetValue("half a reply")
+
+	// esc with a draft asks first; anything but y goes back to it.
+	m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if !m.composing() || !strings.Contains(m.footerView(), "discard unsent reply? y/n") {
+		t.Fatalf("esc with a draft: composing %v, footer %q", m.composing(), m.footerView())
+	}
+	m.Update(keyMsg("n"))
+	if !m.composing() || m.composer.Value() != "half a reply" {
+		t.Fatalf("n: composing %v, draft %q", m.composing(), m.composer.Value())
+	}
+
+	// y throws it away, keeping a copy.
+	m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m.Update(keyMsg("y"))
+	if m.composing() || m.composer.Value() != "" {
+		t.Fatalf("y: composing %v, draft %q", m.composing(), m.composer.Value())
+	}
+	path := strings.TrimPrefix(m.status.text, "reply discarded; saved to ")
+	if saved, err := os.ReadFile(path); err != nil || string(saved) != "half a reply\n" {
+		t.Errorf("status %q: %q, %v", m.status.text, saved, err)
+	}
+
+	// An empty composer closes straight away.
+	m.Update(keyMsg("r"))
+	m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.composing() || m.status.text != "reply discarded" {
+		t.Errorf("esc on an empty composer: composing %v, status %q", m.composing(), m.status.text)
+	}
+}
+
+func TestTUIScrollKeys(t *testing.T) {
+	var body []string
+	for i := 1; i <= 100; i++ {
+		body = append(body, fmt.Sprintf("line %d", i))
+	}
+	m := newTUIModel("o", "r", 1, "all", []reviewThread{
+		{ID: "A", Comments: reviewThreadComment{Nodes: []reviewComment{{ID: "C1", Body: strings.Join(body, "\n\n")}}}},
+		{ID: "B"},
+	})
+	m.Update(tea.WindowSizeMsg{Width: 80, Height: 23})
+	height := m.viewport.Height
+
+	for _, step := range []struct {
+		key  tea.KeyMsg
+		want int
+	}{
+		{tea.KeyMsg{Type: tea.KeyCtrlD}, height / 2},
+		{tea.KeyMsg{Type: tea.KeyCtrlU}, 0},
+		{tea.KeyMsg{Type: tea.KeyCtrlF}, height},
+		{tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")}, 2 * height},
+		{keyMsg("b"), height},
+		{tea.KeyMsg{Type: tea.KeyPgDown}, 2 * height},
+		{tea.KeyMsg{Type: tea.KeyHome}, 0},
+	} {
+		m.Update(step.key)
+		if m.viewport.YOffset != step.want {
+			t.Errorf("after %s offset = %d, want %d", step.key, m.viewport.YOffset, step.want)
+		}
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyEnd})
+	if !m.viewport.AtBottom() || m.threads[m.index].ID != "A" {
+		t.Errorf("end: at bottom %v, thread %s", m.viewport.AtBottom(), m.threads[m.index].ID)
+	}
+
+	// ? shows every binding in place of the thread; any other key closes it.
+	m.Update(keyMsg("?"))
+	if view := m.View(); !strings.Contains(view, "scroll half a page") || strings.Contains(view, "line 1") {
+		t.Errorf("help view:\n%s", view)
+	}
+	m.Update(keyMsg("x"))
+	if m.showHelp || m.threads[m.index].IsResolved {
+		t.Errorf("closing key: help %v, and it did its own thing", m.showHelp)
+	}
+}
+
+func TestTUIUnreadThreads(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	thread := func(id string) reviewThread {
+		return reviewThread{ID: id, Path: id + ".go", Comments: reviewThreadComment{Nodes: []reviewComment{{CreatedAt: "2024-05-01T10:00:00Z"}}}}
+	}
+	seen, err := loadSeen("github.com", "o", "r", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	markAllRead(seen, []reviewThread{thread("B")})
+	m := newTUIModel("o", "r", 1, "all", []reviewThread{thread("A"), thread("B"), thread("C"), thread("D")})
+	m.seen = seen
+
+	// Showing a thread marks it read.
+	m.Update(tea.WindowSizeMsg{Width: 140, Height: 10})
+	if seen.unread(m.threads[0]) || !strings.Contains(m.headerView(), "● 2 unread") {
+		t.Errorf("header = %q", m.headerView())
+	}
+	rows := m.sidebarLines(4)
+	if !strings.HasPrefix(rows[1], "  B.go") || !strings.HasPrefix(rows[2], "● C.go") {
+		t.Errorf("rows = %q", rows)
+	}
+
+	// u skips read threads and goes round the end.
+	m.Update(keyMsg("u"))
+	m.Update(keyMsg("g"))
+	m.Update(keyMsg("u"))
+	if got := m.threads[m.index].ID; got != "D" {
+		t.Errorf("u selected %s, want D", got)
+	}
+	m.Update(keyMsg("u"))
+	if m.status.text != "no unread threads" {
+		t.Errorf("status = %q", m.status.text)
+	}
+
+	// The TUI's marks are saved for next time.
+	reloaded, _ := loadSeen("github.com", "o", "r", 1)
+	for _, id := range []string{"A", "B", "C", "D"} {
+		if reloaded.unread(thread(id)) {
+			t.Errorf("%s is still unread after a restart", id)
+		}
+	}
+}
+
+func TestThreadEditorCmd(t *testing.T) {
+	ctx := context.Background()
+	root, err := git.TopLevel(ctx)
+	if err != nil {
+		t.Skip("not in a git checkout")
+	}
+	t.Setenv("GIT_EDITOR", "nvim")
+	t.Setenv("VISUAL", "code --wait")
+
+	cmd, done, err := threadEditorCmd(ctx, reviewThread{ID: "A", Path: "go.mod", Line: intPtr(3)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"sh", "-c", `nvim "$@"`, "sh", "+3", filepath.Join(root, "go.mod")}
+	if strings.Join(cmd.Args, " ") != strings.Join(want, " ") || done != "" {
+		t.Errorf("args %q, message %q", cmd.Args, done)
+	}
+
+	// Outdated threads open where they were left, with a warning after.
+	_, done, _ = threadEditorCmd(ctx, reviewThread{ID: "A", Path: "go.mod", IsOutdated: true, Line: intPtr(9), OriginalLine: intPtr(2)})
+	if !strings.Contains(done, "line 2 is where it was left") {
+		t.Errorf("outdated message = %q", done)
+	}
+
+	t.Setenv("GIT_EDITOR", "ed")
+	cmd, done, _ = threadEditorCmd(ctx, reviewThread{ID: "A", Path: "go.mod", Line: intPtr(3)})
+	if cmd.Args[len(cmd.Args)-2] != "sh" || !strings.Contains(done, `don't know how to open "ed" at a line`) {
+		t.Errorf("unknown editor: args %q, message %q", cmd.Args, done)
+	}
+
+	for _, thread := range []reviewThread{
+		{ID: "A", Path: "renamed/away.go", Line: intPtr(1)},
+		{ID: "B"},
+	} {
+		m := newTUIModel("o", "r", 1, "all", []reviewThread{thread})
+		if _, cmd := m.Update(keyMsg("e")); cmd != nil || m.status.level != statusError {
+			t.Errorf("%s: cmd %v, status %+v", thread.ID, cmd, m.status)
+		}
+	}
+}
+
+func TestTUICycleSort(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	m := newTUIModel("o", "r", 1, "all", sortFixture())
+	m.Update(tea.WindowSizeMsg{Width: 100, Height: 30})
+	m.jumpTo(4) // a-3
+
+	for _, tt := range []struct {
+		order string
+		want  []string
+	}{
+		{sortPath, []string{"a-3", "a-20", "b-1", "b-9", "pending"}},
+		{sortNewest, []string{"a-20", "a-3", "b-1", "b-9", "pending"}},
+		{sortOldestUnresolved, []string{"a-3", "b-9", "pending", "a-20", "b-1"}},
+		{sortAPI, []string{"b-9", "a-20", "pending", "a-3", "b-1"}},
+	} {
+		m.Update(keyMsg("s"))
+		if got := threadIDs(m.threads); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.order, got, tt.want)
+		}
+		if m.threads[m.index].ID != "a-3" {
+			t.Errorf("%s: selected %s, want a-3", tt.order, m.threads[m.index].ID)
+		}
+		if !strings.Contains(m.headerView(), "Sort: "+tt.order) {
+			t.Errorf("%s: header %q", tt.order, m.headerView())
+		}
+	}
+	if got := threadIDs(m.allThreads); !reflect.DeepEqual(got, []string{"b-9", "a-20", "pending", "a-3", "b-1"}) {
+		t.Errorf("allThreads reordered: %v", got)
+	}
+
+	// The order holds when the filter changes.
+	m.cycleSort()
+	m.cycleFilter() // unresolved
+	if got := threadIDs(m.threads); !reflect.DeepEqual(got, []string{"a-3", "b-9", "pending"}) {
+		t.Errorf("unresolved by path: %v", got)
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mattn/go-runewidth"
+)
+
+// tuiFilter is what decides which threads the TUI lists.
+type tuiFilter struct {
+	// status is one of the --status values.
+	status string
+	// author, when set, keeps only threads with a comment by that login.
+	author string
+}
+
+func (f tuiFilter) apply(threads []reviewThread) []reviewThread {
+	threads = filterThreads(threads, f.status)
+	if f.author == "" {
+		return threads
+	}
+	var kept []reviewThread
+	for _, t := range threads {
+		if threadHasAuthor(t, f.author) {
+			kept = append(kept, t)
+		}
+	}
+	return kept
+}
+
+func (f tuiFilter) String() string {
+	if f.author == "" {
+		return f.status
+	}
+	return f.status + ", author: " + f.author
+}
+
+func threadHasAuthor(t reviewThread, login string) bool {
+	for _, c := range t.Comments.Nodes {
+		if c.Author.Login == login {
+			return true
+		}
+	}
+	return false
+}
+
+// authorCount is a comment author and the number of threads they have
+// commented on.
+type authorCount struct {
+	login   string
+	threads int
+}
+
+// countAuthors lists the comment authors in threads, busiest first.
+func countAuthors(threads []reviewThread) []authorCount {
+	counts := map[string]int{}
+	for _, t := range threads {
+		seen := map[string]bool{}
+		for _, c := range t.Comments.Nodes {
+			login := c.Author.Login
+			if login == "" || seen[login] {
+				continue
+			}
+			seen[login] = true
+			counts[login]++
+		}
+	}
+	authors := make([]authorCount, 0, len(counts))
+	for login, n := range counts {
+		authors = append(authors, authorCount{login: login, threads: n})
+	}
+	sort.Slice(authors, func(i, j int) bool {
+		if authors[i].threads != authors[j].threads {
+			return authors[i].threads > authors[j].threads
+		}
+		return authors[i].login < authors[j].login
+	})
+	return authors
+}
+
+// authorPicker is the open author list. Row 0 is "(all)"; row i is
+// authors[i-1].
+type authorPicker struct {
+	authors []authorCount
+	index   int
+}
+
+func (m *tuiModel) openAuthorPicker() {
+	picker := &authorPicker{authors: m.authors}
+	for i, a := range m.authors {
+		if a.login == m.filter.author {
+			picker.index = i + 1
+		}
+	}
+	m.picker = picker
+}
+
+// updatePicker handles a key while the author picker is open.
+func (m *tuiModel) updatePicker(msg tea.KeyMsg) {
+	p := m.picker
+	switch msg.String() {
+	case "j", "down":
+		if p.index < len(p.authors) {
+			p.index++
+		}
+	case "k", "up":
+		if p.index > 0 {
+			p.index--
+		}
+	case "g", "home":
+		p.index = 0
+	case "G", "end":
+		p.index = len(p.authors)
+	case "esc", "q", "a":
+		m.picker = nil
+	case "enter":
+		author := ""
+		if p.index > 0 {
+			author = p.authors[p.index-1].login
+		}
+		m.picker = nil
+		m.filterAuthor(author)
+	}
+}
+
+// filterAuthor lists only the threads author has commented on, or every
+// thread if author is empty.
+func (m *tuiModel) filterAuthor(author string) {
+	m.filter.author = author
+	m.setThreads(m.listedThreads())
+	m.viewport.SetContent(m.threadContent())
+	if author != "" && len(m.threads) == 0 {
+		m.setStatus(statusInfo, fmt.Sprintf("no threads by %s match the %s filter", author, m.filter.status))
+	}
+}
+
+// pickerView draws the picker in place of the thread, height rows tall,
+// scrolled to keep the selected row in view.
+func (m *tuiModel) pickerView(height int) string {
+	styler := newStyler(os.Stdout)
+	p := m.picker
+	rows := []string{"(all)"}
+	width := runewidth.StringWidth(rows[0])
+	for _, a := range p.authors {
+		if w := runewidth.StringWidth(a.login); w > width {
+			width = w
+		}
+	}
+	for _, a := range p.authors {
+		rows = append(rows, fmt.Sprintf("%s  %s", runewidth.FillRight(a.login, width), styler.dim(fmt.Sprintf("%d threads", a.threads))))
+	}
+	rows[0] = fmt.Sprintf("%s  %s", runewidth.FillRight(rows[0], width), styler.dim(fmt.Sprintf("%d threads", len(m.allThreads))))
+
+	lines := []string{styler.label("Show threads with comments by:") + "  " + styler.dim("enter picks, esc cancels")}
+	visible := height - 1
+	top := 0
+	if p.index >= visible {
+		top = p.index - visible + 1
+	}
+	for i := top; i < len(rows) && len(lines) < height; i++ {
+		marker := "  "
+		if i == p.index {
+			marker = "> "
+		}
+		lines = append(lines, marker+rows[i])
+	}
+	for len(lines) < height {
+		lines = append(lines, "")
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// composerHeight is the number of text lines in the reply composer.
+const composerHeight = 5
+
+// replyPostedMsg reports the outcome of a reply sent from the composer.
+type replyPostedMsg struct {
+	threadID string
+	body     string
+	comment  postedComment
+	err      error
+}
+
+func newComposer() textarea.Model {
+	ta := textarea.New()
+	ta.Placeholder = "Write a reply (markdown)…"
+	ta.ShowLineNumbers = false
+	ta.CharLimit = 0
+	ta.SetHeight(composerHeight)
+	return ta
+}
+
+// composing reports whether the reply composer has the keyboard.
+func (m *tuiModel) composing() bool {
+	return m.composer.Focused()
+}
+
+// openComposer shows the composer for the current thread. A draft for the
+// same thread is kept; one for another thread is dropped.
+func (m *tuiModel) openComposer() tea.Cmd {
+	if len(m.threads) == 0 {
+		return nil
+	}
+	thread := m.threads[m.index]
+	if err := checkReplyTarget(thread.ID, thread, nil).err; err != nil {
+		m.setStatus(statusError, err.Error())
+		return nil
+	}
+	if m.client == nil {
+		m.setStatus(statusError, "not connected to GitHub")
+		return nil
+	}
+	if m.composerThread != thread.ID {
+		m.composer.Reset()
+		m.composerThread = thread.ID
+	}
+	m.composer.SetWidth(m.width)
+	cmd := m.composer.Focus()
+	m.resizeViewport()
+	return cmd
+}
+
+func (m *tuiModel) closeComposer() {
+	m.composer.Blur()
+	m.resizeViewport()
+}
+
+// updateComposer handles a key while the composer is open: ctrl+s sends,
+// esc throws the draft away (asking first if there is one), and everything
+// else is typing.
+func (m *tuiModel) updateComposer(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "esc":
+		if m.unsentDraft() != "" {
+			m.confirmingDiscard = true
+			return nil
+		}
+		m.discardDraft()
+		return nil
+	case "ctrl+s":
+		return m.sendReply()
+	}
+	var cmd tea.Cmd
+	m.composer, cmd = m.composer.Update(msg)
+	return cmd
+}
+
+// discardDraft closes the composer, saving any draft to a file first the
+// way quitting does.
+func (m *tuiModel) discardDraft() {
+	text := "reply discarded"
+	if draft := m.unsentDraft(); draft != "" {
+		path, err := stashDraft(m.composerThread, draft, time.Now())
+		if err != nil {
+			m.setStatus(statusError, fmt.Sprintf("couldn't save the reply, so it was kept: %v", err))
+			return
+		}
+		text += "; saved to " + path
+	}
+	m.composer.Reset()
+	m.composerThread = ""
+	m.closeComposer()
+	m.setStatus(statusInfo, text)
+}
+
+// sendReply posts the draft in the background. The reply is shown on the
+// thread straight away and taken back out if GitHub refuses it.
+func (m *tuiModel) sendReply() tea.Cmd {
+	body := strings.TrimSpace(m.composer.Value())
+	if body == "" {
+		m.setStatus(statusError, "the reply is empty")
+		return nil
+	}
+	threadID := m.composerThread
+	m.composer.Reset()
+	m.composerThread = ""
+	m.closeComposer()
+	m.updateThread(threadID, func(t *reviewThread) {
+		// The lists share comment arrays; appending to a full-length slice
+		// gives each thread its own.
+		nodes := t.Comments.Nodes
+		t.Comments.Nodes = append(nodes[:len(nodes):len(nodes)], reviewComment{
+			Author:    actor{Login: "you"},
+			Body:      body,
+			CreatedAt: time.Now().UTC().Format(time.RFC3339),
+		})
+	})
+	m.setStatus(statusInfo, "sending reply…")
+	m.sending++
+	client := m.client
+	return func() tea.Msg {
+		comment, err := replyToThread(context.Background(), client, threadID, body)
+		return replyPostedMsg{threadID: threadID, body: body, comment: comment, err: err}
+	}
+}
+
+// applyReply settles the reply shown while it was being sent: it gets its
+// ID and URL, or is removed and put back in the composer so nothing typed
+// is lost.
+func (m *tuiModel) applyReply(msg replyPostedMsg) {
+	m.sending--
+	m.updateThread(msg.threadID, func(t *reviewThread) {
+		nodes := t.Comments.Nodes
+		for i := len(nodes) - 1; i >= 0; i-- {
+			if nodes[i].ID != "" || nodes[i].Body != msg.body {
+				continue
+			}
+			if msg.err != nil {
+				t.Comments.Nodes = append(nodes[:i:i], nodes[i+1:]...)
+			} else {
+				nodes[i].ID = msg.comment.ID
+				nodes[i].URL = msg.comment.URL
+				nodes[i].CreatedAt = msg.comment.CreatedAt
+			}
+			return
+		}
+	})
+	if msg.err == nil {
+		m.setStatus(statusSuccess, "replied "+msg.comment.URL)
+		return
+	}
+	text := "reply not sent: " + msg.err.Error()
+	if m.composerThread == "" {
+		m.composerThread = msg.threadID
+		m.composer.SetValue(msg.body)
+		text += " (r to edit and resend)"
+	}
+	m.setStatus(statusError, text)
+}
+
+func (m *tuiModel) composerView() string {
+	styler := newStyler(os.Stdout)
+	title := fmt.Sprintf("%s %s  %s send  %s discard",
+		styler.label("Reply to"),
+		styler.threadID(m.composerThread),
+		styler.label("ctrl+s"),
+		styler.label("esc"),
+	)
+	return title + "\n" + m.composer.View()
+}
+
+// unsentDraft returns the composer's text if there is any worth keeping.
+func (m *tuiModel) unsentDraft() string {
+	if m.composerThread == "" {
+		return ""
+	}
+	return strings.TrimSpace(m.composer.Value())
+}
+
+// requestQuit quits, or first asks when quitting would lose a draft or
+// abandon changes still being sent.
+func (m *tuiModel) requestQuit() tea.Cmd {
+	if m.unsentDraft() == "" && m.sending == 0 && len(m.resolving) == 0 {
+		return m.quit()
+	}
+	m.confirmingQuit = true
+	return nil
+}
+
+func (m *tuiModel) quitQuestion() string {
+	draft := m.unsentDraft() != ""
+	inFlight := m.sending > 0 || len(m.resolving) > 0
+	switch {
+	case draft && inFlight:
+		return "discard unsent reply and stop waiting for GitHub? y/n"
+	case draft:
+		return "discard unsent reply? y/n"
+	}
+	return "changes are still being sent to GitHub; quit anyway? y/n"
+}
+
+// quit saves any draft to a file before quitting, so it can be found
+// again; runTUI says where.
+func (m *tuiModel) quit() tea.Cmd {
+	if draft := m.unsentDraft(); draft != "" {
+		m.stashedDraft, m.stashErr = stashDraft(m.composerThread, draft, time.Now())
+	}
+	return tea.Quit
+}
+
+// stashDraft writes a reply that was never sent under the cache directory
+// and returns its path.
+func stashDraft(threadID, body string, now time.Time) (string, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return "", err
+	}
+	dir = filepath.Join(dir, "drafts")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, fmt.Sprintf("reply-%s-%s.md", threadID, now.Format("20060102-150405")))
+	if err := os.WriteFile(path, []byte(body+"\n"), 0o600); err != nil {
+		return "", err
+	}
+	return path, nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"gh-pr-review/internal/git"
+)
+
+// openEditor suspends the TUI to open the thread's file in the editor at
+// the commented line, as goto does, and comes back when the editor exits.
+func (m *tuiModel) openEditor() tea.Cmd {
+	if len(m.threads) == 0 {
+		return nil
+	}
+	cmd, done, err := threadEditorCmd(context.Background(), m.threads[m.index])
+	if err != nil {
+		m.setStatus(statusError, err.Error())
+		return nil
+	}
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		if err != nil {
+			err = fmt.Errorf("editor failed: %w", err)
+		}
+		return actionDoneMsg{message: done, err: err}
+	})
+}
+
+// threadEditorCmd builds the editor command for the thread's file in the
+// local checkout, with anything worth saying once the editor exits.
+func threadEditorCmd(ctx context.Context, t reviewThread) (*exec.Cmd, string, error) {
+	if t.Path == "" {
+		return nil, "", fmt.Errorf("thread %s is not attached to a file", t.ID)
+	}
+	root, err := git.TopLevel(ctx)
+	if err != nil {
+		return nil, "", errors.New("not in a git checkout, so there is no local file to open")
+	}
+	path := filepath.Join(root, filepath.FromSlash(t.Path))
+	if _, err := os.Stat(path); err != nil {
+		return nil, "", fmt.Errorf("%s is not in the working tree (is the PR branch checked out?)", t.Path)
+	}
+	line := gotoLine(t)
+	editor := editorCommand()
+	var done string
+	jump, ok := editorJumpArgs(editor, path, line)
+	switch {
+	case !ok:
+		jump = []string{path}
+		done = fmt.Sprintf("opened %s at the top: don't know how to open %q at a line", t.Path, editor)
+	case t.IsOutdated && line > 0:
+		done = fmt.Sprintf("thread is outdated; line %d is where it was left and may have moved", line)
+	}
+	return editorCmd(ctx, editor, jump...), done, nil
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mattn/go-runewidth"
+)
+
+// tuiKeys lists every key binding, by section, for the help overlay.
+var tuiKeys = []struct {
+	section string
+	keys    [][2]string
+}{
+	{"Threads", [][2]string{
+		{"j / k", "next / previous thread"},
+		{"g / G", "first / last thread"},
+		{"N enter, :N, NG", "thread number N"},
+		{"tab", "show or hide the thread list"},
+		{"f", "cycle the status filter"},
+		{"s", "cycle the order (api, path, newest, oldest unresolved)"},
+		{"a", "show one author's threads"},
+		{"/", "search (enter filters, alt+enter jumps)"},
+		{"n / N", "next / previous match"},
+		{"u", "next unread thread"},
+	}},
+	{"Reading", [][2]string{
+		{"up / down", "scroll a line"},
+		{"ctrl+d / ctrl+u", "scroll half a page"},
+		{"ctrl+f / ctrl+b", "scroll a page (also pgdn / pgup, space / b)"},
+		{"home / end", "top / bottom of the thread"},
+		{"d", "show the whole diff hunk"},
+	}},
+	{"Actions", [][2]string{
+		{"x", "resolve or unresolve"},
+		{"r", "reply (ctrl+s sends, esc discards)"},
+		{"e", "open the file at the line in $EDITOR"},
+		{"o / O", "open in the browser / copy the URL"},
+		{"y / Y", "copy the thread ID / latest comment"},
+		{"R", "fetch the threads again"},
+		{"?", "this help"},
+		{"q", "quit"},
+	}},
+}
+
+// threadViewportKeys leaves the viewport only the arrow keys; update binds
+// the paging keys itself so they don't take letters other commands use.
+func threadViewportKeys() viewport.KeyMap {
+	return viewport.KeyMap{
+		Up:   key.NewBinding(key.WithKeys("up")),
+		Down: key.NewBinding(key.WithKeys("down")),
+	}
+}
+
+func newThreadViewport(width, height int) viewport.Model {
+	vp := viewport.New(width, height)
+	vp.KeyMap = threadViewportKeys()
+	return vp
+}
+
+// scrollThread handles the paging keys and reports whether key was one.
+func (m *tuiModel) scrollThread(key string) bool {
+	switch key {
+	case "ctrl+d":
+		m.viewport.HalfViewDown()
+	case "ctrl+u":
+		m.viewport.HalfViewUp()
+	case "ctrl+f", "pgdown", " ":
+		m.viewport.ViewDown()
+	case "ctrl+b", "pgup", "b":
+		m.viewport.ViewUp()
+	case "home":
+		m.viewport.GotoTop()
+	case "end":
+		m.viewport.GotoBottom()
+	default:
+		return false
+	}
+	return true
+}
+
+// updateHelp handles a key while the help is open: up and down scroll it
+// and anything else closes it.
+func (m *tuiModel) updateHelp(msg tea.KeyMsg) {
+	switch msg.String() {
+	case "j", "down":
+		if m.helpTop < len(helpLines())-m.viewport.Height {
+			m.helpTop++
+		}
+	case "k", "up":
+		if m.helpTop > 0 {
+			m.helpTop--
+		}
+	default:
+		m.showHelp = false
+		m.helpTop = 0
+	}
+}
+
+func helpLines() []string {
+	styler := newStyler(os.Stdout)
+	width := 0
+	for _, s := range tuiKeys {
+		for _, k := range s.keys {
+			if w := runewidth.StringWidth(k[0]); w > width {
+				width = w
+			}
+		}
+	}
+	var lines []string
+	for i, s := range tuiKeys {
+		if i > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, styler.label(s.section))
+		for _, k := range s.keys {
+			lines = append(lines, "  "+styler.threadID(runewidth.FillRight(k[0], width))+"  "+k[1])
+		}
+	}
+	return lines
+}
+
+// helpView draws the key bindings in place of the thread, height rows
+// tall.
+func (m *tuiModel) helpView(height int) string {
+	lines := helpLines()
+	if m.helpTop < len(lines) {
+		lines = lines[m.helpTop:]
+	}
+	if len(lines) > height {
+		lines = lines[:height]
+	}
+	for len(lines) < height {
+		lines = append(lines, "")
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// hunkTailLines is how much of the diff hunk is shown until d expands it.
+// GitHub's hunks end at the commented line, so the tail is what matters.
+const hunkTailLines = 8
+
+// hunkBlock draws the diff hunk in a box width columns wide, indented by
+// two. Lines are cut at the box rather than wrapped so the code keeps its
+// shape; unless full, only the last hunkTailLines are shown.
+func hunkBlock(hunk string, width int, full bool, styler styler) string {
+	hunk = strings.TrimRight(hunk, "\n")
+	if hunk == "" {
+		return ""
+	}
+	inner := width - 6
+	if inner < 10 {
+		inner = 10
+	}
+	lines := strings.Split(hunk, "\n")
+	var hidden int
+	if !full && len(lines) > hunkTailLines {
+		hidden = len(lines) - hunkTailLines
+		lines = lines[hidden:]
+	}
+	var b strings.Builder
+	border := strings.Repeat("─", inner+2)
+	b.WriteString(styler.dim("  ┌"+border+"┐") + "\n")
+	if hidden > 0 {
+		b.WriteString(hunkRow(styler.dim, fmt.Sprintf("… %d earlier lines (d shows them)", hidden), inner, styler))
+	}
+	for _, line := range lines {
+		b.WriteString(hunkRow(styler.diffLine, line, inner, styler))
+	}
+	b.WriteString(styler.dim("  └"+border+"┘") + "\n")
+	return b.String()
+}
+
+func hunkRow(colour func(string) string, line string, inner int, styler styler) string {
+	line = strings.ReplaceAll(line, "\t", "    ")
+	line = runewidth.Truncate(line, inner, "…")
+	padding := strings.Repeat(" ", inner-runewidth.StringWidth(line))
+	return styler.dim("  │") + " " + colour(line) + padding + " " + styler.dim("│") + "\n"
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func newSearchInput() textinput.Model {
+	ti := textinput.New()
+	ti.Prompt = "/"
+	ti.Placeholder = "search paths and comments"
+	return ti
+}
+
+// searchPrompting reports whether the search prompt has the keyboard.
+func (m *tuiModel) searchPrompting() bool {
+	return m.searchInput.Focused()
+}
+
+func (m *tuiModel) openSearch() tea.Cmd {
+	m.searchInput.SetValue(m.query)
+	m.searchInput.CursorEnd()
+	return m.searchInput.Focus()
+}
+
+// updateSearch handles a key while the search prompt is open. enter lists
+// only the matching threads; alt+enter keeps every thread and moves to the
+// next match, for stepping through with n and N.
+func (m *tuiModel) updateSearch(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "esc":
+		m.searchInput.Blur()
+		return nil
+	case "enter", "alt+enter":
+		m.searchInput.Blur()
+		m.search(strings.TrimSpace(m.searchInput.Value()), msg.String() == "enter")
+		return nil
+	}
+	var cmd tea.Cmd
+	m.searchInput, cmd = m.searchInput.Update(msg)
+	return cmd
+}
+
+// search makes query the active search. An empty query clears it.
+func (m *tuiModel) search(query string, filter bool) {
+	m.query = query
+	m.searchFilter = filter && query != ""
+	m.setThreads(m.listedThreads())
+	// setThreads only redraws when the selection moves; highlights change
+	// either way.
+	m.viewport.SetContent(m.threadContent())
+	if query == "" {
+		return
+	}
+	if m.matchCount() == 0 {
+		m.setStatus(statusInfo, fmt.Sprintf("no threads match %q", query))
+		return
+	}
+	if !m.searchFilter && !threadMatches(m.threads[m.index], query) {
+		m.nextMatch(true)
+	}
+}
+
+func (m *tuiModel) clearSearch() {
+	m.search("", false)
+}
+
+// listedThreads is what the filter and, when it filters, the search leave
+// of allThreads, in the chosen order.
+func (m *tuiModel) listedThreads() []reviewThread {
+	threads := m.filter.apply(m.allThreads)
+	if m.searchFilter {
+		var matched []reviewThread
+		for _, t := range threads {
+			if threadMatches(t, m.query) {
+				matched = append(matched, t)
+			}
+		}
+		threads = matched
+	}
+	if m.order != "" && m.order != sortAPI {
+		// apply may return allThreads itself, which stays in API order.
+		threads = append([]reviewThread(nil), threads...)
+		sortThreads(threads, m.order, nil)
+	}
+	return threads
+}
+
+func (m *tuiModel) matchCount() int {
+	n := 0
+	for _, t := range m.threads {
+		if threadMatches(t, m.query) {
+			n++
+		}
+	}
+	return n
+}
+
+// nextMatch moves to the next matching thread, or the previous one, going
+// round the end of the list.
+func (m *tuiModel) nextMatch(forward bool) {
+	if m.query == "" || len(m.threads) == 0 {
+		return
+	}
+	step := 1
+	if !forward {
+		step = -1
+	}
+	n := len(m.threads)
+	for i := 1; i <= n; i++ {
+		index := ((m.index+step*i)%n + n) % n
+		if threadMatches(m.threads[index], m.query) {
+			if index != m.index {
+				m.index = index
+				m.viewport.SetContent(m.threadContent())
+				m.viewport.GotoTop()
+			}
+			return
+		}
+	}
+	m.setStatus(statusInfo, fmt.Sprintf("no threads match %q", m.query))
+}
+
+func (m *tuiModel) searchSummary() string {
+	if m.query == "" {
+		return ""
+	}
+	return fmt.Sprintf("search: %q (%d matches)", m.query, m.matchCount())
+}
+
+// threadMatches reports whether the thread's path or any comment contains
+// query, ignoring case.
+func threadMatches(t reviewThread, query string) bool {
+	query = strings.ToLower(query)
+	if strings.Contains(strings.ToLower(t.Path), query) {
+		return true
+	}
+	for _, c := range t.Comments.Nodes {
+		if strings.Contains(strings.ToLower(c.Body), query) {
+			return true
+		}
+	}
+	return false
+}
+
+// highlightMatches shows each case-insensitive match of query in reverse
+// video. Matching looks through the escape sequences already in text, so a
+// word glamour has coloured letter by letter is still found, and reverse
+// video is turned back on after each sequence inside a match in case it was
+// a reset.
+func highlightMatches(text, query string) string {
+	needle := []rune(strings.ToLower(query))
+	if len(needle) == 0 {
+		return text
+	}
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = highlightLine(line, needle)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func highlightLine(line string, needle []rune) string {
+	// Visible runes of the line and where each starts.
+	var runes []rune
+	var offsets []int
+	for i := 0; i < len(line); {
+		if n := escapeLen(line[i:]); n > 0 {
+			i += n
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(line[i:])
+		runes = append(runes, unicode.ToLower(r))
+		offsets = append(offsets, i)
+		i += size
+	}
+	// Byte ranges of the matches.
+	var starts, ends []int
+	for i := 0; i+len(needle) <= len(runes); {
+		if !runesEqual(runes[i:i+len(needle)], needle) {
+			i++
+			continue
+		}
+		starts = append(starts, offsets[i])
+		last := offsets[i+len(needle)-1]
+		_, size := utf8.DecodeRuneInString(line[last:])
+		ends = append(ends, last+size)
+		i += len(needle)
+	}
+	if len(starts) == 0 {
+		return line
+	}
+	var b strings.Builder
+	match := 0
+	inside := false
+	for i := 0; i < len(line); {
+		if match < len(starts) && i == starts[match] && !inside {
+			b.WriteString("\x1b[7m")
+			inside = true
+		}
+		if n := escapeLen(line[i:]); n > 0 {
+			b.WriteString(line[i : i+n])
+			if inside {
+				b.WriteString("\x1b[7m")
+			}
+			i += n
+			continue
+		}
+		b.WriteByte(line[i])
+		i++
+		if inside && i == ends[match] {
+			b.WriteString("\x1b[27m")
+			inside = false
+			match++
+		}
+	}
+	return b.String()
+}
+
+// escapeLen is the length of the CSI escape sequence s starts with, or 0.
+func escapeLen(s string) int {
+	if len(s) < 2 || s[0] != 0x1b || s[1] != '[' {
+		return 0
+	}
+	for i := 2; i < len(s); i++ {
+		if s[i] >= 0x40 && s[i] <= 0x7e {
+			return i + 1
+		}
+	}
+	return len(s)
+}
+
+func runesEqual(a, b []rune) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
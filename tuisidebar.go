@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+)
+
+const (
+	// sidebarAutoWidth is the terminal width from which the thread list is
+	// shown without asking.
+	sidebarAutoWidth = 120
+	// sidebarMinWidth is the narrowest terminal the thread list fits in;
+	// below it the TUI is always single-pane.
+	sidebarMinWidth = 80
+)
+
+// sidebarVisible reports whether the thread list is on screen: by default
+// on wide terminals, flipped by tab, and never on narrow ones.
+func (m *tuiModel) sidebarVisible() bool {
+	if m.width < sidebarMinWidth {
+		return false
+	}
+	return (m.width >= sidebarAutoWidth) != m.sidebarToggled
+}
+
+func (m *tuiModel) sidebarWidth() int {
+	width := m.width / 4
+	if width < 24 {
+		width = 24
+	}
+	if width > 40 {
+		width = 40
+	}
+	return width
+}
+
+// paneWidth is the width left for the thread itself, which is what its
+// content is wrapped, rendered and cached at.
+func (m *tuiModel) paneWidth() int {
+	if m.sidebarVisible() {
+		return m.width - m.sidebarWidth() - 1
+	}
+	return m.width
+}
+
+func (m *tuiModel) toggleSidebar() {
+	if m.width < sidebarMinWidth {
+		m.setStatus(statusInfo, fmt.Sprintf("the thread list needs a terminal at least %d columns wide", sidebarMinWidth))
+		return
+	}
+	m.sidebarToggled = !m.sidebarToggled
+	m.viewport.Width = m.paneWidth()
+	m.viewport.SetContent(m.threadContent())
+}
+
+// scrollSidebar scrolls the thread list just far enough to show the
+// selected thread.
+func (m *tuiModel) scrollSidebar() {
+	height := m.viewport.Height
+	switch {
+	case m.index < m.sidebarTop:
+		m.sidebarTop = m.index
+	case m.index >= m.sidebarTop+height:
+		m.sidebarTop = m.index - height + 1
+	}
+	if last := len(m.threads) - height; m.sidebarTop > last {
+		m.sidebarTop = last
+	}
+	if m.sidebarTop < 0 {
+		m.sidebarTop = 0
+	}
+}
+
+// sidebarLines renders height rows of the thread list, each exactly the
+// sidebar's width.
+func (m *tuiModel) sidebarLines(height int) []string {
+	styler := newStyler(os.Stdout)
+	width := m.sidebarWidth()
+	lines := make([]string, 0, height)
+	if len(m.threads) == 0 {
+		lines = append(lines, runewidth.FillRight(" no threads", width))
+	}
+	for i := m.sidebarTop; i < len(m.threads) && len(lines) < height; i++ {
+		unread := m.seen != nil && m.seen.unread(m.threads[i])
+		lines = append(lines, sidebarRow(m.threads[i], width, i == m.index, unread, styler))
+	}
+	for len(lines) < height {
+		lines = append(lines, strings.Repeat(" ", width))
+	}
+	return lines
+}
+
+// sidebarRow shows a thread as "path:line [status]", cutting the start of
+// the path rather than the file name when it doesn't fit. Unread threads
+// are marked with a dot.
+func sidebarRow(t reviewThread, width int, selected, unread bool, styler styler) string {
+	marker := "  "
+	switch {
+	case selected:
+		marker = "> "
+	case unread:
+		marker = "● "
+	}
+	location := ""
+	if _, end, ok := threadLines(t); ok {
+		location = fmt.Sprintf(":%d", end)
+	}
+	status := resolutionState(t.IsResolved)
+	suffix := fmt.Sprintf("%s [%s]", location, status)
+	path := t.Path
+	if room := width - runewidth.StringWidth(marker) - runewidth.StringWidth(suffix); runewidth.StringWidth(path) > room {
+		path = truncateLeft(path, room)
+	}
+	plain := runewidth.Truncate(marker+path+suffix, width, "")
+	row := runewidth.FillRight(plain, width)
+	if selected && styler.enabled {
+		return "\x1b[7m" + row + "\x1b[27m"
+	}
+	// Colour the status only when the row is whole.
+	if strings.HasSuffix(plain, suffix) {
+		padding := row[len(plain):]
+		return strings.TrimSuffix(plain, "["+status+"]") + "[" + styler.status(status) + "]" + padding
+	}
+	return row
+}
+
+// truncateLeft shortens s to width columns by dropping its start.
+func truncateLeft(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	runes := []rune(s)
+	for i := range runes {
+		if rest := string(runes[i:]); runewidth.StringWidth(rest)+1 <= width {
+			return "…" + rest
+		}
+	}
+	return "…"
+}
+
+// withSidebar puts the thread list to the left of the thread.
+func (m *tuiModel) withSidebar(pane string) string {
+	paneLines := strings.Split(pane, "\n")
+	sideLines := m.sidebarLines(len(paneLines))
+	separator := newStyler(os.Stdout).dim("│")
+	for i := range paneLines {
+		paneLines[i] = sideLines[i] + separator + paneLines[i]
+	}
+	return strings.Join(paneLines, "\n")
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// statusLevel says how a status message is shown and how long it stays.
+type statusLevel int
+
+const (
+	statusInfo statusLevel = iota
+	statusSuccess
+	statusError
+)
+
+// statusTTL is how long info and success messages stay in the footer.
+// Errors stay until the next key press so they can't be missed.
+var statusTTL = 4 * time.Second
+
+// tuiStatus is the message in the footer. seq tells messages apart, so an
+// expiry only clears the message it was started for.
+type tuiStatus struct {
+	level statusLevel
+	text  string
+	seq   int
+}
+
+// statusExpiredMsg clears the status message numbered seq if it is still
+// showing.
+type statusExpiredMsg struct {
+	seq int
+}
+
+// setStatus shows text in the footer in place of the key help. Every
+// action reports through here; Update starts the expiry.
+func (m *tuiModel) setStatus(level statusLevel, text string) {
+	m.statusSeq++
+	m.status = tuiStatus{level: level, text: text, seq: m.statusSeq}
+}
+
+func (m *tuiModel) clearStatus() {
+	m.status = tuiStatus{}
+}
+
+// expireStatus returns the tick that clears a message set since seq, or
+// nil if there is none to clear.
+func (m *tuiModel) expireStatus(seq int) tea.Cmd {
+	current := m.status
+	if current.seq == seq || current.text == "" || current.level == statusError {
+		return nil
+	}
+	return tea.Tick(statusTTL, func(time.Time) tea.Msg {
+		return statusExpiredMsg{seq: current.seq}
+	})
+}
+
+func (m *tuiModel) statusView(styler styler) string {
+	switch m.status.level {
+	case statusError:
+		return styler.wrap("31", "error: "+m.status.text)
+	case statusSuccess:
+		return styler.wrap("32", m.status.text)
+	}
+	return m.status.text
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"gh-pr-review/internal/gh"
+)
+
+type viewFlags struct {
+	threadID      string
+	commentURL    string
+	repo          string
+	pr            int
+	jsonOut       bool
+	diff          bool
+	web           bool
+	maxLines      int
+	theme         string
+	noRenderCache bool
+	host          string
+}
+
+func (o *viewFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.threadID, "thread-id", "", "Review thread ID")
+	fs.StringVar(&o.commentURL, "url", "", "review comment URL or numeric ID")
+	fs.StringVar(&o.repo, "repo", "", "owner/name for a numeric --url ID (defaults to gh repo view)")
+	fs.IntVar(&o.pr, "pr", 0, "PR number for a numeric --url ID")
+	fs.BoolVar(&o.jsonOut, "json", false, "output JSON")
+	fs.BoolVar(&o.diff, "diff", false, "show the diff hunk the thread is on")
+	fs.BoolVar(&o.web, "web", false, "open the thread in the browser")
+	fs.IntVar(&o.maxLines, "max-lines", 0, "truncate each comment body to N lines (0 = no limit)")
+	fs.StringVar(&o.theme, "theme", "auto", "markdown style (auto|dark|light|...)")
+	fs.BoolVar(&o.noRenderCache, "no-render-cache", false, "disable the on-disk render cache")
+	fs.StringVar(&o.host, "host", gh.DefaultHost(), "GitHub host")
+}
+
+func runView(args []string) error {
+	fs := newFlagSet("view", printViewUsage)
+	var flags viewFlags
+	flags.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	// A single argument may stand in for --thread-id or --url.
+	switch fs.NArg() {
+	case 0:
+	case 1:
+		if flags.threadID != "" || flags.commentURL != "" {
+			return errors.New("provide the thread as an argument or with --thread-id/--url, not both")
+		}
+		if _, err := parseCommentRef(fs.Arg(0)); err == nil {
+			flags.commentURL = fs.Arg(0)
+		} else {
+			flags.threadID = fs.Arg(0)
+		}
+	default:
+		return errors.New("view shows one thread at a time")
+	}
+	switch {
+	case flags.threadID != "" && flags.commentURL != "":
+		return errors.New("provide only one of --thread-id or --url")
+	case flags.threadID == "" && flags.commentURL == "":
+		return errors.New("--thread-id or --url is required")
+	case flags.web && flags.jsonOut:
+		return errors.New("provide only one of --web or --json")
+	case flags.maxLines < 0:
+		return fmt.Errorf("invalid --max-lines %d", flags.maxLines)
+	}
+	var refs []commentRef
+	if flags.commentURL != "" {
+		ref, err := parseCommentRef(flags.commentURL)
+		if err != nil {
+			return err
+		}
+		refs = append(refs, ref)
+	}
+	if err := setTheme(flags.theme); err != nil {
+		return err
+	}
+	openRenderCache(flags.noRenderCache)
+
+	ctx := context.Background()
+	client, err := newClient(ctx, commentRefHost(refs, flags.host))
+	if err != nil {
+		return err
+	}
+	for _, ref := range refs {
+		if flags.threadID, err = threadIDForComment(ctx, client, ref, flags.repo, flags.pr); err != nil {
+			return err
+		}
+	}
+	thread, err := fetchThread(ctx, client, flags.threadID)
+	if err != nil {
+		return err
+	}
+	switch {
+	case flags.jsonOut:
+		return writeJSON(os.Stdout, thread)
+	case flags.web:
+		if len(thread.Comments.Nodes) == 0 || thread.Comments.Nodes[0].URL == "" {
+			return fmt.Errorf("thread %s has no comment URL to open", flags.threadID)
+		}
+		url := thread.Comments.Nodes[0].URL
+		fmt.Fprintf(os.Stderr, "Opening %s in your browser.\n", url)
+		return openBrowser(ctx, url)
+	}
+	defer startPager()()
+	printThreads([]reviewThread{thread}, printOptions{maxLines: flags.maxLines, diff: flags.diff})
+	return nil
+}
+
+func printViewUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage:")
+	fmt.Fprintln(w, "  gh-pr-review view --thread-id <id> [--diff] [--max-lines n] [--json|--web] [--theme style] [--no-render-cache] [--host host]")
+	fmt.Fprintln(w, "  gh-pr-review view --url <comment-url|comment-id> [--pr <number>] [--repo owner/name] [--diff] [--json|--web] [--host host]")
+	fmt.Fprintln(w, "  gh-pr-review view <id|comment-url>")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Flags:")
+	fmt.Fprintln(w, "  --thread-id <id>   Review thread ID")
+	fmt.Fprintln(w, "  --url <url>   Review comment URL (.../pull/42#discussion_r123), or a numeric comment ID with --pr")
+	fmt.Fprintln(w, "  --pr <number>   PR for a numeric --url ID (defaults to current branch PR if available)")
+	fmt.Fprintln(w, "  --repo <owner/name>   Repository for a numeric --url ID (defaults to gh repo view)")
+	fmt.Fprintln(w, "  --diff   Show the diff hunk the thread is attached to")
+	fmt.Fprintln(w, "  --max-lines <n>   Truncate each comment body to n display lines (0 keeps full bodies)")
+	fmt.Fprintln(w, "  --json   Print the thread as JSON (same shape as list --json)")
+	fmt.Fprintln(w, "  --web   Open the thread in the browser ($BROWSER, or the system default) instead of printing it")
+	fmt.Fprintln(w, "  --theme <style>   Markdown style: auto, dark, light, notty, ... (auto honours GH_PR_REVIEW_BACKGROUND)")
+	fmt.Fprintln(w, "  --no-render-cache   Don't read or write the on-disk render cache")
+	fmt.Fprintln(w, "  --host <host>   GitHub host")
+}
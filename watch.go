@@ -0,0 +1,322 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+
+	"gh-pr-review/internal/gh"
+	"gh-pr-review/internal/github"
+)
+
+// minWatchInterval keeps a forgotten watch from eating the rate limit.
+const minWatchInterval = 10 * time.Second
+
+// watchCursor is what watch has already reported for a PR, saved between
+// runs so a restart picks up where the last one stopped.
+type watchCursor struct {
+	Threads map[string]watchThreadState `json:"threads"`
+}
+
+type watchThreadState struct {
+	// LastComment is the createdAt of the newest comment seen.
+	LastComment string `json:"lastComment"`
+	Resolved    bool   `json:"resolved"`
+}
+
+type watchEvent struct {
+	Kind     string `json:"kind"` // thread, comment, resolved or unresolved
+	ThreadID string `json:"threadId"`
+	Location string `json:"location,omitempty"`
+	Author   string `json:"author,omitempty"`
+	Body     string `json:"body,omitempty"`
+	URL      string `json:"url,omitempty"`
+	At       string `json:"at"`
+}
+
+type watchFlags struct {
+	repo       string
+	pr         int
+	interval   time.Duration
+	notifyFlag bool
+	jsonOut    bool
+	host       string
+}
+
+func (o *watchFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.repo, "repo", "", "owner/name (defaults to gh repo view)")
+	fs.IntVar(&o.pr, "pr", 0, "PR number")
+	fs.DurationVar(&o.interval, "interval", time.Minute, "time between polls")
+	fs.BoolVar(&o.notifyFlag, "notify", false, "send a desktop notification for each event")
+	fs.BoolVar(&o.jsonOut, "json", false, "print one JSON object per event")
+	fs.StringVar(&o.host, "host", gh.DefaultHost(), "GitHub host")
+}
+
+func runWatch(args []string) error {
+	fs := newFlagSet("watch", printWatchUsage)
+	var flags watchFlags
+	flags.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+	if flags.interval < minWatchInterval {
+		return fmt.Errorf("invalid --interval %s (minimum %s)", flags.interval, minWatchInterval)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	var err error
+	flags.pr, err = resolvePR(ctx, flags.pr)
+	if err != nil {
+		return err
+	}
+	owner, name, err := resolveRepo(ctx, flags.repo)
+	if err != nil {
+		return err
+	}
+	client, err := newClient(ctx, flags.host)
+	if err != nil {
+		return err
+	}
+	path, err := watchCursorPath(flags.host, owner, name, flags.pr)
+	if err != nil {
+		return err
+	}
+	cursor := loadWatchCursor(path)
+	title := fmt.Sprintf("%s/%s#%d", owner, name, flags.pr)
+	fmt.Fprintf(os.Stderr, "watching %s every %s (Ctrl-C to stop)\n", title, flags.interval)
+
+	styler := newStyler(os.Stdout)
+	enc := json.NewEncoder(os.Stdout)
+	for {
+		wait := flags.interval
+		threads, err := fetchAllThreads(ctx, client, owner, name, flags.pr)
+		switch {
+		case ctx.Err() != nil:
+			return nil
+		case err != nil:
+			if d, limited := github.RateLimited(err); limited {
+				if d > wait {
+					wait = d
+				}
+				fmt.Fprintf(os.Stderr, "rate limited; next poll in %s\n", wait.Round(time.Second))
+			} else if github.Transient(err) {
+				fmt.Fprintf(os.Stderr, "warning: %v; retrying in %s\n", err, wait)
+			} else {
+				return err
+			}
+		default:
+			var events []watchEvent
+			events, cursor = diffWatch(cursor, threads, time.Now())
+			for _, ev := range events {
+				if flags.jsonOut {
+					_ = enc.Encode(ev)
+				} else {
+					printWatchEvent(os.Stdout, ev, styler)
+				}
+				if flags.notifyFlag {
+					if err := notify(title, watchEventText(ev)); err != nil {
+						fmt.Fprintf(os.Stderr, "warning: notification failed, turning notifications off: %v\n", err)
+						flags.notifyFlag = false
+					}
+				}
+			}
+			if err := saveWatchCursor(path, cursor); err != nil {
+				fmt.Fprintf(os.Stderr, "warning: saving watch position: %v\n", err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(wait):
+		}
+	}
+}
+
+// diffWatch compares threads with what the cursor has already reported and
+// returns the events in between plus the updated cursor. A cursor with no
+// threads map (the first run for a PR) only records the current state, so
+// the existing history isn't replayed.
+func diffWatch(cursor watchCursor, threads []reviewThread, now time.Time) ([]watchEvent, watchCursor) {
+	firstRun := cursor.Threads == nil
+	next := watchCursor{Threads: map[string]watchThreadState{}}
+	var events []watchEvent
+	for _, t := range threads {
+		location := strings.Trim(strings.TrimSpace(formatLineInfo(t)), "[]")
+		prev, seen := cursor.Threads[t.ID]
+		state := watchThreadState{LastComment: prev.LastComment, Resolved: t.IsResolved}
+		for i, c := range t.Comments.Nodes {
+			if c.State == "PENDING" || !commentAfter(c.CreatedAt, prev.LastComment) {
+				continue
+			}
+			state.LastComment = c.CreatedAt
+			if firstRun {
+				continue
+			}
+			kind := "comment"
+			if i == 0 && !seen {
+				kind = "thread"
+			}
+			events = append(events, watchEvent{
+				Kind:     kind,
+				ThreadID: t.ID,
+				Location: location,
+				Author:   c.Author.Login,
+				Body:     truncateRunes(firstLine(c.Body), 100),
+				URL:      c.URL,
+				At:       c.CreatedAt,
+			})
+		}
+		if !firstRun && seen && prev.Resolved != t.IsResolved {
+			ev := watchEvent{Kind: "unresolved", ThreadID: t.ID, Location: location, At: now.UTC().Format(time.RFC3339)}
+			if t.IsResolved {
+				ev.Kind = "resolved"
+				if t.ResolvedBy != nil {
+					ev.Author = t.ResolvedBy.Login
+				}
+			}
+			events = append(events, ev)
+		}
+		next.Threads[t.ID] = state
+	}
+	return events, next
+}
+
+// commentAfter reports whether createdAt is later than last; an empty last
+// means nothing has been seen yet.
+func commentAfter(createdAt, last string) bool {
+	if last == "" {
+		return true
+	}
+	at, err := time.Parse(time.RFC3339, createdAt)
+	if err != nil {
+		return false
+	}
+	seen, err := time.Parse(time.RFC3339, last)
+	if err != nil {
+		return true
+	}
+	return at.After(seen)
+}
+
+func watchEventText(ev watchEvent) string {
+	where := ""
+	if ev.Location != "" {
+		where = " on " + ev.Location
+	}
+	switch ev.Kind {
+	case "thread":
+		return fmt.Sprintf("%s opened a thread%s: %s", ev.Author, where, ev.Body)
+	case "comment":
+		return fmt.Sprintf("%s replied%s: %s", ev.Author, where, ev.Body)
+	case "resolved":
+		if ev.Author != "" {
+			return fmt.Sprintf("%s resolved the thread%s", ev.Author, where)
+		}
+		return "thread resolved" + where
+	default:
+		return "thread reopened" + where
+	}
+}
+
+func printWatchEvent(w io.Writer, ev watchEvent, styler styler) {
+	at := time.Now()
+	if t, err := time.Parse(time.RFC3339, ev.At); err == nil {
+		at = t
+	}
+	fmt.Fprintf(w, "%s %s %s\n", styler.dim(at.Local().Format("15:04:05")), styler.status(fmt.Sprintf("%-10s", ev.Kind)), watchEventText(ev))
+}
+
+// notify shows a desktop notification with whichever notifier the platform
+// has: terminal-notifier or osascript on macOS, notify-send elsewhere.
+func notify(title, message string) error {
+	var cmd *exec.Cmd
+	switch {
+	case runtime.GOOS == "darwin":
+		if path, err := exec.LookPath("terminal-notifier"); err == nil {
+			cmd = exec.Command(path, "-title", title, "-message", message)
+		} else {
+			script := fmt.Sprintf("display notification %s with title %s", appleScriptString(message), appleScriptString(title))
+			cmd = exec.Command("osascript", "-e", script)
+		}
+	default:
+		cmd = exec.Command("notify-send", title, message)
+	}
+	out, err := cmd.CombinedOutput()
+	if err != nil && len(out) > 0 {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return err
+}
+
+func appleScriptString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+func watchCursorPath(host, owner, name string, pr int) (string, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "watch", fmt.Sprintf("%s_%s_%s_%d.json", host, owner, name, pr)), nil
+}
+
+// loadWatchCursor returns the saved cursor, or an empty one when there is
+// none or it can't be read, which makes the next poll a fresh start.
+func loadWatchCursor(path string) watchCursor {
+	var cursor watchCursor
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cursor
+	}
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return watchCursor{}
+	}
+	return cursor
+}
+
+func saveWatchCursor(path string, cursor watchCursor) error {
+	data, err := json.MarshalIndent(cursor, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+func printWatchUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage:")
+	fmt.Fprintln(w, "  gh-pr-review watch [--pr <number>] [--repo owner/name] [--interval 60s] [--notify] [--json] [--host host]")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Polls the PR and prints a line for each new thread, reply, resolve and unresolve until")
+	fmt.Fprintln(w, "interrupted. What has been reported is saved under the user cache directory, so a restart")
+	fmt.Fprintln(w, "reports only what happened since the last run. When the API rate limit is hit, the next")
+	fmt.Fprintln(w, "poll waits until the limit resets.")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Flags:")
+	fmt.Fprintln(w, "  --pr <number>   PR number (defaults to current branch PR if available)")
+	fmt.Fprintln(w, "  --repo <owner/name>   Repository (defaults to gh repo view)")
+	fmt.Fprintln(w, "  --interval <duration>   Time between polls (default 60s, minimum 10s)")
+	fmt.Fprintln(w, "  --notify   Also send a desktop notification (notify-send, terminal-notifier or osascript)")
+	fmt.Fprintln(w, "  --json   Print one JSON object per event: {kind, threadId, location, author, body, url, at}")
+	fmt.Fprintln(w, "  --host <host>   GitHub host")
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func watchThread(id string, resolved bool, comments ...reviewComment) reviewThread {
+	line := 12
+	t := reviewThread{ID: id, IsResolved: resolved, Path: "main.go", Line: &line}
+	t.Comments.Nodes = comments
+	return t
+}
+
+func watchComment(author, at, body string) reviewComment {
+	return reviewComment{Author: actor{Login: author}, CreatedAt: at, Body: body}
+}
+
+func TestDiffWatch(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	threads := []reviewThread{
+		watchThread("T1", false, watchComment("alice", "2024-05-01T10:00:00Z", "Use a buffered channel")),
+	}
+
+	// The first run records what is there without replaying it.
+	events, cursor := diffWatch(watchCursor{}, threads, now)
+	if len(events) != 0 {
+		t.Fatalf("first run events = %+v, want none", events)
+	}
+	if cursor.Threads["T1"].LastComment != "2024-05-01T10:00:00Z" {
+		t.Fatalf("cursor = %+v", cursor)
+	}
+
+	reply := watchComment("bob", "2024-05-01T11:00:00Z", "Done\n\nin abc123")
+	pending := watchComment("me", "2024-05-01T11:30:00Z", "draft")
+	pending.State = "PENDING"
+	resolved := watchThread("T1", true, threads[0].Comments.Nodes[0], reply, pending)
+	resolved.ResolvedBy = &actor{Login: "alice"}
+	threads = []reviewThread{
+		resolved,
+		watchThread("T2", false, watchComment("carol", "2024-05-01T11:15:00Z", "Typo")),
+	}
+	events, cursor = diffWatch(cursor, threads, now)
+	var got []string
+	for _, ev := range events {
+		got = append(got, ev.Kind+" "+ev.ThreadID+" "+watchEventText(ev))
+	}
+	want := []string{
+		"comment T1 bob replied on main.go:12: Done",
+		"resolved T1 alice resolved the thread on main.go:12",
+		"thread T2 carol opened a thread on main.go:12: Typo",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("events = %q, want %q", got, want)
+	}
+
+	// Nothing new: no events, and the pending comment is still not reported.
+	if events, _ := diffWatch(cursor, threads, now); len(events) != 0 {
+		t.Fatalf("repeat poll events = %+v, want none", events)
+	}
+
+	threads[0].IsResolved = false
+	events, _ = diffWatch(cursor, threads, now)
+	if len(events) != 1 || events[0].Kind != "unresolved" || events[0].At != "2024-05-01T12:00:00Z" {
+		t.Fatalf("events = %+v, want one unresolved event", events)
+	}
+}
+
+func TestWatchCursorRoundTrip(t *testing.T) {
+	path := t.TempDir() + "/watch/github.com_o_r_1.json"
+	if c := loadWatchCursor(path); c.Threads != nil {
+		t.Fatalf("missing file gave %+v, want an empty cursor", c)
+	}
+	cursor := watchCursor{Threads: map[string]watchThreadState{"T1": {LastComment: "2024-05-01T10:00:00Z", Resolved: true}}}
+	if err := saveWatchCursor(path, cursor); err != nil {
+		t.Fatal(err)
+	}
+	if got := loadWatchCursor(path); !reflect.DeepEqual(got, cursor) {
+		t.Fatalf("loaded %+v, want %+v", got, cursor)
+	}
+}
+
+func TestAppleScriptString(t *testing.T) {
+	if got := appleScriptString(`say "hi" \o/`); got != `"say \"hi\" \\o/"` {
+		t.Fatalf("appleScriptString = %s", got)
+	}
+}